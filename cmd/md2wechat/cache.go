@@ -0,0 +1,144 @@
+package main
+
+import (
+	"github.com/geekjourneyx/md2wechat-skill/internal/image"
+	"github.com/geekjourneyx/md2wechat-skill/internal/wechat"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+// cacheCmd cache 命令
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Manage the uploaded image cache",
+	Long: `Manage the local cache of uploaded images.
+
+Images are cached by the SHA-256 of the processed (compressed) bytes, the
+WeChat AppID and the upload kind, so uploading the same image again reuses
+its media_id or URL instead of uploading a duplicate.
+
+Inline body images are uploaded as article content images (kind "content"),
+which have no media_id and are skipped by verify and prune. Draft covers are
+cached separately (kind "cover").
+
+Subcommands:
+  list    List cached uploads
+  verify  Check cached media_ids still exist in the material library
+  prune   Remove cached entries whose media no longer exist`,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		return initConfig()
+	},
+}
+
+var cachePruneAll bool
+
+func init() {
+	// list 子命令
+	var listCmd = &cobra.Command{
+		Use:   "list",
+		Short: "List cached uploads",
+		Run: func(cmd *cobra.Command, args []string) {
+			cache, err := image.OpenUploadCache(cfg.CacheDir())
+			if err != nil {
+				responseError(err)
+				return
+			}
+			entries := cache.List()
+			responseSuccess(map[string]any{
+				"path":    cache.Path(),
+				"count":   len(entries),
+				"entries": entries,
+			})
+		},
+	}
+	cacheCmd.AddCommand(listCmd)
+
+	// verify 子命令
+	var verifyCmd = &cobra.Command{
+		Use:   "verify",
+		Short: "Check cached media_ids still exist",
+		Run: func(cmd *cobra.Command, args []string) {
+			result, err := verifyUploadCache(false)
+			if err != nil {
+				responseError(err)
+				return
+			}
+			responseSuccess(result)
+		},
+	}
+	cacheCmd.AddCommand(verifyCmd)
+
+	// prune 子命令
+	var pruneCmd = &cobra.Command{
+		Use:   "prune",
+		Short: "Remove cached entries whose media no longer exist",
+		Run: func(cmd *cobra.Command, args []string) {
+			result, err := verifyUploadCache(true)
+			if err != nil {
+				responseError(err)
+				return
+			}
+			responseSuccess(result)
+		},
+	}
+	pruneCmd.Flags().BoolVar(&cachePruneAll, "all", false, "Remove all entries of the current AppID without checking")
+	cacheCmd.AddCommand(pruneCmd)
+}
+
+// cacheVerifyResult 缓存校验结果
+type cacheVerifyResult struct {
+	Path    string             `json:"path"`
+	Checked int                `json:"checked"`
+	Valid   int                `json:"valid"`
+	Missing []image.CacheEntry `json:"missing"`
+	Skipped int                `json:"skipped"` // 其他 AppID 的条目及内容图片（没有 media_id）
+	Removed int                `json:"removed"`
+}
+
+// verifyUploadCache 校验当前 AppID 的缓存条目，prune 为 true 时删除失效条目
+func verifyUploadCache(prune bool) (*cacheVerifyResult, error) {
+	cache, err := image.OpenUploadCache(cfg.CacheDir())
+	if err != nil {
+		return nil, err
+	}
+
+	result := &cacheVerifyResult{
+		Path:    cache.Path(),
+		Missing: []image.CacheEntry{},
+	}
+
+	// 查询素材库中现有的图片（--all 时无需查询）
+	var existing map[string]bool
+	if !(prune && cachePruneAll) {
+		existing, err = wechat.NewService(cfg, log).ListImageMaterialIDs()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, entry := range cache.List() {
+		if entry.AppID != cfg.WechatAppID || entry.Kind == image.UploadContent {
+			result.Skipped++
+			continue
+		}
+		result.Checked++
+		if existing[entry.MediaID] {
+			result.Valid++
+			continue
+		}
+		result.Missing = append(result.Missing, entry)
+		if prune {
+			cache.Delete(entry.Key)
+			result.Removed++
+		}
+	}
+
+	if result.Removed > 0 {
+		if err := cache.Save(); err != nil {
+			return nil, err
+		}
+		log.Info("upload cache pruned", zap.Int("removed", result.Removed))
+	}
+
+	return result, nil
+}
@@ -125,7 +125,7 @@ This is the global config location, used by all your projects.`,
 // showConfig 显示配置
 func showConfig(showSecret bool) error {
 	// 加载配置
-	cfg, err := config.Load()
+	cfg, err := config.LoadAccount(accountName)
 	if err != nil {
 		// 如果加载失败，可能是缺少必需配置，尝试创建一个用于显示
 		if os.Getenv("WECHAT_APPID") == "" && os.Getenv("WECHAT_SECRET") == "" {
@@ -151,7 +151,7 @@ func showConfig(showSecret bool) error {
 
 // validateConfig 验证配置
 func validateConfig() error {
-	cfg, err := config.Load()
+	cfg, err := config.LoadAccount(accountName)
 	if err != nil {
 		return err
 	}
@@ -186,6 +186,7 @@ func initConfigFile(outputFile string) error {
 		CompressImages:     true,
 		MaxImageWidth:      1920,
 		MaxImageSize:       5 * 1024 * 1024,
+		ImageWorkers:       4,
 		HTTPTimeout:        30,
 	}
 
@@ -217,6 +218,10 @@ func printYAMLConfig(cfg *config.Config, maskSecret bool) {
 	fmt.Printf("  md2wechat_key: %s\n", maskAPIKey(cfg.MD2WechatAPIKey, maskSecret))
 	fmt.Printf("  image_key: %s\n", maskAPIKey(cfg.ImageAPIKey, maskSecret))
 	fmt.Printf("  image_base_url: %s\n", cfg.ImageAPIBase)
+	fmt.Printf("  llm_provider: %s\n", cfg.LLMProvider)
+	fmt.Printf("  llm_key: %s\n", maskAPIKey(cfg.LLMAPIKey, maskSecret))
+	fmt.Printf("  llm_base_url: %s\n", cfg.LLMAPIBase)
+	fmt.Printf("  llm_model: %s\n", cfg.LLMModel)
 	fmt.Printf("  convert_mode: %s\n", cfg.DefaultConvertMode)
 	fmt.Printf("  default_theme: %s\n", cfg.DefaultTheme)
 	fmt.Printf("  http_timeout: %d\n\n", cfg.HTTPTimeout)
@@ -225,6 +230,24 @@ func printYAMLConfig(cfg *config.Config, maskSecret bool) {
 	fmt.Printf("  compress: %v\n", cfg.CompressImages)
 	fmt.Printf("  max_width: %d\n", cfg.MaxImageWidth)
 	fmt.Printf("  max_size_mb: %d\n", cfg.MaxImageSize/1024/1024)
+	fmt.Printf("  workers: %d\n", cfg.ImageWorkers)
+
+	fmt.Println("\ndiagram:")
+	fmt.Printf("  mermaid: %s\n", cfg.MermaidCommand)
+	fmt.Printf("  plantuml: %s\n", cfg.PlantUMLCommand)
+	fmt.Printf("  font: %s\n", cfg.DiagramFont)
+
+	if names := cfg.AccountNames(); len(names) > 0 {
+		fmt.Println("\naccounts:")
+		for _, name := range names {
+			marker := ""
+			if name == cfg.Account {
+				marker = "  # active"
+			}
+			fmt.Printf("  %s:%s\n", name, marker)
+		}
+		fmt.Printf("# active account: %s\n", cfg.Account)
+	}
 }
 
 func maskAPIKey(key string, mask bool) string {
@@ -1,34 +1,57 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
 
 	"github.com/geekjourneyx/md2wechat-skill/internal/converter"
 	"github.com/geekjourneyx/md2wechat-skill/internal/draft"
 	"github.com/geekjourneyx/md2wechat-skill/internal/image"
-	"github.com/geekjourneyx/md2wechat-skill/internal/wechat"
+	"github.com/geekjourneyx/md2wechat-skill/internal/input"
 	"github.com/spf13/cobra"
 	"go.uber.org/zap"
 )
 
 // convertCmd convert 命令
 var convertCmd = &cobra.Command{
-	Use:   "convert <markdown_file>",
+	Use:   "convert <file>",
 	Short: "Convert Markdown to WeChat HTML",
 	Long: `Convert Markdown article to WeChat Official Account formatted HTML.
 
-Supports two conversion modes:
-  - api: Use md2wechat.cn API (stable, requires API key)
-  - ai:  Use Claude AI to generate HTML (flexible, requires AI)
+Supports three conversion modes:
+  - api:   Use md2wechat.cn API (stable, requires API key)
+  - ai:    Use AI to generate HTML (flexible; calls the configured LLM,
+           or prints the prompt for an agent when no LLM is configured)
+  - local: Render offline with theme colors (no API key or network needed)
 
 Supported themes:
   API modes: default, bytedance, apple, sports, chinese, cyber
-  AI modes: autumn-warm, spring-fresh, ocean-calm, custom`,
+  AI modes: autumn-warm, spring-fresh, ocean-calm, custom
+  Local mode: any theme above (uses the theme's colors)
+  CSS themes: paper (stylesheet inlined locally, api or local mode)
+
+Besides Markdown, the input can be a Word document (.docx), an HTML file,
+a Notion export (folder or .zip) or an Obsidian note/vault. The format is
+picked by file extension, or set with --from. Embedded images are extracted
+to local files and handled like any other local image.
+
+Obsidian notes (inside a vault, or with --from obsidian / --vault) resolve
+![[image.png|300]] embeds against the vault and keep the width, render
+> [!tip] callouts as boxes in theme colors, and turn [[note]] links into text
+(or a reference when the linked note has a source_url).`,
 	Args: cobra.ExactArgs(1),
 	PreRunE: func(cmd *cobra.Command, args []string) error {
-		return initConfig()
+		// 只有 --upload 和 --draft 需要微信公众号凭证
+		if convertUpload || convertDraft {
+			return initConfig()
+		}
+		return initOfflineConfig()
 	},
 	Run: func(cmd *cobra.Command, args []string) {
 		if err := runConvert(cmd, args); err != nil {
@@ -50,12 +73,21 @@ var (
 	convertDraft        bool
 	convertSaveDraft    string
 	convertCoverImage   string // 封面图片路径
+	convertImageReport  string // 图片处理报告输出路径
+	convertNoCache      bool   // 不使用上传缓存
+	convertLineNumbers  bool   // 代码块显示行号
+	convertNoHighlight  bool   // 关闭代码高亮
+	convertMath         string // 公式输出格式
+	convertNoDiagram    bool   // 不渲染图表代码块
+	convertLinks        string // 外部链接处理方式
+	convertFrom         string // 输入格式
+	convertVault        string // Obsidian 笔记库目录
 )
 
 func init() {
 	// 添加 flags
-	convertCmd.Flags().StringVar(&convertMode, "mode", "api", "Conversion mode: api or ai")
-	convertCmd.Flags().StringVar(&convertTheme, "theme", "default", "Theme name")
+	convertCmd.Flags().StringVar(&convertMode, "mode", "api", "Conversion mode: api, ai or local")
+	convertCmd.Flags().StringVar(&convertTheme, "theme", "default", "Theme name (overrides front matter theme)")
 	convertCmd.Flags().StringVar(&convertAPIKey, "api-key", "", "API key for md2wechat.cn")
 	convertCmd.Flags().StringVar(&convertFontSize, "font-size", "medium", "Font size: small/medium/large (API mode only)")
 	convertCmd.Flags().StringVar(&convertCustomPrompt, "custom-prompt", "", "Custom AI prompt (AI mode only)")
@@ -64,7 +96,16 @@ func init() {
 	convertCmd.Flags().BoolVar(&convertUpload, "upload", false, "Upload images to WeChat and replace URLs")
 	convertCmd.Flags().BoolVar(&convertDraft, "draft", false, "Create WeChat draft after conversion")
 	convertCmd.Flags().StringVar(&convertSaveDraft, "save-draft", "", "Save draft JSON to file")
-	convertCmd.Flags().StringVar(&convertCoverImage, "cover", "", "Cover image path for draft (required when using --draft)")
+	convertCmd.Flags().StringVar(&convertCoverImage, "cover", "", "Cover image path for draft (overrides front matter cover)")
+	convertCmd.Flags().BoolVar(&convertNoCache, "no-cache", false, "Always upload images, ignoring the upload cache")
+	convertCmd.Flags().StringVar(&convertImageReport, "image-report", "", "Write per-image upload report JSON to file (- for stdout)")
+	convertCmd.Flags().BoolVar(&convertLineNumbers, "line-numbers", false, "Show line numbers in code blocks")
+	convertCmd.Flags().BoolVar(&convertNoHighlight, "no-highlight", false, "Disable code syntax highlighting")
+	convertCmd.Flags().StringVar(&convertMath, "math", "svg", "Math formula output: svg (inline), png (uploaded image) or off")
+	convertCmd.Flags().BoolVar(&convertNoDiagram, "no-diagram", false, "Keep mermaid/plantuml code blocks instead of rendering them as images")
+	convertCmd.Flags().StringVar(&convertLinks, "links", "references", "External links: references (numbered list at the end), keep or strip")
+	convertCmd.Flags().StringVar(&convertFrom, "from", "auto", "Input format: auto, markdown, docx, html, notion or obsidian")
+	convertCmd.Flags().StringVar(&convertVault, "vault", "", "Obsidian vault root for ![[embeds]] and [[links]] (implies --from obsidian)")
 }
 
 // runConvert 执行转换
@@ -76,28 +117,58 @@ func runConvert(cmd *cobra.Command, args []string) error {
 		zap.String("mode", convertMode),
 		zap.String("theme", convertTheme))
 
-	// 读取 Markdown 文件
-	markdown, err := os.ReadFile(markdownFile)
+	from, err := input.ParseFormat(convertFrom)
+	if err != nil {
+		return err
+	}
+	if convertVault != "" && from == "" {
+		from = input.FormatObsidian
+	}
+
+	// 读取文章，其它格式先转换为 Markdown
+	doc, err := loadInput(markdownFile, from)
 	if err != nil {
-		return fmt.Errorf("read markdown file: %w", err)
+		return err
 	}
 
 	// 创建转换器
 	conv := converter.NewConverter(cfg, log)
 
+	// 未显式指定 --theme 时，优先使用 front matter 中的主题
+	theme := convertTheme
+	if !cmd.Flags().Changed("theme") {
+		theme = ""
+	}
+
 	// 构建转换请求
 	req := &converter.ConvertRequest{
-		Markdown:     string(markdown),
+		Markdown:     doc.Markdown,
 		Mode:         converter.ConvertMode(convertMode),
-		Theme:        convertTheme,
+		Theme:        theme,
+		BaseDir:      doc.BaseDir(),
 		APIKey:       convertAPIKey,
 		FontSize:     convertFontSize,
 		CustomPrompt: convertCustomPrompt,
+		LineNumbers:  convertLineNumbers,
+		NoHighlight:  convertNoHighlight,
+		Math:         converter.MathFormat(convertMath),
+		NoDiagram:    convertNoDiagram,
+		Links:        converter.LinkPolicy(convertLinks),
+		Obsidian:     doc.Format == input.FormatObsidian,
+		VaultDir:     doc.Vault,
+	}
+	if convertVault != "" {
+		req.VaultDir = convertVault
 	}
 
 	// 执行转换
 	result := conv.Convert(req)
 
+	// 未配置 LLM 的 AI 模式需要外部处理
+	if convertMode == "ai" && converter.IsAIRequest(result) {
+		return handleAIResult(result, markdownFile)
+	}
+
 	if !result.Success {
 		return fmt.Errorf("conversion failed: %s", result.Error)
 	}
@@ -107,16 +178,10 @@ func runConvert(cmd *cobra.Command, args []string) error {
 		zap.String("theme", result.Theme),
 		zap.Int("image_count", len(result.Images)))
 
-	// 根据模式处理结果
-	if convertMode == "ai" && converter.IsAIRequest(result) {
-		// AI 模式需要外部处理
-		return handleAIResult(result, markdownFile)
-	}
-
 	// 处理图片
 	if convertUpload || convertDraft {
 		if err := processImages(result); err != nil {
-			log.Warn("image processing failed", zap.Error(err))
+			return fmt.Errorf("process images: %w", err)
 		}
 	}
 
@@ -128,7 +193,7 @@ func runConvert(cmd *cobra.Command, args []string) error {
 	}
 
 	if convertDraft {
-		if err := createWeChatDraft(result, convertCoverImage); err != nil {
+		if _, err := createWeChatDraft(result, resolveCoverPath(doc.Path, result.Meta)); err != nil {
 			return fmt.Errorf("create draft: %w", err)
 		}
 	}
@@ -139,6 +204,37 @@ func runConvert(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// convertMarkdownFile 转换 Markdown 文件并上传其中的图片，用于更新、同步草稿等非交互场景
+// theme 为空时使用 front matter 中的主题
+func convertMarkdownFile(markdownFile string, mode converter.ConvertMode, theme string) (*converter.ConvertResult, error) {
+	doc, err := loadInput(markdownFile, "")
+	if err != nil {
+		return nil, err
+	}
+
+	conv := converter.NewConverter(cfg, log)
+	result := conv.Convert(&converter.ConvertRequest{
+		Markdown: doc.Markdown,
+		Mode:     mode,
+		Theme:    theme,
+		BaseDir:  doc.BaseDir(),
+		Obsidian: doc.Format == input.FormatObsidian,
+		VaultDir: doc.Vault,
+	})
+
+	if mode == converter.ModeAI && converter.IsAIRequest(result) {
+		return nil, fmt.Errorf("AI mode needs an LLM for this command, set api.llm_key or use --mode api/local")
+	}
+	if !result.Success {
+		return nil, fmt.Errorf("conversion failed: %s", result.Error)
+	}
+
+	if err := processImages(result); err != nil {
+		return nil, fmt.Errorf("process images: %w", err)
+	}
+	return result, nil
+}
+
 // handleAIResult 处理 AI 模式结果
 func handleAIResult(result *converter.ConvertResult, markdownFile string) error {
 	prompt, images, ok := converter.GetAIRequestInfo(result)
@@ -172,71 +268,166 @@ func handleAIResult(result *converter.ConvertResult, markdownFile string) error
 	return nil
 }
 
-// processImages 处理图片上传
+// processImages 并发处理图片上传，任一图片失败时返回错误
 func processImages(result *converter.ConvertResult) error {
 	if len(result.Images) == 0 {
 		log.Info("no images to process")
 		return nil
 	}
 
-	processor := image.NewProcessor(cfg, log)
-
-	for i, imgRef := range result.Images {
-		log.Info("processing image",
-			zap.Int("index", i),
-			zap.String("type", string(imgRef.Type)),
-			zap.String("original", imgRef.Original))
-
-		var uploadResult *image.UploadResult
-		var err error
-
+	jobs := make([]image.Job, 0, len(result.Images))
+	for _, imgRef := range result.Images {
+		// 正文图片只需要 URL，使用 uploadimg 接口，不占用永久素材配额
+		job := image.Job{Index: imgRef.Index, Source: imgRef.Original, Kind: image.UploadContent}
 		switch imgRef.Type {
 		case converter.ImageTypeLocal:
-			uploadResult, err = processor.UploadLocalImage(imgRef.Original)
+			job.Type = image.JobLocal
 		case converter.ImageTypeOnline:
-			uploadResult, err = processor.DownloadAndUpload(imgRef.Original)
+			job.Type = image.JobOnline
 		case converter.ImageTypeAI:
-			// AI 生成的图片需要先调用生成 API
-			genResult, genErr := processor.GenerateAndUpload(imgRef.AIPrompt)
-			if genErr != nil {
-				err = genErr
-			} else {
-				uploadResult = &image.UploadResult{
-					MediaID:   genResult.MediaID,
-					WechatURL: genResult.WechatURL,
-				}
-			}
+			job.Type = image.JobGenerate
+			job.Source = imgRef.AIPrompt
 		}
+		jobs = append(jobs, job)
+	}
 
-		if err != nil {
-			log.Warn("image upload failed",
-				zap.Int("index", i),
-				zap.Error(err))
-			continue
-		}
+	// Ctrl+C 时停止开始新的图片任务
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-		// 更新图片 URL
-		result.Images[i].WechatURL = uploadResult.WechatURL
+	processor := image.NewProcessor(cfg, log)
+	if convertNoCache {
+		processor.DisableCache()
+	}
+	pipeline := image.NewPipeline(processor, log, cfg.ImageWorkers)
+	report := pipeline.Run(ctx, jobs)
 
-		log.Info("image uploaded",
-			zap.Int("index", i),
-			zap.String("media_id", maskMediaID(uploadResult.MediaID)),
-			zap.String("wechat_url", uploadResult.WechatURL))
+	// 更新图片 URL
+	for _, r := range report.Images {
+		if r.Status == image.StatusUploaded && r.Index < len(result.Images) {
+			result.Images[r.Index].WechatURL = r.WechatURL
+		}
 	}
 
 	// 替换 HTML 中的图片占位符
 	result.HTML = converter.ReplaceImagePlaceholders(result.HTML, result.Images)
 
+	log.Info("image processing completed",
+		zap.Int("total", report.Total),
+		zap.Int("uploaded", report.Uploaded),
+		zap.Int("failed", report.Failed),
+		zap.Int("cancelled", report.Cancelled),
+		zap.Int64("duration_ms", report.DurationMs))
+
+	if err := writeImageReport(report, convertImageReport); err != nil {
+		log.Warn("failed to write image report", zap.Error(err))
+	}
+
+	if !report.OK() {
+		return fmt.Errorf("%d of %d images failed (%d cancelled)", report.Failed+report.Cancelled, report.Total, report.Cancelled)
+	}
 	return nil
 }
 
+// writeImageReport 输出图片处理报告 JSON（"-" 表示标准输出）
+func writeImageReport(report *image.PipelineReport, path string) error {
+	switch path {
+	case "":
+		return nil
+	case "-":
+		printJSON(report)
+		return nil
+	}
+
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// buildDraftArticle 根据转换结果和文章元数据构建草稿文章
+func buildDraftArticle(result *converter.ConvertResult, thumbMediaID string) draft.Article {
+	meta := result.Meta
+	if meta == nil {
+		meta = &converter.ArticleMeta{}
+	}
+
+	// ResolveArticleMeta 已经补全标题，没有元数据时（例如外部 AI 转换结果）取正文开头
+	title := meta.Title
+	if title == "" {
+		title = draft.GenerateDigestFromContent(result.HTML, 30)
+	}
+	if title == "" {
+		title = "未命名文章"
+	}
+
+	digest := meta.Digest
+	if digest == "" {
+		digest = draft.GenerateDigestFromContent(result.HTML, 120)
+	}
+
+	article := draft.Article{
+		Title:            title,
+		Author:           meta.Author,
+		Digest:           digest,
+		Content:          result.HTML,
+		ContentSourceURL: meta.SourceURL,
+	}
+
+	if thumbMediaID != "" {
+		article.ThumbMediaID = thumbMediaID
+		article.ShowCoverPic = 1 // 显示封面
+	}
+
+	// 评论设置
+	if meta.OpenComment {
+		article.NeedOpenComment = 1
+		if meta.FansOnlyComment {
+			article.OnlyFansCanComment = 1
+		}
+	}
+
+	return article
+}
+
+// loadInput 读取文章；docx、HTML、Notion、Obsidian 转换为 Markdown，解压的图片保存在缓存目录
+func loadInput(file string, from input.Format) (*input.Document, error) {
+	doc, err := input.Load(file, from, filepath.Join(cfg.CacheDir(), "input"))
+	if err != nil {
+		return nil, err
+	}
+	if doc.Format != input.FormatMarkdown {
+		log.Info("input converted to markdown",
+			zap.String("format", string(doc.Format)),
+			zap.String("file", doc.Path))
+	}
+	return doc, nil
+}
+
+// resolveCoverPath 确定封面图片：--cover 参数优先，其次 front matter 的 cover（相对于 Markdown 文件）
+func resolveCoverPath(markdownFile string, meta *converter.ArticleMeta) string {
+	if convertCoverImage != "" {
+		return convertCoverImage
+	}
+	if meta == nil || meta.Cover == "" {
+		return ""
+	}
+	return resolveArticlePath(markdownFile, meta.Cover)
+}
+
+// resolveArticlePath 将文章中的相对路径解析为相对于 Markdown 文件所在目录的路径
+func resolveArticlePath(markdownFile, path string) string {
+	if path == "" || filepath.IsAbs(path) || strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://") {
+		return path
+	}
+	return filepath.Join(filepath.Dir(markdownFile), path)
+}
+
 // saveDraft 保存草稿 JSON 到文件
 func saveDraft(result *converter.ConvertResult) error {
 	articles := []draft.Article{
-		{
-			Title:   "Draft Article", // TODO: 从 markdown 提取标题
-			Content: result.HTML,
-		},
+		buildDraftArticle(result, ""),
 	}
 
 	draftData := map[string]any{
@@ -257,58 +448,50 @@ func saveDraft(result *converter.ConvertResult) error {
 }
 
 // createWeChatDraft 创建微信草稿
-func createWeChatDraft(result *converter.ConvertResult, coverImagePath string) error {
+func createWeChatDraft(result *converter.ConvertResult, coverImagePath string) (*draft.DraftResult, error) {
 	svc := draft.NewService(cfg, log)
 
 	// 检查封面图片（微信要求必须有封面图）
 	if coverImagePath == "" {
-		return &DraftError{
+		return nil, &DraftError{
 			Message: "创建草稿需要封面图片",
-			Hint:    "请使用 --cover 参数指定封面图片路径，例如: --cover /path/to/cover.jpg\n" +
-				"或者先上传封面图片到微信素材库: md2wechat upload_image /path/to/cover.jpg",
+			Hint: "请使用 --cover 参数指定封面图片路径，例如: --cover /path/to/cover.jpg\n" +
+				"或者在 Markdown front matter 中设置 cover: ./cover.jpg",
 		}
 	}
 
 	// 上传封面图片到微信素材库
 	log.Info("uploading cover image", zap.String("path", coverImagePath))
-	coverMediaID, err := uploadCoverImage(coverImagePath)
+	cover, err := uploadCoverImage(coverImagePath)
 	if err != nil {
-		return fmt.Errorf("上传封面图片失败: %w", err)
+		return nil, fmt.Errorf("上传封面图片失败: %w", err)
 	}
+	coverMediaID := cover.MediaID
 	log.Info("cover image uploaded", zap.String("media_id", maskMediaID(coverMediaID)))
 
-	// 提取标题（TODO: 从 markdown frontmatter 或第一个标题获取）
-	title := "Article Title"
-
 	draftResult, err := svc.CreateDraft([]draft.Article{
-		{
-			Title:          title,
-			Content:        result.HTML,
-			Digest:         draft.GenerateDigestFromContent(result.HTML, 120),
-			ThumbMediaID:   coverMediaID,
-			ShowCoverPic:   1, // 显示封面
-		},
+		buildDraftArticle(result, coverMediaID),
 	})
 
 	if err != nil {
-		return fmt.Errorf("create draft: %w", err)
+		return nil, fmt.Errorf("create draft: %w", err)
 	}
 
 	log.Info("draft created",
 		zap.String("media_id", maskMediaID(draftResult.MediaID)),
 		zap.String("draft_url", draftResult.DraftURL))
 
-	return nil
+	return draftResult, nil
 }
 
-// uploadCoverImage 上传封面图片到微信素材库
-func uploadCoverImage(imagePath string) (string, error) {
-	svc := wechat.NewService(cfg, log)
-	result, err := svc.UploadMaterial(imagePath)
-	if err != nil {
-		return "", err
+// uploadCoverImage 上传封面图片到微信素材库（支持在线图片 URL）
+// 使用上传缓存，同一公众号下相同的封面只上传一次（命中缓存时 Cached 为 true）
+func uploadCoverImage(imagePath string) (*image.UploadResult, error) {
+	processor := image.NewProcessor(cfg, log)
+	if convertNoCache {
+		processor.DisableCache()
 	}
-	return result.MediaID, nil
+	return processor.UploadCover(imagePath)
 }
 
 // DraftError 草稿错误
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/geekjourneyx/md2wechat-skill/internal/image"
+)
+
+func TestUploadCoverImageNoCache(t *testing.T) {
+	uploads := 0
+	fakeWechat(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/cgi-bin/material/add_material" {
+			t.Errorf("unexpected request %s", r.URL.Path)
+		}
+		uploads++
+		_, _ = io.WriteString(w, `{"media_id":"fresh-media","url":"https://mmbiz.qpic.cn/fresh"}`)
+	})
+	t.Cleanup(func() { convertNoCache = false })
+
+	cover := filepath.Join(t.TempDir(), "cover.png")
+	if err := os.WriteFile(cover, []byte("cover bytes"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cache, err := image.OpenUploadCache(cfg.CacheDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	cache.Put(image.CacheEntry{AppID: "wx-test", Kind: image.UploadCover, Hash: image.HashContent([]byte("cover bytes")), MediaID: "cached-media"})
+	if err := cache.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	if result, err := uploadCoverImage(cover); err != nil || !result.Cached || result.MediaID != "cached-media" {
+		t.Fatalf("uploadCoverImage() = %+v, %v, want cached", result, err)
+	}
+
+	// --no-cache 时封面同样重新上传
+	convertNoCache = true
+	result, err := uploadCoverImage(cover)
+	if err != nil || result.Cached || result.MediaID != "fresh-media" || uploads != 1 {
+		t.Errorf("uploadCoverImage() with --no-cache = %+v, %v (uploads %d)", result, err, uploads)
+	}
+}
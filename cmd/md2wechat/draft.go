@@ -0,0 +1,292 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/geekjourneyx/md2wechat-skill/internal/converter"
+	"github.com/geekjourneyx/md2wechat-skill/internal/draft"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+// draftCmd draft 命令
+var draftCmd = &cobra.Command{
+	Use:   "draft",
+	Short: "Manage WeChat drafts",
+	Long: `Manage drafts in the WeChat Official Account draft box.
+
+Subcommands:
+  list    List drafts
+  get     Show the articles of a draft
+  update  Update one article of a draft in place
+  delete  Delete a draft
+  count   Count drafts
+  compose Create one multi-article draft from several Markdown files
+
+Examples:
+  md2wechat draft list --count 10
+  md2wechat draft get <media_id>
+  md2wechat draft update <media_id> --title "Fixed title"
+  md2wechat draft update <media_id> --markdown article.md
+  md2wechat draft delete <media_id> --yes
+  md2wechat draft compose main.md second.md`,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		return initConfig()
+	},
+}
+
+// draft 命令参数
+var (
+	draftListOffset  int
+	draftListCount   int
+	draftListContent bool
+
+	draftUpdateIndex     int
+	draftUpdateTitle     string
+	draftUpdateAuthor    string
+	draftUpdateDigest    string
+	draftUpdateSourceURL string
+	draftUpdateHTML      string
+	draftUpdateMarkdown  string
+	draftUpdateMode      string
+	draftUpdateTheme     string
+	draftUpdateCover     string
+
+	draftDeleteYes bool
+)
+
+func init() {
+	// list 子命令
+	var listCmd = &cobra.Command{
+		Use:   "list",
+		Short: "List drafts",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			svc := draft.NewService(cfg, log)
+			result, err := svc.ListDrafts(draftListOffset, draftListCount, draftListContent)
+			if err != nil {
+				responseError(err)
+				return
+			}
+			responseSuccess(result)
+		},
+	}
+	listCmd.Flags().IntVar(&draftListOffset, "offset", 0, "Offset of the first draft")
+	listCmd.Flags().IntVar(&draftListCount, "count", 20, "Number of drafts to return (1-20)")
+	listCmd.Flags().BoolVar(&draftListContent, "content", false, "Include article HTML content")
+	draftCmd.AddCommand(listCmd)
+
+	// get 子命令
+	var getCmd = &cobra.Command{
+		Use:   "get <media_id>",
+		Short: "Show the articles of a draft",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			svc := draft.NewService(cfg, log)
+			articles, err := svc.GetDraft(args[0])
+			if err != nil {
+				responseError(err)
+				return
+			}
+			responseSuccess(map[string]any{
+				"media_id": args[0],
+				"articles": articles,
+			})
+		},
+	}
+	draftCmd.AddCommand(getCmd)
+
+	// update 子命令
+	var updateCmd = &cobra.Command{
+		Use:   "update <media_id>",
+		Short: "Update one article of a draft in place",
+		Long: `Update one article of an existing draft without creating a new copy.
+
+The current article is fetched first and only the given fields are replaced.
+With --markdown the article is re-converted (images are uploaded) and its
+front matter replaces title, author, digest, source URL and comment settings;
+the existing cover is kept unless --cover is given.
+Field flags are applied last and win over --markdown.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			result, err := runDraftUpdate(cmd, args[0])
+			if err != nil {
+				responseError(err)
+				return
+			}
+			responseSuccess(result)
+		},
+	}
+	updateCmd.Flags().IntVar(&draftUpdateIndex, "index", 0, "Index of the article in the draft (0-based)")
+	updateCmd.Flags().StringVar(&draftUpdateTitle, "title", "", "New title")
+	updateCmd.Flags().StringVar(&draftUpdateAuthor, "author", "", "New author")
+	updateCmd.Flags().StringVar(&draftUpdateDigest, "digest", "", "New digest")
+	updateCmd.Flags().StringVar(&draftUpdateSourceURL, "source-url", "", "New source URL (阅读原文)")
+	updateCmd.Flags().StringVar(&draftUpdateHTML, "html", "", "Replace content with this HTML file")
+	updateCmd.Flags().StringVar(&draftUpdateMarkdown, "markdown", "", "Re-convert this Markdown file as the new content")
+	updateCmd.Flags().StringVar(&draftUpdateMode, "mode", "api", "Conversion mode for --markdown: api, ai or local")
+	updateCmd.Flags().StringVar(&draftUpdateTheme, "theme", "", "Theme for --markdown (default: front matter theme)")
+	updateCmd.Flags().StringVar(&draftUpdateCover, "cover", "", "Upload this image as the new cover")
+	draftCmd.AddCommand(updateCmd)
+
+	// delete 子命令
+	var deleteCmd = &cobra.Command{
+		Use:   "delete <media_id>",
+		Short: "Delete a draft",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if !draftDeleteYes {
+				responseError(&DraftError{
+					Message: "删除草稿不可恢复",
+					Hint:    "确认删除请添加 --yes 参数",
+				})
+				return
+			}
+			svc := draft.NewService(cfg, log)
+			if err := svc.DeleteDraft(args[0]); err != nil {
+				responseError(err)
+				return
+			}
+			responseSuccess(map[string]any{
+				"media_id": args[0],
+				"deleted":  true,
+			})
+		},
+	}
+	deleteCmd.Flags().BoolVar(&draftDeleteYes, "yes", false, "Confirm deletion")
+	draftCmd.AddCommand(deleteCmd)
+
+	// count 子命令
+	var countCmd = &cobra.Command{
+		Use:   "count",
+		Short: "Count drafts",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			svc := draft.NewService(cfg, log)
+			total, err := svc.CountDrafts()
+			if err != nil {
+				responseError(err)
+				return
+			}
+			responseSuccess(map[string]any{
+				"total_count": total,
+			})
+		},
+	}
+	draftCmd.AddCommand(countCmd)
+}
+
+// runDraftUpdate 获取草稿中的文章，替换指定字段后整体更新
+func runDraftUpdate(cmd *cobra.Command, mediaID string) (map[string]any, error) {
+	if draftUpdateHTML != "" && draftUpdateMarkdown != "" {
+		return nil, fmt.Errorf("--html and --markdown cannot be used together")
+	}
+
+	svc := draft.NewService(cfg, log)
+	articles, err := svc.GetDraft(mediaID)
+	if err != nil {
+		return nil, err
+	}
+	if draftUpdateIndex < 0 || draftUpdateIndex >= len(articles) {
+		return nil, fmt.Errorf("index %d out of range, draft has %d article(s)", draftUpdateIndex, len(articles))
+	}
+
+	article := articles[draftUpdateIndex]
+	var updated []string
+
+	// 重新转换 Markdown
+	if draftUpdateMarkdown != "" {
+		result, err := convertMarkdownFile(draftUpdateMarkdown, converter.ConvertMode(draftUpdateMode), draftUpdateTheme)
+		if err != nil {
+			return nil, err
+		}
+
+		// 只替换正文和元数据，封面（避免每次更新都重复上传）及其裁剪设置保持不变
+		built := buildDraftArticle(result, "")
+		article.Title = built.Title
+		article.Author = built.Author
+		article.Digest = built.Digest
+		article.Content = built.Content
+		article.ContentSourceURL = built.ContentSourceURL
+		article.NeedOpenComment = built.NeedOpenComment
+		article.OnlyFansCanComment = built.OnlyFansCanComment
+		updated = append(updated, "content", "title", "author", "digest", "source_url", "comment")
+	}
+
+	if draftUpdateHTML != "" {
+		html, err := os.ReadFile(draftUpdateHTML)
+		if err != nil {
+			return nil, fmt.Errorf("read html file: %w", err)
+		}
+		article.Content = string(html)
+		updated = append(updated, "content")
+	}
+
+	if draftUpdateCover != "" {
+		cover, err := uploadCoverImage(draftUpdateCover)
+		if err != nil {
+			return nil, fmt.Errorf("上传封面图片失败: %w", err)
+		}
+		article.ThumbMediaID = cover.MediaID
+		article.ShowCoverPic = 1
+		// 原裁剪坐标针对旧封面，换图后由微信按默认方式裁剪
+		article.PicCrop235_1, article.PicCrop1_1 = "", ""
+		updated = append(updated, "cover")
+	}
+
+	// 字段参数最后应用
+	flags := cmd.Flags()
+	if flags.Changed("title") {
+		article.Title = draftUpdateTitle
+		updated = append(updated, "title")
+	}
+	if flags.Changed("author") {
+		article.Author = draftUpdateAuthor
+		updated = append(updated, "author")
+	}
+	if flags.Changed("digest") {
+		article.Digest = draftUpdateDigest
+		updated = append(updated, "digest")
+	}
+	if flags.Changed("source-url") {
+		article.ContentSourceURL = draftUpdateSourceURL
+		updated = append(updated, "source_url")
+	}
+
+	if len(updated) == 0 {
+		return nil, &DraftError{
+			Message: "没有需要更新的内容",
+			Hint:    "请指定 --title、--author、--digest、--source-url、--html、--markdown 或 --cover",
+		}
+	}
+
+	if err := svc.UpdateDraft(mediaID, draftUpdateIndex, article); err != nil {
+		return nil, err
+	}
+
+	log.Info("draft article updated",
+		zap.String("media_id", maskMediaID(mediaID)),
+		zap.Int("index", draftUpdateIndex),
+		zap.Strings("fields", updated))
+
+	return map[string]any{
+		"media_id": mediaID,
+		"index":    draftUpdateIndex,
+		"title":    article.Title,
+		"updated":  dedupStrings(updated),
+	}, nil
+}
+
+// dedupStrings 去除重复项，保持原有顺序
+func dedupStrings(items []string) []string {
+	seen := make(map[string]bool, len(items))
+	result := make([]string, 0, len(items))
+	for _, item := range items {
+		if !seen[item] {
+			seen[item] = true
+			result = append(result, item)
+		}
+	}
+	return result
+}
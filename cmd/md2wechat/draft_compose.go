@@ -0,0 +1,291 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/geekjourneyx/md2wechat-skill/internal/converter"
+	"github.com/geekjourneyx/md2wechat-skill/internal/draft"
+	"github.com/geekjourneyx/md2wechat-skill/internal/image"
+	"github.com/geekjourneyx/md2wechat-skill/internal/wechat"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+)
+
+// draft compose 命令参数
+var (
+	composeManifest string
+	composeMode     string
+	composeTheme    string
+)
+
+// composeManifestFile 多图文草稿清单（YAML）
+type composeManifestFile struct {
+	Mode     string             `yaml:"mode"`
+	Theme    string             `yaml:"theme"`
+	Articles []composeArticleIn `yaml:"articles"`
+}
+
+// composeArticleIn 清单中的单篇文章
+type composeArticleIn struct {
+	File  string `yaml:"file"`
+	Theme string `yaml:"theme"` // 为空时使用清单的 theme，其次 front matter
+	Cover string `yaml:"cover"` // 为空时使用 front matter 的 cover
+	Mode  string `yaml:"mode"`  // 为空时使用清单的 mode
+}
+
+// composeArticleResult 已加入草稿的文章
+type composeArticleResult struct {
+	File         string `json:"file"`
+	Title        string `json:"title"`
+	Theme        string `json:"theme"`
+	ThumbMediaID string `json:"thumb_media_id"`
+}
+
+func init() {
+	var composeCmd = &cobra.Command{
+		Use:   "compose [markdown_file...]",
+		Short: "Create one multi-article draft from several Markdown files",
+		Long: `Create one draft containing several articles, in the order given.
+The first article is the headline.
+
+Each file is converted with its own theme and cover (front matter, or the
+manifest entry), its images are uploaded, and then a single draft is created.
+If the draft cannot be created, the covers newly uploaded for it are deleted
+again; covers reused from the upload cache are kept.
+
+A draft holds at most 8 articles.
+
+Manifest format (paths are relative to the manifest file):
+
+  mode: api
+  theme: default
+  articles:
+    - file: main.md
+      theme: apple
+      cover: covers/main.jpg
+    - file: second.md
+      mode: local
+
+Examples:
+  md2wechat draft compose main.md second.md third.md
+  md2wechat draft compose --manifest issue-42.yaml`,
+		Run: func(cmd *cobra.Command, args []string) {
+			result, err := runDraftCompose(cmd, args)
+			if err != nil {
+				responseError(err)
+				return
+			}
+			responseSuccess(result)
+		},
+	}
+	composeCmd.Flags().StringVarP(&composeManifest, "manifest", "m", "", "YAML manifest listing the articles")
+	composeCmd.Flags().StringVar(&composeMode, "mode", "api", "Conversion mode: api, ai or local")
+	composeCmd.Flags().StringVar(&composeTheme, "theme", "", "Theme for all articles (default: front matter theme)")
+	draftCmd.AddCommand(composeCmd)
+}
+
+// runDraftCompose 转换所有文章并创建一个多图文草稿
+func runDraftCompose(cmd *cobra.Command, args []string) (map[string]any, error) {
+	entries, err := composeEntries(cmd, args)
+	if err != nil {
+		return nil, err
+	}
+
+	// 上传前先检查文章数量和封面，避免上传后才发现问题
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("no articles given, pass Markdown files or --manifest")
+	}
+	if len(entries) > draft.MaxArticlesPerDraft {
+		return nil, fmt.Errorf("too many articles: %d (a draft holds at most %d)", len(entries), draft.MaxArticlesPerDraft)
+	}
+	for i := range entries {
+		if err := resolveComposeCover(&entries[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	var uploadedCovers []string         // 本次新上传的封面，复用缓存的封面可能被其它草稿使用，不回滚
+	coverIDs := make(map[string]string) // 同一封面只上传一次
+	articles := make([]draft.Article, 0, len(entries))
+	results := make([]composeArticleResult, 0, len(entries))
+
+	for i, entry := range entries {
+		log.Info("composing article",
+			zap.Int("index", i),
+			zap.String("file", entry.File))
+
+		result, err := convertMarkdownFile(entry.File, converter.ConvertMode(entry.Mode), entry.Theme)
+		if err != nil {
+			rollbackCovers(uploadedCovers)
+			return nil, fmt.Errorf("%s: %w", entry.File, err)
+		}
+
+		thumbMediaID, ok := coverIDs[entry.Cover]
+		if !ok {
+			cover, err := uploadCoverImage(entry.Cover)
+			if err != nil {
+				rollbackCovers(uploadedCovers)
+				return nil, fmt.Errorf("%s: 上传封面图片失败: %w", entry.File, err)
+			}
+			thumbMediaID = cover.MediaID
+			coverIDs[entry.Cover] = thumbMediaID
+			if !cover.Cached {
+				uploadedCovers = append(uploadedCovers, thumbMediaID)
+			}
+		}
+
+		article := buildDraftArticle(result, thumbMediaID)
+		articles = append(articles, article)
+		results = append(results, composeArticleResult{
+			File:         entry.File,
+			Title:        article.Title,
+			Theme:        result.Theme,
+			ThumbMediaID: thumbMediaID,
+		})
+	}
+
+	svc := draft.NewService(cfg, log)
+	draftResult, err := svc.CreateDraft(articles)
+	if err != nil {
+		rollbackCovers(uploadedCovers)
+		return nil, fmt.Errorf("create draft: %w", err)
+	}
+
+	log.Info("multi-article draft created",
+		zap.String("media_id", maskMediaID(draftResult.MediaID)),
+		zap.Int("articles", len(articles)))
+
+	return map[string]any{
+		"media_id": draftResult.MediaID,
+		"count":    len(articles),
+		"articles": results,
+	}, nil
+}
+
+// composeEntries 从命令行参数或清单文件确定文章列表
+func composeEntries(cmd *cobra.Command, args []string) ([]composeArticleIn, error) {
+	if composeManifest != "" && len(args) > 0 {
+		return nil, fmt.Errorf("pass either Markdown files or --manifest, not both")
+	}
+
+	if composeManifest == "" {
+		entries := make([]composeArticleIn, 0, len(args))
+		for _, file := range args {
+			entries = append(entries, composeArticleIn{File: file, Theme: composeTheme, Mode: composeMode})
+		}
+		return entries, nil
+	}
+
+	data, err := os.ReadFile(composeManifest)
+	if err != nil {
+		return nil, fmt.Errorf("read manifest: %w", err)
+	}
+	var manifest composeManifestFile
+	if err := yaml.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("parse manifest %s: %w", composeManifest, err)
+	}
+
+	// 命令行参数优先于清单
+	if cmd.Flags().Changed("mode") || manifest.Mode == "" {
+		manifest.Mode = composeMode
+	}
+	if cmd.Flags().Changed("theme") {
+		manifest.Theme = composeTheme
+	}
+
+	baseDir := filepath.Dir(composeManifest)
+	entries := manifest.Articles
+	for i := range entries {
+		if entries[i].File == "" {
+			return nil, fmt.Errorf("manifest article %d: file is required", i+1)
+		}
+		entries[i].File = resolveManifestPath(baseDir, entries[i].File)
+		entries[i].Cover = resolveManifestPath(baseDir, entries[i].Cover)
+		if entries[i].Mode == "" {
+			entries[i].Mode = manifest.Mode
+		}
+		if entries[i].Theme == "" {
+			entries[i].Theme = manifest.Theme
+		}
+	}
+	return entries, nil
+}
+
+// resolveManifestPath 将清单中的相对路径解析为相对于清单文件的路径
+func resolveManifestPath(baseDir, path string) string {
+	if path == "" || filepath.IsAbs(path) || strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://") {
+		return path
+	}
+	return filepath.Join(baseDir, path)
+}
+
+// resolveComposeCover 确定文章封面（清单优先，其次 front matter），每篇文章都必须有封面
+func resolveComposeCover(entry *composeArticleIn) error {
+	markdown, err := os.ReadFile(entry.File)
+	if err != nil {
+		return fmt.Errorf("read markdown file: %w", err)
+	}
+
+	if entry.Cover == "" {
+		meta, _, err := converter.ParseFrontMatter(string(markdown))
+		if err != nil {
+			return fmt.Errorf("%s: %w", entry.File, err)
+		}
+		entry.Cover = resolveArticlePath(entry.File, meta.Cover)
+	}
+
+	if entry.Cover == "" {
+		return &DraftError{
+			Message: fmt.Sprintf("%s 没有封面图片", entry.File),
+			Hint:    "多图文草稿中每篇文章都需要封面，请在 front matter 中设置 cover，或在清单中设置 cover",
+		}
+	}
+	if !strings.HasPrefix(entry.Cover, "http://") && !strings.HasPrefix(entry.Cover, "https://") {
+		if _, err := os.Stat(entry.Cover); err != nil {
+			return fmt.Errorf("%s: cover not found: %s", entry.File, entry.Cover)
+		}
+	}
+	return nil
+}
+
+// rollbackCovers 删除已上传的封面素材（草稿创建失败时调用），
+// 同时删除对应的上传缓存条目，避免之后复用已删除的 media_id
+func rollbackCovers(mediaIDs []string) {
+	if len(mediaIDs) == 0 {
+		return
+	}
+
+	ws := wechat.NewService(cfg, log)
+	deleted := make(map[string]bool, len(mediaIDs))
+	for _, mediaID := range mediaIDs {
+		if err := ws.DeleteMaterial(mediaID); err != nil {
+			log.Warn("failed to roll back uploaded cover",
+				zap.String("media_id", mediaID),
+				zap.Error(err))
+			continue
+		}
+		deleted[mediaID] = true
+	}
+	log.Info("uploaded covers rolled back", zap.Int("count", len(deleted)))
+
+	if len(deleted) == 0 {
+		return
+	}
+	cache, err := image.OpenUploadCache(cfg.CacheDir())
+	if err != nil {
+		log.Warn("failed to open upload cache", zap.Error(err))
+		return
+	}
+	for _, entry := range cache.List() {
+		if entry.AppID == cfg.WechatAppID && entry.Kind == image.UploadCover && deleted[entry.MediaID] {
+			cache.Delete(entry.Key)
+		}
+	}
+	if err := cache.Save(); err != nil {
+		log.Warn("failed to save upload cache", zap.Error(err))
+	}
+}
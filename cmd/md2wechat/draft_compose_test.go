@@ -0,0 +1,85 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/geekjourneyx/md2wechat-skill/internal/image"
+)
+
+func TestRunDraftComposeRollback(t *testing.T) {
+	var deleted []string
+	fakeWechat(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/cgi-bin/material/add_material":
+			_, _ = io.WriteString(w, `{"media_id":"fresh-media","url":"https://mmbiz.qpic.cn/fresh"}`)
+		case "/cgi-bin/draft/add":
+			_, _ = io.WriteString(w, `{"errcode":45009,"errmsg":"reach max api daily quota limit"}`)
+		case "/cgi-bin/material/del_material":
+			var req struct {
+				MediaID string `json:"media_id"`
+			}
+			_ = json.NewDecoder(r.Body).Decode(&req)
+			deleted = append(deleted, req.MediaID)
+			_, _ = io.WriteString(w, `{"errcode":0,"errmsg":"ok"}`)
+		default:
+			t.Errorf("unexpected request %s", r.URL.Path)
+		}
+	})
+	oldMode := composeMode
+	composeMode = "local"
+	t.Cleanup(func() { composeMode = oldMode })
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"cached.png": "cached cover",
+		"fresh.png":  "fresh cover",
+		"a.md":       "---\ntitle: 第一篇\ncover: cached.png\n---\n\n正文\n",
+		"b.md":       "---\ntitle: 第二篇\ncover: fresh.png\n---\n\n正文\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 之前的运行已上传过第一篇的封面，可能仍被其它草稿使用
+	cache, err := image.OpenUploadCache(cfg.CacheDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	cache.Put(image.CacheEntry{AppID: "wx-test", Kind: image.UploadCover, Hash: image.HashContent([]byte("cached cover")), MediaID: "cached-media"})
+	if err := cache.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd, _, err := draftCmd.Find([]string{"compose"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = runDraftCompose(cmd, []string{filepath.Join(dir, "a.md"), filepath.Join(dir, "b.md")})
+	if err == nil || !strings.Contains(err.Error(), "45009") {
+		t.Fatalf("runDraftCompose() error = %v, want draft creation failure", err)
+	}
+
+	// 只回滚本次新上传的封面
+	if strings.Join(deleted, ",") != "fresh-media" {
+		t.Errorf("deleted materials = %v, want [fresh-media]", deleted)
+	}
+	cache, err = image.OpenUploadCache(cfg.CacheDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var mediaIDs []string
+	for _, entry := range cache.List() {
+		mediaIDs = append(mediaIDs, entry.MediaID)
+	}
+	if strings.Join(mediaIDs, ",") != "cached-media" {
+		t.Errorf("cached covers = %v, want [cached-media]", mediaIDs)
+	}
+}
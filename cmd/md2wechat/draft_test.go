@@ -0,0 +1,179 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/geekjourneyx/md2wechat-skill/internal/config"
+	"github.com/silenceper/wechat/v2/util"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"go.uber.org/zap"
+)
+
+// draftGetResponse 模拟草稿中的一篇文章，带封面裁剪设置
+const draftGetResponse = `{"news_item":[{"title":"旧标题","author":"旧作者","digest":"旧摘要","content":"<p>旧正文</p>",
+	"content_source_url":"https://example.com/old","thumb_media_id":"thumb-1","show_cover_pic":1,
+	"pic_crop_235_1":"0.1_0_0.9_1","pic_crop_1_1":"0.2_0_0.8_1"}]}`
+
+// fakeWechat 启动模拟的微信接口（access_token 接口已内置）并替换全局配置，测试结束后恢复
+func fakeWechat(t *testing.T, handle http.HandlerFunc) {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/cgi-bin/token" {
+			_, _ = io.WriteString(w, `{"access_token":"test-token","expires_in":7200}`)
+			return
+		}
+		handle(w, r)
+	}))
+	util.SetURIModifier(func(uri string) string {
+		return strings.Replace(uri, "https://api.weixin.qq.com", srv.URL, 1)
+	})
+	t.Setenv("MD2WECHAT_CACHE_DIR", t.TempDir())
+
+	oldCfg, oldLog := cfg, log
+	cfg = &config.Config{WechatAppID: "wx-test", WechatSecret: "secret"}
+	log = zap.NewNop()
+	t.Cleanup(func() {
+		util.SetURIModifier(nil)
+		srv.Close()
+		cfg, log = oldCfg, oldLog
+	})
+}
+
+// setupDraftUpdate 启动模拟的微信草稿接口，返回 update 子命令和提交的文章（JSON 解码为 map）
+func setupDraftUpdate(t *testing.T) (*cobra.Command, *map[string]any) {
+	t.Helper()
+	var updated map[string]any
+	fakeWechat(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/cgi-bin/draft/get":
+			_, _ = io.WriteString(w, draftGetResponse)
+		case "/cgi-bin/draft/update":
+			var req struct {
+				Articles map[string]any `json:"articles"`
+			}
+			_ = json.NewDecoder(r.Body).Decode(&req)
+			updated = req.Articles
+			_, _ = io.WriteString(w, `{"errcode":0,"errmsg":"ok"}`)
+		default:
+			t.Errorf("unexpected request %s", r.URL.Path)
+		}
+	})
+
+	cmd, _, err := draftCmd.Find([]string{"update"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		cmd.Flags().VisitAll(func(f *pflag.Flag) {
+			_ = f.Value.Set(f.DefValue)
+			f.Changed = false
+		})
+	})
+	return cmd, &updated
+}
+
+func TestRunDraftUpdate(t *testing.T) {
+	dir := t.TempDir()
+	htmlFile := filepath.Join(dir, "content.html")
+	mdFile := filepath.Join(dir, "article.md")
+	_ = os.WriteFile(htmlFile, []byte("<p>新正文</p>"), 0644)
+	_ = os.WriteFile(mdFile, []byte("---\ntitle: 新标题\nauthor: 新作者\n---\n\n正文段落\n"), 0644)
+
+	tests := []struct {
+		name    string
+		flags   map[string]string
+		want    map[string]any
+		updated []string
+	}{
+		{
+			name:    "title only",
+			flags:   map[string]string{"title": "改过的标题"},
+			want:    map[string]any{"title": "改过的标题", "author": "旧作者", "content": "<p>旧正文</p>"},
+			updated: []string{"title"},
+		},
+		{
+			name:    "html and source url",
+			flags:   map[string]string{"html": htmlFile, "source-url": ""},
+			want:    map[string]any{"title": "旧标题", "content": "<p>新正文</p>", "content_source_url": nil},
+			updated: []string{"content", "source_url"},
+		},
+		{
+			name:  "markdown keeps cover",
+			flags: map[string]string{"markdown": mdFile, "mode": "local", "author": "覆盖作者"},
+			want: map[string]any{"title": "新标题", "author": "覆盖作者", "digest": "正文段落",
+				"content_source_url": nil, "thumb_media_id": "thumb-1", "show_cover_pic": 1.0},
+			updated: []string{"content", "title", "author", "digest", "source_url", "comment"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd, updated := setupDraftUpdate(t)
+			for name, value := range tt.flags {
+				if err := cmd.Flags().Set(name, value); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			result, err := runDraftUpdate(cmd, "media-1")
+			if err != nil {
+				t.Fatalf("runDraftUpdate() error = %v", err)
+			}
+			if got := strings.Join(result["updated"].([]string), ","); got != strings.Join(tt.updated, ",") {
+				t.Errorf("updated = %s, want %s", got, strings.Join(tt.updated, ","))
+			}
+			article := *updated
+			for key, want := range tt.want {
+				if got := article[key]; got != want {
+					t.Errorf("%s = %v, want %v", key, got, want)
+				}
+			}
+			// 封面裁剪设置原样保留
+			if article["pic_crop_235_1"] != "0.1_0_0.9_1" || article["pic_crop_1_1"] != "0.2_0_0.8_1" {
+				t.Errorf("crop settings lost: %v", article)
+			}
+		})
+	}
+}
+
+func TestRunDraftUpdateErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		flags map[string]string
+		want  string
+	}{
+		{"nothing to update", nil, "没有需要更新的内容"},
+		{"index out of range", map[string]string{"index": "1", "title": "x"}, "index 1 out of range, draft has 1 article(s)"},
+		{"html with markdown", map[string]string{"html": "a.html", "markdown": "a.md"}, "--html and --markdown cannot be used together"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd, updated := setupDraftUpdate(t)
+			for name, value := range tt.flags {
+				if err := cmd.Flags().Set(name, value); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			_, err := runDraftUpdate(cmd, "media-1")
+			var draftErr *DraftError
+			if errors.As(err, &draftErr) {
+				err = errors.New(draftErr.Message)
+			}
+			if err == nil || err.Error() != tt.want {
+				t.Errorf("runDraftUpdate() error = %v, want %q", err, tt.want)
+			}
+			if *updated != nil {
+				t.Errorf("draft updated: %v", *updated)
+			}
+		})
+	}
+}
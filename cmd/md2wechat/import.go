@@ -0,0 +1,159 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/geekjourneyx/md2wechat-skill/internal/importer"
+	"github.com/spf13/cobra"
+)
+
+// importCmd import 命令
+var importCmd = &cobra.Command{
+	Use:   "import <url|html_file>",
+	Short: "Convert a WeChat article back to Markdown",
+	Long: `Rebuild Markdown from a published WeChat article (URL) or saved article/draft HTML.
+
+Inline styles are dropped; headings, lists, blockquotes, code blocks, tables,
+links and images are recovered. Images are saved into an assets folder next
+to the Markdown file and referenced with relative paths. Title, author and
+digest from the article page go into front matter.
+
+The output defaults to the HTML file name with .md, or the article title for
+URLs. Existing files are not overwritten without --force.
+
+Examples:
+  md2wechat import https://mp.weixin.qq.com/s/xxxx
+  md2wechat import saved.html -o posts/hello.md --assets images
+  md2wechat import draft.html --no-images --no-front-matter`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runImport(args[0]); err != nil {
+			responseError(err)
+		}
+	},
+}
+
+// import 命令参数
+var (
+	importOutput        string
+	importAssets        string
+	importNoImages      bool
+	importNoFrontMatter bool
+	importForce         bool
+)
+
+func init() {
+	importCmd.Flags().StringVarP(&importOutput, "output", "o", "", "Markdown output file (default: <html_file>.md or <title>.md)")
+	importCmd.Flags().StringVar(&importAssets, "assets", "assets", "Image folder, relative to the Markdown file")
+	importCmd.Flags().BoolVar(&importNoImages, "no-images", false, "Keep the original image URLs instead of downloading images")
+	importCmd.Flags().BoolVar(&importNoFrontMatter, "no-front-matter", false, "Do not write title/author/digest front matter")
+	importCmd.Flags().BoolVar(&importForce, "force", false, "Overwrite an existing Markdown file")
+}
+
+// runImport 导入文章
+func runImport(source string) error {
+	ctx := context.Background()
+	client := importer.NewHTTPClient()
+
+	var src, pageURL, baseDir string
+	if strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") {
+		var err error
+		if src, err = importer.Fetch(ctx, client, source); err != nil {
+			return err
+		}
+		pageURL = source
+	} else {
+		data, err := os.ReadFile(source)
+		if err != nil {
+			return fmt.Errorf("read html file: %w", err)
+		}
+		src = string(data)
+		baseDir = filepath.Dir(source)
+	}
+
+	article, err := importer.Parse(src, pageURL)
+	if err != nil {
+		return err
+	}
+
+	output := importOutput
+	if output == "" {
+		output = importOutputName(source, pageURL, article.Meta.Title)
+	}
+	if _, err := os.Stat(output); err == nil && !importForce {
+		return fmt.Errorf("%s already exists, use --force to overwrite", output)
+	}
+
+	sources := article.ImageSources()
+	var paths map[string]string
+	var images []importer.ImageResult
+	if !importNoImages && len(sources) > 0 {
+		dir := importAssets
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(filepath.Dir(output), dir)
+		}
+		rel, err := filepath.Rel(filepath.Dir(output), dir)
+		if err != nil {
+			rel = dir
+		}
+		paths, images = importer.SaveImages(ctx, client, sources, baseDir, dir, rel)
+	}
+
+	markdown, err := article.Markdown(paths, !importNoFrontMatter)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(output), 0755); err != nil {
+		return fmt.Errorf("create output dir: %w", err)
+	}
+	if err := os.WriteFile(output, []byte(markdown), 0644); err != nil {
+		return fmt.Errorf("write markdown: %w", err)
+	}
+
+	failed := 0
+	for _, img := range images {
+		if img.Error != "" {
+			failed++
+		}
+	}
+	responseSuccess(map[string]any{
+		"source":        source,
+		"output":        output,
+		"title":         article.Meta.Title,
+		"author":        article.Meta.Author,
+		"image_count":   len(sources),
+		"images_failed": failed,
+		"images":        images,
+	})
+	return nil
+}
+
+// importOutputName 默认输出文件名：HTML 文件同名的 .md，URL 使用文章标题
+func importOutputName(source, pageURL, title string) string {
+	if pageURL == "" {
+		return strings.TrimSuffix(source, filepath.Ext(source)) + ".md"
+	}
+
+	name := strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`/\:*?"<>|`, r) || r < ' ' {
+			return -1
+		}
+		if r == ' ' {
+			return '-'
+		}
+		return r
+	}, strings.TrimSpace(title))
+	for utf8.RuneCountInString(name) > 60 {
+		_, size := utf8.DecodeLastRuneInString(name)
+		name = name[:len(name)-size]
+	}
+	if name == "" {
+		name = "article"
+	}
+	return name + ".md"
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/geekjourneyx/md2wechat-skill/internal/converter"
+	"github.com/geekjourneyx/md2wechat-skill/internal/htmllint"
+	"github.com/spf13/cobra"
+)
+
+// lintCmd lint 命令
+var lintCmd = &cobra.Command{
+	Use:   "lint <html_file|markdown_file>",
+	Short: "Check HTML for content the WeChat editor drops",
+	Long: `Check article HTML for constructs WeChat strips or rejects without warning:
+
+  unsupported-tag     <style>, <script>, <iframe>, <link>, form elements, ...
+  unsupported-attr    class, id, on* event handlers, javascript: URLs
+  unsupported-style   position: absolute | fixed | sticky
+  external-resource   url(...) in styles (external fonts, background images)
+  external-link       <a href> outside mp.weixin.qq.com
+  external-image      images not uploaded to WeChat
+  missing-style       elements relying on a stylesheet, bare headings/tables
+  base64-image        oversized inline data: images
+  body-size           HTML over 1MB
+  text-length         more than 20,000 characters of text
+
+Markdown input is converted first (images are not uploaded).
+With --fix, fixable issues are removed and the fixed HTML is written to
+--output (HTML input without --output is fixed in place).
+
+The report is JSON (or --format text); the command exits with status 1
+when an unfixed issue at or above --fail-on remains, so CI can fail on it.
+
+Examples:
+  md2wechat lint article.html
+  md2wechat lint article.md --mode local --fail-on warning
+  md2wechat lint article.html --fix --output fixed.html`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runLint(args[0]); err != nil {
+			responseError(err)
+		}
+	},
+}
+
+// lint 命令参数
+var (
+	lintFix    bool
+	lintOutput string
+	lintFailOn string
+	lintFormat string
+	lintMode   string
+	lintTheme  string
+)
+
+func init() {
+	lintCmd.Flags().BoolVar(&lintFix, "fix", false, "Remove fixable issues")
+	lintCmd.Flags().StringVarP(&lintOutput, "output", "o", "", "Write the fixed HTML to this file")
+	lintCmd.Flags().StringVar(&lintFailOn, "fail-on", "error", "Exit with status 1 on issues at this level: error, warning, info or none")
+	lintCmd.Flags().StringVar(&lintFormat, "format", "json", "Report format: json or text")
+	lintCmd.Flags().StringVar(&lintMode, "mode", "local", "Conversion mode for Markdown input: api, ai or local")
+	lintCmd.Flags().StringVar(&lintTheme, "theme", "", "Theme for Markdown input (default: front matter theme)")
+}
+
+// runLint 检查文件并输出报告
+func runLint(file string) error {
+	failOn, err := htmllint.ParseSeverity(lintFailOn)
+	if err != nil {
+		return err
+	}
+	if lintFormat != "json" && lintFormat != "text" {
+		return fmt.Errorf("invalid format %q (json or text)", lintFormat)
+	}
+
+	content, source, err := lintInput(file)
+	if err != nil {
+		return err
+	}
+
+	var report *htmllint.Report
+	output := ""
+	if lintFix {
+		output = lintOutput
+		if output == "" {
+			if source != "html" {
+				return fmt.Errorf("--fix on Markdown input needs --output for the fixed HTML")
+			}
+			output = file
+		}
+
+		var fixed string
+		fixed, report = htmllint.Fix(content, htmllint.DefaultOptions())
+		if err := os.WriteFile(output, []byte(fixed), 0644); err != nil {
+			return fmt.Errorf("write fixed html: %w", err)
+		}
+	} else {
+		report = htmllint.Lint(content, htmllint.DefaultOptions())
+	}
+
+	passed := !report.Failed(failOn)
+	if lintFormat == "text" {
+		printLintText(file, report, passed)
+	} else {
+		printJSON(map[string]any{
+			"success": passed,
+			"data": map[string]any{
+				"file":    file,
+				"source":  source,
+				"fail_on": lintFailOn,
+				"passed":  passed,
+				"output":  output,
+				"report":  report,
+			},
+		})
+	}
+
+	if !passed {
+		os.Exit(1)
+	}
+	return nil
+}
+
+// lintInput 读取要检查的 HTML；Markdown 文件先转换（不上传图片）
+func lintInput(file string) (string, string, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return "", "", fmt.Errorf("read file: %w", err)
+	}
+
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".md", ".markdown":
+	default:
+		return string(data), "html", nil
+	}
+
+	if err := initOfflineConfig(); err != nil {
+		return "", "", err
+	}
+
+	conv := converter.NewConverter(cfg, log)
+	result := conv.Convert(&converter.ConvertRequest{
+		Markdown: string(data),
+		Mode:     converter.ConvertMode(lintMode),
+		Theme:    lintTheme,
+		BaseDir:  filepath.Dir(file),
+	})
+	if converter.IsAIRequest(result) {
+		return "", "", fmt.Errorf("AI mode needs an LLM for this command, set api.llm_key or use --mode api/local")
+	}
+	if !result.Success {
+		return "", "", fmt.Errorf("conversion failed: %s", result.Error)
+	}
+	return result.HTML, "markdown", nil
+}
+
+// printLintText 以 file:line: 格式输出报告
+func printLintText(file string, report *htmllint.Report, passed bool) {
+	for _, issue := range report.Issues {
+		fixed := ""
+		if issue.Fixed {
+			fixed = " (fixed)"
+		}
+		fmt.Printf("%s:%d: %s [%s] %s%s\n", file, issue.Line, issue.Severity, issue.Rule, issue.Message, fixed)
+	}
+
+	status := "passed"
+	if !passed {
+		status = "failed"
+	}
+	fmt.Printf("%s: %d errors, %d warnings, %d infos, %d fixed — %s\n",
+		file, report.Errors, report.Warnings, report.Infos, report.Fixed, status)
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 
@@ -15,16 +16,32 @@ import (
 var (
 	cfg *config.Config
 	log *zap.Logger
+
+	// accountName 全局 --account 参数：使用配置文件中的指定账号
+	accountName string
 )
 
 // initConfig 初始化配置（延迟加载，允许 help 命令无需配置）
 func initConfig() error {
+	if cfg != nil && log != nil {
+		return cfg.ValidateWechat()
+	}
+	return loadConfig(config.LoadAccount)
+}
+
+// initOfflineConfig 初始化配置但不要求微信公众号凭证，用于不一定调用微信接口的命令（本地转换、lint）
+// 之后需要上传或创建草稿时用 cfg.ValidateWechat 检查
+func initOfflineConfig() error {
 	if cfg != nil && log != nil {
 		return nil
 	}
+	return loadConfig(config.LoadAccountOffline)
+}
 
+// loadConfig 使用 load 加载 --account 指定账号的配置并创建日志
+func loadConfig(load func(account string) (*config.Config, error)) error {
 	var err error
-	cfg, err = config.Load()
+	cfg, err = load(accountName)
 	if err != nil {
 		return err
 	}
@@ -45,21 +62,30 @@ func main() {
 and supports uploading materials and creating drafts.
 
 Environment Variables:
-  WECHAT_APPID                   WeChat Official Account AppID (required)
-  WECHAT_SECRET                  WeChat API Secret (required)
+  WECHAT_APPID                   WeChat Official Account AppID (required for upload and drafts)
+  WECHAT_SECRET                  WeChat API Secret (required for upload and drafts)
   IMAGE_API_KEY                  Image generation API key (for AI images)
   IMAGE_API_BASE                 Image API base URL (default: https://api.openai.com/v1)
+  LLM_PROVIDER                   LLM for AI convert mode: openai or anthropic (default: openai)
+  LLM_API_KEY                    LLM API key (enables headless AI conversion)
+  LLM_API_BASE                   LLM API base URL (OpenAI/Anthropic compatible)
+  LLM_MODEL                      LLM model name
   COMPRESS_IMAGES                Compress images > 1920px (default: true)
   MAX_IMAGE_WIDTH                Max image width in pixels (default: 1920)
+  IMAGE_WORKERS                  Concurrent image uploads (default: 4)
+  MD2WECHAT_ACCOUNT              Account profile to use (same as --account)
 
 Examples:
   md2wechat upload_image ./photo.jpg
   md2wechat download_and_upload https://example.com/image.jpg
   md2wechat generate_image "A cute cat"
-  md2wechat create_draft draft.json`,
+  md2wechat create_draft draft.json
+  md2wechat draft list
+  md2wechat publish article.md`,
 		SilenceErrors: true,
 		SilenceUsage:  true,
 	}
+	rootCmd.PersistentFlags().StringVar(&accountName, "account", "", "Account profile from the config file (env MD2WECHAT_ACCOUNT)")
 
 	// upload_image command
 	var uploadImageCmd = &cobra.Command{
@@ -166,6 +192,24 @@ Examples:
 	// config command
 	rootCmd.AddCommand(configCmd)
 
+	// cache command
+	rootCmd.AddCommand(cacheCmd)
+
+	// draft command
+	rootCmd.AddCommand(draftCmd)
+
+	// publish command
+	rootCmd.AddCommand(publishCmd)
+
+	// sync command
+	rootCmd.AddCommand(syncCmd)
+
+	// lint command
+	rootCmd.AddCommand(lintCmd)
+
+	// import command
+	rootCmd.AddCommand(importCmd)
+
 	// write command
 	rootCmd.AddCommand(writeCmd)
 
@@ -193,11 +237,25 @@ func responseSuccess(data any) {
 	printJSON(response)
 }
 
+// fieldsError 需要在错误输出中附带额外字段的错误
+type fieldsError interface {
+	error
+	Fields() map[string]any
+}
+
 func responseError(err error) {
 	response := map[string]any{
 		"success": false,
 		"error":   err.Error(),
 	}
+	var withFields fieldsError
+	if errors.As(err, &withFields) {
+		for key, value := range withFields.Fields() {
+			if _, ok := response[key]; !ok {
+				response[key] = value
+			}
+		}
+	}
 	printJSON(response)
 	os.Exit(1)
 }
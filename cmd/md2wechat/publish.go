@@ -0,0 +1,229 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/geekjourneyx/md2wechat-skill/internal/converter"
+	"github.com/geekjourneyx/md2wechat-skill/internal/publish"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+// publishCmd publish 命令
+var publishCmd = &cobra.Command{
+	Use:   "publish <draft_media_id|markdown_file>",
+	Short: "Publish a draft through the WeChat free-publish API",
+	Long: `Publish a draft and wait for the result.
+
+The argument is either the media_id of an existing draft, or a Markdown
+file which is converted (images uploaded) into a new draft first.
+The publish status is polled until the article is published or fails,
+then the publish_id, article_id and article URL are reported.
+
+Subcommands:
+  status  Query the status of a publish task
+  delete  Delete a published article
+
+Examples:
+  md2wechat publish <media_id>
+  md2wechat publish article.md --mode local --cover cover.jpg
+  md2wechat publish <media_id> --no-wait
+  md2wechat publish status <publish_id> --wait
+  md2wechat publish delete <article_id> --yes`,
+	Args: cobra.ExactArgs(1),
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		return initConfig()
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		result, err := runPublish(args[0])
+		if err != nil {
+			responseError(err)
+			return
+		}
+		responseSuccess(result)
+	},
+}
+
+// publish 命令参数
+var (
+	publishMode     string
+	publishTheme    string
+	publishCover    string
+	publishNoWait   bool
+	publishTimeout  time.Duration
+	publishInterval time.Duration
+
+	publishStatusWait bool
+
+	publishDeleteIndex int
+	publishDeleteYes   bool
+)
+
+func init() {
+	publishCmd.Flags().StringVar(&publishMode, "mode", "api", "Conversion mode for Markdown input: api, ai or local")
+	publishCmd.Flags().StringVar(&publishTheme, "theme", "", "Theme for Markdown input (default: front matter theme)")
+	publishCmd.Flags().StringVar(&publishCover, "cover", "", "Cover image for Markdown input (overrides front matter cover)")
+	publishCmd.Flags().BoolVar(&publishNoWait, "no-wait", false, "Submit only, do not wait for the publish result")
+	publishCmd.PersistentFlags().DurationVar(&publishTimeout, "timeout", 5*time.Minute, "Maximum time to wait for the publish result")
+	publishCmd.PersistentFlags().DurationVar(&publishInterval, "interval", 3*time.Second, "Status polling interval")
+
+	// status 子命令
+	var statusCmd = &cobra.Command{
+		Use:   "status <publish_id>",
+		Short: "Query the status of a publish task",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			publishID, err := strconv.ParseInt(args[0], 10, 64)
+			if err != nil {
+				responseError(fmt.Errorf("invalid publish_id: %s", args[0]))
+				return
+			}
+
+			svc := newPublishService()
+			var status *publish.Status
+			if publishStatusWait {
+				ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+				defer stop()
+				status, err = svc.Wait(ctx, publishID)
+			} else {
+				status, err = svc.Status(publishID)
+			}
+			if err != nil {
+				responseError(err)
+				return
+			}
+			responseSuccess(status)
+		},
+	}
+	statusCmd.Flags().BoolVar(&publishStatusWait, "wait", false, "Wait until the publish task finishes")
+	publishCmd.AddCommand(statusCmd)
+
+	// delete 子命令
+	var deleteCmd = &cobra.Command{
+		Use:   "delete <article_id>",
+		Short: "Delete a published article",
+		Long: `Delete a published article. This cannot be undone.
+
+--index selects the article in a multi-article post (the first is 1);
+0 deletes all articles of the post.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if !publishDeleteYes {
+				responseError(&DraftError{
+					Message: "删除已发布文章不可恢复",
+					Hint:    "确认删除请添加 --yes 参数",
+				})
+				return
+			}
+			if err := newPublishService().Delete(args[0], publishDeleteIndex); err != nil {
+				responseError(err)
+				return
+			}
+			responseSuccess(map[string]any{
+				"article_id": args[0],
+				"index":      publishDeleteIndex,
+				"deleted":    true,
+			})
+		},
+	}
+	deleteCmd.Flags().IntVar(&publishDeleteIndex, "index", 0, "Article position in the post (1-based, 0 deletes all)")
+	deleteCmd.Flags().BoolVar(&publishDeleteYes, "yes", false, "Confirm deletion")
+	publishCmd.AddCommand(deleteCmd)
+}
+
+// newPublishService 创建发布服务并应用轮询参数
+func newPublishService() *publish.Service {
+	svc := publish.NewService(cfg, log)
+	svc.SetPolling(publishInterval, publishTimeout)
+	return svc
+}
+
+// runPublish 发布草稿（参数为 Markdown 文件时先创建草稿）
+func runPublish(target string) (map[string]any, error) {
+	mediaID := target
+	if info, err := os.Stat(target); err == nil && !info.IsDir() {
+		draftMediaID, err := createDraftFromMarkdown(target)
+		if err != nil {
+			return nil, err
+		}
+		mediaID = draftMediaID
+	}
+
+	svc := newPublishService()
+	publishID, err := svc.Submit(mediaID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := map[string]any{
+		"media_id":   mediaID,
+		"publish_id": publishID,
+	}
+	if publishNoWait {
+		result["state"] = publish.StatePublishing
+		return result, nil
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	status, err := svc.Wait(ctx, publishID)
+	if err != nil {
+		return nil, &publishWaitError{MediaID: mediaID, PublishID: publishID, Err: err}
+	}
+
+	result["state"] = status.State
+	result["article_id"] = status.ArticleID
+	result["url"] = status.URL()
+	result["articles"] = status.Articles
+	return result, nil
+}
+
+// publishWaitError 发布任务已提交但等待结果失败（超时、取消或发布失败），
+// 错误输出中附带 media_id 和 publish_id，便于之后查询状态
+type publishWaitError struct {
+	MediaID   string
+	PublishID int64
+	Err       error
+}
+
+func (e *publishWaitError) Error() string { return e.Err.Error() }
+
+func (e *publishWaitError) Unwrap() error { return e.Err }
+
+// Fields 错误输出中附带的字段
+func (e *publishWaitError) Fields() map[string]any {
+	return map[string]any{
+		"media_id":   e.MediaID,
+		"publish_id": e.PublishID,
+	}
+}
+
+// createDraftFromMarkdown 转换 Markdown 文件并创建草稿，返回草稿 media_id
+func createDraftFromMarkdown(markdownFile string) (string, error) {
+	result, err := convertMarkdownFile(markdownFile, converter.ConvertMode(publishMode), publishTheme)
+	if err != nil {
+		return "", err
+	}
+
+	coverPath := publishCover
+	if coverPath == "" && result.Meta != nil {
+		coverPath = resolveArticlePath(markdownFile, result.Meta.Cover)
+	}
+
+	draftResult, err := createWeChatDraft(result, coverPath)
+	if err != nil {
+		return "", err
+	}
+
+	log.Info("draft created for publishing",
+		zap.String("file", markdownFile),
+		zap.String("media_id", maskMediaID(draftResult.MediaID)))
+	return draftResult.MediaID, nil
+}
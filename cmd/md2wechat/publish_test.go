@@ -0,0 +1,43 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/geekjourneyx/md2wechat-skill/internal/publish"
+)
+
+func TestRunPublishWaitError(t *testing.T) {
+	fakeWechat(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/cgi-bin/freepublish/submit":
+			_, _ = io.WriteString(w, `{"errcode":0,"errmsg":"ok","publish_id":42}`)
+		case "/cgi-bin/freepublish/get":
+			_, _ = io.WriteString(w, `{"publish_id":42,"publish_status":1}`)
+		default:
+			t.Errorf("unexpected request %s", r.URL.Path)
+		}
+	})
+	oldInterval, oldTimeout := publishInterval, publishTimeout
+	publishInterval, publishTimeout = time.Millisecond, 20*time.Millisecond
+	t.Cleanup(func() { publishInterval, publishTimeout = oldInterval, oldTimeout })
+
+	_, err := runPublish("media-1")
+
+	// 等待超时时仍返回已提交任务的 media_id 和 publish_id
+	var waitErr *publishWaitError
+	if !errors.As(err, &waitErr) {
+		t.Fatalf("runPublish() error = %v, want *publishWaitError", err)
+	}
+	fields := waitErr.Fields()
+	if fields["media_id"] != "media-1" || fields["publish_id"] != int64(42) {
+		t.Errorf("Fields() = %v", fields)
+	}
+	var publishErr *publish.PublishError
+	if !errors.As(err, &publishErr) || publishErr.Code != "timeout" {
+		t.Errorf("runPublish() error = %v, want timeout", err)
+	}
+}
@@ -0,0 +1,279 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/geekjourneyx/md2wechat-skill/internal/converter"
+	"github.com/geekjourneyx/md2wechat-skill/internal/draft"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+// syncCmd sync 命令
+var syncCmd = &cobra.Command{
+	Use:   "sync <markdown_file...>",
+	Short: "Create or update drafts so each article has exactly one draft",
+	Long: `Sync Markdown articles to WeChat drafts without creating duplicates.
+
+A local state file records, per article, the draft media_id and a hash of
+the article content (Markdown, local images, cover, theme files and
+conversion options):
+
+  - first sync:        the article is converted and a new draft is created
+  - content changed:   the existing draft is updated in place
+  - nothing changed:   the article is skipped (no conversion, no upload)
+
+Articles are identified by the front matter "id" when set, otherwise by
+their path relative to the state file. If the recorded draft no longer
+exists (deleted or published), a new draft is created.
+
+Examples:
+  md2wechat sync article.md
+  md2wechat sync posts/*.md --mode local
+  md2wechat sync article.md --state .drafts.json --dry-run`,
+	Args: cobra.MinimumNArgs(1),
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		return initConfig()
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		result, err := runSync(args)
+		if err != nil {
+			responseError(err)
+			return
+		}
+		responseSuccess(result)
+	},
+}
+
+// sync 命令参数
+var (
+	syncState  string
+	syncMode   string
+	syncTheme  string
+	syncCover  string
+	syncForce  bool
+	syncDryRun bool
+)
+
+func init() {
+	syncCmd.Flags().StringVar(&syncState, "state", draft.DefaultSyncStateFile, "Sync state file")
+	syncCmd.Flags().StringVar(&syncMode, "mode", "api", "Conversion mode: api, ai or local")
+	syncCmd.Flags().StringVar(&syncTheme, "theme", "", "Theme (default: front matter theme)")
+	syncCmd.Flags().StringVar(&syncCover, "cover", "", "Cover image (overrides front matter cover)")
+	syncCmd.Flags().BoolVar(&syncForce, "force", false, "Update drafts even if the content is unchanged")
+	syncCmd.Flags().BoolVar(&syncDryRun, "dry-run", false, "Only report what would be done")
+}
+
+// runSync 依次同步所有文章，每篇完成后立即保存状态
+func runSync(files []string) (map[string]any, error) {
+	state, err := draft.OpenSyncState(syncState)
+	if err != nil {
+		return nil, err
+	}
+	syncer := &draft.Syncer{
+		State:  state,
+		Store:  draft.NewService(cfg, log),
+		AppID:  cfg.WechatAppID,
+		Force:  syncForce,
+		DryRun: syncDryRun,
+		Log:    log,
+	}
+
+	themes := converter.NewThemeManager()
+	results := make([]draft.SyncResult, 0, len(files))
+	counts := map[string]int{}
+	for _, file := range files {
+		result, err := syncArticle(syncer, themes, file)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		results = append(results, *result)
+		counts[result.Action]++
+
+		if !syncDryRun && result.Action != draft.SyncActionSkipped {
+			if err := state.Save(); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return map[string]any{
+		"state_file": state.Path(),
+		"dry_run":    syncDryRun,
+		"created":    counts[draft.SyncActionCreated],
+		"updated":    counts[draft.SyncActionUpdated],
+		"skipped":    counts[draft.SyncActionSkipped],
+		"articles":   results,
+	}, nil
+}
+
+// syncArticle 计算文章的标识和内容哈希后交给 syncer 决定动作，需要时才转换文章和上传封面
+func syncArticle(syncer *draft.Syncer, themes *converter.ThemeManager, file string) (*draft.SyncResult, error) {
+	markdown, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("read markdown file: %w", err)
+	}
+	meta, _, err := converter.ParseFrontMatter(string(markdown))
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := syncArticleKey(syncer.State.Path(), file, meta)
+	if err != nil {
+		return nil, err
+	}
+
+	coverPath := syncCover
+	if coverPath == "" {
+		coverPath = resolveArticlePath(file, meta.Cover)
+	}
+	if coverPath == "" {
+		return nil, &DraftError{
+			Message: "创建草稿需要封面图片",
+			Hint:    "请使用 --cover 参数指定封面图片，或者在 Markdown front matter 中设置 cover: ./cover.jpg",
+		}
+	}
+	coverHash, err := hashSource(coverPath)
+	if err != nil {
+		return nil, fmt.Errorf("read cover: %w", err)
+	}
+
+	article := draft.SyncArticle{
+		Key:       key,
+		File:      file,
+		Hash:      syncContentHash(file, markdown, coverHash, syncThemeHash(themes, meta)),
+		CoverHash: coverHash,
+	}
+	result, err := syncer.Sync(article, func(previous *draft.SyncEntry) (draft.Article, error) {
+		converted, err := convertMarkdownFile(file, converter.ConvertMode(syncMode), syncTheme)
+		if err != nil {
+			return draft.Article{}, err
+		}
+
+		// 封面未变化时复用记录的素材，否则经上传缓存上传
+		thumbMediaID := ""
+		if previous != nil && previous.CoverHash == coverHash {
+			thumbMediaID = previous.ThumbMediaID
+		}
+		if thumbMediaID == "" {
+			cover, err := uploadCoverImage(coverPath)
+			if err != nil {
+				return draft.Article{}, fmt.Errorf("上传封面图片失败: %w", err)
+			}
+			thumbMediaID = cover.MediaID
+		}
+		return buildDraftArticle(converted, thumbMediaID), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if result.Action != draft.SyncActionSkipped && !syncer.DryRun {
+		log.Info("article synced",
+			zap.String("file", file),
+			zap.String("action", result.Action),
+			zap.String("media_id", maskMediaID(result.MediaID)))
+	}
+	return result, nil
+}
+
+// syncArticleKey 文章标识：front matter 的 id，否则为相对于状态文件目录的路径
+func syncArticleKey(statePath, file string, meta *converter.ArticleMeta) (string, error) {
+	if id := strings.TrimSpace(meta.ID); id != "" {
+		return id, nil
+	}
+
+	stateDir, err := filepath.Abs(filepath.Dir(statePath))
+	if err != nil {
+		return "", err
+	}
+	absFile, err := filepath.Abs(file)
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(stateDir, absFile)
+	if err != nil {
+		return "", err
+	}
+	return filepath.ToSlash(rel), nil
+}
+
+// syncContentHash 计算影响草稿内容的所有输入的哈希：
+// Markdown 原文、本地图片内容、封面、主题、转换参数和账号的作者、页脚设置
+func syncContentHash(file string, markdown []byte, coverHash, themeHash string) string {
+	h := sha256.New()
+	write := func(parts ...string) {
+		for _, p := range parts {
+			h.Write([]byte(p))
+			h.Write([]byte{0})
+		}
+	}
+
+	write("mode", syncMode, "theme", themeHash, "cover", coverHash)
+	write("author", cfg.DefaultAuthor, "footer", cfg.FooterTemplate)
+	write("markdown", string(markdown))
+
+	for _, img := range converter.ParseImages(string(markdown), filepath.Dir(file)) {
+		if img.Type != converter.ImageTypeLocal {
+			continue
+		}
+		imgHash, err := hashSource(img.Original)
+		if err != nil {
+			// 图片缺失时转换会报错，这里只需让哈希随之变化
+			imgHash = "missing"
+		}
+		write("image", img.Original, imgHash)
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// syncThemeHash 计算文章实际使用的主题的哈希（--theme、front matter、账号默认主题依次生效）：
+// 主题名以及主题文件、继承的父主题文件和样式表的内容。
+// 没有主题文件的内置 API 主题只包含名称
+func syncThemeHash(themes *converter.ThemeManager, meta *converter.ArticleMeta) string {
+	name := syncTheme
+	if name == "" {
+		name = meta.Theme
+	}
+	if name == "" {
+		name = cfg.DefaultTheme
+	}
+	if name == "" {
+		name = "default"
+	}
+
+	h := sha256.New()
+	h.Write([]byte(name))
+	if theme, err := themes.GetTheme(name); err == nil {
+		for _, file := range theme.SourceFiles() {
+			fileHash, err := hashSource(file)
+			if err != nil {
+				fileHash = "missing"
+			}
+			h.Write([]byte{0})
+			h.Write([]byte(fileHash))
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// hashSource 计算本地文件内容的哈希；在线地址以地址本身为准
+func hashSource(path string) (string, error) {
+	if strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://") {
+		sum := sha256.Sum256([]byte(path))
+		return hex.EncodeToString(sum[:]), nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/geekjourneyx/md2wechat-skill/internal/config"
+	"github.com/geekjourneyx/md2wechat-skill/internal/converter"
+)
+
+func TestSyncThemeHash(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		t.Helper()
+		p := filepath.Join(dir, "themes", name)
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("paper.yaml", "name: paper\ntype: css\nstylesheet: paper.css\n")
+	write("paper.css", "h2 { color: #111111 }\n")
+	write("dark.yaml", "name: dark\nextends: paper\ndescription: 深色\n")
+	t.Chdir(dir)
+
+	oldCfg := cfg
+	cfg = &config.Config{}
+	t.Cleanup(func() { cfg = oldCfg })
+
+	hash := func(theme string) string {
+		return syncThemeHash(converter.NewThemeManager(), &converter.ArticleMeta{Theme: theme})
+	}
+	base := hash("dark")
+
+	// 修改主题文件、父主题或样式表都会改变哈希
+	for _, edit := range [][2]string{
+		{"dark.yaml", "name: dark\nextends: paper\ndescription: 更深\n"},
+		{"paper.yaml", "name: paper\ntype: css\nstylesheet: paper.css\ncolors:\n  primary: \"#222222\"\n"},
+		{"paper.css", "h2 { color: #333333 }\n"},
+	} {
+		write(edit[0], edit[1])
+		next := hash("dark")
+		if next == base {
+			t.Errorf("hash unchanged after editing %s", edit[0])
+		}
+		base = next
+	}
+
+	if hash("dark") != base {
+		t.Error("hash not stable")
+	}
+	// 没有主题文件的内置主题按名称区分
+	if hash("default") == hash("other") {
+		t.Error("builtin themes share a hash")
+	}
+}
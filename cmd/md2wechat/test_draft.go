@@ -33,11 +33,12 @@ var testHTMLCmd = &cobra.Command{
 
 		// 上传封面图片
 		log.Info("uploading cover image", zap.String("path", coverImage))
-		coverMediaID, err := uploadCoverImage(coverImage)
+		cover, err := uploadCoverImage(coverImage)
 		if err != nil {
 			responseError(fmt.Errorf("upload cover: %w", err))
 			return
 		}
+		coverMediaID := cover.MediaID
 		log.Info("cover uploaded", zap.String("media_id", maskMediaID(coverMediaID)))
 
 		// 创建草稿
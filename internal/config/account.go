@@ -0,0 +1,126 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// accountNamePattern 账号名只允许字母、数字、点、下划线和连字符（用作缓存目录名）
+var accountNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// AccountProfile 公众号账号配置（多账号），未设置的字段沿用顶层配置
+type AccountProfile struct {
+	AppID         string       `json:"appid" yaml:"appid"`
+	Secret        string       `json:"secret" yaml:"secret"`
+	DefaultTheme  string       `json:"default_theme,omitempty" yaml:"default_theme,omitempty"`
+	DefaultAuthor string       `json:"default_author,omitempty" yaml:"default_author,omitempty"`
+	Footer        string       `json:"footer,omitempty" yaml:"footer,omitempty"` // 追加到文章末尾的 Markdown 模板
+	Image         AccountImage `json:"image,omitempty" yaml:"image,omitempty"`
+}
+
+// AccountImage 账号级图片处理配置
+type AccountImage struct {
+	Compress *bool `json:"compress,omitempty" yaml:"compress,omitempty"`
+	MaxWidth int   `json:"max_width,omitempty" yaml:"max_width,omitempty"`
+	MaxSize  int   `json:"max_size_mb,omitempty" yaml:"max_size_mb,omitempty"`
+	Workers  int   `json:"workers,omitempty" yaml:"workers,omitempty"`
+}
+
+// selectAccount 选择账号并应用其配置
+// 优先级：参数（--account） > 环境变量 MD2WECHAT_ACCOUNT > 配置文件 default_account
+// 选中的账号配置优先于环境变量和顶层配置
+func (c *Config) selectAccount(name string) error {
+	if name == "" {
+		name = os.Getenv("MD2WECHAT_ACCOUNT")
+	}
+	if name == "" {
+		name = c.defaultAccount
+	}
+	if name == "" {
+		return nil
+	}
+
+	if !accountNamePattern.MatchString(name) {
+		return &ConfigError{
+			Field:   "Account",
+			Message: fmt.Sprintf("账号名 %q 不合法", name),
+			Hint:    "账号名只能包含字母、数字、点、下划线和连字符",
+		}
+	}
+
+	profile, ok := c.accounts[name]
+	if !ok {
+		hint := "在配置文件的 accounts 下添加该账号"
+		if names := c.AccountNames(); len(names) > 0 {
+			hint = "可用账号: " + strings.Join(names, ", ")
+		}
+		return &ConfigError{
+			Field:   "Account",
+			Message: fmt.Sprintf("账号 %q 未配置", name),
+			Hint:    hint,
+		}
+	}
+
+	c.Account = name
+	if profile.AppID != "" {
+		c.WechatAppID = profile.AppID
+	}
+	if profile.Secret != "" {
+		c.WechatSecret = profile.Secret
+	}
+	if profile.DefaultTheme != "" {
+		c.DefaultTheme = profile.DefaultTheme
+	}
+	if profile.DefaultAuthor != "" {
+		c.DefaultAuthor = profile.DefaultAuthor
+	}
+	if profile.Footer != "" {
+		c.FooterTemplate = profile.Footer
+	}
+	if profile.Image.Compress != nil {
+		c.CompressImages = *profile.Image.Compress
+	}
+	if profile.Image.MaxWidth > 0 {
+		c.MaxImageWidth = profile.Image.MaxWidth
+	}
+	if profile.Image.MaxSize > 0 {
+		c.MaxImageSize = int64(profile.Image.MaxSize) * 1024 * 1024
+	}
+	if profile.Image.Workers > 0 {
+		c.ImageWorkers = profile.Image.Workers
+	}
+
+	return nil
+}
+
+// AccountNames 返回配置文件中所有账号名（已排序）
+func (c *Config) AccountNames() []string {
+	names := make([]string, 0, len(c.accounts))
+	for name := range c.accounts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// accountsToMap 账号列表（用于显示）
+func (c *Config) accountsToMap(maskSecret bool) []map[string]any {
+	result := make([]map[string]any, 0, len(c.accounts))
+	for _, name := range c.AccountNames() {
+		profile := c.accounts[name]
+		result = append(result, map[string]any{
+			"name":           name,
+			"active":         name == c.Account,
+			"default":        name == c.defaultAccount,
+			"appid":          profile.AppID,
+			"secret":         maskIf(profile.Secret, maskSecret),
+			"default_theme":  profile.DefaultTheme,
+			"default_author": profile.DefaultAuthor,
+			"footer":         profile.Footer != "",
+		})
+	}
+	return result
+}
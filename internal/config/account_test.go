@@ -0,0 +1,74 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const accountsYAML = `
+wechat:
+  appid: wx_top
+  secret: top_secret
+image:
+  compress: true
+default_account: news
+accounts:
+  news:
+    appid: wx_news
+    secret: news_secret
+    default_theme: apple
+    default_author: 新闻部
+    footer: "—— {{author}}"
+    image:
+      compress: false
+      workers: 8
+  tech:
+    appid: wx_tech
+    secret: tech_secret
+`
+
+func TestLoadForAccount(t *testing.T) {
+	for _, key := range []string{"WECHAT_APPID", "WECHAT_SECRET", "MD2WECHAT_ACCOUNT"} {
+		t.Setenv(key, "")
+	}
+	t.Setenv("MD2WECHAT_CACHE_DIR", "/tmp/md2wechat-cache")
+
+	path := filepath.Join(t.TempDir(), "md2wechat.yaml")
+	if err := os.WriteFile(path, []byte(accountsYAML), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	// 未指定账号时使用 default_account
+	cfg, err := LoadForAccount(path, "")
+	if err != nil {
+		t.Fatalf("LoadForAccount() error = %v", err)
+	}
+	if cfg.Account != "news" || cfg.WechatAppID != "wx_news" || cfg.DefaultTheme != "apple" || cfg.DefaultAuthor != "新闻部" {
+		t.Errorf("default account not applied: %+v", cfg)
+	}
+	if cfg.CompressImages || cfg.ImageWorkers != 8 {
+		t.Errorf("account image settings not applied: compress=%v workers=%d", cfg.CompressImages, cfg.ImageWorkers)
+	}
+	if want := filepath.Join("/tmp/md2wechat-cache", "accounts", "news"); cfg.CacheDir() != want {
+		t.Errorf("CacheDir() = %s, want %s", cfg.CacheDir(), want)
+	}
+
+	// 环境变量优先于 default_account
+	t.Setenv("MD2WECHAT_ACCOUNT", "tech")
+	cfg, err = LoadForAccount(path, "")
+	if err != nil {
+		t.Fatalf("LoadForAccount() error = %v", err)
+	}
+	if cfg.WechatAppID != "wx_tech" || cfg.DefaultTheme != "default" || !cfg.CompressImages {
+		t.Errorf("MD2WECHAT_ACCOUNT not applied: %+v", cfg)
+	}
+
+	// 参数优先于环境变量；未知账号报错
+	if _, err := LoadForAccount(path, "missing"); err == nil {
+		t.Error("LoadForAccount(missing) error = nil, want error")
+	}
+	if _, err := LoadForAccount(path, "../evil"); err == nil {
+		t.Error("LoadForAccount(../evil) error = nil, want error")
+	}
+}
@@ -29,14 +29,33 @@ type Config struct {
 	ImageModel    string `json:"image_model" yaml:"image_model" env:"IMAGE_MODEL"`
 	ImageSize     string `json:"image_size" yaml:"image_size" env:"IMAGE_SIZE"`
 
+	// AI 转换 LLM 配置（配置后 AI 模式直接调用模型完成转换）
+	LLMProvider string `json:"llm_provider" yaml:"llm_provider" env:"LLM_PROVIDER"`
+	LLMAPIKey   string `json:"llm_api_key" yaml:"llm_api_key" env:"LLM_API_KEY"`
+	LLMAPIBase  string `json:"llm_api_base" yaml:"llm_api_base" env:"LLM_API_BASE"`
+	LLMModel    string `json:"llm_model" yaml:"llm_model" env:"LLM_MODEL"`
+
 	// 图片处理配置
 	CompressImages bool  `json:"compress_images" yaml:"compress_images" env:"COMPRESS_IMAGES"`
 	MaxImageWidth  int   `json:"max_image_width" yaml:"max_image_width" env:"MAX_IMAGE_WIDTH"`
 	MaxImageSize   int64 `json:"max_image_size" yaml:"max_image_size" env:"MAX_IMAGE_SIZE"`
+	ImageWorkers   int   `json:"image_workers" yaml:"image_workers" env:"IMAGE_WORKERS"`
+
+	// 图表渲染配置（mermaid / plantuml 代码块）
+	MermaidCommand  string `json:"mermaid_command" yaml:"mermaid_command" env:"MERMAID_COMMAND"`
+	PlantUMLCommand string `json:"plantuml_command" yaml:"plantuml_command" env:"PLANTUML_COMMAND"`
+	DiagramFont     string `json:"diagram_font" yaml:"diagram_font" env:"DIAGRAM_FONT"`
 
 	// 超时配置
 	HTTPTimeout int `json:"http_timeout" yaml:"http_timeout" env:"HTTP_TIMEOUT"`
 
+	// 多账号配置（通过 --account 或 MD2WECHAT_ACCOUNT 选择）
+	Account        string `json:"account" yaml:"account" env:"MD2WECHAT_ACCOUNT"` // 当前账号，为空时使用顶层 wechat 配置
+	DefaultAuthor  string `json:"default_author" yaml:"default_author"`
+	FooterTemplate string `json:"footer" yaml:"footer"`
+	accounts       map[string]AccountProfile
+	defaultAccount string
+
 	// 配置文件路径（用于追踪）
 	configFile string
 }
@@ -55,6 +74,10 @@ type configFile struct {
 		ImageProvider string `json:"image_provider" yaml:"image_provider"`
 		ImageModel    string `json:"image_model" yaml:"image_model"`
 		ImageSize     string `json:"image_size" yaml:"image_size"`
+		LLMProvider   string `json:"llm_provider" yaml:"llm_provider"`
+		LLMKey        string `json:"llm_key" yaml:"llm_key"`
+		LLMBaseURL    string `json:"llm_base_url" yaml:"llm_base_url"`
+		LLMModel      string `json:"llm_model" yaml:"llm_model"`
 		ConvertMode  string `json:"convert_mode" yaml:"convert_mode"`
 		DefaultTheme string `json:"default_theme" yaml:"default_theme"`
 		HTTPTimeout  int    `json:"http_timeout" yaml:"http_timeout"`
@@ -64,7 +87,17 @@ type configFile struct {
 		Compress bool `json:"compress" yaml:"compress"`
 		MaxWidth int  `json:"max_width" yaml:"max_width"`
 		MaxSize  int  `json:"max_size_mb" yaml:"max_size_mb"`
+		Workers  int  `json:"workers" yaml:"workers"`
 	} `json:"image" yaml:"image"`
+
+	Diagram struct {
+		Mermaid  string `json:"mermaid,omitempty" yaml:"mermaid,omitempty"`
+		PlantUML string `json:"plantuml,omitempty" yaml:"plantuml,omitempty"`
+		Font     string `json:"font,omitempty" yaml:"font,omitempty"`
+	} `json:"diagram" yaml:"diagram"`
+
+	DefaultAccount string                    `json:"default_account,omitempty" yaml:"default_account,omitempty"`
+	Accounts       map[string]AccountProfile `json:"accounts,omitempty" yaml:"accounts,omitempty"`
 }
 
 // Load 从配置文件和环境变量加载配置
@@ -73,19 +106,42 @@ func Load() (*Config, error) {
 	return LoadWithDefaults("")
 }
 
+// LoadAccount 加载配置并使用指定账号（为空时按 MD2WECHAT_ACCOUNT、default_account 选择）
+func LoadAccount(account string) (*Config, error) {
+	return LoadForAccount("", account)
+}
+
+// LoadAccountOffline 加载配置但不要求微信公众号凭证，用于本地转换、lint 等不调用微信接口的命令
+// 需要调用微信接口时再用 ValidateWechat 检查
+func LoadAccountOffline(account string) (*Config, error) {
+	return loadConfig("", account, false)
+}
+
 // LoadWithDefaults 使用指定配置文件路径加载配置
 func LoadWithDefaults(configPath string) (*Config, error) {
+	return LoadForAccount(configPath, "")
+}
+
+// LoadForAccount 使用指定配置文件路径和账号加载配置
+func LoadForAccount(configPath, account string) (*Config, error) {
+	return loadConfig(configPath, account, true)
+}
+
+// loadConfig 加载配置，requireWechat 为 false 时不检查微信公众号凭证
+func loadConfig(configPath, account string, requireWechat bool) (*Config, error) {
 	cfg := &Config{
 		DefaultConvertMode: "api",
 		DefaultTheme:       "default",
 		CompressImages:     true,
 		MaxImageWidth:      1920,
 		MaxImageSize:       5 * 1024 * 1024, // 5MB
+		ImageWorkers:       4,
 		HTTPTimeout:        30,
 		ImageProvider:      "openai",
 		ImageAPIBase:       "https://api.openai.com/v1",
 		ImageModel:         "dall-e-3",
 		ImageSize:          "1024x1024",
+		LLMProvider:        "openai",
 	}
 
 	// 1. 尝试从配置文件加载
@@ -107,12 +163,22 @@ func LoadWithDefaults(configPath string) (*Config, error) {
 	// 2. 环境变量覆盖配置文件
 	loadFromEnv(cfg)
 
-	// 3. 验证必需配置
-	if err := cfg.Validate(); err != nil {
+	// 3. 应用选中的账号配置
+	if err := cfg.selectAccount(account); err != nil {
 		return nil, err
 	}
 
-	// 4. 处理 MaxImageSize (配置文件中是 MB)
+	// 4. 验证必需配置
+	if requireWechat {
+		if err := cfg.ValidateWechat(); err != nil {
+			return nil, err
+		}
+	}
+	if err := cfg.validateSettings(); err != nil {
+		return nil, err
+	}
+
+	// 5. 处理 MaxImageSize (配置文件中是 MB)
 	if cfg.configFile != "" && cfg.MaxImageSize < 1024*1024 {
 		// 如果值小于 1MB，可能是配置文件使用了 MB 单位
 		cfg.MaxImageSize = cfg.MaxImageSize * 1024 * 1024
@@ -207,6 +273,18 @@ func loadFromYAML(cfg *Config, data []byte) error {
 	if cf.API.ImageSize != "" {
 		cfg.ImageSize = cf.API.ImageSize
 	}
+	if cf.API.LLMProvider != "" {
+		cfg.LLMProvider = cf.API.LLMProvider
+	}
+	if cf.API.LLMKey != "" {
+		cfg.LLMAPIKey = cf.API.LLMKey
+	}
+	if cf.API.LLMBaseURL != "" {
+		cfg.LLMAPIBase = cf.API.LLMBaseURL
+	}
+	if cf.API.LLMModel != "" {
+		cfg.LLMModel = cf.API.LLMModel
+	}
 	if cf.API.ConvertMode != "" {
 		cfg.DefaultConvertMode = cf.API.ConvertMode
 	}
@@ -223,6 +301,20 @@ func loadFromYAML(cfg *Config, data []byte) error {
 	if cf.Image.MaxSize > 0 {
 		cfg.MaxImageSize = int64(cf.Image.MaxSize) * 1024 * 1024
 	}
+	if cf.Image.Workers > 0 {
+		cfg.ImageWorkers = cf.Image.Workers
+	}
+	if cf.Diagram.Mermaid != "" {
+		cfg.MermaidCommand = cf.Diagram.Mermaid
+	}
+	if cf.Diagram.PlantUML != "" {
+		cfg.PlantUMLCommand = cf.Diagram.PlantUML
+	}
+	if cf.Diagram.Font != "" {
+		cfg.DiagramFont = cf.Diagram.Font
+	}
+	cfg.accounts = cf.Accounts
+	cfg.defaultAccount = cf.DefaultAccount
 
 	return nil
 }
@@ -259,6 +351,18 @@ func loadFromJSON(cfg *Config, data []byte) error {
 	if cf.API.ImageSize != "" {
 		cfg.ImageSize = cf.API.ImageSize
 	}
+	if cf.API.LLMProvider != "" {
+		cfg.LLMProvider = cf.API.LLMProvider
+	}
+	if cf.API.LLMKey != "" {
+		cfg.LLMAPIKey = cf.API.LLMKey
+	}
+	if cf.API.LLMBaseURL != "" {
+		cfg.LLMAPIBase = cf.API.LLMBaseURL
+	}
+	if cf.API.LLMModel != "" {
+		cfg.LLMModel = cf.API.LLMModel
+	}
 	if cf.API.ConvertMode != "" {
 		cfg.DefaultConvertMode = cf.API.ConvertMode
 	}
@@ -275,6 +379,20 @@ func loadFromJSON(cfg *Config, data []byte) error {
 	if cf.Image.MaxSize > 0 {
 		cfg.MaxImageSize = int64(cf.Image.MaxSize) * 1024 * 1024
 	}
+	if cf.Image.Workers > 0 {
+		cfg.ImageWorkers = cf.Image.Workers
+	}
+	if cf.Diagram.Mermaid != "" {
+		cfg.MermaidCommand = cf.Diagram.Mermaid
+	}
+	if cf.Diagram.PlantUML != "" {
+		cfg.PlantUMLCommand = cf.Diagram.PlantUML
+	}
+	if cf.Diagram.Font != "" {
+		cfg.DiagramFont = cf.Diagram.Font
+	}
+	cfg.accounts = cf.Accounts
+	cfg.defaultAccount = cf.DefaultAccount
 
 	return nil
 }
@@ -311,6 +429,18 @@ func loadFromEnv(cfg *Config) {
 	if v := os.Getenv("IMAGE_SIZE"); v != "" {
 		cfg.ImageSize = v
 	}
+	if v := os.Getenv("LLM_PROVIDER"); v != "" {
+		cfg.LLMProvider = v
+	}
+	if v := os.Getenv("LLM_API_KEY"); v != "" {
+		cfg.LLMAPIKey = v
+	}
+	if v := os.Getenv("LLM_API_BASE"); v != "" {
+		cfg.LLMAPIBase = v
+	}
+	if v := os.Getenv("LLM_MODEL"); v != "" {
+		cfg.LLMModel = v
+	}
 	if v := os.Getenv("COMPRESS_IMAGES"); v != "" {
 		cfg.CompressImages = getEnvBool("COMPRESS_IMAGES", true)
 	}
@@ -323,10 +453,30 @@ func loadFromEnv(cfg *Config) {
 	if v := os.Getenv("HTTP_TIMEOUT"); v != "" {
 		cfg.HTTPTimeout = getEnvInt("HTTP_TIMEOUT", cfg.HTTPTimeout)
 	}
+	if v := os.Getenv("IMAGE_WORKERS"); v != "" {
+		cfg.ImageWorkers = getEnvInt("IMAGE_WORKERS", cfg.ImageWorkers)
+	}
+	if v := os.Getenv("MERMAID_COMMAND"); v != "" {
+		cfg.MermaidCommand = v
+	}
+	if v := os.Getenv("PLANTUML_COMMAND"); v != "" {
+		cfg.PlantUMLCommand = v
+	}
+	if v := os.Getenv("DIAGRAM_FONT"); v != "" {
+		cfg.DiagramFont = v
+	}
 }
 
 // Validate 验证配置
 func (c *Config) Validate() error {
+	if err := c.ValidateWechat(); err != nil {
+		return err
+	}
+	return c.validateSettings()
+}
+
+// ValidateWechat 验证调用微信接口所需的 AppID 和 Secret
+func (c *Config) ValidateWechat() error {
 	if c.WechatAppID == "" {
 		return &ConfigError{
 			Field:   "WechatAppID",
@@ -341,12 +491,16 @@ func (c *Config) Validate() error {
 			Hint:    "登录微信公众平台 > 设置与开发 > 基本配置 > 获取 Secret",
 		}
 	}
+	return nil
+}
 
+// validateSettings 验证转换模式和数值范围
+func (c *Config) validateSettings() error {
 	// 验证转换模式
-	if c.DefaultConvertMode != "api" && c.DefaultConvertMode != "ai" {
+	if c.DefaultConvertMode != "api" && c.DefaultConvertMode != "ai" && c.DefaultConvertMode != "local" {
 		return &ConfigError{
 			Field:   "ConvertMode",
-			Message: "转换模式必须是 'api' 或 'ai'",
+			Message: "转换模式必须是 'api'、'ai' 或 'local'",
 			Hint:    "配置文件中设置 api.convert_mode: api",
 		}
 	}
@@ -366,6 +520,13 @@ func (c *Config) Validate() error {
 			Hint:    "配置文件中设置 image.max_size_mb: 5",
 		}
 	}
+	if c.ImageWorkers < 1 || c.ImageWorkers > 16 {
+		return &ConfigError{
+			Field:   "ImageWorkers",
+			Message: "图片并发数必须在 1 到 16 之间",
+			Hint:    "配置文件中设置 image.workers: 4",
+		}
+	}
 	if c.HTTPTimeout < 1 || c.HTTPTimeout > 300 {
 		return &ConfigError{
 			Field:   "HTTPTimeout",
@@ -385,6 +546,18 @@ func (c *Config) ValidateForImageGeneration() error {
 	return nil
 }
 
+// ValidateForLLM 验证 AI 转换 LLM 配置
+func (c *Config) ValidateForLLM() error {
+	if c.LLMAPIKey == "" {
+		return &ConfigError{
+			Field:   "LLMAPIKey",
+			Message: "LLM_API_KEY is required for AI conversion",
+			Hint:    "在配置文件中设置 api.llm_key 或环境变量 LLM_API_KEY",
+		}
+	}
+	return nil
+}
+
 // ValidateForAPIConversion 验证 API 转换配置
 func (c *Config) ValidateForAPIConversion() error {
 	if c.MD2WechatAPIKey == "" && c.DefaultConvertMode == "api" {
@@ -398,6 +571,25 @@ func (c *Config) GetConfigFile() string {
 	return c.configFile
 }
 
+// CacheDir 返回本地缓存目录（上传缓存、access_token 等）
+// 默认 ~/.config/md2wechat/cache，可通过环境变量 MD2WECHAT_CACHE_DIR 覆盖
+// 使用多账号时每个账号有独立的子目录 accounts/<name>
+func (c *Config) CacheDir() string {
+	dir := os.Getenv("MD2WECHAT_CACHE_DIR")
+	if dir == "" {
+		if configDir, err := os.UserConfigDir(); err == nil {
+			dir = filepath.Join(configDir, "md2wechat", "cache")
+		} else {
+			homeDir, _ := os.UserHomeDir()
+			dir = filepath.Join(homeDir, ".config", "md2wechat", "cache")
+		}
+	}
+	if c.Account != "" {
+		dir = filepath.Join(dir, "accounts", c.Account)
+	}
+	return dir
+}
+
 // ToMap 转换为 map 用于显示
 func (c *Config) ToMap(maskSecret bool) map[string]any {
 	result := map[string]any{
@@ -411,11 +603,21 @@ func (c *Config) ToMap(maskSecret bool) map[string]any {
 		"image_api_base":       c.ImageAPIBase,
 		"image_model":          c.ImageModel,
 		"image_size":           c.ImageSize,
+		"llm_provider":         c.LLMProvider,
+		"llm_api_key":          maskIf(c.LLMAPIKey, maskSecret),
+		"llm_api_base":         c.LLMAPIBase,
+		"llm_model":            c.LLMModel,
 		"compress_images":      c.CompressImages,
 		"max_image_width":      c.MaxImageWidth,
 		"max_image_size_mb":    c.MaxImageSize / 1024 / 1024,
+		"image_workers":        c.ImageWorkers,
 		"http_timeout":         c.HTTPTimeout,
+		"cache_dir":            c.CacheDir(),
 		"config_file":          c.configFile,
+		"account":              c.Account,
+		"default_author":       c.DefaultAuthor,
+		"footer":               c.FooterTemplate,
+		"accounts":             c.accountsToMap(maskSecret),
 	}
 	return result
 }
@@ -433,12 +635,22 @@ func SaveConfig(path string, cfg *Config) error {
 	cf.API.ImageProvider = cfg.ImageProvider
 	cf.API.ImageModel = cfg.ImageModel
 	cf.API.ImageSize = cfg.ImageSize
+	cf.API.LLMProvider = cfg.LLMProvider
+	cf.API.LLMKey = cfg.LLMAPIKey
+	cf.API.LLMBaseURL = cfg.LLMAPIBase
+	cf.API.LLMModel = cfg.LLMModel
 	cf.API.ConvertMode = cfg.DefaultConvertMode
 	cf.API.DefaultTheme = cfg.DefaultTheme
 	cf.API.HTTPTimeout = cfg.HTTPTimeout
 	cf.Image.Compress = cfg.CompressImages
 	cf.Image.MaxWidth = cfg.MaxImageWidth
 	cf.Image.MaxSize = int(cfg.MaxImageSize / 1024 / 1024)
+	cf.Image.Workers = cfg.ImageWorkers
+	cf.Diagram.Mermaid = cfg.MermaidCommand
+	cf.Diagram.PlantUML = cfg.PlantUMLCommand
+	cf.Diagram.Font = cfg.DiagramFont
+	cf.DefaultAccount = cfg.defaultAccount
+	cf.Accounts = cfg.accounts
 
 	var data []byte
 	var err error
@@ -0,0 +1,39 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadWithoutWechat(t *testing.T) {
+	for _, key := range []string{"WECHAT_APPID", "WECHAT_SECRET", "MD2WECHAT_ACCOUNT"} {
+		t.Setenv(key, "")
+	}
+	path := filepath.Join(t.TempDir(), "md2wechat.yaml")
+	if err := os.WriteFile(path, []byte("api:\n  convert_mode: local\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	// 本地转换等命令不需要微信公众号凭证
+	cfg, err := loadConfig(path, "", false)
+	if err != nil {
+		t.Fatalf("loadConfig(offline) error = %v", err)
+	}
+	var cfgErr *ConfigError
+	if err := cfg.ValidateWechat(); !errors.As(err, &cfgErr) || cfgErr.Field != "WechatAppID" {
+		t.Errorf("ValidateWechat() error = %v, want WechatAppID error", err)
+	}
+	if _, err := LoadForAccount(path, ""); !errors.As(err, &cfgErr) || cfgErr.Field != "WechatAppID" {
+		t.Errorf("LoadForAccount() error = %v, want WechatAppID error", err)
+	}
+
+	// 其它配置仍然校验
+	if err := os.WriteFile(path, []byte("api:\n  convert_mode: fancy\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadConfig(path, "", false); !errors.As(err, &cfgErr) || cfgErr.Field != "ConvertMode" {
+		t.Errorf("loadConfig(invalid mode) error = %v, want ConvertMode error", err)
+	}
+}
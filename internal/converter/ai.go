@@ -1,11 +1,16 @@
 package converter
 
 import (
+	"context"
 	"fmt"
+	"strings"
 
 	"go.uber.org/zap"
 )
 
+// aiRequestPrefix AI 请求结果的 Error 前缀（未配置 LLM 时由外部完成转换）
+const aiRequestPrefix = "AI_MODE_REQUEST:"
+
 // AIConvertRequest AI 转换请求（用于传递给 Claude）
 type AIConvertRequest struct {
 	Markdown     string // Markdown 内容
@@ -36,7 +41,7 @@ func NewAIConverter(log *zap.Logger, theme *ThemeManager) *aiConverter {
 }
 
 // convertViaAI 通过 AI 模式执行转换
-// 注意：实际的 AI 调用由外部（Claude）执行，此方法准备请求结构
+// 配置了 LLM 时直接调用模型生成 HTML；否则由外部（Claude）执行，此方法准备请求结构
 func (c *converter) convertViaAI(req *ConvertRequest) *ConvertResult {
 	result := &ConvertResult{
 		Mode:    ModeAI,
@@ -51,8 +56,13 @@ func (c *converter) convertViaAI(req *ConvertRequest) *ConvertResult {
 		return result
 	}
 
-	// 提取图片引用
-	images := c.ExtractImages(req.Markdown)
+	// 图片引用（由 Convert 解析）
+	images := req.images
+
+	// 配置了 LLM，直接完成转换
+	if c.llm != nil {
+		return c.completeViaLLM(prompt, images, req.Theme)
+	}
 
 	// AI 模式由外部调用者处理，这里返回准备好的请求
 	// 实际使用时，调用者应该：
@@ -62,7 +72,7 @@ func (c *converter) convertViaAI(req *ConvertRequest) *ConvertResult {
 	// 4. 调用 CompleteAIConversion 填充结果
 
 	// 为了保持接口一致性，这里返回一个包含提示词的特殊结果
-	result.Error = "AI_MODE_REQUEST:" + prompt
+	result.Error = aiRequestPrefix + prompt
 	result.Images = images
 
 	c.log.Info("AI conversion request prepared",
@@ -73,6 +83,48 @@ func (c *converter) convertViaAI(req *ConvertRequest) *ConvertResult {
 	return result
 }
 
+// completeViaLLM 调用 LLM 生成 HTML 并完成转换
+func (c *converter) completeViaLLM(prompt string, images []ImageRef, theme string) *ConvertResult {
+	c.log.Info("calling LLM for AI conversion",
+		zap.String("provider", c.llm.Name()),
+		zap.String("theme", theme),
+		zap.Int("prompt_length", len(prompt)))
+
+	ctx, cancel := context.WithTimeout(context.Background(), llmTimeout)
+	defer cancel()
+
+	response, err := c.llm.Complete(ctx, prompt)
+	if err != nil {
+		c.log.Error("LLM conversion failed",
+			zap.String("provider", c.llm.Name()),
+			zap.Error(err))
+		return &ConvertResult{
+			Mode:    ModeAI,
+			Theme:   theme,
+			Images:  images,
+			Success: false,
+			Error:   fmt.Sprintf("%s: %s", ErrAIFailure.Error(), err.Error()),
+		}
+	}
+
+	html := ExtractHTMLFromResponse(response)
+	if html == "" {
+		return &ConvertResult{
+			Mode:    ModeAI,
+			Theme:   theme,
+			Images:  images,
+			Success: false,
+			Error:   ErrAIFailure.Error() + ": LLM returned no HTML",
+		}
+	}
+
+	c.log.Info("AI conversion succeeded",
+		zap.String("provider", c.llm.Name()),
+		zap.Int("html_length", len(html)))
+
+	return CompleteAIConversion(html, images, theme)
+}
+
 // buildAIPrompt 构建 AI 提示词
 func (c *converter) buildAIPrompt(req *ConvertRequest) (string, error) {
 	var prompt string
@@ -85,6 +137,9 @@ func (c *converter) buildAIPrompt(req *ConvertRequest) (string, error) {
 		theme, err := c.theme.GetTheme(req.Theme)
 		if err != nil {
 			// 如果找不到主题，使用通用提示词
+			c.log.Warn("theme not available, using generic prompt",
+				zap.String("theme", req.Theme),
+				zap.Error(err))
 			prompt = c.getGenericPrompt()
 		} else if theme.Type != "ai" {
 			// 不是 AI 主题，使用通用提示词
@@ -133,7 +188,7 @@ func (c *converter) getGenericPrompt() string {
 1. 所有 CSS 必须使用内联 style 属性
 2. 不使用外部样式表或 <style> 标签
 3. 只使用安全的 HTML 标签
-4. 图片使用占位符格式：<!-- IMG:index -->
+4. 图片使用占位符格式：<!-- IMG:index -->，Markdown 中已有的占位符必须原样保留在原位置
 5. 返回完整的 HTML，不需要其他说明文字`
 }
 
@@ -165,13 +220,13 @@ func CompleteAIConversion(html string, images []ImageRef, theme string) *Convert
 
 // IsAIRequest 检查结果是否是 AI 请求
 func IsAIRequest(result *ConvertResult) bool {
-	return result.Error != "" && len(result.Error) > 14 && result.Error[:14] == "AI_MODE_REQUEST"
+	return strings.HasPrefix(result.Error, aiRequestPrefix)
 }
 
 // ExtractAIRequest 从结果中提取 AI 请求
 func ExtractAIRequest(result *ConvertResult) string {
 	if IsAIRequest(result) {
-		return result.Error[14:] // 去掉前缀
+		return strings.TrimPrefix(result.Error, aiRequestPrefix) // 去掉前缀
 	}
 	return ""
 }
@@ -187,20 +242,7 @@ func GetAIRequestInfo(result *ConvertResult) (prompt string, images []ImageRef,
 // BuildAIRequestForExternal 为外部调用者构建 AI 请求
 func BuildAIRequestForExternal(markdown, theme, customPrompt string, themeMgr *ThemeManager) (string, []ImageRef, error) {
 	// 提取图片
-	var images []ImageRef
-
-	// 简单的图片提取逻辑
-	lines := markdown
-	imgIndex := 0
-	for _, line := range splitLines(lines) {
-		if containsImageSyntax(line) {
-			images = append(images, ImageRef{
-				Index:    imgIndex,
-				Original: line,
-			})
-			imgIndex++
-		}
-	}
+	images := ParseImages(markdown, "")
 
 	// 构建提示词
 	var prompt string
@@ -215,48 +257,12 @@ func BuildAIRequestForExternal(markdown, theme, customPrompt string, themeMgr *T
 		}
 	}
 
-	// 添加 Markdown 内容
-	fullPrompt := prompt + "\n\n```\n" + markdown + "\n```"
+	// 添加 Markdown 内容（图片替换为占位符）
+	fullPrompt := prompt + "\n\n```\n" + insertImageSlots(markdown, images, true) + "\n```"
 
 	return fullPrompt, images, nil
 }
 
-// 辅助函数
-func splitLines(s string) []string {
-	// 简单的按行分割
-	lines := []string{}
-	current := ""
-	for _, ch := range s {
-		if ch == '\n' {
-			lines = append(lines, current)
-			current = ""
-		} else {
-			current += string(ch)
-		}
-	}
-	if current != "" {
-		lines = append(lines, current)
-	}
-	return lines
-}
-
-func containsImageSyntax(line string) bool {
-	return len(line) > 4 && (line[0:2] == "![" || contains(line, "<!-- IMG:"))
-}
-
-func contains(s, substr string) bool {
-	return len(s) >= len(substr) && findSubstring(s, substr) >= 0
-}
-
-func findSubstring(s, substr string) int {
-	for i := 0; i <= len(s)-len(substr); i++ {
-		if s[i:i+len(substr)] == substr {
-			return i
-		}
-	}
-	return -1
-}
-
 func getGenericPromptForExternal() string {
 	return `你是一个专业的微信公众号排版助手。请将以下 Markdown 内容转换为微信公众号兼容的 HTML。
 
@@ -264,6 +270,6 @@ func getGenericPromptForExternal() string {
 1. 所有 CSS 必须使用内联 style 属性
 2. 不使用外部样式表或 <style> 标签
 3. 只使用安全的 HTML 标签
-4. 图片使用占位符格式：<!-- IMG:index -->
+4. 图片使用占位符格式：<!-- IMG:index -->，Markdown 中已有的占位符必须原样保留在原位置
 5. 返回完整的 HTML，不需要其他说明文字`
 }
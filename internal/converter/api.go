@@ -51,6 +51,11 @@ func (c *converter) convertViaAPI(req *ConvertRequest) *ConvertResult {
 		Success: false,
 	}
 
+	// CSS 主题只能在本地内联样式表
+	if theme, err := c.theme.GetTheme(req.Theme); err == nil && theme.Type == "css" {
+		return c.convertViaCSS(req, theme)
+	}
+
 	// 获取 API 主题名
 	apiTheme, err := c.theme.GetAPITheme(req.Theme)
 	if err != nil {
@@ -76,8 +81,8 @@ func (c *converter) convertViaAPI(req *ConvertRequest) *ConvertResult {
 		return result
 	}
 
-	// 提取图片引用
-	images := c.ExtractImages(req.Markdown)
+	// 图片引用（由 Convert 解析）
+	images := req.images
 
 	result.HTML = html
 	result.Images = images
@@ -0,0 +1,330 @@
+package converter
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// containerPlaceholderPrefix 转换前替换容器开始行的占位符，只含字母和数字，任何转换模式都会原样保留
+const containerPlaceholderPrefix = "MD2WECHATBOX"
+
+var (
+	// containerOpenPattern 容器开始行：::: type 标题
+	containerOpenPattern = regexp.MustCompile(`^ {0,3}(:{3,})[ \t]*([A-Za-z][\w-]*)[ \t]*(.*?)[ \t]*$`)
+
+	// containerClosePattern 容器结束行：:::
+	containerClosePattern = regexp.MustCompile(`^ {0,3}(:{3,})[ \t]*$`)
+
+	// blockquoteTagPattern 引用块的开始和结束标签
+	blockquoteTagPattern = regexp.MustCompile(`(?i)<blockquote\b[^>]*>|</blockquote>`)
+
+	// containerMarker 容器占位符
+	containerMarker = newBoxMarker(containerPlaceholderPrefix)
+)
+
+// ThemeContainer 主题中一种容器的样式
+// 样式是内联 CSS，可以用 var(--primary) 引用主题 colors 中的颜色
+type ThemeContainer struct {
+	Title      string `yaml:"title,omitempty"`       // 默认标题（::: 后没有写标题时使用）
+	Style      string `yaml:"style,omitempty"`       // 容器 <section> 的样式
+	TitleStyle string `yaml:"title_style,omitempty"` // 标题 <p> 的样式
+}
+
+// neutralContainer 未定义的容器类型使用的样式
+var neutralContainer = ThemeContainer{
+	Style:      "margin:1em 0;padding:12px 16px;border:1px solid var(--border);border-radius:4px;",
+	TitleStyle: "margin:0 0 6px;font-weight:bold;color:var(--text);",
+}
+
+// defaultContainers 内置容器类型（主题可覆盖其中的字段或新增类型）
+var defaultContainers = map[string]ThemeContainer{
+	"tip": {
+		Title:      "提示",
+		Style:      "margin:1em 0;padding:12px 16px;background-color:var(--quote-background);border-left:4px solid var(--success);border-radius:4px;",
+		TitleStyle: "margin:0 0 6px;font-weight:bold;color:var(--success);",
+	},
+	"info": {
+		Title:      "说明",
+		Style:      "margin:1em 0;padding:12px 16px;background-color:var(--quote-background);border-left:4px solid var(--primary);border-radius:4px;",
+		TitleStyle: "margin:0 0 6px;font-weight:bold;color:var(--primary);",
+	},
+	"warning": {
+		Title:      "注意",
+		Style:      "margin:1em 0;padding:12px 16px;background-color:var(--quote-background);border-left:4px solid var(--warning);border-radius:4px;",
+		TitleStyle: "margin:0 0 6px;font-weight:bold;color:var(--warning);",
+	},
+	"danger": {
+		Title:      "警告",
+		Style:      "margin:1em 0;padding:12px 16px;background-color:var(--quote-background);border-left:4px solid var(--danger);border-radius:4px;",
+		TitleStyle: "margin:0 0 6px;font-weight:bold;color:var(--danger);",
+	},
+	"card": {
+		Style:      "margin:1.5em 0;padding:16px 20px;background-color:var(--quote-background);border:1px solid var(--border);border-top:3px solid var(--primary);border-radius:6px;",
+		TitleStyle: "margin:0 0 8px;font-size:1.1em;font-weight:bold;color:var(--secondary);",
+	},
+	"bio": {
+		Style:      "margin:2em 0 1em;padding:16px 20px;background-color:var(--quote-background);border-radius:8px;font-size:14px;text-align:center;",
+		TitleStyle: "margin:0 0 6px;font-size:16px;font-weight:bold;color:var(--primary);",
+	},
+}
+
+// container Markdown 中的一个 ::: 容器
+type container struct {
+	kind  string
+	title string // 标题（Markdown），为空时使用主题的默认标题
+}
+
+// containersEnabled 判断是否处理容器
+// 未配置 LLM 的 AI 模式由外部完成转换，占位符无法还原，保留原始语法
+func (c *converter) containersEnabled(req *ConvertRequest) bool {
+	return req.Mode != ModeAI || c.llm != nil
+}
+
+// extractContainers 将 ::: 容器改写为以占位符开头的引用块，转换后由 renderContainers 还原为带样式的 <section>
+// 任何转换模式都会保留引用块的结构；容器可以嵌套，没有结束行的容器保持原样，代码块中的内容不处理
+func extractContainers(markdown string) (string, []container) {
+	if !strings.Contains(markdown, ":::") {
+		return markdown, nil
+	}
+
+	skip := codeRanges(markdown)
+	lines := strings.SplitAfter(markdown, "\n")
+	type block struct {
+		open, close int // 开始行和结束行
+		colons      int
+	}
+	var blocks []block
+	var stack []block
+	offset := 0
+	for i, line := range lines {
+		start := offset
+		offset += len(line)
+		content := strings.TrimRight(line, "\r\n")
+		if inCode(skip, start+len(content)-len(strings.TrimLeft(content, " "))) {
+			continue
+		}
+		if m := containerOpenPattern.FindStringSubmatch(content); m != nil {
+			stack = append(stack, block{open: i, close: -1, colons: len(m[1])})
+			continue
+		}
+		if m := containerClosePattern.FindStringSubmatch(content); m != nil && len(stack) > 0 {
+			top := stack[len(stack)-1]
+			if len(m[1]) >= top.colons {
+				top.close = i
+				blocks = append(blocks, top)
+				stack = stack[:len(stack)-1]
+			}
+		}
+	}
+	if len(blocks) == 0 {
+		return markdown, nil
+	}
+	sort.Slice(blocks, func(i, j int) bool { return blocks[i].open < blocks[j].open })
+
+	// 每行所在容器的层数
+	depth := make([]int, len(lines)+1)
+	for _, b := range blocks {
+		depth[b.open+1]++
+		depth[b.close]--
+	}
+	for i := 1; i < len(depth); i++ {
+		depth[i] += depth[i-1]
+	}
+
+	opens := make(map[int]bool, len(blocks))
+	closes := make(map[int]bool, len(blocks))
+	for _, b := range blocks {
+		opens[b.open] = true
+		closes[b.close] = true
+	}
+
+	var containers []container
+	var buf strings.Builder
+	for i, line := range lines {
+		content := strings.TrimRight(line, "\r\n")
+		eol := line[len(content):]
+		prefix := strings.Repeat("> ", depth[i])
+		switch {
+		case closes[i]:
+			// 结束行变为空行（外层容器中为空的引用行），结束当前引用块
+			buf.WriteString(strings.TrimRight(prefix, " ") + eol)
+		case opens[i]:
+			m := containerOpenPattern.FindStringSubmatch(content)
+			inner := prefix + "> "
+			buf.WriteString(inner + containerMarker.placeholder(len(containers)) + "\n" + strings.TrimRight(inner, " ") + "\n")
+			title := m[3]
+			if strings.HasPrefix(title, "[") && strings.HasSuffix(title, "]") {
+				title = title[1 : len(title)-1] // ::: tip [标题]
+			}
+			containers = append(containers, container{kind: strings.ToLower(m[2]), title: strings.TrimSpace(title)})
+		case strings.TrimSpace(content) == "":
+			buf.WriteString(strings.TrimRight(prefix, " ") + eol)
+		default:
+			buf.WriteString(prefix + content + eol)
+		}
+	}
+	return buf.String(), containers
+}
+
+// renderContainers 将以占位符开头的引用块替换为主题定义样式的 <section>
+func (c *converter) renderContainers(result *ConvertResult, containers []container, req *ConvertRequest) {
+	if len(containers) == 0 || result.HTML == "" {
+		return
+	}
+	theme, _ := c.theme.GetTheme(req.Theme)
+	colors := NewLocalStyle(theme).Colors
+
+	result.HTML = containerMarker.replace(result.HTML, len(containers), func(i int) string {
+		ct := containers[i]
+		def := theme.container(ct.kind)
+		box, titleStyle, err := def.styles(colors)
+		if err != nil {
+			c.log.Warn("invalid container style, using neutral box", zap.String("type", ct.kind), zap.Error(err))
+			box, titleStyle, _ = neutralContainer.styles(colors)
+		}
+
+		out := `<section style="` + box + `">`
+		if title := firstNonEmpty(ct.title, def.Title); title != "" {
+			out += `<p style="` + titleStyle + `">` + renderFootnoteText(title) + `</p>`
+		}
+		return out
+	}, func(i int) string {
+		if title := containers[i].title; title != "" {
+			return "<strong>" + renderFootnoteText(title) + "</strong>"
+		}
+		return ""
+	})
+	c.log.Info("containers rendered", zap.Int("count", len(containers)))
+}
+
+// container 查找容器类型的样式：主题定义的字段优先，其次内置类型，最后为中性样式
+func (t *Theme) container(kind string) ThemeContainer {
+	def, ok := defaultContainers[kind]
+	if !ok {
+		def = neutralContainer
+	}
+	if t == nil {
+		return def
+	}
+	if own, ok := t.Containers[kind]; ok {
+		def.Title = firstNonEmpty(own.Title, def.Title)
+		def.Style = firstNonEmpty(own.Style, def.Style)
+		def.TitleStyle = firstNonEmpty(own.TitleStyle, def.TitleStyle)
+	}
+	return def
+}
+
+// validateContainers 检查主题中容器样式的语法和引用的颜色
+func (t *Theme) validateContainers() error {
+	colors := NewLocalStyle(t).Colors
+	for kind := range t.Containers {
+		if _, _, err := t.container(kind).styles(colors); err != nil {
+			return fmt.Errorf("containers.%s: %w", kind, err)
+		}
+	}
+	return nil
+}
+
+// styles 展开样式中的 var(--name)，返回容器和标题的内联样式
+func (tc ThemeContainer) styles(colors map[string]string) (box, title string, err error) {
+	if box, err = expandInlineStyle(tc.Style, colors); err != nil {
+		return "", "", fmt.Errorf("style: %w", err)
+	}
+	if title, err = expandInlineStyle(tc.TitleStyle, colors); err != nil {
+		return "", "", fmt.Errorf("title_style: %w", err)
+	}
+	return box, title, nil
+}
+
+// expandInlineStyle 解析内联样式并替换颜色变量
+func expandInlineStyle(style string, colors map[string]string) (string, error) {
+	decls, err := parseDeclarations(style, colors)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	for _, d := range decls {
+		b.WriteString(d.Property + ":" + d.Value)
+		if d.Important {
+			b.WriteString(" !important")
+		}
+		b.WriteString(";")
+	}
+	return strings.ReplaceAll(b.String(), `"`, "'"), nil
+}
+
+// firstNonEmpty 返回第一个非空字符串
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// boxMarker 以占位符段落开头的引用块，转换后替换为带样式的 <section>（callout 和容器共用）
+type boxMarker struct {
+	prefix string
+	start  *regexp.Regexp // 以占位符开头的引用块
+	any    *regexp.Regexp // 任意位置的占位符
+}
+
+// newBoxMarker 创建占位符
+func newBoxMarker(prefix string) boxMarker {
+	return boxMarker{
+		prefix: prefix,
+		start:  regexp.MustCompile(`(?is)<blockquote\b[^>]*>\s*<p\b[^>]*>\s*` + prefix + `(\d+)X\s*</p>`),
+		any:    regexp.MustCompile(prefix + `(\d+)X`),
+	}
+}
+
+// placeholder 生成第 index 个占位符
+func (b boxMarker) placeholder(index int) string {
+	return b.prefix + strconv.Itoa(index) + "X"
+}
+
+// replace 将以占位符开头的引用块替换为 open 返回的开始标签（及标题）和 </section>，
+// 其余位置的占位符（例如被 AI 改写了结构）替换为 fallback 的结果
+func (b boxMarker) replace(content string, count int, open func(index int) string, fallback func(index int) string) string {
+	for {
+		loc := b.start.FindStringSubmatchIndex(content)
+		if loc == nil {
+			break
+		}
+		index, err := strconv.Atoi(content[loc[2]:loc[3]])
+		if err != nil || index >= count {
+			break
+		}
+		end, closeLen := matchingBlockquoteEnd(content, loc[1])
+		content = content[:loc[0]] + open(index) + content[loc[1]:end] + "</section>" + content[end+closeLen:]
+	}
+
+	return b.any.ReplaceAllStringFunc(content, func(match string) string {
+		index, err := strconv.Atoi(b.any.FindStringSubmatch(match)[1])
+		if err != nil || index >= count {
+			return match
+		}
+		return fallback(index)
+	})
+}
+
+// matchingBlockquoteEnd 查找与已打开的引用块配对的结束标签，返回其位置和长度；找不到时为内容末尾
+func matchingBlockquoteEnd(content string, from int) (int, int) {
+	depth := 1
+	for _, m := range blockquoteTagPattern.FindAllStringIndex(content[from:], -1) {
+		if content[from+m[0]+1] == '/' {
+			if depth--; depth == 0 {
+				return from + m[0], m[1] - m[0]
+			}
+		} else {
+			depth++
+		}
+	}
+	return len(content), 0
+}
@@ -0,0 +1,91 @@
+package converter
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/geekjourneyx/md2wechat-skill/internal/config"
+	"go.uber.org/zap"
+)
+
+func TestExtractContainers(t *testing.T) {
+	p := containerMarker.placeholder
+	markdown := "::: tip\n内容\n:::\n\n:::: card [卡片标题]\n外层\n\n::: note\n内层\n:::\n::::\n\n```\n::: tip\n```\n\n::: warning\n没有结束\n"
+	got, containers := extractContainers(markdown)
+
+	want := "> " + p(0) + "\n>\n> 内容\n\n\n> " + p(1) + "\n>\n> 外层\n>\n> > " + p(2) + "\n> >\n> > 内层\n>\n\n\n```\n::: tip\n```\n\n::: warning\n没有结束\n"
+	if got != want {
+		t.Errorf("markdown =\n%q\nwant\n%q", got, want)
+	}
+	wantContainers := []container{{"tip", ""}, {"card", "卡片标题"}, {"note", ""}}
+	if len(containers) != len(wantContainers) {
+		t.Fatalf("containers = %+v", containers)
+	}
+	for i, c := range wantContainers {
+		if containers[i] != c {
+			t.Errorf("containers[%d] = %+v, want %+v", i, containers[i], c)
+		}
+	}
+
+	if got, containers := extractContainers("正文 ::: 不是容器\n"); got != "正文 ::: 不是容器\n" || containers != nil {
+		t.Errorf("extractContainers(inline) = %q, %+v", got, containers)
+	}
+}
+
+func TestConvertContainers(t *testing.T) {
+	conv := NewConverter(&config.Config{}, zap.NewNop())
+	markdown := "::: tip\n内容 **加粗**\n:::\n\n:::: card 卡片 *标题*\n外层\n\n::: custom 自定义\n内层\n:::\n::::\n"
+
+	result := conv.Convert(&ConvertRequest{Markdown: markdown, Mode: ModeLocal})
+	if !result.Success {
+		t.Fatalf("Convert() error = %v", result.Error)
+	}
+	out := result.HTML
+	for _, want := range []string{
+		`<section style="margin:1em 0;padding:12px 16px;background-color:#f5f7fa;border-left:4px solid #2f855a;border-radius:4px;"><p style="margin:0 0 6px;font-weight:bold;color:#2f855a;">提示</p>`,
+		`color:#2c5282;">卡片 <em>标题</em></p>`,
+		// 未定义的类型使用中性样式
+		`<section style="margin:1em 0;padding:12px 16px;border:1px solid #e2e8f0;border-radius:4px;"><p style="margin:0 0 6px;font-weight:bold;color:#3f3f3f;">自定义</p>`,
+		"内层</p>\n</section>\n</section>",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("HTML missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, containerPlaceholderPrefix) || strings.Contains(out, "<blockquote") || strings.Contains(out, ":::") {
+		t.Errorf("container not rendered as box:\n%s", out)
+	}
+}
+
+func TestThemeContainers(t *testing.T) {
+	dir := t.TempDir()
+	good := filepath.Join(dir, "good.yaml")
+	bad := filepath.Join(dir, "bad.yaml")
+	_ = os.WriteFile(good, []byte("name: good\ntype: api\ncolors:\n  primary: \"#123456\"\ncontainers:\n  tip:\n    title: 小贴士\n  quote:\n    style: \"border:2px dashed var(--primary)\"\n"), 0644)
+	_ = os.WriteFile(bad, []byte("name: bad\ntype: api\ncontainers:\n  tip:\n    style: \"color: var(--missing)\"\n"), 0644)
+
+	tm := NewThemeManager()
+	if err := tm.LoadTheme(good); err != nil {
+		t.Fatalf("LoadTheme() error = %v", err)
+	}
+	if err := tm.LoadTheme(bad); err == nil || !strings.Contains(err.Error(), "containers.tip") {
+		t.Errorf("LoadTheme(bad) error = %v, want container style error", err)
+	}
+
+	conv := NewConverter(&config.Config{}, zap.NewNop()).(*converter)
+	conv.theme = tm
+	result := conv.Convert(&ConvertRequest{Markdown: "::: tip\n一\n:::\n\n::: quote 引言\n二\n:::\n", Mode: ModeLocal, Theme: "good"})
+	if !result.Success {
+		t.Fatalf("Convert() error = %v", result.Error)
+	}
+	for _, want := range []string{
+		`border-left:4px solid #2f855a;border-radius:4px;"><p style="margin:0 0 6px;font-weight:bold;color:#2f855a;">小贴士</p>`,
+		`<section style="border:2px dashed #123456;"><p style="margin:0 0 6px;font-weight:bold;color:#3f3f3f;">引言</p>`,
+	} {
+		if !strings.Contains(result.HTML, want) {
+			t.Errorf("HTML missing %q:\n%s", want, result.HTML)
+		}
+	}
+}
@@ -1,12 +1,13 @@
 // Package converter 提供 Markdown 到微信公众号 HTML 的转换功能
-// 支持两种转换模式：API 模式（调用 md2wechat.cn）和 AI 模式（通过 Claude 生成）
+// 支持三种转换模式：API 模式（调用 md2wechat.cn）、AI 模式（通过 Claude 生成）和本地模式（离线渲染）
 package converter
 
 import (
-	"regexp"
-	"strings"
+	"sync"
 
 	"github.com/geekjourneyx/md2wechat-skill/internal/config"
+	"github.com/geekjourneyx/md2wechat-skill/internal/diagram"
+	"github.com/geekjourneyx/md2wechat-skill/internal/latex"
 	"go.uber.org/zap"
 )
 
@@ -14,8 +15,9 @@ import (
 type ConvertMode string
 
 const (
-	ModeAPI ConvertMode = "api" // API 模式：调用 md2wechat.cn
-	ModeAI  ConvertMode = "ai"  // AI 模式：通过 Claude 生成
+	ModeAPI   ConvertMode = "api"   // API 模式：调用 md2wechat.cn
+	ModeAI    ConvertMode = "ai"    // AI 模式：通过 Claude 生成
+	ModeLocal ConvertMode = "local" // 本地模式：离线渲染，使用主题 YAML 的配色
 )
 
 // ImageType 图片类型
@@ -33,6 +35,22 @@ type ConvertRequest struct {
 	Markdown string      // Markdown 内容
 	Mode     ConvertMode // 转换模式
 	Theme    string      // 主题名称 / AI 提示词名称
+	BaseDir  string      // Markdown 文件所在目录，用于解析本地图片的相对路径
+
+	// 代码高亮
+	LineNumbers bool // 代码块显示行号（覆盖主题设置）
+	NoHighlight bool // 不做代码高亮
+
+	// 数学公式和图表
+	Math      MathFormat // 公式输出格式：svg（默认）/png/off
+	NoDiagram bool       // mermaid / plantuml 代码块不渲染为图片
+
+	// 链接和脚注
+	Links LinkPolicy // 外部链接处理方式：references（默认）/keep/strip
+
+	// Obsidian 兼容
+	Obsidian bool   // 处理 ![[图片]] 嵌入、[[笔记]] 链接和 > [!type] callout
+	VaultDir string // 笔记库根目录，用于查找嵌入的图片和链接的笔记（为空时使用 BaseDir）
 
 	// API 模式专用
 	APIKey   string // md2wechat.cn API Key
@@ -40,26 +58,34 @@ type ConvertRequest struct {
 
 	// AI 模式专用
 	CustomPrompt string // 自定义提示词
+
+	images []ImageRef // 已解析的图片引用（由 Convert 填充）
 }
 
 // ImageRef 图片引用
 type ImageRef struct {
 	Index       int       // 位置索引
 	Original    string    // 原始路径或提示词
+	Source      string    // Markdown 中书写的图片地址
 	Placeholder string    // HTML 中的占位符 <!-- IMG:0 -->
 	WechatURL   string    // 上传后的 URL (处理完成后)
 	Type        ImageType // 图片类型
 	AIPrompt    string    // AI 图片的生成提示词
+	Alt         string    // 替代文本
+	Title       string    // 图片标题
+	Line        int       // 在 Markdown 源文件中的行号（从 1 开始）
+	Width       string    // 宽度提示（如 <img width="300">）
 }
 
 // ConvertResult 转换结果
 type ConvertResult struct {
-	HTML    string      // 生成的 HTML（含占位符）
-	Mode    ConvertMode // 使用的模式
-	Theme   string      // 使用的主题
-	Images  []ImageRef  // 图片引用列表
-	Success bool        // 是否成功
-	Error   string      // 错误信息
+	HTML    string       // 生成的 HTML（含占位符）
+	Mode    ConvertMode  // 使用的模式
+	Theme   string       // 使用的主题
+	Images  []ImageRef   // 图片引用列表
+	Meta    *ArticleMeta // 文章元数据（front matter + 标题回退）
+	Success bool         // 是否成功
+	Error   string       // 错误信息
 }
 
 // Converter 转换器接口
@@ -77,15 +103,33 @@ type converter struct {
 	log           *zap.Logger
 	theme         *ThemeManager
 	promptBuilder *PromptBuilder
+	llm           LLMClient        // 为 nil 时 AI 模式只生成提示词
+	diagram       diagram.Renderer // mermaid / plantuml 代码块渲染器
+
+	mathMu    sync.Mutex
+	mathCache map[string]*latex.Formula // 已排版的公式，键为公式内容哈希
 }
 
 // NewConverter 创建转换器
 func NewConverter(cfg *config.Config, log *zap.Logger) Converter {
+	// 配置了 LLM 时，AI 模式直接调用模型完成转换
+	var llm LLMClient
+	if cfg.LLMAPIKey != "" {
+		client, err := NewLLMClient(cfg)
+		if err != nil {
+			log.Warn("failed to create LLM client, AI mode will only prepare prompts", zap.Error(err))
+		} else {
+			llm = client
+		}
+	}
+
 	return &converter{
 		cfg:           cfg,
 		log:           log,
 		theme:         NewThemeManager(),
 		promptBuilder: NewPromptBuilder(),
+		llm:           llm,
+		diagram:       diagram.NewRenderer(cfg),
 	}
 }
 
@@ -96,6 +140,58 @@ func (c *converter) Convert(req *ConvertRequest) *ConvertResult {
 		Theme: req.Theme,
 	}
 
+	// 解析 front matter：元数据随结果返回，正文中去除
+	meta, body, err := ResolveArticleMeta(req.Markdown)
+	if err != nil {
+		result.Success = false
+		result.Error = err.Error()
+		return result
+	}
+	if req.Theme == "" {
+		req.Theme = meta.Theme
+	}
+
+	// 账号默认作者和页脚
+	if meta.Author == "" {
+		meta.Author = c.cfg.DefaultAuthor
+	}
+	body = appendFooter(body, c.cfg.FooterTemplate, meta)
+
+	// Obsidian 语法转换为标准 Markdown，callout 替换为占位符
+	var embeds []obsidianEmbed
+	var callouts []callout
+	if req.Obsidian {
+		body, embeds, callouts = c.prepareObsidian(body, req)
+	}
+
+	// ::: 容器改写为以占位符开头的引用块（容器中的 callout、公式和脚注照常处理）
+	var containers []container
+	if c.containersEnabled(req) {
+		body, containers = extractContainers(body)
+	}
+
+	// mermaid / plantuml 代码块渲染为图片，替换为本地图片引用
+	if !req.NoDiagram {
+		body = c.renderDiagrams(body)
+	}
+
+	// 公式替换为占位符，避免被 Markdown 转义；转换完成后再渲染
+	var formulas []mathFormula
+	if c.mathEnabled(req) {
+		body, formulas = extractMath(body)
+	}
+
+	// 脚注定义移到文末参考资料，引用替换为占位符（脚注中的公式已替换为占位符）
+	var notes []footnote
+	if c.footnotesEnabled(req) {
+		body, notes = extractFootnotes(body)
+	}
+
+	// 为每张图片写入槽位：AI 模式使用 <!-- IMG:n --> 占位符，其它模式替换图片地址
+	req.images = ParseImages(body, req.BaseDir)
+	applyEmbedWidths(req.images, embeds)
+	req.Markdown = insertImageSlots(body, req.images, req.Mode == ModeAI)
+
 	// 验证请求
 	if err := c.validateRequest(req); err != nil {
 		result.Success = false
@@ -106,14 +202,42 @@ func (c *converter) Convert(req *ConvertRequest) *ConvertResult {
 	// 根据模式选择转换器
 	switch req.Mode {
 	case ModeAPI:
-		return c.convertViaAPI(req)
+		result = c.convertViaAPI(req)
 	case ModeAI:
-		return c.convertViaAI(req)
+		result = c.convertViaAI(req)
+	case ModeLocal:
+		result = c.convertViaLocal(req)
 	default:
 		result.Success = false
 		result.Error = "unsupported convert mode: " + string(req.Mode)
-		return result
 	}
+
+	// 代码高亮（AI 模式未调用模型时没有 HTML，跳过）
+	if result.Success && !req.NoHighlight {
+		result.HTML = c.highlightCode(result.HTML, req)
+	}
+
+	// 还原图片槽位，上传后由 ReplaceImagePlaceholders 填入微信 URL
+	result.HTML = restoreImageSlots(result.HTML, req.images)
+	result.HTML = applyImageWidths(result.HTML, req.images)
+
+	// callout 和 ::: 容器渲染为带样式的 <section>
+	if result.Success {
+		c.renderCallouts(result, callouts, req)
+		c.renderContainers(result, containers, req)
+	}
+
+	// 外部链接和脚注改为上标编号，文末附参考资料（在公式之前处理，参考资料中的公式一并渲染）
+	if result.Success {
+		c.processLinks(result, notes, req)
+	}
+
+	// 渲染公式（PNG 格式的公式追加到 result.Images，随其它图片一起上传）
+	if result.Success && len(formulas) > 0 {
+		c.renderMath(result, formulas, req)
+	}
+	result.Meta = meta
+	return result
 }
 
 // validateRequest 验证请求参数
@@ -126,92 +250,51 @@ func (c *converter) validateRequest(req *ConvertRequest) error {
 		req.Mode = ModeAPI
 	}
 
+	if req.Theme == "" {
+		req.Theme = c.cfg.DefaultTheme
+	}
 	if req.Theme == "" {
 		req.Theme = "default"
 	}
 
 	switch req.Mode {
 	case ModeAPI:
+		// CSS 主题在本地渲染，不需要 API Key
+		if c.theme.IsCSSTheme(req.Theme) {
+			break
+		}
 		if req.APIKey == "" && c.cfg.MD2WechatAPIKey == "" {
 			return ErrMissingAPIKey
 		}
 		if req.APIKey == "" {
 			req.APIKey = c.cfg.MD2WechatAPIKey
 		}
-	case ModeAI:
-		// AI 模式不需要额外验证
-	}
-
-	return nil
-}
-
-// ExtractImages 从 Markdown 中提取图片引用
-func (c *converter) ExtractImages(markdown string) []ImageRef {
-	var images []ImageRef
-
-	// 匹配本地图片: ![alt](./path/to/image.png)
-	localPattern := regexp.MustCompile(`!\[([^\]]*)\]\((\.\/[^)]+)\)`)
-	for i, match := range localPattern.FindAllStringSubmatch(markdown, -1) {
-		if len(match) >= 3 {
-			images = append(images, ImageRef{
-				Index:       i,
-				Original:    match[2],
-				Placeholder: "",
-				Type:        ImageTypeLocal,
-			})
-		}
+	case ModeAI, ModeLocal:
+		// AI 模式和本地模式不需要额外验证
 	}
 
-	// 匹配在线图片: ![alt](https://...)
-	onlinePattern := regexp.MustCompile(`!\[([^\]]*)\]\((https?://[^)]+)\)`)
-	offset := len(images)
-	for i, match := range onlinePattern.FindAllStringSubmatch(markdown, -1) {
-		if len(match) >= 3 {
-			images = append(images, ImageRef{
-				Index:       offset + i,
-				Original:    match[2],
-				Placeholder: "",
-				Type:        ImageTypeOnline,
-			})
-		}
+	switch req.Math {
+	case "", MathSVG, MathPNG, MathOff:
+	default:
+		return &ConvertError{Code: "INVALID_MATH_FORMAT", Message: "unsupported math format: " + string(req.Math)}
 	}
 
-	// 匹配 AI 生成图片: ![alt](__generate:prompt__)
-	aiPattern := regexp.MustCompile(`!\[([^\]]*)\]\(__generate:([^)]+)__\)`)
-	offset = len(images)
-	for i, match := range aiPattern.FindAllStringSubmatch(markdown, -1) {
-		if len(match) >= 3 {
-			images = append(images, ImageRef{
-				Index:       offset + i,
-				Original:    match[2],
-				Placeholder: "",
-				Type:        ImageTypeAI,
-				AIPrompt:    match[2],
-			})
-		}
+	switch req.Links {
+	case "", LinkReferences, LinkKeep, LinkStrip:
+	default:
+		return &ConvertError{Code: "INVALID_LINK_POLICY", Message: "unsupported link policy: " + string(req.Links)}
 	}
 
-	return images
-}
-
-// ReplaceImagePlaceholders 在 HTML 中替换图片占位符
-func ReplaceImagePlaceholders(html string, images []ImageRef) string {
-	result := html
-	for _, img := range images {
-		if img.WechatURL != "" {
-			// 替换占位符为实际图片标签
-			imgTag := `<img src="` + img.WechatURL + `" style="max-width:100%;height:auto;display:block;margin:20px auto;" />`
-			result = strings.ReplaceAll(result, img.Placeholder, imgTag)
-		}
-	}
-	return result
+	return nil
 }
 
-// InsertImagePlaceholders 在 HTML 中插入图片占位符
-func InsertImagePlaceholders(html string, images []ImageRef) string {
-	// 简化实现：直接返回原 HTML
-	// 实际的占位符插入由 AI 或 API 转换时处理
-	return html
+// ExtractImages 从 Markdown 中提取图片引用（按文档顺序，相对路径基于当前目录）
+// Obsidian 的 ![[图片|宽度]] 嵌入同样识别，宽度写入 Width
+func (c *converter) ExtractImages(markdown string) []ImageRef {
+	markdown, embeds := newVault("", "").replaceWikiLinks(markdown)
+	images := ParseImages(markdown, "")
+	applyEmbedWidths(images, embeds)
+	return images
 }
 
 // 错误定义
@@ -0,0 +1,373 @@
+package converter
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Stylesheet 解析后的 CSS 主题样式表
+// 支持的选择器：元素、*、类、后代（空格）、子元素（>）、:first-child、:last-child、:nth-child()
+type Stylesheet struct {
+	Rules []CSSRule
+}
+
+// CSSRule 一条样式规则（选择器列表已拆分，每条规则只有一个选择器）
+type CSSRule struct {
+	Selector     *cssSelector
+	Declarations []CSSDeclaration
+	order        int // 在样式表中的顺序
+}
+
+// CSSDeclaration 样式声明
+type CSSDeclaration struct {
+	Property  string
+	Value     string
+	Important bool
+}
+
+// cssSelector 复合选择器链，parts[0] 为最左侧
+type cssSelector struct {
+	text  string
+	parts []cssCompound
+}
+
+// cssCompound 简单选择器组合，combinator 为与左侧的关系（' ' 后代，'>' 子元素）
+type cssCompound struct {
+	tag        string // 为空或 "*" 表示任意元素
+	classes    []string
+	pseudos    []cssPseudo
+	combinator byte
+}
+
+// cssPseudo 结构伪类（:first-child 为 nth-child(1)，:last-child 从末尾计数）
+type cssPseudo struct {
+	a, b     int
+	fromLast bool
+}
+
+// specificity 选择器优先级（类和伪类, 元素）
+func (s *cssSelector) specificity() int {
+	classes, tags := 0, 0
+	for _, p := range s.parts {
+		classes += len(p.classes) + len(p.pseudos)
+		if p.tag != "" && p.tag != "*" {
+			tags++
+		}
+	}
+	return classes*1000 + tags
+}
+
+var (
+	cssCommentPattern = regexp.MustCompile(`(?s)/\*.*?\*/`)
+	cssVarPattern     = regexp.MustCompile(`var\(\s*--([A-Za-z0-9_-]+)\s*(?:,\s*([^)]*))?\)`)
+	cssIdentPattern   = regexp.MustCompile(`^-?[A-Za-z_][A-Za-z0-9_-]*`)
+	cssNthPattern     = regexp.MustCompile(`^([+-]?\d*)n\s*(?:([+-])\s*(\d+))?$`)
+)
+
+// ParseStylesheet 解析 CSS，colors 用于替换 var(--name)（名称中的 - 与 _ 等价）
+// 不支持的选择器、@ 规则和未定义的变量返回错误（带行号）
+func ParseStylesheet(css string, colors map[string]string) (*Stylesheet, error) {
+	// 注释替换为等长的空白，保持行号
+	css = cssCommentPattern.ReplaceAllStringFunc(css, func(c string) string {
+		return strings.Map(func(r rune) rune {
+			if r == '\n' {
+				return r
+			}
+			return ' '
+		}, c)
+	})
+
+	sheet := &Stylesheet{}
+	pos := 0
+	for {
+		start := pos + len(css[pos:]) - len(strings.TrimLeft(css[pos:], " \t\r\n"))
+		if start >= len(css) {
+			break
+		}
+		line := strings.Count(css[:start], "\n") + 1
+
+		open := strings.IndexByte(css[start:], '{')
+		if open < 0 {
+			return nil, fmt.Errorf("line %d: expected '{'", line)
+		}
+		prelude := strings.TrimSpace(css[start : start+open])
+		end := strings.IndexByte(css[start+open:], '}')
+		if end < 0 {
+			return nil, fmt.Errorf("line %d: missing '}'", line)
+		}
+		body := css[start+open+1 : start+open+end]
+		pos = start + open + end + 1
+
+		if strings.HasPrefix(prelude, "@") {
+			return nil, fmt.Errorf("line %d: at-rule %s is not supported (WeChat keeps only inline styles)", line, strings.Fields(prelude)[0])
+		}
+		if strings.Contains(body, "{") {
+			return nil, fmt.Errorf("line %d: nested blocks are not supported", line)
+		}
+
+		decls, err := parseDeclarations(body, colors)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		for _, text := range strings.Split(prelude, ",") {
+			sel, err := parseSelector(strings.TrimSpace(text))
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+			sheet.Rules = append(sheet.Rules, CSSRule{Selector: sel, Declarations: decls, order: len(sheet.Rules)})
+		}
+	}
+
+	return sheet, nil
+}
+
+// parseDeclarations 解析声明块
+func parseDeclarations(body string, colors map[string]string) ([]CSSDeclaration, error) {
+	var decls []CSSDeclaration
+	for _, text := range splitCSSDeclarations(body) {
+		prop, value, ok := strings.Cut(text, ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid declaration %q", text)
+		}
+		decl := CSSDeclaration{
+			Property: strings.ToLower(strings.TrimSpace(prop)),
+			Value:    strings.TrimSpace(value),
+		}
+		if v, found := strings.CutSuffix(decl.Value, "!important"); found {
+			decl.Value = strings.TrimSpace(v)
+			decl.Important = true
+		}
+		if decl.Property == "" || decl.Value == "" {
+			return nil, fmt.Errorf("invalid declaration %q", text)
+		}
+
+		var varErr error
+		decl.Value = cssVarPattern.ReplaceAllStringFunc(decl.Value, func(m string) string {
+			sub := cssVarPattern.FindStringSubmatch(m)
+			if v := lookupColor(colors, sub[1]); v != "" {
+				return v
+			}
+			if fallback := strings.TrimSpace(sub[2]); fallback != "" {
+				return fallback
+			}
+			varErr = fmt.Errorf("undefined variable --%s (add it to the theme colors)", sub[1])
+			return m
+		})
+		if varErr != nil {
+			return nil, varErr
+		}
+
+		decls = append(decls, decl)
+	}
+	return decls, nil
+}
+
+// lookupColor 查找主题颜色（--code-background 与 code_background 等价）
+func lookupColor(colors map[string]string, name string) string {
+	if v := colors[name]; v != "" {
+		return v
+	}
+	return colors[strings.ReplaceAll(name, "-", "_")]
+}
+
+// splitCSSDeclarations 按分号拆分声明（忽略括号和引号内的分号）
+func splitCSSDeclarations(body string) []string {
+	var out []string
+	depth, quote, start := 0, rune(0), 0
+	for i, r := range body {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '(':
+			depth++
+		case r == ')' && depth > 0:
+			depth--
+		case r == ';' && depth == 0:
+			if s := strings.TrimSpace(body[start:i]); s != "" {
+				out = append(out, s)
+			}
+			start = i + 1
+		}
+	}
+	if s := strings.TrimSpace(body[start:]); s != "" {
+		out = append(out, s)
+	}
+	return out
+}
+
+// parseSelector 解析一个选择器（不含逗号）
+func parseSelector(text string) (*cssSelector, error) {
+	if text == "" {
+		return nil, fmt.Errorf("empty selector")
+	}
+
+	sel := &cssSelector{text: text}
+	combinator := byte(0)
+	i := 0
+	for i < len(text) {
+		switch c := text[i]; {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			if combinator == 0 && len(sel.parts) > 0 {
+				combinator = ' '
+			}
+			i++
+			continue
+		case c == '>':
+			if len(sel.parts) == 0 {
+				return nil, fmt.Errorf("selector %q: unexpected '>'", text)
+			}
+			combinator = '>'
+			i++
+			continue
+		}
+
+		compound, n, err := parseCompound(text[i:])
+		if err != nil {
+			return nil, fmt.Errorf("selector %q: %w", text, err)
+		}
+		if len(sel.parts) > 0 {
+			if combinator == 0 {
+				combinator = ' '
+			}
+			compound.combinator = combinator
+		}
+		sel.parts = append(sel.parts, compound)
+		combinator = 0
+		i += n
+	}
+
+	if combinator == '>' {
+		return nil, fmt.Errorf("selector %q: dangling '>'", text)
+	}
+	return sel, nil
+}
+
+// parseCompound 解析一个简单选择器组合，返回消耗的长度
+func parseCompound(text string) (cssCompound, int, error) {
+	var c cssCompound
+	i := 0
+
+	if i < len(text) && text[i] == '*' {
+		c.tag = "*"
+		i++
+	} else if m := cssIdentPattern.FindString(text); m != "" {
+		c.tag = strings.ToLower(m)
+		i += len(m)
+	}
+
+	for i < len(text) {
+		switch text[i] {
+		case '.':
+			m := cssIdentPattern.FindString(text[i+1:])
+			if m == "" {
+				return c, 0, fmt.Errorf("invalid class name")
+			}
+			c.classes = append(c.classes, m)
+			i += 1 + len(m)
+		case ':':
+			pseudo, n, err := parsePseudo(text[i:])
+			if err != nil {
+				return c, 0, err
+			}
+			c.pseudos = append(c.pseudos, pseudo)
+			i += n
+		case ' ', '\t', '\n', '\r', '>':
+			if i == 0 {
+				return c, 0, fmt.Errorf("unexpected %q", text[i])
+			}
+			return c, i, nil
+		case '#':
+			return c, 0, fmt.Errorf("id selectors are not supported (WeChat removes id attributes)")
+		case '[':
+			return c, 0, fmt.Errorf("attribute selectors are not supported")
+		case '+', '~':
+			return c, 0, fmt.Errorf("sibling combinator %q is not supported", text[i])
+		default:
+			return c, 0, fmt.Errorf("unexpected %q", text[i])
+		}
+	}
+
+	if i == 0 {
+		return c, 0, fmt.Errorf("empty selector")
+	}
+	return c, i, nil
+}
+
+// parsePseudo 解析结构伪类
+func parsePseudo(text string) (cssPseudo, int, error) {
+	switch {
+	case strings.HasPrefix(text, ":first-child"):
+		return cssPseudo{a: 0, b: 1}, len(":first-child"), nil
+	case strings.HasPrefix(text, ":last-child"):
+		return cssPseudo{a: 0, b: 1, fromLast: true}, len(":last-child"), nil
+	case strings.HasPrefix(text, ":nth-child("), strings.HasPrefix(text, ":nth-last-child("):
+		fromLast := strings.HasPrefix(text, ":nth-last-child(")
+		open := strings.IndexByte(text, '(')
+		end := strings.IndexByte(text, ')')
+		if end < 0 {
+			return cssPseudo{}, 0, fmt.Errorf("missing ')' in %s", text)
+		}
+		a, b, err := parseNth(text[open+1 : end])
+		if err != nil {
+			return cssPseudo{}, 0, err
+		}
+		return cssPseudo{a: a, b: b, fromLast: fromLast}, end + 1, nil
+	}
+
+	name := text
+	if m := cssIdentPattern.FindString(text[1:]); m != "" {
+		name = ":" + m
+	}
+	return cssPseudo{}, 0, fmt.Errorf("pseudo-class %s is not supported", name)
+}
+
+// parseNth 解析 an+b、odd、even 或整数
+func parseNth(expr string) (int, int, error) {
+	expr = strings.ToLower(strings.TrimSpace(expr))
+	switch expr {
+	case "odd":
+		return 2, 1, nil
+	case "even":
+		return 2, 0, nil
+	}
+	if n, err := strconv.Atoi(expr); err == nil {
+		return 0, n, nil
+	}
+
+	m := cssNthPattern.FindStringSubmatch(expr)
+	if m == nil {
+		return 0, 0, fmt.Errorf("invalid nth-child expression %q", expr)
+	}
+	a := 1
+	switch m[1] {
+	case "", "+":
+	case "-":
+		a = -1
+	default:
+		a, _ = strconv.Atoi(m[1])
+	}
+	b := 0
+	if m[3] != "" {
+		b, _ = strconv.Atoi(m[3])
+		if m[2] == "-" {
+			b = -b
+		}
+	}
+	return a, b, nil
+}
+
+// matches 判断位置 pos（从 1 开始）是否满足 an+b
+func (p cssPseudo) matches(pos int) bool {
+	if p.a == 0 {
+		return pos == p.b
+	}
+	diff := pos - p.b
+	return diff%p.a == 0 && diff/p.a >= 0
+}
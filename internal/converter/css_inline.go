@@ -0,0 +1,308 @@
+package converter
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+	gmhtml "github.com/yuin/goldmark/renderer/html"
+	"go.uber.org/zap"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// cssContainerClass CSS 主题中文章容器的类名（<section class="md2wechat">）
+const cssContainerClass = "md2wechat"
+
+// loadStylesheet 读取并解析 CSS 主题的样式表（路径相对于主题文件所在目录）
+func (t *Theme) loadStylesheet(themeDir string) error {
+	if t.Stylesheet == "" {
+		return fmt.Errorf("css theme %q: stylesheet is required", t.Name)
+	}
+
+	path := t.Stylesheet
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(themeDir, path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("css theme %q: read stylesheet: %w", t.Name, err)
+	}
+
+	sheet, err := ParseStylesheet(string(data), t.Colors)
+	if err != nil {
+		return fmt.Errorf("css theme %q: %s: %w", t.Name, path, err)
+	}
+	t.sheet = sheet
+	t.files = append(t.files, path)
+	return nil
+}
+
+// convertViaCSS 使用 CSS 主题在本地渲染：Markdown 转为普通 HTML 后内联样式表
+func (c *converter) convertViaCSS(req *ConvertRequest, theme *Theme) *ConvertResult {
+	result := &ConvertResult{
+		Mode:    ModeLocal,
+		Theme:   req.Theme,
+		Success: false,
+	}
+
+	html, err := RenderCSS(req.Markdown, theme.sheet)
+	if err != nil {
+		result.Error = fmt.Sprintf("css render failed: %s", err.Error())
+		c.log.Error("css conversion failed",
+			zap.String("theme", req.Theme),
+			zap.Error(err))
+		return result
+	}
+
+	result.HTML = html
+	result.Images = req.images
+	result.Success = true
+
+	c.log.Info("css theme conversion succeeded",
+		zap.String("theme", req.Theme),
+		zap.Int("rules", len(theme.sheet.Rules)),
+		zap.Int("image_count", len(req.images)))
+
+	return result
+}
+
+// RenderCSS 将 Markdown 渲染为 HTML，包裹在 <section class="md2wechat"> 中并内联样式表
+func RenderCSS(markdown string, sheet *Stylesheet) (string, error) {
+	md := goldmark.New(
+		goldmark.WithExtensions(extension.GFM),
+		goldmark.WithRendererOptions(
+			gmhtml.WithUnsafe(),
+			gmhtml.WithEastAsianLineBreaks(gmhtml.EastAsianLineBreaksSimple),
+		),
+	)
+
+	var buf bytes.Buffer
+	buf.WriteString(`<section class="` + cssContainerClass + `">`)
+	if err := md.Convert([]byte(markdown), &buf); err != nil {
+		return "", err
+	}
+	buf.WriteString("</section>")
+
+	return InlineCSS(buf.String(), sheet)
+}
+
+// InlineCSS 将样式表应用到 HTML 片段：匹配的声明写入 style 属性（已有的内联样式优先于普通声明），
+// 并删除 class 属性（微信只保留内联样式）
+func InlineCSS(fragment string, sheet *Stylesheet) (string, error) {
+	root := &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body}
+	nodes, err := html.ParseFragment(strings.NewReader(fragment), root)
+	if err != nil {
+		return "", fmt.Errorf("parse html: %w", err)
+	}
+	for _, n := range nodes {
+		root.AppendChild(n)
+	}
+
+	// 先计算所有样式再修改属性，保证匹配时 class 仍然存在
+	styles := make(map[*html.Node]string)
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && n != root {
+			if style := computeStyle(n, root, sheet); style != "" {
+				styles[n] = style
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(root)
+
+	var strip func(*html.Node)
+	strip = func(n *html.Node) {
+		if n.Type == html.ElementNode && n != root {
+			attrs := n.Attr[:0]
+			for _, a := range n.Attr {
+				if a.Key != "class" && a.Key != "style" {
+					attrs = append(attrs, a)
+				}
+			}
+			if style, ok := styles[n]; ok {
+				attrs = append(attrs, html.Attribute{Key: "style", Val: style})
+			}
+			n.Attr = attrs
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			strip(c)
+		}
+	}
+	strip(root)
+
+	var out bytes.Buffer
+	for c := root.FirstChild; c != nil; c = c.NextSibling {
+		if err := html.Render(&out, c); err != nil {
+			return "", fmt.Errorf("render html: %w", err)
+		}
+	}
+	return out.String(), nil
+}
+
+// computeStyle 计算元素的内联样式
+// 层叠顺序：普通声明（按优先级和顺序） < 元素已有的 style < !important 声明
+func computeStyle(n, root *html.Node, sheet *Stylesheet) string {
+	var matched []CSSRule
+	for _, rule := range sheet.Rules {
+		if matchSelector(n, root, rule.Selector.parts, len(rule.Selector.parts)-1) {
+			matched = append(matched, rule)
+		}
+	}
+	sort.SliceStable(matched, func(i, j int) bool {
+		si, sj := matched[i].Selector.specificity(), matched[j].Selector.specificity()
+		if si != sj {
+			return si < sj
+		}
+		return matched[i].order < matched[j].order
+	})
+
+	var style cssStyle
+	for _, rule := range matched {
+		for _, d := range rule.Declarations {
+			if !d.Important {
+				style.set(d.Property, d.Value)
+			}
+		}
+	}
+	for _, a := range n.Attr {
+		if a.Key != "style" {
+			continue
+		}
+		for _, text := range splitCSSDeclarations(a.Val) {
+			if prop, value, ok := strings.Cut(text, ":"); ok {
+				style.set(strings.ToLower(strings.TrimSpace(prop)), strings.TrimSpace(value))
+			}
+		}
+	}
+	for _, rule := range matched {
+		for _, d := range rule.Declarations {
+			if d.Important {
+				style.set(d.Property, d.Value)
+			}
+		}
+	}
+
+	return style.String()
+}
+
+// cssStyle 有序的样式声明，重复设置的属性移到末尾（保持简写和完整属性的覆盖关系）
+type cssStyle struct {
+	props  []string
+	values map[string]string
+}
+
+func (s *cssStyle) set(prop, value string) {
+	if s.values == nil {
+		s.values = make(map[string]string)
+	}
+	if _, ok := s.values[prop]; ok {
+		for i, p := range s.props {
+			if p == prop {
+				s.props = append(s.props[:i], s.props[i+1:]...)
+				break
+			}
+		}
+	}
+	s.props = append(s.props, prop)
+	s.values[prop] = value
+}
+
+func (s *cssStyle) String() string {
+	var b strings.Builder
+	for _, p := range s.props {
+		b.WriteString(p + ":" + s.values[p] + ";")
+	}
+	return b.String()
+}
+
+// matchSelector 从右向左匹配选择器链
+func matchSelector(n, root *html.Node, parts []cssCompound, i int) bool {
+	if !parts[i].matches(n) {
+		return false
+	}
+	if i == 0 {
+		return true
+	}
+
+	parent := elementParent(n, root)
+	if parts[i].combinator == '>' {
+		return parent != nil && matchSelector(parent, root, parts, i-1)
+	}
+	for p := parent; p != nil; p = elementParent(p, root) {
+		if matchSelector(p, root, parts, i-1) {
+			return true
+		}
+	}
+	return false
+}
+
+// elementParent 返回父元素（片段根节点不参与匹配）
+func elementParent(n, root *html.Node) *html.Node {
+	if n.Parent == nil || n.Parent == root || n.Parent.Type != html.ElementNode {
+		return nil
+	}
+	return n.Parent
+}
+
+// matches 判断元素是否满足简单选择器组合
+func (c cssCompound) matches(n *html.Node) bool {
+	if c.tag != "" && c.tag != "*" && c.tag != n.Data {
+		return false
+	}
+
+	if len(c.classes) > 0 {
+		var classes []string
+		for _, a := range n.Attr {
+			if a.Key == "class" {
+				classes = strings.Fields(a.Val)
+			}
+		}
+		for _, want := range c.classes {
+			found := false
+			for _, cls := range classes {
+				if cls == want {
+					found = true
+					break
+				}
+			}
+			if !found {
+				return false
+			}
+		}
+	}
+
+	for _, p := range c.pseudos {
+		if !p.matches(siblingPosition(n, p.fromLast)) {
+			return false
+		}
+	}
+	return true
+}
+
+// siblingPosition 元素在兄弟元素中的位置（从 1 开始，fromLast 时从末尾计数）
+func siblingPosition(n *html.Node, fromLast bool) int {
+	pos := 1
+	if fromLast {
+		for s := n.NextSibling; s != nil; s = s.NextSibling {
+			if s.Type == html.ElementNode {
+				pos++
+			}
+		}
+		return pos
+	}
+	for s := n.PrevSibling; s != nil; s = s.PrevSibling {
+		if s.Type == html.ElementNode {
+			pos++
+		}
+	}
+	return pos
+}
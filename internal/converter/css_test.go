@@ -0,0 +1,117 @@
+package converter
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/geekjourneyx/md2wechat-skill/internal/config"
+	"go.uber.org/zap"
+)
+
+func TestInlineCSS(t *testing.T) {
+	sheet, err := ParseStylesheet(`
+		p { color: var(--text); margin: 1em 0 }
+		.lead { color: red; margin-top: 0 }
+		section p { font-size: 15px }
+		section > em { font-weight: bold }
+		li:first-child { color: green }
+		li:nth-child(2n+3) { color: blue }
+		li:last-child { border: none !important }
+	`, map[string]string{"text": "#333"})
+	if err != nil {
+		t.Fatalf("ParseStylesheet() error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		html string
+		want string
+	}{
+		{"element", `<p>a</p>`, `<p style="color:#333;margin:1em 0;">a</p>`},
+		{"class wins and is removed", `<p class="lead">a</p>`, `<p style="margin:1em 0;color:red;margin-top:0;">a</p>`},
+		{"descendant", `<section><div><p>a</p></div></section>`, `<section><div><p style="color:#333;margin:1em 0;font-size:15px;">a</p></div></section>`},
+		{"child", `<section><em>a</em><p><em>b</em></p></section>`, `<section><em style="font-weight:bold;">a</em><p style="color:#333;margin:1em 0;font-size:15px;"><em>b</em></p></section>`},
+		{"inline style beats sheet", `<p style="color:#000">a</p>`, `<p style="margin:1em 0;color:#000;">a</p>`},
+		{"important beats inline style", `<ul><li style="border:1px solid">a</li></ul>`, `<ul><li style="color:green;border:none;">a</li></ul>`},
+		{"nth-child", `<ul><li>1</li><li>2</li><li>3</li><li>4</li><li>5</li></ul>`,
+			`<ul><li style="color:green;">1</li><li>2</li><li style="color:blue;">3</li><li>4</li><li style="color:blue;border:none;">5</li></ul>`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := InlineCSS(tt.html, sheet)
+			if err != nil {
+				t.Fatalf("InlineCSS() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("InlineCSS() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseStylesheetErrors(t *testing.T) {
+	tests := []struct {
+		css  string
+		want string
+	}{
+		{"p { color: red }\n#main { color: red }", "line 2: selector \"#main\": id selectors"},
+		{"a:hover { color: red }", "pseudo-class :hover is not supported"},
+		{"@media (max-width: 600px) { p { color: red } }", "at-rule @media is not supported"},
+		{"p { color: var(--accent) }", "undefined variable --accent"},
+		{"p { color red }", "invalid declaration"},
+		{"p + p { margin: 0 }", "sibling combinator"},
+		{"p { color: red", "missing '}'"},
+	}
+
+	for _, tt := range tests {
+		_, err := ParseStylesheet(tt.css, nil)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("ParseStylesheet(%q) error = %v, want %q", tt.css, err, tt.want)
+		}
+	}
+}
+
+func TestCSSTheme(t *testing.T) {
+	dir := t.TempDir()
+	writeFile := func(name, content string) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeFile("t.yaml", "name: t\ntype: css\nstylesheet: t.css\ncolors:\n  primary: \"#123456\"\n")
+	writeFile("t.css", ".md2wechat { color: #111 }\nh2 { color: var(--primary) }\n")
+	writeFile("bad.yaml", "name: bad\ntype: css\nstylesheet: bad.css\n")
+	writeFile("bad.css", "h2:hover { color: red }\n")
+
+	tm := NewThemeManager()
+	if err := tm.LoadTheme(filepath.Join(dir, "t.yaml")); err != nil {
+		t.Fatalf("LoadTheme() error = %v", err)
+	}
+	if err := tm.LoadTheme(filepath.Join(dir, "bad.yaml")); err == nil || !strings.Contains(err.Error(), "bad.css") {
+		t.Errorf("LoadTheme(bad) error = %v, want stylesheet error", err)
+	}
+
+	// CSS 主题在 API 模式下也本地渲染，不需要 API Key
+	conv := NewConverter(&config.Config{}, zap.NewNop()).(*converter)
+	conv.theme = tm
+	result := conv.Convert(&ConvertRequest{
+		Markdown: "## 标题\n\n<p class=\"note\">正文</p>\n",
+		Mode:     ModeAPI,
+		Theme:    "t",
+	})
+	if !result.Success {
+		t.Fatalf("Convert() error = %v", result.Error)
+	}
+	wants := []string{`<section style="color:#111;">`, `<h2 style="color:#123456;">标题</h2>`}
+	for _, want := range wants {
+		if !strings.Contains(result.HTML, want) {
+			t.Errorf("Convert() HTML missing %q\n%s", want, result.HTML)
+		}
+	}
+	if strings.Contains(result.HTML, "class=") {
+		t.Errorf("Convert() HTML keeps class attributes: %s", result.HTML)
+	}
+}
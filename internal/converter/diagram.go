@@ -0,0 +1,133 @@
+package converter
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/geekjourneyx/md2wechat-skill/internal/diagram"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/text"
+	"go.uber.org/zap"
+)
+
+// diagramBlock Markdown 中的一个图表代码块
+type diagramBlock struct {
+	kind   diagram.Kind
+	source string
+	start  int // 代码块（含围栏）起始偏移
+	end    int // 代码块（含围栏）结束偏移
+}
+
+// renderDiagrams 将 mermaid / plantuml 代码块渲染为 PNG，替换为指向缓存文件的 Markdown 图片
+// 之后的图片解析把它们当作本地图片，随其它图片一起上传；渲染失败的代码块保持原样
+// 图片按渲染器和图表源码的哈希缓存在 <cache>/diagrams 下，内容不变时不会重新渲染
+func (c *converter) renderDiagrams(markdown string) string {
+	blocks := findDiagramBlocks(markdown)
+	if len(blocks) == 0 {
+		return markdown
+	}
+
+	var buf strings.Builder
+	last := 0
+	for _, b := range blocks {
+		path, err := c.renderDiagram(b)
+		if err != nil {
+			c.log.Warn("diagram render failed, keeping code block",
+				zap.String("kind", string(b.kind)),
+				zap.Error(err))
+			continue
+		}
+		buf.WriteString(markdown[last:b.start])
+		fmt.Fprintf(&buf, "![%s](<%s>)", b.kind, filepath.ToSlash(path))
+		last = b.end
+	}
+	buf.WriteString(markdown[last:])
+	return buf.String()
+}
+
+// renderDiagram 渲染单个图表，返回 PNG 文件路径（已缓存时直接返回）
+func (c *converter) renderDiagram(b diagramBlock) (string, error) {
+	sum := sha256.Sum256([]byte(c.diagram.Name() + "\x00" + string(b.kind) + "\x00" + b.source))
+	path := filepath.Join(c.cfg.CacheDir(), "diagrams", hex.EncodeToString(sum[:16])+".png")
+	if _, err := os.Stat(path); err == nil {
+		return path, nil
+	}
+
+	data, err := c.diagram.Render(context.Background(), b.kind, b.source)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", fmt.Errorf("create diagram cache dir: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return "", fmt.Errorf("write diagram image: %w", err)
+	}
+	c.log.Info("diagram rendered", zap.String("kind", string(b.kind)), zap.String("path", path))
+	return path, nil
+}
+
+// findDiagramBlocks 查找语言为 mermaid / plantuml 的围栏代码块（按文档顺序）
+func findDiagramBlocks(markdown string) []diagramBlock {
+	if !strings.Contains(markdown, "```") && !strings.Contains(markdown, "~~~") {
+		return nil
+	}
+	source := []byte(markdown)
+	doc := goldmark.New(goldmark.WithExtensions(extension.GFM)).Parser().Parse(text.NewReader(source))
+
+	var blocks []diagramBlock
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		fenced, ok := n.(*ast.FencedCodeBlock)
+		if !entering || !ok || fenced.Info == nil {
+			return ast.WalkContinue, nil
+		}
+		kind, ok := diagram.KindOf(string(fenced.Info.Segment.Value(source)))
+		if !ok {
+			return ast.WalkSkipChildren, nil
+		}
+
+		var code strings.Builder
+		lines := fenced.Lines()
+		for i := 0; i < lines.Len(); i++ {
+			seg := lines.At(i)
+			code.Write(seg.Value(source))
+		}
+		if strings.TrimSpace(code.String()) == "" {
+			return ast.WalkSkipChildren, nil
+		}
+
+		// 开始围栏：信息字符串之前的 ``` 或 ~~~
+		start := fenced.Info.Segment.Start
+		for start > 0 && (source[start-1] == ' ' || source[start-1] == '\t') {
+			start--
+		}
+		for start > 0 && (source[start-1] == '`' || source[start-1] == '~') {
+			start--
+		}
+
+		// 结束围栏：最后一行代码之后的一行（未闭合的代码块到文档末尾）
+		end := lines.At(lines.Len() - 1).Stop
+		if rest := source[end:]; len(rest) > 0 {
+			line := rest
+			if i := strings.IndexByte(string(rest), '\n'); i >= 0 {
+				line = rest[:i]
+			}
+			if fence := strings.TrimLeft(string(line), " \t>"); strings.HasPrefix(fence, "```") || strings.HasPrefix(fence, "~~~") {
+				end += len(line)
+			}
+		}
+
+		blocks = append(blocks, diagramBlock{kind: kind, source: code.String(), start: start, end: end})
+		return ast.WalkSkipChildren, nil
+	})
+	sort.Slice(blocks, func(i, j int) bool { return blocks[i].start < blocks[j].start })
+	return blocks
+}
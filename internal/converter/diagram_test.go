@@ -0,0 +1,77 @@
+package converter
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/geekjourneyx/md2wechat-skill/internal/config"
+	"github.com/geekjourneyx/md2wechat-skill/internal/diagram"
+	"go.uber.org/zap"
+)
+
+// countingRenderer 测试用渲染器，记录调用次数
+type countingRenderer struct {
+	calls int
+}
+
+func (r *countingRenderer) Name() string { return "test" }
+func (r *countingRenderer) Render(ctx context.Context, kind diagram.Kind, source string) ([]byte, error) {
+	r.calls++
+	if strings.Contains(source, "broken") {
+		return nil, errors.New("syntax error")
+	}
+	return []byte("\x89PNG\r\n\x1a\n" + source), nil
+}
+
+func TestFindDiagramBlocks(t *testing.T) {
+	markdown := "# T\n\n```mermaid\ngraph TD\nA-->B\n```\n\n> ~~~plantuml\n> A -> B\n> ~~~\n\n```go\nx := 1\n```\n\n    mermaid indented\n"
+	blocks := findDiagramBlocks(markdown)
+	if len(blocks) != 2 {
+		t.Fatalf("got %d blocks, want 2", len(blocks))
+	}
+	if b := blocks[0]; b.kind != diagram.KindMermaid || b.source != "graph TD\nA-->B\n" || markdown[b.start:b.end] != "```mermaid\ngraph TD\nA-->B\n```" {
+		t.Errorf("block 0 = %+v (%q)", b, markdown[b.start:b.end])
+	}
+	if b := blocks[1]; b.kind != diagram.KindPlantUML || b.source != "A -> B\n" || markdown[b.start:b.end] != "~~~plantuml\n> A -> B\n> ~~~" {
+		t.Errorf("block 1 = %+v (%q)", b, markdown[b.start:b.end])
+	}
+}
+
+func TestConvertDiagrams(t *testing.T) {
+	t.Setenv("MD2WECHAT_CACHE_DIR", t.TempDir())
+	conv := NewConverter(&config.Config{}, zap.NewNop()).(*converter)
+	renderer := &countingRenderer{}
+	conv.diagram = renderer
+
+	markdown := "![图](https://example.com/a.png)\n\n```mermaid\ngraph TD\nA-->B\n```\n\n```mermaid\nbroken\n```\n"
+	result := conv.Convert(&ConvertRequest{Markdown: markdown, Mode: ModeLocal})
+	if !result.Success {
+		t.Fatalf("Convert() error = %v", result.Error)
+	}
+	if len(result.Images) != 2 {
+		t.Fatalf("len(Images) = %d, want 2", len(result.Images))
+	}
+	img := result.Images[1]
+	if img.Type != ImageTypeLocal || img.Alt != "mermaid" || !strings.Contains(img.Original, "diagrams") {
+		t.Errorf("diagram image = %+v", img)
+	}
+	if !strings.Contains(result.HTML, imagePlaceholder(1)) {
+		t.Errorf("HTML missing diagram placeholder: %s", result.HTML)
+	}
+	if !strings.Contains(result.HTML, "broken") {
+		t.Errorf("failed diagram should stay a code block: %s", result.HTML)
+	}
+
+	// 第二次转换命中缓存，只有失败的图表会重试
+	conv.Convert(&ConvertRequest{Markdown: markdown, Mode: ModeLocal})
+	if renderer.calls != 3 {
+		t.Errorf("render calls = %d, want 3", renderer.calls)
+	}
+
+	plain := conv.Convert(&ConvertRequest{Markdown: markdown, Mode: ModeLocal, NoDiagram: true})
+	if len(plain.Images) != 1 {
+		t.Errorf("--no-diagram: len(Images) = %d, want 1", len(plain.Images))
+	}
+}
@@ -0,0 +1,21 @@
+package converter
+
+import (
+	"strings"
+)
+
+// appendFooter 将账号的页脚模板追加到正文末尾
+// 模板为 Markdown，支持 {{title}}、{{author}}、{{source_url}} 占位符
+func appendFooter(body, footer string, meta *ArticleMeta) string {
+	if strings.TrimSpace(footer) == "" {
+		return body
+	}
+
+	replacer := strings.NewReplacer(
+		"{{title}}", meta.Title,
+		"{{author}}", meta.Author,
+		"{{source_url}}", meta.SourceURL,
+	)
+
+	return strings.TrimRight(body, "\n") + "\n\n" + strings.TrimSpace(replacer.Replace(footer)) + "\n"
+}
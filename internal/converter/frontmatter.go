@@ -0,0 +1,79 @@
+package converter
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ArticleMeta 文章元数据（来自 Markdown 的 YAML front matter）
+type ArticleMeta struct {
+	Title           string `yaml:"title" json:"title"`
+	Author          string `yaml:"author,omitempty" json:"author,omitempty"`
+	Digest          string `yaml:"digest,omitempty" json:"digest,omitempty"`
+	Cover           string `yaml:"cover,omitempty" json:"cover,omitempty"`                         // 封面图片路径（相对于 Markdown 文件）
+	SourceURL       string `yaml:"source_url,omitempty" json:"source_url,omitempty"`               // 原文链接（“阅读原文”）
+	OriginalURL     string `yaml:"original_url,omitempty" json:"-"`                                // source_url 的别名
+	OpenComment     bool   `yaml:"open_comment,omitempty" json:"open_comment,omitempty"`           // 开启评论
+	FansOnlyComment bool   `yaml:"fans_only_comment,omitempty" json:"fans_only_comment,omitempty"` // 仅粉丝可评论
+	Theme           string `yaml:"theme,omitempty" json:"theme,omitempty"`                         // 文章默认主题
+	ID              string `yaml:"id,omitempty" json:"id,omitempty"`                               // 文章标识（sync 用于关联草稿，默认使用文件路径）
+}
+
+// frontMatterDelimiter front matter 分隔符
+const frontMatterDelimiter = "---"
+
+// ParseFrontMatter 解析 Markdown 开头的 YAML front matter
+// 返回元数据和去除 front matter 后的正文；没有 front matter 时返回空元数据和原文
+func ParseFrontMatter(markdown string) (*ArticleMeta, string, error) {
+	meta := &ArticleMeta{}
+
+	content := strings.TrimPrefix(markdown, "\ufeff")
+	firstLine, rest, found := strings.Cut(content, "\n")
+	if !found || strings.TrimSpace(firstLine) != frontMatterDelimiter {
+		return meta, markdown, nil
+	}
+
+	// 查找结束分隔符
+	lines := strings.SplitAfter(rest, "\n")
+	end := -1
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == frontMatterDelimiter || trimmed == "..." {
+			end = i
+			break
+		}
+	}
+	if end < 0 {
+		// 没有结束分隔符，视为普通内容（例如以分割线开头的文章）
+		return meta, markdown, nil
+	}
+
+	raw := strings.Join(lines[:end], "")
+	body := strings.Join(lines[end+1:], "")
+
+	if err := yaml.Unmarshal([]byte(raw), meta); err != nil {
+		return nil, markdown, fmt.Errorf("parse front matter: %w", err)
+	}
+
+	if meta.SourceURL == "" {
+		meta.SourceURL = meta.OriginalURL
+	}
+
+	return meta, strings.TrimLeft(body, "\r\n"), nil
+}
+
+// ResolveArticleMeta 解析 front matter 并补全缺省字段（标题回退到正文标题）
+func ResolveArticleMeta(markdown string) (*ArticleMeta, string, error) {
+	meta, body, err := ParseFrontMatter(markdown)
+	if err != nil {
+		return nil, markdown, err
+	}
+
+	if meta.Title == "" {
+		meta.Title = ParseMarkdownTitle(body)
+	}
+
+	return meta, body, nil
+}
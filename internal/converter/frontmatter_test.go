@@ -0,0 +1,115 @@
+package converter
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseFrontMatter(t *testing.T) {
+	tests := []struct {
+		name     string
+		markdown string
+		want     ArticleMeta
+		body     string
+		wantErr  bool
+	}{
+		{
+			name:     "no front matter",
+			markdown: "# 标题\n\n正文\n",
+			body:     "# 标题\n\n正文\n",
+		},
+		{
+			name:     "front matter",
+			markdown: "---\ntitle: 标题\nauthor: 作者\nopen_comment: true\n---\n\n正文\n",
+			want:     ArticleMeta{Title: "标题", Author: "作者", OpenComment: true},
+			body:     "正文\n",
+		},
+		{
+			name:     "bom and crlf",
+			markdown: "\ufeff---\r\ntitle: 标题\r\n---\r\n正文\r\n",
+			want:     ArticleMeta{Title: "标题"},
+			body:     "正文\r\n",
+		},
+		{
+			name:     "dots terminator",
+			markdown: "---\ndigest: 摘要\n...\n正文\n",
+			want:     ArticleMeta{Digest: "摘要"},
+			body:     "正文\n",
+		},
+		{
+			name:     "unterminated delimiter is content",
+			markdown: "---\n\n正文\n",
+			body:     "---\n\n正文\n",
+		},
+		{
+			name:     "original_url alias",
+			markdown: "---\noriginal_url: https://example.com/a\n---\n正文\n",
+			want:     ArticleMeta{SourceURL: "https://example.com/a", OriginalURL: "https://example.com/a"},
+			body:     "正文\n",
+		},
+		{
+			name:     "source_url wins over alias",
+			markdown: "---\nsource_url: https://example.com/s\noriginal_url: https://example.com/o\n---\n正文\n",
+			want:     ArticleMeta{SourceURL: "https://example.com/s", OriginalURL: "https://example.com/o"},
+			body:     "正文\n",
+		},
+		{
+			name:     "invalid yaml",
+			markdown: "---\ntitle: [unclosed\n---\n正文\n",
+			body:     "---\ntitle: [unclosed\n---\n正文\n",
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			meta, body, err := ParseFrontMatter(tt.markdown)
+			if tt.wantErr {
+				if err == nil || !strings.Contains(err.Error(), "parse front matter") {
+					t.Errorf("ParseFrontMatter() error = %v, want parse error", err)
+				}
+				if body != tt.body {
+					t.Errorf("ParseFrontMatter() body = %q, want original markdown", body)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseFrontMatter() error = %v", err)
+			}
+			if *meta != tt.want {
+				t.Errorf("ParseFrontMatter() meta = %+v, want %+v", *meta, tt.want)
+			}
+			if body != tt.body {
+				t.Errorf("ParseFrontMatter() body = %q, want %q", body, tt.body)
+			}
+		})
+	}
+}
+
+func TestResolveArticleMeta(t *testing.T) {
+	tests := []struct {
+		name     string
+		markdown string
+		title    string
+	}{
+		{"front matter title", "---\ntitle: 元数据标题\n---\n# 正文标题\n", "元数据标题"},
+		{"heading fallback", "---\nauthor: 作者\n---\n\n## 正文标题\n", "正文标题"},
+		{"first line fallback", "![图](a.png)\n\n第一段\n", "第一段"},
+		{"empty", "", "未命名文章"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			meta, _, err := ResolveArticleMeta(tt.markdown)
+			if err != nil {
+				t.Fatalf("ResolveArticleMeta() error = %v", err)
+			}
+			if meta.Title != tt.title {
+				t.Errorf("Title = %q, want %q", meta.Title, tt.title)
+			}
+		})
+	}
+
+	if _, _, err := ResolveArticleMeta("---\n: [\n---\n"); err == nil {
+		t.Error("ResolveArticleMeta(invalid) error = nil")
+	}
+}
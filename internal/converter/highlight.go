@@ -0,0 +1,308 @@
+package converter
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/text"
+)
+
+// ThemeHighlight 主题的代码高亮配置
+type ThemeHighlight struct {
+	Disabled    bool              `yaml:"disabled,omitempty"`     // 关闭代码高亮
+	LineNumbers bool              `yaml:"line_numbers,omitempty"` // 显示行号
+	Wrap        bool              `yaml:"wrap,omitempty"`         // 长行自动换行（默认横向滚动）
+	Palette     map[string]string `yaml:"palette,omitempty"`      // token 类别 -> 颜色
+}
+
+// defaultHighlightPalette 默认高亮配色（浅色背景）
+var defaultHighlightPalette = map[string]string{
+	string(TokenText):     "#24292e",
+	string(TokenKeyword):  "#d73a49",
+	string(TokenType):     "#e36209",
+	string(TokenLiteral):  "#005cc5",
+	string(TokenString):   "#032f62",
+	string(TokenNumber):   "#005cc5",
+	string(TokenComment):  "#6a737d",
+	string(TokenFunction): "#6f42c1",
+	string(TokenProperty): "#005cc5",
+	"background":          "",
+	"line_number":         "#a0a0a0",
+}
+
+// validate 检查 palette 的键
+func (h ThemeHighlight) validate() error {
+	for key := range h.Palette {
+		if _, ok := defaultHighlightPalette[key]; !ok {
+			return fmt.Errorf("highlight.palette: unknown key %q", key)
+		}
+	}
+	return nil
+}
+
+// HighlightOptions 代码高亮参数
+type HighlightOptions struct {
+	LineNumbers bool
+	Wrap        bool
+	Palette     map[string]string // 已合并默认值
+}
+
+// NewHighlightOptions 根据主题创建高亮参数，theme 为 nil 时使用默认配色
+func NewHighlightOptions(theme *Theme) HighlightOptions {
+	opts := HighlightOptions{Palette: make(map[string]string, len(defaultHighlightPalette))}
+	for k, v := range defaultHighlightPalette {
+		opts.Palette[k] = v
+	}
+	if theme == nil {
+		return opts
+	}
+
+	opts.LineNumbers = theme.Highlight.LineNumbers
+	opts.Wrap = theme.Highlight.Wrap
+	for k, v := range theme.Highlight.Palette {
+		if v != "" {
+			opts.Palette[k] = v
+		}
+	}
+	return opts
+}
+
+// highlightCode 为转换结果中的代码块添加高亮
+func (c *converter) highlightCode(htmlContent string, req *ConvertRequest) string {
+	theme, _ := c.theme.GetTheme(req.Theme)
+	if theme != nil && theme.Highlight.Disabled {
+		return htmlContent
+	}
+
+	opts := NewHighlightOptions(theme)
+	if req.LineNumbers {
+		opts.LineNumbers = true
+	}
+	return HighlightCodeBlocks(htmlContent, req.Markdown, opts)
+}
+
+// codeBlock Markdown 中的代码块
+type codeBlock struct {
+	language string
+	code     string
+}
+
+var (
+	// preBlockPattern 匹配 <pre> 元素
+	preBlockPattern = regexp.MustCompile(`(?is)<pre\b([^>]*)>(.*?)</pre>`)
+
+	// preStylePattern 匹配 style 属性
+	preStylePattern = regexp.MustCompile(`(?is)\bstyle\s*=\s*("([^"]*)"|'([^']*)')`)
+
+	// codeLanguagePattern 从 class 中识别语言（language-go、lang-go）
+	codeLanguagePattern = regexp.MustCompile(`(?i)\blang(?:uage)?-([\w+#-]+)`)
+
+	// htmlBreakPattern 和 htmlTagPattern 用于提取代码文本
+	htmlBreakPattern = regexp.MustCompile(`(?i)<br\s*/?>`)
+	htmlTagPattern   = regexp.MustCompile(`(?s)<[^>]+>`)
+
+	// codeEscaper 转义代码文本（引号无需转义，保持输出可读）
+	codeEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+)
+
+// HighlightCodeBlocks 将 HTML 中的代码块替换为带内联样式的高亮版本
+// 代码块按文本与 Markdown 中的代码块对应，以获得语言（API 和 AI 模式的输出常常丢失语言信息）；
+// 对应不上时使用 class 中的 language-xxx
+func HighlightCodeBlocks(htmlContent, markdown string, opts HighlightOptions) string {
+	blocks := markdownCodeBlocks(markdown)
+	next := 0
+
+	return preBlockPattern.ReplaceAllStringFunc(htmlContent, func(match string) string {
+		m := preBlockPattern.FindStringSubmatch(match)
+		attrs, inner := m[1], m[2]
+		code := preText(inner)
+
+		language := ""
+		matched := false
+		for i := next; i < len(blocks); i++ {
+			if normalizeCode(blocks[i].code) == normalizeCode(code) {
+				language, code = blocks[i].language, blocks[i].code
+				next = i + 1
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			// 不是 Markdown 代码块生成的 <pre>（例如正文中的原始 HTML），保持原样
+			if !strings.Contains(strings.ToLower(inner), "<code") {
+				return match
+			}
+			if lm := codeLanguagePattern.FindStringSubmatch(attrs + inner); lm != nil {
+				language = lm[1]
+			}
+		}
+
+		return `<pre style="` + preStyle(attrs, opts) + `">` + RenderHighlightedCode(code, language, opts) + `</pre>`
+	})
+}
+
+// markdownCodeBlocks 按顺序提取 Markdown 中的代码块
+func markdownCodeBlocks(markdown string) []codeBlock {
+	source := []byte(markdown)
+	doc := goldmark.New(goldmark.WithExtensions(extension.GFM)).Parser().Parse(text.NewReader(source))
+
+	var blocks []codeBlock
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		var language string
+		switch node := n.(type) {
+		case *ast.FencedCodeBlock:
+			language = string(node.Language(source))
+		case *ast.CodeBlock:
+		default:
+			return ast.WalkContinue, nil
+		}
+
+		var buf bytes.Buffer
+		lines := n.Lines()
+		for i := 0; i < lines.Len(); i++ {
+			line := lines.At(i)
+			buf.Write(line.Value(source))
+		}
+		blocks = append(blocks, codeBlock{language: language, code: buf.String()})
+		return ast.WalkSkipChildren, nil
+	})
+	return blocks
+}
+
+// preText 提取 <pre> 内容的纯文本
+func preText(inner string) string {
+	s := htmlBreakPattern.ReplaceAllString(inner, "\n")
+	s = htmlTagPattern.ReplaceAllString(s, "")
+	return html.UnescapeString(s)
+}
+
+// normalizeCode 比较代码时忽略不间断空格和行尾空白
+func normalizeCode(code string) string {
+	code = strings.ReplaceAll(code, "\u00a0", " ")
+	lines := strings.Split(strings.TrimRight(code, "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t\r")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// preStyle 保留原 <pre> 的样式（没有时使用默认样式），加上背景色和滚动设置
+func preStyle(attrs string, opts HighlightOptions) string {
+	base := "margin:1em 0;padding:12px;border-radius:6px;font-size:13px;line-height:1.6;background-color:#f6f8fa;"
+	if m := preStylePattern.FindStringSubmatch(attrs); m != nil {
+		base = html.UnescapeString(m[2] + m[3])
+	}
+
+	var style cssStyle
+	for _, text := range splitCSSDeclarations(base) {
+		if prop, value, ok := strings.Cut(text, ":"); ok {
+			style.set(strings.ToLower(strings.TrimSpace(prop)), strings.TrimSpace(value))
+		}
+	}
+	if bg := opts.Palette["background"]; bg != "" {
+		style.set("background-color", bg)
+	}
+	if opts.Wrap {
+		style.set("white-space", "pre-wrap")
+	} else {
+		style.set("overflow-x", "auto")
+	}
+	return html.EscapeString(style.String())
+}
+
+// RenderHighlightedCode 渲染带内联样式的 <code> 元素
+// 换行输出为 <br>、缩进输出为 &nbsp;，避免微信编辑器合并空白；
+// 默认不换行并由 <pre> 横向滚动，Wrap 时长行自动换行
+func RenderHighlightedCode(code, language string, opts HighlightOptions) string {
+	lines := splitTokenLines(TokenizeCode(strings.TrimRight(code, "\n"), language))
+
+	codeStyle := "display:block;font-family:Menlo, Consolas, Monaco, monospace;color:" + opts.Palette[string(TokenText)] + ";"
+	if opts.Wrap {
+		codeStyle += "white-space:pre-wrap;word-break:break-all;"
+	} else {
+		codeStyle += "white-space:nowrap;"
+	}
+
+	var b strings.Builder
+	b.WriteString(`<code style="` + html.EscapeString(codeStyle) + `">`)
+
+	numberWidth := len(strconv.Itoa(len(lines)))
+	for i, line := range lines {
+		if i > 0 {
+			b.WriteString("<br>")
+		}
+		if opts.LineNumbers {
+			fmt.Fprintf(&b, `<span style="display:inline-block;width:%.1fem;margin-right:1em;text-align:right;color:%s;">%d</span>`,
+				0.6*float64(numberWidth)+0.6, opts.Palette["line_number"], i+1)
+		}
+
+		leading := true
+		for _, tok := range line {
+			content := tok.Text
+			if leading {
+				// 行首缩进始终保留
+				trimmed := strings.TrimLeft(content, " \t")
+				indent := content[:len(content)-len(trimmed)]
+				indent = strings.ReplaceAll(indent, "\t", "    ")
+				b.WriteString(strings.Repeat("&nbsp;", len(indent)))
+				content = trimmed
+				if content == "" {
+					continue
+				}
+				leading = false
+			}
+
+			escaped := codeEscaper.Replace(content)
+			if !opts.Wrap {
+				escaped = strings.ReplaceAll(escaped, " ", "&nbsp;")
+			}
+			writeCodeSpan(&b, tok.Kind, escaped, opts)
+		}
+	}
+
+	b.WriteString("</code>")
+	return b.String()
+}
+
+// writeCodeSpan 输出一个 token（普通文本不加 span）
+func writeCodeSpan(b *strings.Builder, kind TokenKind, escaped string, opts HighlightOptions) {
+	color := opts.Palette[string(kind)]
+	if kind == TokenText || color == "" || color == opts.Palette[string(TokenText)] {
+		b.WriteString(escaped)
+		return
+	}
+
+	style := "color:" + color + ";"
+	if kind == TokenComment {
+		style += "font-style:italic;"
+	}
+	b.WriteString(`<span style="` + style + `">` + escaped + `</span>`)
+}
+
+// splitTokenLines 将 token 按行拆分（跨行的注释和字符串拆到各行）
+func splitTokenLines(tokens []CodeToken) [][]CodeToken {
+	lines := [][]CodeToken{nil}
+	for _, tok := range tokens {
+		parts := strings.Split(tok.Text, "\n")
+		for i, part := range parts {
+			if i > 0 {
+				lines = append(lines, nil)
+			}
+			if part != "" {
+				last := len(lines) - 1
+				lines[last] = append(lines[last], CodeToken{Kind: tok.Kind, Text: strings.TrimSuffix(part, "\r")})
+			}
+		}
+	}
+	return lines
+}
@@ -0,0 +1,360 @@
+package converter
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// TokenKind 代码高亮的 token 类别（对应 palette 的键）
+type TokenKind string
+
+const (
+	TokenText     TokenKind = "text"
+	TokenKeyword  TokenKind = "keyword"
+	TokenType     TokenKind = "type"
+	TokenLiteral  TokenKind = "literal" // true、false、nil、None 等
+	TokenString   TokenKind = "string"
+	TokenNumber   TokenKind = "number"
+	TokenComment  TokenKind = "comment"
+	TokenFunction TokenKind = "function"
+	TokenProperty TokenKind = "property" // JSON/YAML 的键
+)
+
+// CodeToken 代码片段
+type CodeToken struct {
+	Kind TokenKind
+	Text string
+}
+
+// codeLanguage 语言的词法定义
+type codeLanguage struct {
+	keywords     map[string]bool
+	types        map[string]bool
+	literals     map[string]bool
+	lineComments []string
+	blockComment [2]string
+	quotes       string // 字符串引号
+	ignoreCase   bool   // 关键字不区分大小写（SQL）
+	propertyKeys bool   // 后跟 ':' 的标识符或字符串视为键（JSON/YAML）
+	dollarVars   bool   // $VAR 视为变量（shell）
+}
+
+// words 将空格分隔的单词转为集合
+func words(s string) map[string]bool {
+	m := make(map[string]bool)
+	for _, w := range strings.Fields(s) {
+		m[w] = true
+	}
+	return m
+}
+
+// codeLanguages 支持高亮的语言（别名指向同一定义）
+var codeLanguages = map[string]*codeLanguage{}
+
+func init() {
+	cLike := func(keywords, types, literals string) *codeLanguage {
+		return &codeLanguage{
+			keywords:     words(keywords),
+			types:        words(types),
+			literals:     words(literals),
+			lineComments: []string{"//"},
+			blockComment: [2]string{"/*", "*/"},
+			quotes:       `"'`,
+		}
+	}
+
+	golang := cLike(
+		"break case chan const continue default defer else fallthrough for func go goto if import interface map package range return select struct switch type var",
+		"bool byte complex64 complex128 error float32 float64 int int8 int16 int32 int64 rune string uint uint8 uint16 uint32 uint64 uintptr any",
+		"true false nil iota")
+	golang.quotes = "\"'`"
+
+	js := cLike(
+		"async await break case catch class const continue debugger default delete do else export extends finally for from function if import in instanceof let new of return static super switch this throw try typeof var void while with yield as interface type implements enum readonly private public protected",
+		"string number boolean any unknown never void object Array Promise Map Set Record",
+		"true false null undefined NaN Infinity")
+	js.quotes = "\"'`"
+
+	java := cLike(
+		"abstract assert break case catch class const continue default do else enum extends final finally for goto if implements import instanceof interface native new package private protected public return static strictfp super switch synchronized this throw throws transient try volatile while var record",
+		"boolean byte char double float int long short void String Integer Long Object List Map",
+		"true false null")
+
+	c := cLike(
+		"auto break case const continue default do else enum extern for goto if inline register restrict return sizeof static struct switch typedef union volatile while class namespace template typename public private protected virtual new delete using try catch throw operator friend constexpr nullptr_t",
+		"char double float int long short signed unsigned void bool size_t int8_t int16_t int32_t int64_t uint8_t uint16_t uint32_t uint64_t std string vector",
+		"true false NULL nullptr")
+
+	rust := cLike(
+		"as async await break const continue crate dyn else enum extern fn for if impl in let loop match mod move mut pub ref return self Self static struct super trait type unsafe use where while",
+		"bool char f32 f64 i8 i16 i32 i64 i128 isize str u8 u16 u32 u64 u128 usize String Vec Option Result Box",
+		"true false None Some Ok Err")
+
+	python := &codeLanguage{
+		keywords:     words("and as assert async await break class continue def del elif else except finally for from global if import in is lambda nonlocal not or pass raise return try while with yield match case"),
+		types:        words("int float str bool list dict set tuple bytes object type self cls"),
+		literals:     words("True False None"),
+		lineComments: []string{"#"},
+		quotes:       `"'`,
+	}
+
+	shell := &codeLanguage{
+		keywords:     words("if then else elif fi for while until do done case esac in function return export local readonly set unset shift source alias echo exit cd"),
+		literals:     words("true false"),
+		lineComments: []string{"#"},
+		quotes:       `"'`,
+		dollarVars:   true,
+	}
+
+	sql := &codeLanguage{
+		keywords:     words("select from where and or not insert into values update set delete create table drop alter add index primary key foreign references join left right inner outer full on group by order having limit offset as distinct union all exists in between like is case when then else end with returning default unique"),
+		types:        words("int integer bigint smallint varchar char text boolean date datetime timestamp decimal numeric float double serial json jsonb"),
+		literals:     words("null true false"),
+		lineComments: []string{"--"},
+		blockComment: [2]string{"/*", "*/"},
+		quotes:       `'"`,
+		ignoreCase:   true,
+	}
+
+	json := &codeLanguage{
+		literals:     words("true false null"),
+		quotes:       `"`,
+		propertyKeys: true,
+	}
+
+	yaml := &codeLanguage{
+		literals:     words("true false null yes no on off ~"),
+		lineComments: []string{"#"},
+		quotes:       `"'`,
+		propertyKeys: true,
+	}
+
+	css := &codeLanguage{
+		blockComment: [2]string{"/*", "*/"},
+		quotes:       `"'`,
+		propertyKeys: true,
+	}
+
+	for lang, aliases := range map[*codeLanguage][]string{
+		golang: {"go", "golang"},
+		js:     {"js", "javascript", "jsx", "ts", "typescript", "tsx", "mjs"},
+		java:   {"java", "kotlin", "kt", "scala", "csharp", "cs", "c#"},
+		c:      {"c", "h", "cpp", "c++", "cc", "hpp", "objc"},
+		rust:   {"rust", "rs"},
+		python: {"python", "py", "python3"},
+		shell:  {"sh", "bash", "shell", "zsh", "console"},
+		sql:    {"sql", "mysql", "postgresql", "postgres", "sqlite"},
+		json:   {"json", "jsonc"},
+		yaml:   {"yaml", "yml", "toml", "ini"},
+		css:    {"css", "scss", "less"},
+	} {
+		for _, alias := range aliases {
+			codeLanguages[alias] = lang
+		}
+	}
+}
+
+// lookupLanguage 查找语言定义（未知语言返回 nil）
+func lookupLanguage(name string) *codeLanguage {
+	return codeLanguages[strings.ToLower(strings.TrimSpace(name))]
+}
+
+// TokenizeCode 按语言切分代码；未知语言整体作为普通文本
+func TokenizeCode(code, language string) []CodeToken {
+	lang := lookupLanguage(language)
+	if lang == nil {
+		return []CodeToken{{Kind: TokenText, Text: code}}
+	}
+	return lang.tokenize(code)
+}
+
+// tokenize 扫描代码
+func (l *codeLanguage) tokenize(code string) []CodeToken {
+	var tokens []CodeToken
+	emit := func(kind TokenKind, text string) {
+		if text == "" {
+			return
+		}
+		// 合并相邻的同类 token，减少输出的 span
+		if n := len(tokens); n > 0 && tokens[n-1].Kind == kind {
+			tokens[n-1].Text += text
+			return
+		}
+		tokens = append(tokens, CodeToken{Kind: kind, Text: text})
+	}
+
+	i := 0
+	for i < len(code) {
+		rest := code[i:]
+
+		// 注释
+		if open := l.blockComment[0]; open != "" && strings.HasPrefix(rest, open) {
+			end := strings.Index(rest[len(open):], l.blockComment[1])
+			n := len(rest)
+			if end >= 0 {
+				n = len(open) + end + len(l.blockComment[1])
+			}
+			emit(TokenComment, rest[:n])
+			i += n
+			continue
+		}
+		if l.isLineComment(code, i) {
+			n := strings.IndexByte(rest, '\n')
+			if n < 0 {
+				n = len(rest)
+			}
+			emit(TokenComment, rest[:n])
+			i += n
+			continue
+		}
+
+		r, size := utf8.DecodeRuneInString(rest)
+		switch {
+		case strings.ContainsRune(l.quotes, r):
+			n := scanString(rest, r)
+			kind := TokenString
+			if l.propertyKeys && followedByColon(code[i+n:]) {
+				kind = TokenProperty
+			}
+			emit(kind, rest[:n])
+			i += n
+
+		case isDigitByte(rest[0]) || (r == '.' && len(rest) > 1 && isDigitByte(rest[1])):
+			n := scanNumber(rest)
+			emit(TokenNumber, rest[:n])
+			i += n
+
+		case r == '$' && l.dollarVars:
+			n := 1 + scanIdent(rest[1:], false)
+			if n == 1 && len(rest) > 1 && rest[1] == '{' {
+				if end := strings.IndexByte(rest, '}'); end > 0 {
+					n = end + 1
+				}
+			}
+			emit(TokenProperty, rest[:n])
+			i += n
+
+		case r == '_' || unicode.IsLetter(r):
+			n := scanIdent(rest, l.propertyKeys)
+			if n == 0 {
+				n = size
+			}
+			word := rest[:n]
+			emit(l.classify(word, code[i+n:]), word)
+			i += n
+
+		default:
+			emit(TokenText, rest[:size])
+			i += size
+		}
+	}
+	return tokens
+}
+
+// isLineComment 判断位置 i 是否为行注释开始
+func (l *codeLanguage) isLineComment(code string, i int) bool {
+	for _, prefix := range l.lineComments {
+		if !strings.HasPrefix(code[i:], prefix) {
+			continue
+		}
+		// shell/yaml 的 # 只有在行首或空白后才是注释（排除 $# 和 URL 锚点）
+		if prefix == "#" && i > 0 {
+			prev, _ := utf8.DecodeLastRuneInString(code[:i])
+			if !unicode.IsSpace(prev) {
+				continue
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// classify 判断标识符类别
+func (l *codeLanguage) classify(word, after string) TokenKind {
+	key := word
+	if l.ignoreCase {
+		key = strings.ToLower(word)
+	}
+	switch {
+	case l.propertyKeys && followedByColon(after):
+		return TokenProperty
+	case l.keywords[key]:
+		return TokenKeyword
+	case l.literals[key]:
+		return TokenLiteral
+	case l.types[key]:
+		return TokenType
+	case strings.HasPrefix(strings.TrimLeft(after, " \t"), "("):
+		return TokenFunction
+	}
+	return TokenText
+}
+
+// scanString 扫描字符串（支持反斜杠转义，反引号字符串可跨行，其它字符串在行尾结束）
+func scanString(s string, quote rune) int {
+	i := utf8.RuneLen(quote)
+	for i < len(s) {
+		c := s[i]
+		switch {
+		case c == '\\' && quote != '`':
+			i += 2
+			continue
+		case rune(c) == quote:
+			return i + 1
+		case c == '\n' && quote != '`':
+			return i
+		}
+		i++
+	}
+	return len(s)
+}
+
+// scanNumber 扫描数字（整数、小数、十六进制、指数）
+func scanNumber(s string) int {
+	i := 0
+	for i < len(s) {
+		c := s[i]
+		if isDigitByte(c) || c == '.' || c == '_' || c == 'x' || c == 'X' ||
+			(c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F') {
+			i++
+			continue
+		}
+		if (c == '+' || c == '-') && i > 0 && (s[i-1] == 'e' || s[i-1] == 'E') {
+			i++
+			continue
+		}
+		break
+	}
+	return i
+}
+
+// scanIdent 扫描标识符（字母、数字、下划线，dash 为 true 时允许中间的连字符，用于 CSS/YAML 键）
+func scanIdent(s string, dash bool) int {
+	i := 0
+	for i < len(s) {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r != '_' && r != '-' && !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			break
+		}
+		if r == '-' && (!dash || i == 0 || i+size >= len(s) || !isIdentByte(s[i+size])) {
+			break
+		}
+		i += size
+	}
+	return i
+}
+
+// followedByColon 判断后续内容是否以 ':' 开始（忽略空白），用于识别键
+func followedByColon(after string) bool {
+	after = strings.TrimLeft(after, " \t")
+	return strings.HasPrefix(after, ":") && !strings.HasPrefix(after, "::")
+}
+
+func isDigitByte(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isIdentByte(c byte) bool {
+	return c == '_' || isDigitByte(c) || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c >= 0x80
+}
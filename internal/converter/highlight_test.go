@@ -0,0 +1,129 @@
+package converter
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTokenizeCode(t *testing.T) {
+	tests := []struct {
+		language string
+		code     string
+		want     []CodeToken
+	}{
+		{"go", `func f() error { return nil } // x`, []CodeToken{
+			{TokenKeyword, "func"}, {TokenText, " "}, {TokenFunction, "f"}, {TokenText, "() "},
+			{TokenType, "error"}, {TokenText, " { "}, {TokenKeyword, "return"}, {TokenText, " "},
+			{TokenLiteral, "nil"}, {TokenText, " } "}, {TokenComment, "// x"},
+		}},
+		{"python", "x = 'a#b'  # c\n", []CodeToken{
+			{TokenText, "x = "}, {TokenString, "'a#b'"}, {TokenText, "  "}, {TokenComment, "# c"}, {TokenText, "\n"},
+		}},
+		{"sql", "SELECT 1.5e3 FROM t", []CodeToken{
+			{TokenKeyword, "SELECT"}, {TokenText, " "}, {TokenNumber, "1.5e3"}, {TokenText, " "},
+			{TokenKeyword, "FROM"}, {TokenText, " t"},
+		}},
+		{"json", `{"a-b": "c"}`, []CodeToken{
+			{TokenText, "{"}, {TokenProperty, `"a-b"`}, {TokenText, ": "}, {TokenString, `"c"`}, {TokenText, "}"},
+		}},
+		{"yaml", "font-size: 12 # px", []CodeToken{
+			{TokenProperty, "font-size"}, {TokenText, ": "}, {TokenNumber, "12"}, {TokenText, " "}, {TokenComment, "# px"},
+		}},
+		{"bash", "echo $HOME $#", []CodeToken{
+			{TokenKeyword, "echo"}, {TokenText, " "}, {TokenProperty, "$HOME"}, {TokenText, " "}, {TokenProperty, "$"}, {TokenText, "#"},
+		}},
+		{"unknown", "a := 1", []CodeToken{{TokenText, "a := 1"}}},
+		// 非 ASCII 数字（全角、阿拉伯数字）按普通文本处理
+		{"go", "x := １ + ٣ + 2\n", []CodeToken{{TokenText, "x := １ + ٣ + "}, {TokenNumber, "2"}, {TokenText, "\n"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.language, func(t *testing.T) {
+			got := TokenizeCode(tt.code, tt.language)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("TokenizeCode() =\n%v\nwant\n%v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderHighlightedCode(t *testing.T) {
+	opts := NewHighlightOptions(nil)
+	got := RenderHighlightedCode("if a < b {\n\tx()\n}\n", "go", opts)
+	wants := []string{
+		`white-space:nowrap;`,
+		`<span style="color:#d73a49;">if</span>&nbsp;a&nbsp;&lt;&nbsp;b&nbsp;{<br>`,
+		`<br>&nbsp;&nbsp;&nbsp;&nbsp;<span style="color:#6f42c1;">x</span>()<br>}</code>`,
+	}
+	for _, want := range wants {
+		if !strings.Contains(got, want) {
+			t.Errorf("RenderHighlightedCode() missing %q\n%s", want, got)
+		}
+	}
+
+	opts.Wrap = true
+	opts.LineNumbers = true
+	got = RenderHighlightedCode("a b\n  c", "text", opts)
+	wants = []string{
+		`white-space:pre-wrap;word-break:break-all;`,
+		`color:#a0a0a0;">1</span>a b<br>`,
+		`color:#a0a0a0;">2</span>&nbsp;&nbsp;c</code>`,
+	}
+	for _, want := range wants {
+		if !strings.Contains(got, want) {
+			t.Errorf("RenderHighlightedCode(wrap) missing %q\n%s", want, got)
+		}
+	}
+}
+
+func TestHighlightCodeBlocks(t *testing.T) {
+	markdown := "```go\nreturn 1\n```\n\n```python\nreturn 2\n```\n"
+	// API 模式的输出：没有语言信息，使用 <br> 和 &nbsp;
+	htmlContent := `<pre style="background:#000"><code>return&nbsp;1</code></pre>` +
+		`<pre><code class="language-python">return 2<br></code></pre>` +
+		`<pre>ascii art</pre>`
+
+	opts := NewHighlightOptions(nil)
+	opts.Palette["background"] = "#fafafa"
+	got := HighlightCodeBlocks(htmlContent, markdown, opts)
+
+	wants := []string{
+		`<pre style="background:#000;background-color:#fafafa;overflow-x:auto;">`,
+		`<span style="color:#d73a49;">return</span>&nbsp;<span style="color:#005cc5;">1</span>`,
+		`<span style="color:#d73a49;">return</span>&nbsp;<span style="color:#005cc5;">2</span></code>`,
+		`<pre>ascii art</pre>`,
+	}
+	for _, want := range wants {
+		if !strings.Contains(got, want) {
+			t.Errorf("HighlightCodeBlocks() missing %q\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "class=") {
+		t.Errorf("HighlightCodeBlocks() keeps class attributes: %s", got)
+	}
+}
+
+func TestThemeHighlight(t *testing.T) {
+	dir := t.TempDir()
+	good := filepath.Join(dir, "good.yaml")
+	bad := filepath.Join(dir, "bad.yaml")
+	_ = os.WriteFile(good, []byte("name: good\ntype: api\nhighlight:\n  line_numbers: true\n  palette:\n    keyword: \"#ff0000\"\n"), 0644)
+	_ = os.WriteFile(bad, []byte("name: bad\ntype: api\nhighlight:\n  palette:\n    keywords: \"#ff0000\"\n"), 0644)
+
+	tm := NewThemeManager()
+	if err := tm.LoadTheme(good); err != nil {
+		t.Fatalf("LoadTheme() error = %v", err)
+	}
+	if err := tm.LoadTheme(bad); err == nil || !strings.Contains(err.Error(), `unknown key "keywords"`) {
+		t.Errorf("LoadTheme(bad) error = %v, want unknown palette key", err)
+	}
+
+	theme, _ := tm.GetTheme("good")
+	opts := NewHighlightOptions(theme)
+	if !opts.LineNumbers || opts.Palette["keyword"] != "#ff0000" || opts.Palette["string"] != "#032f62" {
+		t.Errorf("NewHighlightOptions() = %+v", opts)
+	}
+}
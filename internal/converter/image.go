@@ -95,91 +95,17 @@ func (p *imageProcessor) isImageLine(line string) bool {
 
 // ReplacePlaceholders 替换图片占位符为实际图片
 func (p *imageProcessor) ReplacePlaceholders(html string, images []ImageRef) string {
-	result := html
-
-	for _, img := range images {
-		if img.Placeholder == "" {
-			continue
-		}
-		if img.WechatURL == "" {
-			// 没有微信 URL，跳过
-			continue
-		}
-
-		// 生成 img 标签
-		imgTag := p.buildImageTag(img)
-
-		// 替换占位符
-		result = strings.ReplaceAll(result, img.Placeholder, imgTag)
-	}
-
-	return result
-}
-
-// buildImageTag 构建图片标签
-func (p *imageProcessor) buildImageTag(img ImageRef) string {
-	style := "max-width:100%;height:auto;display:block;margin:20px auto;"
-	return fmt.Sprintf(`<img src="%s" style="%s" alt="" />`, img.WechatURL, style)
+	return ReplaceImagePlaceholders(html, images)
 }
 
 // CountImages 统计 Markdown 中的图片数量
 func (p *imageProcessor) CountImages(markdown string) int {
-	count := 0
-
-	// 匹配 ![alt](url) 格式
-	pattern := regexp.MustCompile(`!\[([^\]]*)\]\([^)]+\)`)
-	matches := pattern.FindAllString(markdown, -1)
-	count += len(matches)
-
-	return count
+	return len(ParseImages(markdown, ""))
 }
 
 // ParseImageSyntax 解析图片语法
 func (p *imageProcessor) ParseImageSyntax(markdown string) []ImageRef {
-	var images []ImageRef
-	index := 0
-
-	// 匹配本地图片: ![alt](./path/to/image.png)
-	localPattern := regexp.MustCompile(`!\[([^\]]*)\]\((\.\/[^)]+)\)`)
-	for _, match := range localPattern.FindAllStringSubmatch(markdown, -1) {
-		if len(match) >= 3 {
-			images = append(images, ImageRef{
-				Index:    index,
-				Original: match[2],
-				Type:     ImageTypeLocal,
-			})
-			index++
-		}
-	}
-
-	// 匹配在线图片: ![alt](https://...)
-	onlinePattern := regexp.MustCompile(`!\[([^\]]*)\]\((https?://[^)]+)\)`)
-	for _, match := range onlinePattern.FindAllStringSubmatch(markdown, -1) {
-		if len(match) >= 3 {
-			images = append(images, ImageRef{
-				Index:    index,
-				Original: match[2],
-				Type:     ImageTypeOnline,
-			})
-			index++
-		}
-	}
-
-	// 匹配 AI 生成图片: ![alt](__generate:prompt__)
-	aiPattern := regexp.MustCompile(`!\[([^\]]*)\]\(__generate:([^)]+)__\)`)
-	for _, match := range aiPattern.FindAllStringSubmatch(markdown, -1) {
-		if len(match) >= 3 {
-			images = append(images, ImageRef{
-				Index:    index,
-				Original: match[2],
-				Type:     ImageTypeAI,
-				AIPrompt: match[2],
-			})
-			index++
-		}
-	}
-
-	return images
+	return ParseImages(markdown, "")
 }
 
 // 辅助函数
@@ -0,0 +1,401 @@
+package converter
+
+import (
+	"bytes"
+	"html"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// aiImagePrefix AI 生成图片的路径前缀: ![alt](__generate:prompt__)
+const aiImagePrefix = "__generate:"
+
+var (
+	// aiImageDestPattern 匹配 AI 图片目标，提示词中可能含空格，需要用 <> 包裹才是合法的 Markdown 链接
+	aiImageDestPattern = regexp.MustCompile(`\]\((__generate:[^)<>\n]*__)\)`)
+
+	// htmlImgTagPattern 匹配 HTML 中的 <img> 标签
+	htmlImgTagPattern = regexp.MustCompile(`(?is)<img\b[^>]*>`)
+
+	// htmlAttrPattern 匹配 HTML 标签属性
+	htmlAttrPattern = regexp.MustCompile(`(?is)([a-z_:][-a-z0-9_:.]*)\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s"'=<>` + "`" + `]+))`)
+)
+
+// normalizeImageSyntax 预处理 Markdown，使 AI 图片语法可以被解析器识别
+func normalizeImageSyntax(markdown string) string {
+	return aiImageDestPattern.ReplaceAllString(markdown, "](<$1>)")
+}
+
+// ParseImages 解析 Markdown 中的图片引用（按文档顺序）
+// 支持行内图片、引用式图片、HTML <img> 标签；相对路径基于 baseDir 解析（为空时保持原样）
+func ParseImages(markdown, baseDir string) []ImageRef {
+	_, images, _ := scanImages(markdown, baseDir)
+	return images
+}
+
+// imageSpan 图片在（预处理后的）Markdown 源中的位置
+type imageSpan struct {
+	index    int  // 对应 ImageRef.Index
+	start    int  // 图片语法起始偏移
+	end      int  // 图片语法结束偏移
+	html     bool // 是否为 HTML <img> 标签
+	srcStart int  // HTML 标签中 src 属性值的起始偏移
+	srcEnd   int  // HTML 标签中 src 属性值的结束偏移
+	alt      string
+}
+
+// scanImages 解析图片引用，并尽可能定位每张图片在源中的位置
+// 返回预处理后的源、图片引用和可定位的图片位置
+func scanImages(markdown, baseDir string) ([]byte, []ImageRef, []imageSpan) {
+	source := []byte(normalizeImageSyntax(markdown))
+	md := goldmark.New(goldmark.WithExtensions(extension.GFM))
+	doc := md.Parser().Parse(text.NewReader(source))
+
+	var images []ImageRef
+	var spans []imageSpan
+	add := func(dest, alt, title string, offset int) (int, bool) {
+		ref, ok := newImageRef(dest, baseDir)
+		if !ok {
+			return 0, false
+		}
+		ref.Index = len(images)
+		ref.Source = dest
+		ref.Alt = alt
+		ref.Title = title
+		ref.Line = lineAt(source, offset)
+		images = append(images, ref)
+		return ref.Index, true
+	}
+	addHTML := func(segments *text.Segments) {
+		if segments.Len() == 0 {
+			return
+		}
+		start := segments.At(0).Start
+		end := segments.At(segments.Len() - 1).Stop
+		for _, tag := range parseHTMLImgTags(source[start:end]) {
+			index, ok := add(tag.src, tag.alt, tag.title, start+tag.start)
+			if !ok {
+				continue
+			}
+			images[index].Width = tag.width
+			spans = append(spans, imageSpan{
+				index:    index,
+				start:    start + tag.start,
+				end:      start + tag.end,
+				html:     true,
+				srcStart: start + tag.srcStart,
+				srcEnd:   start + tag.srcEnd,
+			})
+		}
+	}
+
+	cursor := 0
+	_ = ast.Walk(doc, func(node ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+
+		switch n := node.(type) {
+		case *ast.Image:
+			span, found := locateImage(n, source, cursor)
+			offset := imageOffset(n, source)
+			if found {
+				offset = span.start
+			}
+			index, ok := add(string(n.Destination), string(nodeText(n, source)), string(n.Title), offset)
+			if ok && found {
+				span.index = index
+				spans = append(spans, span)
+				cursor = span.end
+			}
+			return ast.WalkSkipChildren, nil
+		case *ast.HTMLBlock:
+			addHTML(n.Lines())
+		case *ast.RawHTML:
+			addHTML(n.Segments)
+		}
+		return ast.WalkContinue, nil
+	})
+
+	return source, images, spans
+}
+
+// newImageRef 根据图片地址判断类型并解析路径
+func newImageRef(dest, baseDir string) (ImageRef, bool) {
+	dest = strings.TrimSpace(dest)
+	if dest == "" || strings.HasPrefix(dest, "data:") {
+		// 内嵌 data URI 无需上传
+		return ImageRef{}, false
+	}
+
+	lower := strings.ToLower(dest)
+	switch {
+	case strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://"):
+		return ImageRef{Original: dest, Type: ImageTypeOnline}, true
+	case strings.HasPrefix(dest, aiImagePrefix) && strings.HasSuffix(dest, "__") && len(dest) > len(aiImagePrefix)+2:
+		prompt := strings.TrimSuffix(strings.TrimPrefix(dest, aiImagePrefix), "__")
+		return ImageRef{Original: prompt, Type: ImageTypeAI, AIPrompt: prompt}, true
+	case strings.HasPrefix(lower, "//"):
+		// 协议相对地址按 HTTPS 下载
+		return ImageRef{Original: "https:" + dest, Type: ImageTypeOnline}, true
+	}
+
+	return ImageRef{Original: resolveLocalImagePath(dest, baseDir), Type: ImageTypeLocal}, true
+}
+
+// resolveLocalImagePath 将本地图片地址解析为文件路径
+func resolveLocalImagePath(dest, baseDir string) string {
+	path := strings.TrimPrefix(dest, "file://")
+	// 去掉查询参数和锚点，还原 %20 等转义字符
+	if i := strings.IndexAny(path, "?#"); i >= 0 {
+		path = path[:i]
+	}
+	if unescaped, err := url.PathUnescape(path); err == nil {
+		path = unescaped
+	}
+	path = filepath.FromSlash(path)
+
+	if baseDir == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(baseDir, path)
+}
+
+// imageOffset 估算图片节点在源文件中的字节偏移
+func imageOffset(n *ast.Image, source []byte) int {
+	// 有 alt 文本时，文本段的位置就是图片所在位置
+	for c := n.FirstChild(); c != nil; c = c.NextSibling() {
+		if t, ok := c.(*ast.Text); ok {
+			return t.Segment.Start
+		}
+	}
+
+	// 否则在所属块中查找图片地址
+	for p := n.Parent(); p != nil; p = p.Parent() {
+		if p.Type() != ast.TypeBlock || p.Lines().Len() == 0 {
+			continue
+		}
+		lines := p.Lines()
+		start := lines.At(0).Start
+		end := lines.At(lines.Len() - 1).Stop
+		if i := bytes.Index(source[start:end], n.Destination); i >= 0 {
+			return start + i
+		}
+		return start
+	}
+	return 0
+}
+
+// lineAt 计算字节偏移所在的行号（从 1 开始）
+func lineAt(source []byte, offset int) int {
+	if offset > len(source) {
+		offset = len(source)
+	}
+	return bytes.Count(source[:offset], []byte("\n")) + 1
+}
+
+// htmlImgTag HTML 图片标签的属性
+type htmlImgTag struct {
+	start    int // 标签在片段中的起始偏移
+	end      int // 标签在片段中的结束偏移
+	srcStart int // src 属性值的起始偏移
+	srcEnd   int // src 属性值的结束偏移
+	src      string
+	alt      string
+	title    string
+	width    string
+}
+
+// parseHTMLImgTags 解析 HTML 片段中的 <img> 标签
+func parseHTMLImgTags(fragment []byte) []htmlImgTag {
+	var tags []htmlImgTag
+	for _, loc := range htmlImgTagPattern.FindAllIndex(fragment, -1) {
+		tag := htmlImgTag{start: loc[0], end: loc[1]}
+		attrsStart := loc[0] + len("<img")
+		seen := make(map[string]bool)
+		for _, m := range htmlAttrPattern.FindAllSubmatchIndex(fragment[attrsStart:loc[1]], -1) {
+			name := strings.ToLower(string(fragment[attrsStart+m[2] : attrsStart+m[3]]))
+			if seen[name] {
+				continue
+			}
+			seen[name] = true
+
+			// 属性值位于双引号、单引号或无引号分组之一
+			var valStart, valEnd int
+			for g := 2; g <= 4; g++ {
+				if m[2*g] >= 0 {
+					valStart, valEnd = attrsStart+m[2*g], attrsStart+m[2*g+1]
+					break
+				}
+			}
+			value := html.UnescapeString(string(fragment[valStart:valEnd]))
+
+			switch name {
+			case "src":
+				tag.src, tag.srcStart, tag.srcEnd = value, valStart, valEnd
+			case "alt":
+				tag.alt = value
+			case "title":
+				tag.title = value
+			case "width":
+				tag.width = value
+			}
+		}
+		if tag.src == "" {
+			continue
+		}
+		tags = append(tags, tag)
+	}
+	return tags
+}
+
+// locateImage 在所属块中定位 Markdown 图片语法的位置
+// 从 cursor 开始查找 "![" 并解析完整语法，行内图片需要地址与节点一致
+func locateImage(n *ast.Image, source []byte, cursor int) (imageSpan, bool) {
+	var block ast.Node
+	for p := n.Parent(); p != nil; p = p.Parent() {
+		if p.Type() == ast.TypeBlock && p.Lines().Len() > 0 {
+			block = p
+			break
+		}
+	}
+	if block == nil {
+		return imageSpan{}, false
+	}
+
+	lines := block.Lines()
+	from := lines.At(0).Start
+	limit := lines.At(lines.Len() - 1).Stop
+	if cursor > from {
+		from = cursor
+	}
+
+	for from < limit {
+		i := bytes.Index(source[from:limit], []byte("!["))
+		if i < 0 {
+			break
+		}
+		start := from + i
+		from = start + 2
+		if start > 0 && source[start-1] == '\\' {
+			continue
+		}
+
+		span, dest, inline, ok := parseImageSyntax(source, start, limit)
+		if !ok {
+			continue
+		}
+		if inline && dest != string(n.Destination) && string(util.UnescapePunctuations([]byte(dest))) != string(n.Destination) {
+			continue
+		}
+		return span, true
+	}
+	return imageSpan{}, false
+}
+
+// parseImageSyntax 从 start 处解析 ![alt](dest "title")、![alt][ref] 或 ![alt]
+// 返回位置、行内图片的地址以及是否为行内图片
+func parseImageSyntax(source []byte, start, limit int) (imageSpan, string, bool, bool) {
+	// alt 文本，允许嵌套方括号
+	i := start + 2
+	depth := 1
+	for ; i < limit; i++ {
+		switch source[i] {
+		case '\\':
+			i++
+		case '[':
+			depth++
+		case ']':
+			depth--
+		}
+		if depth == 0 {
+			break
+		}
+	}
+	if depth != 0 || i >= limit {
+		return imageSpan{}, "", false, false
+	}
+	span := imageSpan{start: start, alt: string(source[start+2 : i])}
+	i++
+
+	switch {
+	case i < limit && source[i] == '(':
+		// 行内图片
+		j := skipSpaces(source, i+1, limit)
+		var dest string
+		if j < limit && source[j] == '<' {
+			end := bytes.IndexByte(source[j:limit], '>')
+			if end < 0 {
+				return imageSpan{}, "", false, false
+			}
+			dest = string(source[j+1 : j+end])
+			j += end + 1
+		} else {
+			destStart := j
+			parens := 0
+		dest:
+			for ; j < limit; j++ {
+				switch source[j] {
+				case '\\':
+					j++
+				case '(':
+					parens++
+				case ')':
+					if parens == 0 {
+						break dest
+					}
+					parens--
+				case ' ', '\t', '\n', '\r':
+					break dest
+				}
+			}
+			dest = string(source[destStart:min(j, limit)])
+		}
+
+		j = skipSpaces(source, j, limit)
+		if j < limit && (source[j] == '"' || source[j] == '\'' || source[j] == '(') {
+			closer := source[j]
+			if closer == '(' {
+				closer = ')'
+			}
+			for j++; j < limit && source[j] != closer; j++ {
+				if source[j] == '\\' {
+					j++
+				}
+			}
+			j = skipSpaces(source, j+1, limit)
+		}
+		if j >= limit || source[j] != ')' {
+			return imageSpan{}, "", false, false
+		}
+		span.end = j + 1
+		return span, dest, true, true
+	case i < limit && source[i] == '[':
+		// 完整或折叠的引用式图片
+		end := bytes.IndexByte(source[i:limit], ']')
+		if end < 0 {
+			return imageSpan{}, "", false, false
+		}
+		span.end = i + end + 1
+	default:
+		// 简写引用式图片
+		span.end = i
+	}
+	return span, "", false, true
+}
+
+// skipSpaces 跳过空白字符
+func skipSpaces(source []byte, i, limit int) int {
+	for i < limit && (source[i] == ' ' || source[i] == '\t' || source[i] == '\n' || source[i] == '\r') {
+		i++
+	}
+	return i
+}
@@ -0,0 +1,54 @@
+package converter
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestParseImages(t *testing.T) {
+	markdown := `# 标题
+
+![在线](https://example.com/a.png)
+
+段落中的图片 ![带标题](images/b.png "图片标题") 和 ![](../shared/c%20d.png)
+
+![括号](img/photo(1).png)
+
+![引用式][logo]
+
+<img src="./e.jpg" alt="HTML 图片" width="300">
+
+![AI](__generate:a cat on the moon__)
+
+[logo]: ./logo.png
+`
+
+	images := ParseImages(markdown, "/docs/post")
+
+	want := []ImageRef{
+		{Index: 0, Original: "https://example.com/a.png", Source: "https://example.com/a.png", Type: ImageTypeOnline, Alt: "在线", Line: 3},
+		{Index: 1, Original: filepath.FromSlash("/docs/post/images/b.png"), Source: "images/b.png", Type: ImageTypeLocal, Alt: "带标题", Title: "图片标题", Line: 5},
+		{Index: 2, Original: filepath.FromSlash("/docs/shared/c d.png"), Source: "../shared/c%20d.png", Type: ImageTypeLocal, Line: 5},
+		{Index: 3, Original: filepath.FromSlash("/docs/post/img/photo(1).png"), Source: "img/photo(1).png", Type: ImageTypeLocal, Alt: "括号", Line: 7},
+		{Index: 4, Original: filepath.FromSlash("/docs/post/logo.png"), Source: "./logo.png", Type: ImageTypeLocal, Alt: "引用式", Line: 9},
+		{Index: 5, Original: filepath.FromSlash("/docs/post/e.jpg"), Source: "./e.jpg", Type: ImageTypeLocal, Alt: "HTML 图片", Line: 11, Width: "300"},
+		{Index: 6, Original: "a cat on the moon", Source: "__generate:a cat on the moon__", Type: ImageTypeAI, AIPrompt: "a cat on the moon", Alt: "AI", Line: 13},
+	}
+
+	if len(images) != len(want) {
+		t.Fatalf("ParseImages() returned %d images, want %d: %+v", len(images), len(want), images)
+	}
+	for i := range want {
+		if images[i] != want[i] {
+			t.Errorf("images[%d] = %+v, want %+v", i, images[i], want[i])
+		}
+	}
+}
+
+func TestParseImagesIgnoresCode(t *testing.T) {
+	markdown := "`![inline](a.png)`\n\n```\n![block](b.png)\n<img src=\"c.png\">\n```\n"
+
+	if images := ParseImages(markdown, ""); len(images) != 0 {
+		t.Errorf("ParseImages() = %+v, want no images", images)
+	}
+}
@@ -0,0 +1,148 @@
+package converter
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// imageSlotPrefix 转换前写入图片地址的槽位标记，转换后还原为原始地址
+const imageSlotPrefix = "md2wechat-img-"
+
+var (
+	// imageSlotTagPattern 匹配地址为槽位标记的 <img> 标签
+	imageSlotTagPattern = regexp.MustCompile(`(?is)<img\b[^>]*?` + imageSlotPrefix + `(\d+)[^>]*>`)
+
+	// imagePlaceholderPattern 匹配占位符及其后紧跟的 <img> 标签
+	imagePlaceholderPattern = regexp.MustCompile(`(?is)<!--\s*IMG:(\d+)\s*-->(\s*<img\b[^>]*>)?`)
+
+	// imgSrcAttrPattern 匹配 <img> 标签的 src 属性
+	imgSrcAttrPattern = regexp.MustCompile(`(?is)(\ssrc\s*=\s*)("[^"]*"|'[^']*'|[^\s>]+)`)
+
+	// imgAltAttrPattern 匹配 <img> 标签的 alt 属性
+	imgAltAttrPattern = regexp.MustCompile(`(?is)\salt\s*=`)
+)
+
+// imagePlaceholder 生成图片占位符
+func imagePlaceholder(index int) string {
+	return fmt.Sprintf("<!-- IMG:%d -->", index)
+}
+
+// imageSlotURL 生成图片槽位标记
+func imageSlotURL(index int) string {
+	return imageSlotPrefix + strconv.Itoa(index)
+}
+
+// InsertImagePlaceholders 在 Markdown 中为每张图片写入槽位标记
+// 图片语法保持不变，只替换地址，转换器输出的 <img> 标签保留 alt 和样式；
+// 转换完成后由 Convert 还原为 <!-- IMG:n --> 占位符加原始地址
+func InsertImagePlaceholders(markdown string, images []ImageRef) string {
+	return insertImageSlots(markdown, images, false)
+}
+
+// insertImageSlots 为图片写入槽位
+// markersOnly 为 true 时用 <!-- IMG:n --> 替换整个图片（AI 模式，由模型按提示词保留占位符）
+func insertImageSlots(markdown string, images []ImageRef, markersOnly bool) string {
+	source, _, spans := scanImages(markdown, "")
+	if len(spans) == 0 {
+		return markdown
+	}
+
+	sort.Slice(spans, func(i, j int) bool { return spans[i].start < spans[j].start })
+
+	var buf strings.Builder
+	last := 0
+	for _, span := range spans {
+		if span.start < last || span.index >= len(images) {
+			continue
+		}
+		images[span.index].Placeholder = imagePlaceholder(span.index)
+
+		switch {
+		case markersOnly:
+			buf.Write(source[last:span.start])
+			buf.WriteString(imagePlaceholder(span.index))
+			last = span.end
+		case span.html:
+			buf.Write(source[last:span.srcStart])
+			buf.WriteString(imageSlotURL(span.index))
+			last = span.srcEnd
+		default:
+			buf.Write(source[last:span.start])
+			buf.WriteString("![" + span.alt + "](" + imageSlotURL(span.index))
+			if title := images[span.index].Title; title != "" {
+				buf.WriteString(` "` + strings.ReplaceAll(title, `"`, `\"`) + `"`)
+			}
+			buf.WriteString(")")
+			last = span.end
+		}
+	}
+	buf.Write(source[last:])
+
+	return buf.String()
+}
+
+// restoreImageSlots 将转换结果中的槽位标记还原为 <!-- IMG:n --> 占位符和原始地址
+func restoreImageSlots(htmlContent string, images []ImageRef) string {
+	return imageSlotTagPattern.ReplaceAllStringFunc(htmlContent, func(tag string) string {
+		index, err := strconv.Atoi(imageSlotTagPattern.FindStringSubmatch(tag)[1])
+		if err != nil || index >= len(images) {
+			return tag
+		}
+		original := html.EscapeString(images[index].Source)
+		return imagePlaceholder(index) + strings.Replace(tag, imageSlotURL(index), original, 1)
+	})
+}
+
+// ReplaceImagePlaceholders 将已上传图片的微信 URL 填入对应的占位符
+// 占位符后紧跟 <img> 标签时只替换 src（保留 alt、宽度和样式），否则生成新的图片标签
+func ReplaceImagePlaceholders(htmlContent string, images []ImageRef) string {
+	return imagePlaceholderPattern.ReplaceAllStringFunc(htmlContent, func(match string) string {
+		m := imagePlaceholderPattern.FindStringSubmatch(match)
+		index, err := strconv.Atoi(m[1])
+		if err != nil || index >= len(images) || images[index].WechatURL == "" {
+			return match
+		}
+		img := images[index]
+
+		tag := strings.TrimSpace(m[2])
+		if tag == "" {
+			return buildImageTag(img)
+		}
+
+		src := html.EscapeString(img.WechatURL)
+		tag = imgSrcAttrPattern.ReplaceAllString(tag, `${1}"`+strings.ReplaceAll(src, "$", "$$")+`"`)
+		if img.Alt != "" && !imgAltAttrPattern.MatchString(tag) {
+			tag = strings.Replace(tag, "<img", `<img alt="`+html.EscapeString(img.Alt)+`"`, 1)
+		}
+		return tag
+	})
+}
+
+// buildImageTag 构建图片标签（保留 alt 和宽度提示）
+func buildImageTag(img ImageRef) string {
+	style := "max-width:100%;height:auto;display:block;margin:20px auto;"
+	if width := imageWidthStyle(img.Width); width != "" {
+		style += "width:" + width + ";"
+	}
+	return fmt.Sprintf(`<img src="%s" style="%s" alt="%s" />`,
+		html.EscapeString(img.WechatURL), style, html.EscapeString(img.Alt))
+}
+
+// imageWidthStyle 将宽度提示转换为 CSS 宽度（纯数字视为像素）
+func imageWidthStyle(width string) string {
+	width = strings.TrimSpace(width)
+	if width == "" {
+		return ""
+	}
+	if _, err := strconv.Atoi(width); err == nil {
+		return width + "px"
+	}
+	if strings.HasSuffix(width, "px") || strings.HasSuffix(width, "%") {
+		return width
+	}
+	return ""
+}
@@ -0,0 +1,81 @@
+package converter
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/geekjourneyx/md2wechat-skill/internal/config"
+	"go.uber.org/zap"
+)
+
+func TestInsertImagePlaceholders(t *testing.T) {
+	markdown := "![a](x.png \"标题\") 和 ![](y(1).png)\n\n![引用][r]\n\n<img src=\"z.png\" width=\"300\">\n\n`![code](c.png)`\n\n[r]: ./r.png\n"
+
+	images := ParseImages(markdown, "")
+	got := InsertImagePlaceholders(markdown, images)
+
+	for _, want := range []string{
+		`![a](md2wechat-img-0 "标题")`,
+		`![](md2wechat-img-1)`,
+		`![引用](md2wechat-img-2)`,
+		`<img src="md2wechat-img-3" width="300">`,
+		"`![code](c.png)`",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("InsertImagePlaceholders() = %q, want to contain %q", got, want)
+		}
+	}
+	for i, img := range images {
+		if img.Placeholder != imagePlaceholder(i) {
+			t.Errorf("images[%d].Placeholder = %q, want %q", i, img.Placeholder, imagePlaceholder(i))
+		}
+	}
+
+	markers := insertImageSlots(markdown, images, true)
+	if !strings.Contains(markers, "<!-- IMG:0 --> 和 <!-- IMG:1 -->") || !strings.Contains(markers, "<!-- IMG:3 -->") {
+		t.Errorf("insertImageSlots(markersOnly) = %q", markers)
+	}
+}
+
+func TestImageSlotRoundTrip(t *testing.T) {
+	conv := NewConverter(&config.Config{}, zap.NewNop())
+	result := conv.Convert(&ConvertRequest{
+		Markdown: "# 标题\n\n![第一张](a.png)\n\n文字\n\n<img src=\"b.png\" alt=\"第二张\" width=\"300\">\n",
+		Mode:     ModeLocal,
+	})
+	if !result.Success {
+		t.Fatalf("Convert() error = %s", result.Error)
+	}
+	if len(result.Images) != 2 {
+		t.Fatalf("len(Images) = %d, want 2", len(result.Images))
+	}
+
+	// 未上传时保留原始地址
+	if !strings.Contains(result.HTML, `<!-- IMG:0 --><img src="a.png"`) {
+		t.Errorf("HTML = %q, want placeholder with original src", result.HTML)
+	}
+
+	result.Images[0].WechatURL = "https://mmbiz.qpic.cn/one"
+	result.Images[1].WechatURL = "https://mmbiz.qpic.cn/two"
+	html := ReplaceImagePlaceholders(result.HTML, result.Images)
+
+	if strings.Contains(html, "IMG:") || strings.Contains(html, "a.png") || strings.Contains(html, "b.png") {
+		t.Errorf("ReplaceImagePlaceholders() left placeholders or local paths: %q", html)
+	}
+	if !strings.Contains(html, `src="https://mmbiz.qpic.cn/one"`) || !strings.Contains(html, `alt="第一张"`) {
+		t.Errorf("first image not replaced in place: %q", html)
+	}
+	if !strings.Contains(html, `src="https://mmbiz.qpic.cn/two" alt="第二张" width="300"`) {
+		t.Errorf("second image lost attributes: %q", html)
+	}
+}
+
+func TestReplaceImagePlaceholdersMarkerOnly(t *testing.T) {
+	images := []ImageRef{{Index: 0, Alt: "图", Width: "50%", WechatURL: "https://mmbiz.qpic.cn/x"}}
+
+	got := ReplaceImagePlaceholders("<p><!-- IMG:0 --></p>", images)
+	want := `<p><img src="https://mmbiz.qpic.cn/x" style="max-width:100%;height:auto;display:block;margin:20px auto;width:50%;" alt="图" /></p>`
+	if got != want {
+		t.Errorf("ReplaceImagePlaceholders() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,311 @@
+package converter
+
+import (
+	"bytes"
+	"html"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+	"go.uber.org/zap"
+)
+
+// LinkPolicy 外部链接的处理方式
+// 微信公众号文章只能点击 mp.weixin.qq.com 的链接，其它链接在任何策略下都不可点击
+type LinkPolicy string
+
+const (
+	LinkReferences LinkPolicy = "references" // 外部链接改为上标编号，文末列出参考资料（默认）
+	LinkKeep       LinkPolicy = "keep"       // 保留所有链接
+	LinkStrip      LinkPolicy = "strip"      // 去掉外部链接，只保留文字
+)
+
+const (
+	// footnotePlaceholderPrefix 转换前替换脚注引用的占位符，只含字母和数字，任何转换模式都会原样保留
+	footnotePlaceholderPrefix = "MD2WECHATFN"
+
+	// referencesTitle 参考资料列表的标题
+	referencesTitle = "参考资料"
+)
+
+var (
+	// footnoteDefPattern 脚注定义：[^label]: 内容
+	footnoteDefPattern = regexp.MustCompile(`^ {0,3}\[\^([^\]\s]+)\]:[ \t]?(.*)$`)
+
+	// footnoteRefPattern 脚注引用：[^label]
+	footnoteRefPattern = regexp.MustCompile(`\[\^([^\]\s]+)\]`)
+
+	// linkOrFootnotePattern 按文档顺序匹配 <a> 标签和脚注占位符
+	linkOrFootnotePattern = regexp.MustCompile(`(?is)<a\b([^>]*)>(.*?)</a>|` + footnotePlaceholderPrefix + `(\d+)X`)
+)
+
+// footnote Markdown 中的一条脚注
+type footnote struct {
+	label string
+	text  string // 脚注内容（Markdown）
+}
+
+// reference 参考资料列表中的一项
+type reference struct {
+	text string // 已转义的 HTML
+	url  string // 链接地址（脚注为空）
+}
+
+// footnotePlaceholder 生成脚注引用占位符
+func footnotePlaceholder(index int) string {
+	return footnotePlaceholderPrefix + strconv.Itoa(index) + "X"
+}
+
+// footnotesEnabled 判断是否处理脚注
+// 未配置 LLM 的 AI 模式由外部完成转换，占位符无法还原，保留原始脚注
+func (c *converter) footnotesEnabled(req *ConvertRequest) bool {
+	return req.Mode != ModeAI || c.llm != nil
+}
+
+// extractFootnotes 去掉 Markdown 中的脚注定义，并将引用替换为占位符
+// 脚注按第一次引用的顺序编号；没有被引用的定义会被丢弃，没有定义的引用原样保留
+// 定义后紧跟的缩进行视为同一条脚注的内容；代码中的 [^label] 不处理
+func extractFootnotes(markdown string) (string, []footnote) {
+	if !strings.Contains(markdown, "[^") {
+		return markdown, nil
+	}
+	skip := codeRanges(markdown)
+
+	// 收集定义，记录需要删除的区间（按文档顺序）
+	type span struct{ start, end int }
+	var removed []span
+	defs := make(map[string]string)
+	lines := strings.SplitAfter(markdown, "\n")
+	offset := 0
+	for i := 0; i < len(lines); i++ {
+		start := offset
+		offset += len(lines[i])
+		if inCode(skip, start) {
+			continue
+		}
+		m := footnoteDefPattern.FindStringSubmatch(strings.TrimRight(lines[i], "\r\n"))
+		if m == nil {
+			continue
+		}
+
+		body := []string{strings.TrimSpace(m[2])}
+		for i+1 < len(lines) && isIndentedLine(lines[i+1]) {
+			i++
+			offset += len(lines[i])
+			body = append(body, strings.TrimSpace(lines[i]))
+		}
+		if _, dup := defs[m[1]]; !dup {
+			defs[m[1]] = strings.Join(body, "\n")
+		}
+		removed = append(removed, span{start, offset})
+	}
+	if len(defs) == 0 {
+		return markdown, nil
+	}
+
+	// 在定义之外的正文中替换引用
+	var notes []footnote
+	index := make(map[string]int)
+	var buf strings.Builder
+	replace := func(start, end int) {
+		last := start
+		for _, loc := range footnoteRefPattern.FindAllStringSubmatchIndex(markdown[start:end], -1) {
+			refStart, refEnd := start+loc[0], start+loc[1]
+			label := markdown[start+loc[2] : start+loc[3]]
+			text, ok := defs[label]
+			if !ok || inCode(skip, refStart) {
+				continue
+			}
+			n, ok := index[label]
+			if !ok {
+				n = len(notes)
+				index[label] = n
+				notes = append(notes, footnote{label: label, text: text})
+			}
+			buf.WriteString(markdown[last:refStart])
+			buf.WriteString(footnotePlaceholder(n))
+			last = refEnd
+		}
+		buf.WriteString(markdown[last:end])
+	}
+	pos := 0
+	for _, r := range removed {
+		replace(pos, r.start)
+		pos = r.end
+	}
+	replace(pos, len(markdown))
+	return buf.String(), notes
+}
+
+// isIndentedLine 判断是否为缩进的非空行
+func isIndentedLine(line string) bool {
+	return strings.TrimSpace(line) != "" && (strings.HasPrefix(line, "    ") || strings.HasPrefix(line, "\t"))
+}
+
+// inCode 判断位置是否位于代码中
+func inCode(skip map[int]int, pos int) bool {
+	for start, end := range skip {
+		if pos >= start && pos < end {
+			return true
+		}
+	}
+	return false
+}
+
+// processLinks 按链接策略处理 HTML 中的链接，并将脚注占位符替换为上标编号
+// 外部链接和脚注按文档顺序统一编号，同一地址只编号一次，文末追加参考资料列表
+func (c *converter) processLinks(result *ConvertResult, notes []footnote, req *ConvertRequest) {
+	if result.HTML == "" {
+		return
+	}
+	policy := req.Links
+	if policy == "" {
+		policy = LinkReferences
+	}
+	theme, _ := c.theme.GetTheme(req.Theme)
+	style := NewLocalStyle(theme)
+
+	var refs []reference
+	byURL := make(map[string]int)
+	byNote := make(map[int]int)
+	marker := func(n int) string {
+		return `<sup style="` + style.Element("footnote_ref") + `">[` + strconv.Itoa(n) + `]</sup>`
+	}
+
+	changed := 0
+	result.HTML = linkOrFootnotePattern.ReplaceAllStringFunc(result.HTML, func(match string) string {
+		m := linkOrFootnotePattern.FindStringSubmatch(match)
+
+		// 脚注占位符
+		if m[3] != "" {
+			i, err := strconv.Atoi(m[3])
+			if err != nil || i >= len(notes) {
+				return match
+			}
+			n, ok := byNote[i]
+			if !ok {
+				refs = append(refs, reference{text: renderFootnoteText(notes[i].text)})
+				n = len(refs)
+				byNote[i] = n
+			}
+			return marker(n)
+		}
+
+		href := html.UnescapeString(htmlAttr(m[1], "href"))
+		inner := m[2]
+		if href == "" || isWechatLink(href) || policy == LinkKeep {
+			return match
+		}
+		changed++
+		if policy == LinkStrip || !isWebLink(href) {
+			return inner
+		}
+
+		// 链接文字就是地址本身时，读者已经能看到地址
+		label := strings.TrimSpace(htmlTagPattern.ReplaceAllString(inner, ""))
+		if html.UnescapeString(label) == href {
+			return inner
+		}
+		n, ok := byURL[href]
+		if !ok {
+			refs = append(refs, reference{text: label, url: href})
+			n = len(refs)
+			byURL[href] = n
+		}
+		return inner + marker(n)
+	})
+
+	if len(refs) > 0 {
+		result.HTML = appendReferences(result.HTML, renderReferences(refs, style), result.Mode == ModeLocal)
+	}
+	if changed > 0 || len(refs) > 0 {
+		c.log.Info("links processed",
+			zap.String("policy", string(policy)),
+			zap.Int("links", changed),
+			zap.Int("references", len(refs)))
+	}
+}
+
+// htmlAttr 从标签属性中读取指定属性的值（未解码）
+func htmlAttr(attrs, name string) string {
+	for _, m := range htmlAttrPattern.FindAllStringSubmatch(attrs, -1) {
+		if strings.EqualFold(m[1], name) {
+			return m[2] + m[3] + m[4]
+		}
+	}
+	return ""
+}
+
+// isWechatLink 判断是否为微信文章链接（公众号中唯一可点击的链接）
+func isWechatLink(href string) bool {
+	u, err := url.Parse(href)
+	return err == nil && strings.EqualFold(u.Hostname(), "mp.weixin.qq.com")
+}
+
+// isWebLink 判断是否为 http(s) 链接
+func isWebLink(href string) bool {
+	u, err := url.Parse(href)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// renderFootnoteText 将脚注内容渲染为行内 HTML，其中的外部链接改为“文字 (地址)”
+func renderFootnoteText(markdown string) string {
+	var buf bytes.Buffer
+	md := goldmark.New(goldmark.WithExtensions(extension.GFM))
+	if err := md.Convert([]byte(markdown), &buf); err != nil {
+		return html.EscapeString(markdown)
+	}
+	out := strings.TrimSpace(buf.String())
+	out = strings.ReplaceAll(out, "</p>\n<p>", "<br/>")
+	out = strings.TrimSuffix(strings.TrimPrefix(out, "<p>"), "</p>")
+
+	return linkOrFootnotePattern.ReplaceAllStringFunc(out, func(match string) string {
+		m := linkOrFootnotePattern.FindStringSubmatch(match)
+		href := html.UnescapeString(htmlAttr(m[1], "href"))
+		if m[3] != "" || href == "" || isWechatLink(href) {
+			return match
+		}
+		label := strings.TrimSpace(htmlTagPattern.ReplaceAllString(m[2], ""))
+		if html.UnescapeString(label) == href {
+			return m[2]
+		}
+		return m[2] + " (" + html.EscapeString(href) + ")"
+	})
+}
+
+// renderReferences 渲染参考资料列表
+func renderReferences(refs []reference, style *LocalStyle) string {
+	var buf strings.Builder
+	buf.WriteString(`<section style="` + style.Element("references") + `">`)
+	buf.WriteString(`<p style="` + style.Element("references_title") + `">` + referencesTitle + `</p>`)
+	for i, ref := range refs {
+		buf.WriteString(`<p style="` + style.Element("references_item") + `">`)
+		buf.WriteString(`<span style="` + style.Element("references_index") + `">[` + strconv.Itoa(i+1) + `]</span>`)
+		switch {
+		case ref.url == "":
+			buf.WriteString(ref.text)
+		case ref.text == "":
+			buf.WriteString(html.EscapeString(ref.url))
+		default:
+			buf.WriteString(ref.text + ": " + html.EscapeString(ref.url))
+		}
+		buf.WriteString(`</p>`)
+	}
+	buf.WriteString(`</section>`)
+	return buf.String()
+}
+
+// appendReferences 将参考资料放在文末
+// wrapped 表示 HTML 由本地渲染（包括 CSS 主题）生成、整体包裹在文章容器中，此时放在容器内，沿用正文的字体和背景；
+// 其它来源的 HTML 末尾的 </section> 可能属于提示框等元素，参考资料直接追加在后面
+func appendReferences(content, refs string, wrapped bool) string {
+	trimmed := strings.TrimRight(content, " \t\r\n")
+	if wrapped && strings.HasSuffix(trimmed, "</section>") {
+		i := len(trimmed) - len("</section>")
+		return trimmed[:i] + refs + trimmed[i:] + content[len(trimmed):]
+	}
+	return content + refs
+}
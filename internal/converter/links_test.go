@@ -0,0 +1,93 @@
+package converter
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/geekjourneyx/md2wechat-skill/internal/config"
+	"go.uber.org/zap"
+)
+
+func TestExtractFootnotes(t *testing.T) {
+	markdown := "A[^x] B[^y] C[^x] D[^none] `E[^x]`\n\n```\n[^y]: in code\n```\n\n[^y]: Second\n    continued\n[^x]: First\n[^unused]: Unused\n"
+	got, notes := extractFootnotes(markdown)
+
+	want := "A" + footnotePlaceholder(0) + " B" + footnotePlaceholder(1) + " C" + footnotePlaceholder(0) + " D[^none] `E[^x]`\n\n```\n[^y]: in code\n```\n\n"
+	if got != want {
+		t.Errorf("markdown =\n%q\nwant\n%q", got, want)
+	}
+	if len(notes) != 2 || notes[0].text != "First" || notes[1].text != "Second\ncontinued" {
+		t.Errorf("notes = %+v", notes)
+	}
+
+	if out, notes := extractFootnotes("no footnotes [^a] here"); out != "no footnotes [^a] here" || notes != nil {
+		t.Errorf("undefined reference changed: %q, %+v", out, notes)
+	}
+}
+
+func TestConvertLinks(t *testing.T) {
+	conv := NewConverter(&config.Config{}, zap.NewNop())
+	markdown := "[Go](https://go.dev) [again](https://go.dev) [文章](https://mp.weixin.qq.com/s/x) <https://example.com> [top](#top) note[^1]\n\n[^1]: See [spec](https://go.dev/ref/spec).\n"
+
+	convert := func(policy LinkPolicy) string {
+		t.Helper()
+		result := conv.Convert(&ConvertRequest{Markdown: markdown, Mode: ModeLocal, Links: policy})
+		if !result.Success {
+			t.Fatalf("Convert(%q) error = %v", policy, result.Error)
+		}
+		return result.HTML
+	}
+
+	html := convert("")
+	for _, want := range []string{
+		`Go<sup style="`, `>[1]</sup> again<sup`, // 同一地址共用编号
+		`<a href="https://mp.weixin.qq.com/s/x"`, // 微信链接保留
+		"https://example.com ", "top note<sup",
+		referencesTitle, "Go: https://go.dev</p>",
+		"See spec (https://go.dev/ref/spec).</p>",
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("references HTML missing %q:\n%s", want, html)
+		}
+	}
+	if strings.Count(html, "<a ") != 1 || strings.Contains(html, "[^1]") {
+		t.Errorf("unexpected links left:\n%s", html)
+	}
+	if !strings.HasSuffix(strings.TrimSpace(html), "</p></section></section>") {
+		t.Errorf("references should be inside the container:\n%s", html)
+	}
+
+	if html := convert(LinkKeep); strings.Count(html, "<a ") != 5 || !strings.Contains(html, referencesTitle) {
+		t.Errorf("keep: want all links and the footnote list:\n%s", html)
+	}
+	if html := convert(LinkStrip); strings.Count(html, "<a ") != 1 || strings.Contains(html, "https://go.dev</p>") {
+		t.Errorf("strip: want only the WeChat link and no link references:\n%s", html)
+	}
+
+	result := conv.Convert(&ConvertRequest{Markdown: markdown, Mode: ModeLocal, Links: "bad"})
+	if result.Success || !strings.Contains(result.Error, "INVALID_LINK_POLICY") {
+		t.Errorf("invalid policy: success=%v error=%q", result.Success, result.Error)
+	}
+}
+
+func TestAppendReferences(t *testing.T) {
+	refs := `<section id="refs"></section>`
+	tests := []struct {
+		name    string
+		content string
+		wrapped bool
+		want    string
+	}{
+		{"local container", "<section><p>正文</p></section>\n", true, `<section><p>正文</p><section id="refs"></section></section>` + "\n"},
+		{"ends with callout", `<p>正文</p><section style="border:1px solid"><p>提示</p></section>`, false,
+			`<p>正文</p><section style="border:1px solid"><p>提示</p></section><section id="refs"></section>`},
+		{"plain html", "<p>正文</p>", true, `<p>正文</p><section id="refs"></section>`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := appendReferences(tt.content, refs, tt.wrapped); got != tt.want {
+				t.Errorf("appendReferences() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
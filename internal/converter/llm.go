@@ -0,0 +1,65 @@
+package converter
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/geekjourneyx/md2wechat-skill/internal/config"
+)
+
+// llmTimeout 单次 LLM 调用超时（整篇文章生成耗时较长）
+const llmTimeout = 180 * time.Second
+
+// LLMClient 大模型客户端接口，用于 AI 模式直接完成转换
+type LLMClient interface {
+	// Name 返回客户端名称
+	Name() string
+
+	// Complete 发送提示词，返回模型输出的文本
+	Complete(ctx context.Context, prompt string) (string, error)
+}
+
+// NewLLMClient 根据配置创建对应的 LLMClient
+func NewLLMClient(cfg *config.Config) (LLMClient, error) {
+	if err := cfg.ValidateForLLM(); err != nil {
+		return nil, err
+	}
+
+	switch cfg.LLMProvider {
+	case "openai", "":
+		return NewOpenAILLMClient(cfg), nil
+	case "anthropic", "claude":
+		return NewAnthropicLLMClient(cfg), nil
+	default:
+		return nil, &config.ConfigError{
+			Field:   "LLMProvider",
+			Message: fmt.Sprintf("未知的 LLM 服务提供者: %s", cfg.LLMProvider),
+			Hint:    "支持的提供者: openai（兼容 OpenAI 接口的服务）, anthropic",
+		}
+	}
+}
+
+// codeFencePattern 匹配模型输出中的 ```html 代码块
+var codeFencePattern = regexp.MustCompile("(?s)```(?:html|HTML)?\\s*\\n(.*?)\\n?```")
+
+// bodyPattern 匹配完整 HTML 文档中的 <body> 内容
+var bodyPattern = regexp.MustCompile(`(?is)<body[^>]*>(.*)</body>`)
+
+// ExtractHTMLFromResponse 从模型输出中提取 HTML
+// 去除 Markdown 代码块包裹和 <html>/<body> 外壳，只保留正文
+func ExtractHTMLFromResponse(response string) string {
+	html := strings.TrimSpace(response)
+
+	if match := codeFencePattern.FindStringSubmatch(html); len(match) >= 2 {
+		html = strings.TrimSpace(match[1])
+	}
+
+	if match := bodyPattern.FindStringSubmatch(html); len(match) >= 2 {
+		html = strings.TrimSpace(match[1])
+	}
+
+	return html
+}
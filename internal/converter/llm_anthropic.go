@@ -0,0 +1,117 @@
+package converter
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/geekjourneyx/md2wechat-skill/internal/config"
+)
+
+// anthropicAPIVersion Anthropic Messages API 版本
+const anthropicAPIVersion = "2023-06-01"
+
+// AnthropicLLMClient 兼容 Anthropic Messages 接口的客户端
+type AnthropicLLMClient struct {
+	apiKey    string
+	baseURL   string
+	model     string
+	maxTokens int
+	client    *http.Client
+}
+
+// NewAnthropicLLMClient 创建 Anthropic 兼容客户端
+func NewAnthropicLLMClient(cfg *config.Config) *AnthropicLLMClient {
+	baseURL := cfg.LLMAPIBase
+	if baseURL == "" {
+		baseURL = "https://api.anthropic.com" // 默认地址
+	}
+
+	model := cfg.LLMModel
+	if model == "" {
+		model = "claude-3-5-sonnet-latest" // 默认模型
+	}
+
+	return &AnthropicLLMClient{
+		apiKey:    cfg.LLMAPIKey,
+		baseURL:   strings.TrimRight(baseURL, "/"),
+		model:     model,
+		maxTokens: 8192,
+		client: &http.Client{
+			Timeout: llmTimeout,
+		},
+	}
+}
+
+// Name 返回客户端名称
+func (c *AnthropicLLMClient) Name() string {
+	return "Anthropic"
+}
+
+// Complete 调用 /v1/messages 生成内容
+func (c *AnthropicLLMClient) Complete(ctx context.Context, prompt string) (string, error) {
+	reqBody := map[string]any{
+		"model":      c.model,
+		"max_tokens": c.maxTokens,
+		"messages": []map[string]string{
+			{"role": "user", "content": prompt},
+		},
+	}
+
+	jsonData, err := json.Marshal(reqBody)
+	if err != nil {
+		return "", fmt.Errorf("marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/v1/messages", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return "", fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("x-api-key", c.apiKey)
+	req.Header.Set("anthropic-version", anthropicAPIVersion)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return "", &ConvertError{Code: "LLM_ERROR", Message: c.Name() + " request failed", Err: err}
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return "", &ConvertError{
+			Code:    "LLM_ERROR",
+			Message: fmt.Sprintf("%s returned HTTP %d: %s", c.Name(), resp.StatusCode, string(body)),
+		}
+	}
+
+	var result struct {
+		Content []struct {
+			Type string `json:"type"`
+			Text string `json:"text"`
+		} `json:"content"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return "", fmt.Errorf("parse response: %w (body: %s)", err, string(body))
+	}
+
+	var text strings.Builder
+	for _, block := range result.Content {
+		if block.Type == "text" {
+			text.WriteString(block.Text)
+		}
+	}
+	if text.Len() == 0 {
+		return "", &ConvertError{Code: "LLM_ERROR", Message: c.Name() + " returned empty content"}
+	}
+
+	return text.String(), nil
+}
@@ -0,0 +1,105 @@
+package converter
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/geekjourneyx/md2wechat-skill/internal/config"
+)
+
+// OpenAILLMClient 兼容 OpenAI Chat Completions 接口的客户端
+type OpenAILLMClient struct {
+	apiKey  string
+	baseURL string
+	model   string
+	client  *http.Client
+}
+
+// NewOpenAILLMClient 创建 OpenAI 兼容客户端
+func NewOpenAILLMClient(cfg *config.Config) *OpenAILLMClient {
+	baseURL := cfg.LLMAPIBase
+	if baseURL == "" {
+		baseURL = "https://api.openai.com/v1" // 默认地址
+	}
+
+	model := cfg.LLMModel
+	if model == "" {
+		model = "gpt-4o" // 默认模型
+	}
+
+	return &OpenAILLMClient{
+		apiKey:  cfg.LLMAPIKey,
+		baseURL: strings.TrimRight(baseURL, "/"),
+		model:   model,
+		client: &http.Client{
+			Timeout: llmTimeout,
+		},
+	}
+}
+
+// Name 返回客户端名称
+func (c *OpenAILLMClient) Name() string {
+	return "OpenAI"
+}
+
+// Complete 调用 /chat/completions 生成内容
+func (c *OpenAILLMClient) Complete(ctx context.Context, prompt string) (string, error) {
+	reqBody := map[string]any{
+		"model": c.model,
+		"messages": []map[string]string{
+			{"role": "user", "content": prompt},
+		},
+	}
+
+	jsonData, err := json.Marshal(reqBody)
+	if err != nil {
+		return "", fmt.Errorf("marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/chat/completions", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return "", fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+c.apiKey)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return "", &ConvertError{Code: "LLM_ERROR", Message: c.Name() + " request failed", Err: err}
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return "", &ConvertError{
+			Code:    "LLM_ERROR",
+			Message: fmt.Sprintf("%s returned HTTP %d: %s", c.Name(), resp.StatusCode, string(body)),
+		}
+	}
+
+	var result struct {
+		Choices []struct {
+			Message struct {
+				Content string `json:"content"`
+			} `json:"message"`
+		} `json:"choices"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return "", fmt.Errorf("parse response: %w (body: %s)", err, string(body))
+	}
+
+	if len(result.Choices) == 0 || result.Choices[0].Message.Content == "" {
+		return "", &ConvertError{Code: "LLM_ERROR", Message: c.Name() + " returned empty content"}
+	}
+
+	return result.Choices[0].Message.Content, nil
+}
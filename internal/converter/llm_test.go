@@ -0,0 +1,115 @@
+package converter
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/geekjourneyx/md2wechat-skill/internal/config"
+)
+
+func TestOpenAILLMClient_Complete(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/chat/completions" {
+			t.Errorf("Path = %v, want /chat/completions", r.URL.Path)
+		}
+		if r.Header.Get("Authorization") != "Bearer test-key" {
+			t.Errorf("Authorization header incorrect")
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{
+			"choices": []map[string]any{
+				{"message": map[string]string{"content": "<section>ok</section>"}},
+			},
+		})
+	}))
+	defer server.Close()
+
+	client, err := NewLLMClient(&config.Config{
+		LLMProvider: "openai",
+		LLMAPIKey:   "test-key",
+		LLMAPIBase:  server.URL,
+	})
+	if err != nil {
+		t.Fatalf("NewLLMClient() error = %v", err)
+	}
+
+	got, err := client.Complete(context.Background(), "prompt")
+	if err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+	if got != "<section>ok</section>" {
+		t.Errorf("Complete() = %v, want <section>ok</section>", got)
+	}
+}
+
+func TestAnthropicLLMClient_Complete(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/messages" {
+			t.Errorf("Path = %v, want /v1/messages", r.URL.Path)
+		}
+		if r.Header.Get("x-api-key") != "test-key" {
+			t.Errorf("x-api-key header incorrect")
+		}
+		if r.Header.Get("anthropic-version") == "" {
+			t.Errorf("anthropic-version header missing")
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{
+			"content": []map[string]string{
+				{"type": "text", "text": "<p>hello</p>"},
+			},
+		})
+	}))
+	defer server.Close()
+
+	client, err := NewLLMClient(&config.Config{
+		LLMProvider: "anthropic",
+		LLMAPIKey:   "test-key",
+		LLMAPIBase:  server.URL,
+	})
+	if err != nil {
+		t.Fatalf("NewLLMClient() error = %v", err)
+	}
+
+	got, err := client.Complete(context.Background(), "prompt")
+	if err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+	if got != "<p>hello</p>" {
+		t.Errorf("Complete() = %v, want <p>hello</p>", got)
+	}
+}
+
+func TestNewLLMClientRequiresKey(t *testing.T) {
+	if _, err := NewLLMClient(&config.Config{LLMProvider: "openai"}); err == nil {
+		t.Error("NewLLMClient() without key should fail")
+	}
+	if _, err := NewLLMClient(&config.Config{LLMProvider: "unknown", LLMAPIKey: "k"}); err == nil {
+		t.Error("NewLLMClient() with unknown provider should fail")
+	}
+}
+
+func TestExtractHTMLFromResponse(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+		want     string
+	}{
+		{"plain", "<section>a</section>", "<section>a</section>"},
+		{"fenced", "好的：\n```html\n<section>a</section>\n```\n", "<section>a</section>"},
+		{"document", "<html><head></head><body>\n<div>b</div>\n</body></html>", "<div>b</div>"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExtractHTMLFromResponse(tt.response); got != tt.want {
+				t.Errorf("ExtractHTMLFromResponse() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
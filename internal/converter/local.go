@@ -0,0 +1,533 @@
+package converter
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	east "github.com/yuin/goldmark/extension/ast"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/renderer/html"
+	"github.com/yuin/goldmark/util"
+	"go.uber.org/zap"
+)
+
+// defaultLocalColors 本地渲染的默认配色（主题未定义 colors 时使用）
+var defaultLocalColors = map[string]string{
+	"background":       "#ffffff",
+	"text":             "#3f3f3f",
+	"primary":          "#2b6cb0",
+	"secondary":        "#2c5282",
+	"quote_background": "#f5f7fa",
+	"code_background":  "#f6f8fa",
+	"code_text":        "#c7254e",
+	"border":           "#e2e8f0",
+	"success":          "#2f855a",
+	"warning":          "#c05621",
+	"danger":           "#c53030",
+}
+
+// LocalStyle 本地渲染样式（由主题 YAML 推导）
+type LocalStyle struct {
+	Colors        map[string]string // 配色（已合并默认值）
+	FontSize      string            // 正文字号
+	LineHeight    string            // 行高
+	FontFamily    string            // 字体族
+	LetterSpacing string            // 字间距
+}
+
+// NewLocalStyle 根据主题创建本地渲染样式，theme 为 nil 时使用默认样式
+func NewLocalStyle(theme *Theme) *LocalStyle {
+	style := &LocalStyle{
+		Colors:        make(map[string]string, len(defaultLocalColors)),
+		FontSize:      "16px",
+		LineHeight:    "1.75",
+		FontFamily:    "-apple-system, BlinkMacSystemFont, 'Helvetica Neue', 'PingFang SC', 'Microsoft YaHei', sans-serif",
+		LetterSpacing: "0.5px",
+	}
+	for k, v := range defaultLocalColors {
+		style.Colors[k] = v
+	}
+
+	if theme == nil {
+		return style
+	}
+
+	for k, v := range theme.Colors {
+		if v != "" {
+			style.Colors[k] = v
+		}
+	}
+	if theme.StyleInfo.FontSize != "" {
+		style.FontSize = theme.StyleInfo.FontSize
+	}
+	if theme.StyleInfo.LineHeight != "" {
+		style.LineHeight = theme.StyleInfo.LineHeight
+	}
+	if theme.StyleInfo.FontFamily != "" {
+		style.FontFamily = theme.StyleInfo.FontFamily
+	}
+	if theme.StyleInfo.LetterSpacing != "" {
+		style.LetterSpacing = theme.StyleInfo.LetterSpacing
+	}
+
+	return style
+}
+
+// Color 获取颜色，未定义时返回默认值
+func (s *LocalStyle) Color(name string) string {
+	if v, ok := s.Colors[name]; ok && v != "" {
+		return v
+	}
+	return defaultLocalColors[name]
+}
+
+// Element 获取指定元素的内联样式
+func (s *LocalStyle) Element(name string) string {
+	text := s.Color("text")
+	primary := s.Color("primary")
+	secondary := s.Color("secondary")
+
+	switch name {
+	case "container":
+		return fmt.Sprintf("background-color:%s;color:%s;font-size:%s;line-height:%s;font-family:%s;letter-spacing:%s;padding:10px 8px;word-break:break-word;",
+			s.Color("background"), text, s.FontSize, s.LineHeight, s.FontFamily, s.LetterSpacing)
+	case "h1":
+		return fmt.Sprintf("margin:1.2em 0 0.8em;font-size:1.6em;font-weight:bold;color:%s;text-align:center;", primary)
+	case "h2":
+		return fmt.Sprintf("margin:1.2em 0 0.8em;padding-bottom:6px;font-size:1.4em;font-weight:bold;color:%s;border-bottom:2px solid %s;", secondary, primary)
+	case "h3":
+		return fmt.Sprintf("margin:1em 0 0.6em;padding-left:10px;font-size:1.2em;font-weight:bold;color:%s;border-left:4px solid %s;", secondary, primary)
+	case "h4", "h5", "h6":
+		return fmt.Sprintf("margin:1em 0 0.6em;font-size:1.05em;font-weight:bold;color:%s;", secondary)
+	case "p":
+		return fmt.Sprintf("margin:1em 0;color:%s;font-size:%s;line-height:%s;", text, s.FontSize, s.LineHeight)
+	case "blockquote":
+		return fmt.Sprintf("margin:1em 0;padding:12px 16px;background-color:%s;border-left:4px solid %s;color:%s;border-radius:4px;", s.Color("quote_background"), primary, text)
+	case "pre":
+		return fmt.Sprintf("margin:1em 0;padding:12px;background-color:%s;border-radius:6px;overflow-x:auto;font-size:13px;line-height:1.6;", s.Color("code_background"))
+	case "pre_code":
+		return fmt.Sprintf("display:block;white-space:pre;font-family:Menlo, Consolas, Monaco, monospace;color:%s;", text)
+	case "code":
+		return fmt.Sprintf("padding:2px 4px;margin:0 2px;background-color:%s;color:%s;border-radius:4px;font-size:0.9em;font-family:Menlo, Consolas, Monaco, monospace;", s.Color("code_background"), s.Color("code_text"))
+	case "ul":
+		return fmt.Sprintf("margin:1em 0;padding-left:2em;list-style-type:disc;color:%s;", text)
+	case "ol":
+		return fmt.Sprintf("margin:1em 0;padding-left:2em;list-style-type:decimal;color:%s;", text)
+	case "li":
+		return fmt.Sprintf("margin:0.4em 0;color:%s;line-height:%s;", text, s.LineHeight)
+	case "a":
+		return fmt.Sprintf("color:%s;text-decoration:none;border-bottom:1px dashed %s;", primary, primary)
+	case "footnote_ref":
+		return fmt.Sprintf("color:%s;font-size:0.75em;line-height:0;margin:0 1px;", primary)
+	case "references":
+		return fmt.Sprintf("margin:2em 0 1em;padding-top:1em;border-top:1px solid %s;font-size:14px;line-height:1.6;color:%s;", s.Color("border"), text)
+	case "references_title":
+		return fmt.Sprintf("margin:0 0 0.6em;font-size:15px;font-weight:bold;color:%s;", secondary)
+	case "references_item":
+		return fmt.Sprintf("margin:0.3em 0;color:%s;word-break:break-all;", text)
+	case "references_index":
+		return fmt.Sprintf("margin-right:4px;color:%s;", primary)
+	case "strong":
+		return fmt.Sprintf("font-weight:bold;color:%s;", secondary)
+	case "em":
+		return "font-style:italic;"
+	case "del":
+		return "text-decoration:line-through;"
+	case "hr":
+		return fmt.Sprintf("margin:2em 0;border:none;height:1px;background-color:%s;", s.Color("border"))
+	case "img":
+		return "max-width:100%;height:auto;display:block;margin:20px auto;"
+	case "table":
+		return fmt.Sprintf("width:100%%;margin:1em 0;border-collapse:collapse;font-size:14px;color:%s;", text)
+	case "th":
+		return fmt.Sprintf("padding:8px;border:1px solid %s;background-color:%s;font-weight:bold;", s.Color("border"), s.Color("quote_background"))
+	case "td":
+		return fmt.Sprintf("padding:8px;border:1px solid %s;", s.Color("border"))
+	}
+	return ""
+}
+
+// convertViaLocal 通过本地渲染执行转换（无需网络和 API Key）
+func (c *converter) convertViaLocal(req *ConvertRequest) *ConvertResult {
+	result := &ConvertResult{
+		Mode:    ModeLocal,
+		Theme:   req.Theme,
+		Success: false,
+	}
+
+	// 获取主题（找不到时使用默认样式）
+	theme, err := c.theme.GetTheme(req.Theme)
+	if err != nil {
+		c.log.Warn("theme not found, using default local style",
+			zap.String("theme", req.Theme),
+			zap.Error(err))
+	}
+	if theme != nil && theme.Type == "css" {
+		return c.convertViaCSS(req, theme)
+	}
+
+	html, err := RenderLocal(req.Markdown, NewLocalStyle(theme))
+	if err != nil {
+		result.Error = fmt.Sprintf("local render failed: %s", err.Error())
+		c.log.Error("local conversion failed",
+			zap.String("theme", req.Theme),
+			zap.Error(err))
+		return result
+	}
+
+	// 图片引用（由 Convert 解析）
+	images := req.images
+
+	result.HTML = html
+	result.Images = images
+	result.Success = true
+
+	c.log.Info("local conversion succeeded",
+		zap.String("theme", req.Theme),
+		zap.Int("image_count", len(images)))
+
+	return result
+}
+
+// RenderLocal 将 Markdown 渲染为带内联样式的微信 HTML
+func RenderLocal(markdown string, style *LocalStyle) (string, error) {
+	if style == nil {
+		style = NewLocalStyle(nil)
+	}
+
+	md := goldmark.New(
+		goldmark.WithExtensions(extension.GFM),
+		goldmark.WithRendererOptions(
+			html.WithUnsafe(),
+			html.WithEastAsianLineBreaks(html.EastAsianLineBreaksSimple),
+		),
+	)
+	md.Renderer().AddOptions(renderer.WithNodeRenderers(
+		util.Prioritized(newLocalRenderer(style), 100),
+	))
+
+	var buf bytes.Buffer
+	if err := md.Convert([]byte(markdown), &buf); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// localRenderer goldmark 节点渲染器，为每个元素输出内联样式
+type localRenderer struct {
+	style *LocalStyle
+}
+
+func newLocalRenderer(style *LocalStyle) *localRenderer {
+	return &localRenderer{style: style}
+}
+
+// RegisterFuncs 实现 renderer.NodeRenderer
+func (r *localRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	// 块级元素
+	reg.Register(ast.KindDocument, r.renderDocument)
+	reg.Register(ast.KindHeading, r.renderHeading)
+	reg.Register(ast.KindBlockquote, r.renderBlockquote)
+	reg.Register(ast.KindCodeBlock, r.renderCodeBlock)
+	reg.Register(ast.KindFencedCodeBlock, r.renderCodeBlock)
+	reg.Register(ast.KindList, r.renderList)
+	reg.Register(ast.KindListItem, r.renderListItem)
+	reg.Register(ast.KindParagraph, r.renderParagraph)
+	reg.Register(ast.KindThematicBreak, r.renderThematicBreak)
+
+	// 行内元素
+	reg.Register(ast.KindAutoLink, r.renderAutoLink)
+	reg.Register(ast.KindCodeSpan, r.renderCodeSpan)
+	reg.Register(ast.KindEmphasis, r.renderEmphasis)
+	reg.Register(ast.KindImage, r.renderImage)
+	reg.Register(ast.KindLink, r.renderLink)
+
+	// GFM 扩展
+	reg.Register(east.KindTable, r.renderTable)
+	reg.Register(east.KindTableHeader, r.renderTableHeader)
+	reg.Register(east.KindTableRow, r.renderTableRow)
+	reg.Register(east.KindTableCell, r.renderTableCell)
+	reg.Register(east.KindStrikethrough, r.renderStrikethrough)
+	reg.Register(east.KindTaskCheckBox, r.renderTaskCheckBox)
+}
+
+// openTag 输出带内联样式的开始标签
+func (r *localRenderer) openTag(w util.BufWriter, tag, element string) {
+	_, _ = w.WriteString("<" + tag)
+	if style := r.style.Element(element); style != "" {
+		_, _ = w.WriteString(` style="` + style + `"`)
+	}
+	_ = w.WriteByte('>')
+}
+
+func (r *localRenderer) renderDocument(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if entering {
+		r.openTag(w, "section", "container")
+		_ = w.WriteByte('\n')
+	} else {
+		_, _ = w.WriteString("</section>\n")
+	}
+	return ast.WalkContinue, nil
+}
+
+func (r *localRenderer) renderHeading(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	n := node.(*ast.Heading)
+	tag := fmt.Sprintf("h%d", n.Level)
+	if entering {
+		r.openTag(w, tag, tag)
+	} else {
+		_, _ = w.WriteString("</" + tag + ">\n")
+	}
+	return ast.WalkContinue, nil
+}
+
+func (r *localRenderer) renderBlockquote(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if entering {
+		r.openTag(w, "blockquote", "blockquote")
+		_ = w.WriteByte('\n')
+	} else {
+		_, _ = w.WriteString("</blockquote>\n")
+	}
+	return ast.WalkContinue, nil
+}
+
+func (r *localRenderer) renderCodeBlock(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+
+	r.openTag(w, "pre", "pre")
+	r.openTag(w, "code", "pre_code")
+	lines := node.Lines()
+	for i := 0; i < lines.Len(); i++ {
+		line := lines.At(i)
+		_, _ = w.Write(util.EscapeHTML(line.Value(source)))
+	}
+	_, _ = w.WriteString("</code></pre>\n")
+	return ast.WalkSkipChildren, nil
+}
+
+func (r *localRenderer) renderList(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	n := node.(*ast.List)
+	tag := "ul"
+	if n.IsOrdered() {
+		tag = "ol"
+	}
+	if entering {
+		_, _ = w.WriteString("<" + tag)
+		if n.IsOrdered() && n.Start != 1 {
+			_, _ = fmt.Fprintf(w, ` start="%d"`, n.Start)
+		}
+		_, _ = w.WriteString(` style="` + r.style.Element(tag) + `">` + "\n")
+	} else {
+		_, _ = w.WriteString("</" + tag + ">\n")
+	}
+	return ast.WalkContinue, nil
+}
+
+func (r *localRenderer) renderListItem(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if entering {
+		r.openTag(w, "li", "li")
+	} else {
+		_, _ = w.WriteString("</li>\n")
+	}
+	return ast.WalkContinue, nil
+}
+
+func (r *localRenderer) renderParagraph(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if entering {
+		r.openTag(w, "p", "p")
+	} else {
+		_, _ = w.WriteString("</p>\n")
+	}
+	return ast.WalkContinue, nil
+}
+
+func (r *localRenderer) renderThematicBreak(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if entering {
+		_, _ = w.WriteString(`<hr style="` + r.style.Element("hr") + `" />` + "\n")
+	}
+	return ast.WalkContinue, nil
+}
+
+func (r *localRenderer) renderAutoLink(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+	n := node.(*ast.AutoLink)
+	url := n.URL(source)
+	label := n.Label(source)
+	if n.AutoLinkType == ast.AutoLinkEmail && !bytes.HasPrefix(bytes.ToLower(url), []byte("mailto:")) {
+		url = append([]byte("mailto:"), url...)
+	}
+	_, _ = w.WriteString(`<a href="`)
+	_, _ = w.Write(util.EscapeHTML(util.URLEscape(url, false)))
+	_, _ = w.WriteString(`" style="` + r.style.Element("a") + `">`)
+	_, _ = w.Write(util.EscapeHTML(label))
+	_, _ = w.WriteString("</a>")
+	return ast.WalkContinue, nil
+}
+
+func (r *localRenderer) renderCodeSpan(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		_, _ = w.WriteString("</code>")
+		return ast.WalkContinue, nil
+	}
+	r.openTag(w, "code", "code")
+	for c := node.FirstChild(); c != nil; c = c.NextSibling() {
+		if t, ok := c.(*ast.Text); ok {
+			value := t.Segment.Value(source)
+			if bytes.HasSuffix(value, []byte("\n")) {
+				value = append(value[:len(value)-1:len(value)-1], ' ')
+			}
+			_, _ = w.Write(util.EscapeHTML(value))
+		} else if s, ok := c.(*ast.String); ok {
+			_, _ = w.Write(util.EscapeHTML(s.Value))
+		}
+	}
+	return ast.WalkSkipChildren, nil
+}
+
+func (r *localRenderer) renderEmphasis(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	n := node.(*ast.Emphasis)
+	tag, element := "em", "em"
+	if n.Level == 2 {
+		tag, element = "strong", "strong"
+	}
+	if entering {
+		r.openTag(w, tag, element)
+	} else {
+		_, _ = w.WriteString("</" + tag + ">")
+	}
+	return ast.WalkContinue, nil
+}
+
+func (r *localRenderer) renderImage(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+	n := node.(*ast.Image)
+	_, _ = w.WriteString(`<img src="`)
+	_, _ = w.Write(util.EscapeHTML(util.URLEscape(n.Destination, true)))
+	_, _ = w.WriteString(`" alt="`)
+	_, _ = w.Write(util.EscapeHTML(nodeText(n, source)))
+	_ = w.WriteByte('"')
+	if n.Title != nil {
+		_, _ = w.WriteString(` title="`)
+		_, _ = w.Write(util.EscapeHTML(n.Title))
+		_ = w.WriteByte('"')
+	}
+	_, _ = w.WriteString(` style="` + r.style.Element("img") + `" />`)
+	return ast.WalkSkipChildren, nil
+}
+
+func (r *localRenderer) renderLink(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	n := node.(*ast.Link)
+	if !entering {
+		_, _ = w.WriteString("</a>")
+		return ast.WalkContinue, nil
+	}
+	_, _ = w.WriteString(`<a href="`)
+	_, _ = w.Write(util.EscapeHTML(util.URLEscape(n.Destination, true)))
+	_ = w.WriteByte('"')
+	if n.Title != nil {
+		_, _ = w.WriteString(` title="`)
+		_, _ = w.Write(util.EscapeHTML(n.Title))
+		_ = w.WriteByte('"')
+	}
+	_, _ = w.WriteString(` style="` + r.style.Element("a") + `">`)
+	return ast.WalkContinue, nil
+}
+
+func (r *localRenderer) renderTable(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if entering {
+		r.openTag(w, "table", "table")
+		_ = w.WriteByte('\n')
+	} else {
+		_, _ = w.WriteString("</tbody>\n</table>\n")
+	}
+	return ast.WalkContinue, nil
+}
+
+func (r *localRenderer) renderTableHeader(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if entering {
+		_, _ = w.WriteString("<thead>\n<tr>\n")
+	} else {
+		_, _ = w.WriteString("</tr>\n</thead>\n<tbody>\n")
+	}
+	return ast.WalkContinue, nil
+}
+
+func (r *localRenderer) renderTableRow(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if entering {
+		_, _ = w.WriteString("<tr>\n")
+	} else {
+		_, _ = w.WriteString("</tr>\n")
+	}
+	return ast.WalkContinue, nil
+}
+
+func (r *localRenderer) renderTableCell(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	n := node.(*east.TableCell)
+	tag := "td"
+	if _, ok := n.Parent().(*east.TableHeader); ok {
+		tag = "th"
+	}
+	if !entering {
+		_, _ = w.WriteString("</" + tag + ">\n")
+		return ast.WalkContinue, nil
+	}
+
+	style := r.style.Element(tag)
+	switch n.Alignment {
+	case east.AlignLeft:
+		style += "text-align:left;"
+	case east.AlignRight:
+		style += "text-align:right;"
+	case east.AlignCenter:
+		style += "text-align:center;"
+	}
+	_, _ = w.WriteString("<" + tag + ` style="` + style + `">`)
+	return ast.WalkContinue, nil
+}
+
+func (r *localRenderer) renderStrikethrough(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if entering {
+		r.openTag(w, "del", "del")
+	} else {
+		_, _ = w.WriteString("</del>")
+	}
+	return ast.WalkContinue, nil
+}
+
+func (r *localRenderer) renderTaskCheckBox(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+	// 微信编辑器会移除 <input>，使用字符代替复选框
+	if node.(*east.TaskCheckBox).IsChecked {
+		_, _ = w.WriteString("☑ ")
+	} else {
+		_, _ = w.WriteString("☐ ")
+	}
+	return ast.WalkContinue, nil
+}
+
+// nodeText 提取节点下的纯文本（用于图片 alt 等）
+func nodeText(node ast.Node, source []byte) []byte {
+	var buf bytes.Buffer
+	for c := node.FirstChild(); c != nil; c = c.NextSibling() {
+		switch t := c.(type) {
+		case *ast.Text:
+			buf.Write(t.Segment.Value(source))
+		case *ast.String:
+			buf.Write(t.Value)
+		default:
+			buf.Write(nodeText(c, source))
+		}
+	}
+	return bytes.TrimSpace(buf.Bytes())
+}
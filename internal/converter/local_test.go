@@ -0,0 +1,84 @@
+package converter
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/geekjourneyx/md2wechat-skill/internal/config"
+	"go.uber.org/zap"
+)
+
+func TestRenderLocal(t *testing.T) {
+	markdown := "# 标题\n\n正文 **加粗** 和 `code`。\n\n> 引用\n\n```go\nfmt.Println(\"<hi>\")\n```\n\n| a | b |\n|---|:-:|\n| 1 | 2 |\n"
+
+	html, err := RenderLocal(markdown, NewLocalStyle(&Theme{
+		Name:   "test",
+		Colors: map[string]string{"primary": "#123456"},
+	}))
+	if err != nil {
+		t.Fatalf("RenderLocal() error = %v", err)
+	}
+
+	wants := []string{
+		`<section style="`,
+		`<h1 style="`,
+		`color:#123456;`,
+		`<strong style="`,
+		`<blockquote style="`,
+		`fmt.Println(&quot;&lt;hi&gt;&quot;)`,
+		`<th style="`,
+		`text-align:center;`,
+	}
+	for _, want := range wants {
+		if !strings.Contains(html, want) {
+			t.Errorf("RenderLocal() output missing %q\n%s", want, html)
+		}
+	}
+
+	for _, unwanted := range []string{"class=", "<style", "<input"} {
+		if strings.Contains(html, unwanted) {
+			t.Errorf("RenderLocal() output contains %q", unwanted)
+		}
+	}
+}
+
+func TestNewLocalStyleDefaults(t *testing.T) {
+	style := NewLocalStyle(nil)
+
+	if style.Color("primary") != defaultLocalColors["primary"] {
+		t.Errorf("Color(primary) = %v, want %v", style.Color("primary"), defaultLocalColors["primary"])
+	}
+
+	style = NewLocalStyle(&Theme{
+		Colors:    map[string]string{"text": "#000000"},
+		StyleInfo: ThemeStyleInfo{FontSize: "15px"},
+	})
+	if style.Color("text") != "#000000" {
+		t.Errorf("Color(text) = %v, want #000000", style.Color("text"))
+	}
+	if style.FontSize != "15px" {
+		t.Errorf("FontSize = %v, want 15px", style.FontSize)
+	}
+}
+
+func TestConvertLocalMode(t *testing.T) {
+	conv := NewConverter(&config.Config{}, zap.NewNop())
+
+	result := conv.Convert(&ConvertRequest{
+		Markdown: "## Hello\n\n![图](https://example.com/a.png)\n",
+		Mode:     ModeLocal,
+		Theme:    "no-such-theme",
+	})
+	if !result.Success {
+		t.Fatalf("Convert() error = %v", result.Error)
+	}
+	if result.Mode != ModeLocal {
+		t.Errorf("Mode = %v, want local", result.Mode)
+	}
+	if !strings.Contains(result.HTML, "<h2 style=") {
+		t.Errorf("HTML missing styled heading: %s", result.HTML)
+	}
+	if len(result.Images) != 1 {
+		t.Errorf("len(Images) = %d, want 1", len(result.Images))
+	}
+}
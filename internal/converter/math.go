@@ -0,0 +1,344 @@
+package converter
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"html"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/geekjourneyx/md2wechat-skill/internal/latex"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/text"
+	"go.uber.org/zap"
+)
+
+// MathFormat 公式的输出格式
+type MathFormat string
+
+const (
+	MathSVG MathFormat = "svg" // 内联 SVG，不需要上传（默认）
+	MathPNG MathFormat = "png" // PNG 图片，随正文图片上传到微信
+	MathOff MathFormat = "off" // 不处理公式，$ 原样保留
+)
+
+const (
+	// mathPlaceholderPrefix 转换前替换公式的占位符，只含字母和数字，任何转换模式都会原样保留
+	mathPlaceholderPrefix = "MD2WECHATMATH"
+
+	// mathFontSize PNG 公式按此字号（px）计算显示尺寸
+	mathFontSize = 16
+
+	// mathPNGScale PNG 公式的像素倍率，适配高清屏
+	mathPNGScale = 3
+)
+
+// mathFormula Markdown 中的一个公式
+type mathFormula struct {
+	source  string
+	display bool // $$...$$
+	raw     string
+}
+
+// mathPlaceholderPattern 匹配公式占位符；独占一段的占位符连同 <p> 一起匹配，用于行间公式
+var mathPlaceholderPattern = regexp.MustCompile(`(?s)(<p\b[^>]*>\s*)?` + mathPlaceholderPrefix + `(\d+)X(\s*</p>)?`)
+
+// mathPlaceholder 生成公式占位符
+func mathPlaceholder(index int) string {
+	return mathPlaceholderPrefix + strconv.Itoa(index) + "X"
+}
+
+// mathEnabled 判断是否处理公式
+// 未配置 LLM 的 AI 模式由外部完成转换，占位符无法还原，保留原始公式
+func (c *converter) mathEnabled(req *ConvertRequest) bool {
+	if req.Math == MathOff {
+		return false
+	}
+	return req.Mode != ModeAI || c.llm != nil
+}
+
+// extractMath 将 Markdown 中的 $...$ 和 $$...$$ 公式替换为占位符
+// 代码块和行内代码中的 $ 不处理；行内公式遵循 Pandoc 规则：开头 $ 后和结尾 $ 前不能是空白，
+// 结尾 $ 后不能紧跟数字（避免把 "$5 和 $10" 识别为公式）
+func extractMath(markdown string) (string, []mathFormula) {
+	if !strings.Contains(markdown, "$") {
+		return markdown, nil
+	}
+
+	skip := codeRanges(markdown)
+	var formulas []mathFormula
+	var buf strings.Builder
+	last, i := 0, 0
+	for i < len(markdown) {
+		if end, ok := skip[i]; ok {
+			i = end
+			continue
+		}
+
+		switch markdown[i] {
+		case '\\':
+			i += 2
+			continue
+		case '$':
+		default:
+			i++
+			continue
+		}
+
+		display := strings.HasPrefix(markdown[i:], "$$")
+		var start, end int
+		if display {
+			start = i + 2
+			end = strings.Index(markdown[start:], "$$")
+			if end >= 0 {
+				end += start
+			}
+		} else {
+			start = i + 1
+			end = inlineMathEnd(markdown, start, skip)
+		}
+		if end < 0 || strings.TrimSpace(markdown[start:end]) == "" || overlapsCode(skip, start, end) {
+			i = start
+			continue
+		}
+
+		closeLen := 1
+		if display {
+			closeLen = 2
+		}
+		formulas = append(formulas, mathFormula{
+			source:  strings.TrimSpace(markdown[start:end]),
+			display: display,
+			raw:     markdown[i : end+closeLen],
+		})
+		buf.WriteString(markdown[last:i])
+		buf.WriteString(mathPlaceholder(len(formulas) - 1))
+		i = end + closeLen
+		last = i
+	}
+	if len(formulas) == 0 {
+		return markdown, nil
+	}
+	buf.WriteString(markdown[last:])
+	return buf.String(), formulas
+}
+
+// inlineMathEnd 查找行内公式的结尾 $，找不到时返回 -1（行内公式不跨段落，也不跨入代码）
+func inlineMathEnd(s string, start int, skip map[int]int) int {
+	if start >= len(s) || isMathSpace(s[start]) {
+		return -1
+	}
+	for k := start; k < len(s); k++ {
+		if _, ok := skip[k]; ok {
+			return -1
+		}
+		switch s[k] {
+		case '\\':
+			k++
+		case '\n':
+			if rest := strings.TrimLeft(s[k+1:], " \t"); rest == "" || rest[0] == '\n' {
+				return -1
+			}
+		case '$':
+			if isMathSpace(s[k-1]) || (k+1 < len(s) && s[k+1] >= '0' && s[k+1] <= '9') {
+				continue
+			}
+			return k
+		}
+	}
+	return -1
+}
+
+func isMathSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+// codeRanges 返回代码块和行内代码内容的字节范围（起点 -> 终点）
+func codeRanges(markdown string) map[int]int {
+	source := []byte(markdown)
+	doc := goldmark.New(goldmark.WithExtensions(extension.GFM)).Parser().Parse(text.NewReader(source))
+
+	ranges := make(map[int]int)
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch n.(type) {
+		case *ast.FencedCodeBlock, *ast.CodeBlock, *ast.HTMLBlock:
+			lines := n.Lines()
+			for i := 0; i < lines.Len(); i++ {
+				seg := lines.At(i)
+				ranges[seg.Start] = seg.Stop
+			}
+			return ast.WalkSkipChildren, nil
+		case *ast.CodeSpan:
+			for child := n.FirstChild(); child != nil; child = child.NextSibling() {
+				if t, ok := child.(*ast.Text); ok {
+					ranges[t.Segment.Start] = t.Segment.Stop
+				}
+			}
+			return ast.WalkSkipChildren, nil
+		}
+		return ast.WalkContinue, nil
+	})
+	return ranges
+}
+
+// overlapsCode 判断公式（含结尾定界符）是否跨入代码
+func overlapsCode(ranges map[int]int, start, end int) bool {
+	for s := range ranges {
+		if s >= start && s <= end {
+			return true
+		}
+	}
+	return false
+}
+
+// renderedMath 渲染后的公式（按公式内容哈希缓存）
+type renderedMath struct {
+	formula *latex.Formula
+	err     error
+	image   int // PNG 格式时对应的图片索引，-1 表示尚未生成
+}
+
+// renderMath 将转换结果中的公式占位符替换为 SVG 或图片
+// PNG 公式写入缓存目录（文件名为内容哈希，重复的公式只生成和上传一次），并追加到 result.Images
+func (c *converter) renderMath(result *ConvertResult, formulas []mathFormula, req *ConvertRequest) {
+	theme, _ := c.theme.GetTheme(req.Theme)
+	fill := NewLocalStyle(theme).Colors["text"]
+
+	rendered := make(map[string]*renderedMath)
+	failed := 0
+	result.HTML = mathPlaceholderPattern.ReplaceAllStringFunc(result.HTML, func(match string) string {
+		m := mathPlaceholderPattern.FindStringSubmatch(match)
+		index, err := strconv.Atoi(m[2])
+		if err != nil || index >= len(formulas) {
+			return match
+		}
+		f := formulas[index]
+		block := f.display && m[1] != "" && m[3] != ""
+
+		key := mathKey(f)
+		r, ok := rendered[key]
+		if !ok {
+			r = &renderedMath{image: -1}
+			r.formula, r.err = c.layoutMath(f, key)
+			rendered[key] = r
+		}
+		if r.err != nil {
+			failed++
+			c.log.Warn("formula render failed, keeping source",
+				zap.String("formula", f.raw),
+				zap.Error(r.err))
+			return m[1] + `<code>` + html.EscapeString(f.raw) + `</code>` + m[3]
+		}
+
+		var out string
+		if req.Math == MathPNG {
+			out, err = c.mathImage(result, r, key, fill)
+			if err != nil {
+				failed++
+				c.log.Warn("formula image failed, keeping source", zap.String("formula", f.raw), zap.Error(err))
+				return m[1] + `<code>` + html.EscapeString(f.raw) + `</code>` + m[3]
+			}
+		} else {
+			out = r.formula.SVG(fill)
+		}
+
+		switch {
+		case block:
+			return `<section style="text-align:center;margin:1em 0;overflow-x:auto;">` + out + `</section>`
+		case f.display:
+			return m[1] + `<span style="display:block;text-align:center;margin:1em 0;overflow-x:auto;">` + out + `</span>` + m[3]
+		}
+		return m[1] + out + m[3]
+	})
+
+	c.log.Info("formulas rendered",
+		zap.Int("count", len(formulas)),
+		zap.Int("unique", len(rendered)),
+		zap.Int("failed", failed),
+		zap.String("format", string(req.Math)))
+}
+
+// layoutMath 排版公式，结果按公式内容哈希缓存在转换器中，重复出现的公式只排版一次
+func (c *converter) layoutMath(f mathFormula, key string) (*latex.Formula, error) {
+	c.mathMu.Lock()
+	defer c.mathMu.Unlock()
+	if formula, ok := c.mathCache[key]; ok {
+		return formula, nil
+	}
+
+	formula, err := latex.Render(f.source, f.display)
+	if err != nil {
+		return nil, err
+	}
+	if c.mathCache == nil {
+		c.mathCache = make(map[string]*latex.Formula)
+	}
+	c.mathCache[key] = formula
+	return formula, nil
+}
+
+// mathKey 公式的缓存键
+func mathKey(f mathFormula) string {
+	sum := sha256.Sum256([]byte(strconv.FormatBool(f.display) + "\x00" + f.source))
+	return hex.EncodeToString(sum[:])
+}
+
+// mathImage 生成 PNG 公式（已存在时复用）并返回带占位符的 <img> 标签
+func (c *converter) mathImage(result *ConvertResult, r *renderedMath, key, fill string) (string, error) {
+	f := r.formula
+	if r.image < 0 {
+		sum := sha256.Sum256([]byte(key + "\x00" + fill))
+		path := filepath.Join(c.cfg.CacheDir(), "math", hex.EncodeToString(sum[:16])+".png")
+		if _, err := os.Stat(path); err != nil {
+			col, err := latex.ParseColor(fill)
+			if err != nil {
+				return "", err
+			}
+			data, err := f.PNG(mathFontSize*mathPNGScale, col)
+			if err != nil {
+				return "", err
+			}
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				return "", fmt.Errorf("create math cache dir: %w", err)
+			}
+			if err := os.WriteFile(path, data, 0644); err != nil {
+				return "", fmt.Errorf("write formula image: %w", err)
+			}
+		}
+
+		r.image = len(result.Images)
+		result.Images = append(result.Images, ImageRef{
+			Index:       r.image,
+			Original:    path,
+			Source:      path,
+			Placeholder: imagePlaceholder(r.image),
+			Type:        ImageTypeLocal,
+			Alt:         f.Source,
+		})
+	}
+
+	// PNG 四周各有 1 像素空白（按倍率缩小）
+	pad := 1.0 / mathPNGScale
+	width := math.Ceil(f.Width*mathFontSize*mathPNGScale)/mathPNGScale + 2*pad
+	height := math.Ceil((f.Height+f.Depth)*mathFontSize*mathPNGScale)/mathPNGScale + 2*pad
+	style := fmt.Sprintf("display:inline-block;margin:0;width:%spx;height:%spx;vertical-align:-%spx;",
+		pxString(width), pxString(height), pxString(f.Depth*mathFontSize+pad))
+	img := result.Images[r.image]
+	return fmt.Sprintf(`%s<img src="%s" alt="%s" style="%s">`,
+		img.Placeholder, html.EscapeString(img.Source), html.EscapeString(f.Source), style), nil
+}
+
+// pxString 保留两位小数
+func pxString(v float64) string {
+	return strconv.FormatFloat(math.Round(v*100)/100, 'f', -1, 64)
+}
@@ -0,0 +1,122 @@
+package converter
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/geekjourneyx/md2wechat-skill/internal/config"
+	"go.uber.org/zap"
+)
+
+func TestExtractMath(t *testing.T) {
+	tests := []struct {
+		name     string
+		markdown string
+		want     []string // 提取到的公式（行间公式以 $$ 开头）
+	}{
+		{"inline", "质能方程 $E=mc^2$ 成立", []string{"E=mc^2"}},
+		{"display", "$$\n\\frac{a}{b}\n$$\n", []string{"$$\\frac{a}{b}"}},
+		{"prices", "价格从 $5 涨到 $10", nil},
+		{"space after opening", "$ x$ 和 $y $", nil},
+		{"escaped", `\$x\$ 不是公式`, nil},
+		{"code span", "行内代码 `$x$` 和 $y$", []string{"y"}},
+		{"fenced code", "```\n$x$\n```\n\n$y$", []string{"y"}},
+		{"no paragraph crossing", "$a\n\nb$", nil},
+		{"soft line break", "$a +\nb$", []string{"a +\nb"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, formulas := extractMath(tt.markdown)
+			var got []string
+			for _, f := range formulas {
+				if f.display {
+					got = append(got, "$$"+f.source)
+				} else {
+					got = append(got, f.source)
+				}
+			}
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("extractMath(%q) = %q, want %q", tt.markdown, got, tt.want)
+			}
+			if len(formulas) == 0 && body != tt.markdown {
+				t.Errorf("body changed without formulas: %q", body)
+			}
+			for i := range formulas {
+				if !strings.Contains(body, mathPlaceholder(i)) {
+					t.Errorf("body missing placeholder %d: %q", i, body)
+				}
+			}
+		})
+	}
+}
+
+func TestConvertMathSVG(t *testing.T) {
+	conv := NewConverter(&config.Config{}, zap.NewNop())
+
+	result := conv.Convert(&ConvertRequest{
+		Markdown: "行内 $a_i$ 公式\n\n$$\n\\sum_{i=1}^n a_i\n$$\n\n错误 $\\foo$ 保留\n",
+		Mode:     ModeLocal,
+	})
+	if !result.Success {
+		t.Fatalf("Convert() error = %v", result.Error)
+	}
+	if strings.Contains(result.HTML, mathPlaceholderPrefix) {
+		t.Errorf("HTML still contains placeholders: %s", result.HTML)
+	}
+	if n := strings.Count(result.HTML, "<svg "); n != 2 {
+		t.Errorf("got %d svg formulas, want 2", n)
+	}
+	if !strings.Contains(result.HTML, `<section style="text-align:center;`) {
+		t.Errorf("display formula is not a centered block: %s", result.HTML)
+	}
+	if !strings.Contains(result.HTML, `fill="#3f3f3f"`) {
+		t.Errorf("formula does not use the theme text color")
+	}
+	if !strings.Contains(result.HTML, `<code>$\foo$</code>`) {
+		t.Errorf("invalid formula should fall back to source: %s", result.HTML)
+	}
+
+	off := conv.Convert(&ConvertRequest{Markdown: "$a_i$", Mode: ModeLocal, Math: MathOff})
+	if strings.Contains(off.HTML, "<svg") || !strings.Contains(off.HTML, "$a_i$") {
+		t.Errorf("--math off should keep source: %s", off.HTML)
+	}
+}
+
+func TestConvertMathPNG(t *testing.T) {
+	t.Setenv("MD2WECHAT_CACHE_DIR", t.TempDir())
+	conv := NewConverter(&config.Config{}, zap.NewNop())
+
+	result := conv.Convert(&ConvertRequest{
+		Markdown: "![图](https://example.com/a.png)\n\n$x^2$ 与 $x^2$ 相同，$y$ 不同\n",
+		Mode:     ModeLocal,
+		Math:     MathPNG,
+	})
+	if !result.Success {
+		t.Fatalf("Convert() error = %v", result.Error)
+	}
+
+	// 重复的公式共用一张图片
+	if len(result.Images) != 3 {
+		t.Fatalf("len(Images) = %d, want 3 (1 picture + 2 formulas)", len(result.Images))
+	}
+	formula := result.Images[1]
+	if formula.Index != 1 || formula.Type != ImageTypeLocal || formula.Alt != "x^2" {
+		t.Errorf("formula image = %+v", formula)
+	}
+	if _, err := os.Stat(formula.Source); err != nil {
+		t.Errorf("formula png not written: %v", err)
+	}
+	if n := strings.Count(result.HTML, imagePlaceholder(1)+`<img src=`); n != 2 {
+		t.Errorf("got %d slots for image 1, want 2: %s", n, result.HTML)
+	}
+	if !strings.Contains(result.HTML, "vertical-align:-") {
+		t.Errorf("formula image missing baseline alignment: %s", result.HTML)
+	}
+
+	result.Images[1].WechatURL = "https://mmbiz.qpic.cn/x.png"
+	html := ReplaceImagePlaceholders(result.HTML, result.Images)
+	if strings.Count(html, "https://mmbiz.qpic.cn/x.png") != 2 {
+		t.Errorf("uploaded formula URL not applied to both occurrences: %s", html)
+	}
+}
@@ -0,0 +1,446 @@
+package converter
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// calloutPlaceholderPrefix 转换前替换 callout 标记行的占位符，只含字母和数字，任何转换模式都会原样保留
+const calloutPlaceholderPrefix = "MD2WECHATCALLOUT"
+
+var (
+	// wikiLinkPattern Obsidian 内部链接和嵌入：[[note]]、[[note|别名]]、![[image.png|300]]
+	wikiLinkPattern = regexp.MustCompile(`(!?)\[\[([^\[\]\n]+)\]\]`)
+
+	// calloutPattern callout 标记行：> [!tip] 标题（+/- 为折叠标记，公众号中忽略）
+	calloutPattern = regexp.MustCompile(`^((?:[ \t]{0,3}>[ \t]?)+)\[!([A-Za-z][\w-]*)\][+-]?[ \t]*(.*)$`)
+
+	// embedSizePattern 嵌入图片的尺寸：300 或 300x200
+	embedSizePattern = regexp.MustCompile(`^(\d+)(?:x\d+)?$`)
+
+	// calloutMarker callout 占位符
+	calloutMarker = newBoxMarker(calloutPlaceholderPrefix)
+
+	// imgStyleAttrPattern 匹配 <img> 标签的 style 属性
+	imgStyleAttrPattern = regexp.MustCompile(`(?is)(\sstyle\s*=\s*)("[^"]*"|'[^']*')`)
+)
+
+// embedImageExts 可以作为图片嵌入的文件扩展名
+var embedImageExts = map[string]bool{".png": true, ".jpg": true, ".jpeg": true, ".gif": true, ".webp": true, ".bmp": true, ".svg": true}
+
+// calloutKinds callout 类型别名（与 Obsidian 一致）
+var calloutKinds = map[string]string{
+	"summary": "abstract", "tldr": "abstract",
+	"hint": "tip", "important": "tip",
+	"check": "success", "done": "success",
+	"help": "question", "faq": "question",
+	"caution": "warning", "attention": "warning",
+	"fail": "failure", "missing": "failure",
+	"error": "danger",
+	"cite":  "quote",
+}
+
+// calloutTitles callout 的默认标题
+var calloutTitles = map[string]string{
+	"note":     "备注",
+	"abstract": "摘要",
+	"info":     "信息",
+	"todo":     "待办",
+	"tip":      "提示",
+	"success":  "完成",
+	"question": "问题",
+	"warning":  "注意",
+	"failure":  "失败",
+	"danger":   "危险",
+	"bug":      "缺陷",
+	"example":  "示例",
+	"quote":    "引用",
+}
+
+// calloutColors callout 类型使用的主题颜色（主题可用 callout_<类型> 单独指定）
+var calloutColors = map[string]string{
+	"tip":      "success",
+	"success":  "success",
+	"question": "secondary",
+	"warning":  "warning",
+	"failure":  "danger",
+	"danger":   "danger",
+	"bug":      "danger",
+}
+
+// callout Markdown 中的一个 Obsidian callout
+type callout struct {
+	kind  string // 规范化后的类型
+	title string // 标题（Markdown），为空时使用默认标题
+}
+
+// obsidianEmbed 转换为标准语法的图片嵌入
+type obsidianEmbed struct {
+	dest  string // 写入 Markdown 的图片地址
+	width string // 宽度提示
+}
+
+// calloutPlaceholder 生成 callout 占位符
+func calloutPlaceholder(index int) string {
+	return calloutMarker.placeholder(index)
+}
+
+// calloutsEnabled 判断是否将 callout 渲染为提示框
+// 未配置 LLM 的 AI 模式由外部完成转换，占位符无法还原，保留原始语法
+func (c *converter) calloutsEnabled(req *ConvertRequest) bool {
+	return req.Obsidian && (req.Mode != ModeAI || c.llm != nil)
+}
+
+// prepareObsidian 将 Obsidian 语法转换为标准 Markdown
+// ![[图片|300]] 转换为图片语法并记录宽度，[[笔记]] 转换为文字或链接，callout 标记行替换为占位符
+func (c *converter) prepareObsidian(markdown string, req *ConvertRequest) (string, []obsidianEmbed, []callout) {
+	vault := newVault(req.VaultDir, req.BaseDir)
+	markdown, embeds := vault.replaceWikiLinks(markdown)
+
+	var callouts []callout
+	if c.calloutsEnabled(req) {
+		markdown, callouts = extractCallouts(markdown)
+	}
+	if len(embeds) > 0 || len(callouts) > 0 {
+		c.log.Debug("obsidian syntax converted",
+			zap.Int("embeds", len(embeds)),
+			zap.Int("callouts", len(callouts)))
+	}
+	return markdown, embeds, callouts
+}
+
+// vault Obsidian 笔记库，用于查找嵌入的图片和链接的笔记
+type vault struct {
+	root    string
+	baseDir string            // 当前笔记所在目录
+	byName  map[string]string // 文件名（小写）-> 路径，首次按名称查找时建立
+}
+
+// newVault 创建笔记库；root 为空时使用笔记所在目录
+func newVault(root, baseDir string) *vault {
+	if root == "" {
+		root = baseDir
+	}
+	if root == "" {
+		root = "."
+	}
+	return &vault{root: root, baseDir: baseDir}
+}
+
+// replaceWikiLinks 转换代码以外的 [[...]] 和 ![[...]]
+func (v *vault) replaceWikiLinks(markdown string) (string, []obsidianEmbed) {
+	if !strings.Contains(markdown, "[[") {
+		return markdown, nil
+	}
+	skip := codeRanges(markdown)
+
+	var embeds []obsidianEmbed
+	var buf strings.Builder
+	last := 0
+	for _, m := range wikiLinkPattern.FindAllStringSubmatchIndex(markdown, -1) {
+		if inCode(skip, m[0]) {
+			continue
+		}
+		buf.WriteString(markdown[last:m[0]])
+		last = m[1]
+
+		target, param, _ := strings.Cut(markdown[m[4]:m[5]], "|")
+		target = strings.TrimSpace(target)
+		param = strings.TrimSpace(param)
+		if m[3] > m[2] && embedImageExts[strings.ToLower(filepath.Ext(target))] {
+			embed := obsidianEmbed{dest: v.imageDest(target)}
+			alt := ""
+			if size := embedSizePattern.FindStringSubmatch(param); size != nil {
+				embed.width = size[1]
+			} else {
+				alt = param
+			}
+			embeds = append(embeds, embed)
+			buf.WriteString("![" + escapeLinkText(alt) + "](" + embed.dest + ")")
+			continue
+		}
+		buf.WriteString(v.noteLink(target, param))
+	}
+	buf.WriteString(markdown[last:])
+	return buf.String(), embeds
+}
+
+// imageDest 嵌入图片在 Markdown 中的地址：找到的文件使用相对于笔记目录的路径，找不到时保留原名（上传时报告缺失）
+func (v *vault) imageDest(target string) string {
+	path := v.find(target)
+	if path == "" {
+		return markdownDest(target)
+	}
+	if rel, err := filepath.Rel(v.dirOrDot(), path); err == nil {
+		path = rel
+	}
+	return markdownDest(filepath.ToSlash(path))
+}
+
+// noteLink 转换笔记链接：显示别名或笔记名（去掉 #标题 和 ^块引用）；
+// 笔记的 front matter 有 source_url 时转换为链接，按链接策略列入参考资料
+func (v *vault) noteLink(target, alias string) string {
+	name, heading, _ := strings.Cut(target, "#")
+	name = strings.TrimSpace(name)
+	heading = strings.TrimPrefix(strings.TrimSpace(heading), "^")
+
+	text := alias
+	if text == "" {
+		base := strings.TrimSuffix(filepath.Base(name), ".md")
+		switch {
+		case name == "":
+			text = heading
+		case heading != "":
+			text = base + " > " + heading
+		default:
+			text = base
+		}
+	}
+	text = escapeLinkText(text)
+
+	if name == "" {
+		return text
+	}
+	file := name
+	if !strings.EqualFold(filepath.Ext(file), ".md") {
+		file += ".md"
+	}
+	path := v.find(file)
+	if path == "" {
+		return text
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return text
+	}
+	meta, _, err := ParseFrontMatter(string(data))
+	if err != nil || !isWebLink(meta.SourceURL) {
+		return text
+	}
+	return "[" + text + "](" + markdownDest(meta.SourceURL) + ")"
+}
+
+// find 查找笔记库中的文件：笔记目录、笔记库根目录，最后按文件名在全库查找
+func (v *vault) find(target string) string {
+	for _, dir := range []string{v.dirOrDot(), v.root} {
+		p := filepath.Join(dir, filepath.FromSlash(target))
+		if info, err := os.Stat(p); err == nil && !info.IsDir() {
+			return p
+		}
+	}
+
+	if v.byName == nil {
+		v.byName = make(map[string]string)
+		_ = filepath.WalkDir(v.root, func(p string, d os.DirEntry, err error) error {
+			if err != nil {
+				return nil
+			}
+			if d.IsDir() {
+				if p != v.root && (strings.HasPrefix(d.Name(), ".") || d.Name() == "node_modules") {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			key := strings.ToLower(d.Name())
+			if _, ok := v.byName[key]; !ok {
+				v.byName[key] = p
+			}
+			return nil
+		})
+	}
+	return v.byName[strings.ToLower(filepath.Base(target))]
+}
+
+// dirOrDot 笔记所在目录，为空时为当前目录
+func (v *vault) dirOrDot() string {
+	if v.baseDir == "" {
+		return "."
+	}
+	return v.baseDir
+}
+
+// markdownDest 转义 Markdown 链接地址中的空格和括号
+func markdownDest(dest string) string {
+	return strings.NewReplacer(" ", "%20", "(", "%28", ")", "%29").Replace(dest)
+}
+
+// escapeLinkText 转义会破坏链接文字的方括号
+func escapeLinkText(text string) string {
+	return strings.NewReplacer("[", `\[`, "]", `\]`).Replace(text)
+}
+
+// extractCallouts 将 callout 标记行替换为单独成段的占位符，转换后由 renderCallouts 还原为提示框
+// 只处理引用块的第一行（嵌套引用的第一行也可以是 callout），代码中的内容不处理
+func extractCallouts(markdown string) (string, []callout) {
+	if !strings.Contains(markdown, "[!") {
+		return markdown, nil
+	}
+	skip := codeRanges(markdown)
+
+	var callouts []callout
+	lines := strings.SplitAfter(markdown, "\n")
+	offset := 0
+	prevDepth := 0
+	for i, line := range lines {
+		start := offset
+		offset += len(line)
+		content := strings.TrimRight(line, "\r\n")
+
+		m := calloutPattern.FindStringSubmatch(content)
+		depth := quoteDepth(content)
+		if m == nil || depth <= prevDepth || inCode(skip, start+len(m[1])) {
+			prevDepth = depth
+			continue
+		}
+		prevDepth = depth
+
+		kind := strings.ToLower(m[2])
+		if alias, ok := calloutKinds[kind]; ok {
+			kind = alias
+		}
+		title := strings.TrimSpace(m[3])
+		if title == "" {
+			if title = calloutTitles[kind]; title == "" {
+				title = m[2]
+			}
+		}
+		prefix := strings.TrimRight(m[1], " \t")
+		lines[i] = m[1] + calloutPlaceholder(len(callouts)) + "\n" + prefix + line[len(content):]
+		callouts = append(callouts, callout{kind: kind, title: title})
+	}
+	return strings.Join(lines, ""), callouts
+}
+
+// quoteDepth 行首引用标记的层数
+func quoteDepth(line string) int {
+	depth := 0
+	for _, r := range line {
+		switch r {
+		case '>':
+			depth++
+		case ' ', '\t':
+		default:
+			return depth
+		}
+	}
+	return depth
+}
+
+// renderCallouts 将以占位符开头的引用块替换为提示框，其余占位符替换为加粗的标题
+func (c *converter) renderCallouts(result *ConvertResult, callouts []callout, req *ConvertRequest) {
+	if len(callouts) == 0 || result.HTML == "" {
+		return
+	}
+	theme, _ := c.theme.GetTheme(req.Theme)
+	style := NewLocalStyle(theme)
+
+	result.HTML = calloutMarker.replace(result.HTML, len(callouts), func(i int) string {
+		box, title := style.Callout(callouts[i].kind)
+		return `<section style="` + box + `"><p style="` + title + `">` + renderFootnoteText(callouts[i].title) + `</p>`
+	}, func(i int) string {
+		return "<strong>" + renderFootnoteText(callouts[i].title) + "</strong>"
+	})
+	c.log.Info("callouts rendered", zap.Int("count", len(callouts)))
+}
+
+// Callout 提示框及其标题的内联样式
+// 颜色依次取主题的 callout_<类型>、类型对应的语义颜色（success/warning/danger/secondary）和 primary，背景为该颜色的浅色
+func (s *LocalStyle) Callout(kind string) (box, title string) {
+	color := s.Colors["callout_"+kind]
+	if color == "" {
+		name := calloutColors[kind]
+		if name == "" {
+			name = "primary"
+		}
+		color = s.Color(name)
+	}
+	background := tintColor(color, s.Color("background"), 0.1)
+	if background == "" {
+		background = s.Color("quote_background")
+	}
+	box = "margin:1em 0;padding:12px 16px;background-color:" + background + ";border-left:4px solid " + color + ";border-radius:4px;color:" + s.Color("text") + ";"
+	title = "margin:0 0 6px;font-weight:bold;color:" + color + ";"
+	return box, title
+}
+
+// tintColor 将颜色按比例混合到背景色上，颜色不是 #rgb/#rrggbb 时返回空字符串
+func tintColor(color, background string, ratio float64) string {
+	fg, ok := parseHexColor(color)
+	if !ok {
+		return ""
+	}
+	bg, ok := parseHexColor(background)
+	if !ok {
+		bg = [3]float64{255, 255, 255}
+	}
+	var out strings.Builder
+	out.WriteByte('#')
+	for i := range fg {
+		v := int(math.Round(bg[i] + (fg[i]-bg[i])*ratio))
+		out.WriteString(strconv.FormatInt(int64(v)|0x100, 16)[1:])
+	}
+	return out.String()
+}
+
+// parseHexColor 解析 #rgb / #rrggbb 颜色
+func parseHexColor(color string) ([3]float64, bool) {
+	var rgb [3]float64
+	hex := strings.TrimPrefix(strings.TrimSpace(color), "#")
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	if len(hex) != 6 || !strings.HasPrefix(strings.TrimSpace(color), "#") {
+		return rgb, false
+	}
+	for i := range rgb {
+		v, err := strconv.ParseUint(hex[i*2:i*2+2], 16, 8)
+		if err != nil {
+			return rgb, false
+		}
+		rgb[i] = float64(v)
+	}
+	return rgb, true
+}
+
+// applyEmbedWidths 将嵌入图片的宽度写入对应的图片引用（嵌入按文档顺序出现在图片列表中）
+func applyEmbedWidths(images []ImageRef, embeds []obsidianEmbed) {
+	j := 0
+	for i := range images {
+		if j == len(embeds) {
+			return
+		}
+		if images[i].Source == embeds[j].dest {
+			images[i].Width = embeds[j].width
+			j++
+		}
+	}
+}
+
+// applyImageWidths 为有宽度提示但标签中没有 width 属性的图片加上 CSS 宽度（如 Obsidian 的 ![[img.png|300]]）
+func applyImageWidths(htmlContent string, images []ImageRef) string {
+	return imagePlaceholderPattern.ReplaceAllStringFunc(htmlContent, func(match string) string {
+		m := imagePlaceholderPattern.FindStringSubmatch(match)
+		index, err := strconv.Atoi(m[1])
+		tag := strings.TrimSpace(m[2])
+		if err != nil || index >= len(images) || tag == "" {
+			return match
+		}
+		width := imageWidthStyle(images[index].Width)
+		if width == "" || htmlAttr(tag[len("<img"):], "width") != "" {
+			return match
+		}
+
+		sized := strings.Replace(tag, "<img", `<img style="width:`+width+`;"`, 1)
+		if style := imgStyleAttrPattern.FindStringSubmatch(tag); style != nil {
+			value := strings.TrimSuffix(strings.TrimSpace(style[2][1:len(style[2])-1]), ";") + ";width:" + width + ";"
+			sized = strings.Replace(tag, style[0], style[1]+`"`+strings.ReplaceAll(value, `"`, "&quot;")+`"`, 1)
+		}
+		return strings.Replace(match, tag, sized, 1)
+	})
+}
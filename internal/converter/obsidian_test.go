@@ -0,0 +1,117 @@
+package converter
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/geekjourneyx/md2wechat-skill/internal/config"
+	"go.uber.org/zap"
+)
+
+func TestExtractCallouts(t *testing.T) {
+	markdown := "> [!TIP] 记住\n> 内容\n\n> 普通引用\n> [!note] 不是第一行\n\n> [!faq]-\n> > [!warning]\n> > 嵌套\n\n```\n> [!tip] in code\n```\n"
+	got, callouts := extractCallouts(markdown)
+
+	want := "> " + calloutPlaceholder(0) + "\n>\n> 内容\n\n> 普通引用\n> [!note] 不是第一行\n\n> " + calloutPlaceholder(1) + "\n>\n> > " + calloutPlaceholder(2) + "\n> >\n> > 嵌套\n\n```\n> [!tip] in code\n```\n"
+	if got != want {
+		t.Errorf("markdown =\n%q\nwant\n%q", got, want)
+	}
+	wantCallouts := []callout{{"tip", "记住"}, {"question", "问题"}, {"warning", "注意"}}
+	if len(callouts) != len(wantCallouts) {
+		t.Fatalf("callouts = %+v", callouts)
+	}
+	for i, c := range wantCallouts {
+		if callouts[i] != c {
+			t.Errorf("callouts[%d] = %+v, want %+v", i, callouts[i], c)
+		}
+	}
+}
+
+func TestReplaceWikiLinks(t *testing.T) {
+	vault := t.TempDir()
+	files := map[string]string{
+		"assets/my pic.png": "png",
+		"notes/post.md":     "",
+		"notes/local.png":   "png",
+		"published.md":      "---\nsource_url: https://mp.weixin.qq.com/s/abc\n---\n正文",
+		".trash/gone.png":   "png",
+	}
+	for name, content := range files {
+		p := filepath.Join(vault, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	v := newVault(vault, filepath.Join(vault, "notes"))
+	markdown := "![[my pic.png|300]] ![[local.png|说明]] ![[assets/my pic.png]] ![[gone.png]]\n\n" +
+		"[[draft]] [[draft|别名]] [[draft#小节]] [[#本节]] [[published]] `[[code]]`\n"
+	got, embeds := v.replaceWikiLinks(markdown)
+
+	want := "![](../assets/my%20pic.png) ![说明](local.png) ![](../assets/my%20pic.png) ![](gone.png)\n\n" +
+		"draft 别名 draft > 小节 本节 [published](https://mp.weixin.qq.com/s/abc) `[[code]]`\n"
+	if got != want {
+		t.Errorf("markdown =\n%q\nwant\n%q", got, want)
+	}
+	if len(embeds) != 4 || embeds[0].width != "300" || embeds[1].width != "" {
+		t.Errorf("embeds = %+v", embeds)
+	}
+}
+
+func TestConvertObsidian(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "a.png"), []byte("png"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	conv := NewConverter(&config.Config{}, zap.NewNop())
+	markdown := "![[a.png|300]]\n\n> [!warning] 小心 **加粗**\n> 正文\n> > 引用\n\n> [!custom]\n\n见 [[笔记]]\n"
+
+	result := conv.Convert(&ConvertRequest{Markdown: markdown, Mode: ModeLocal, BaseDir: dir, Obsidian: true})
+	if !result.Success {
+		t.Fatalf("Convert() error = %v", result.Error)
+	}
+	if len(result.Images) != 1 || result.Images[0].Width != "300" || result.Images[0].Original != filepath.Join(dir, "a.png") {
+		t.Fatalf("Images = %+v", result.Images)
+	}
+
+	out := result.HTML
+	for _, want := range []string{
+		`margin:20px auto;width:300px;`,
+		`border-left:4px solid #c05621;`, // warning 使用主题的 warning 颜色
+		`background-color:#f9eee9;`,      // 背景为颜色的浅色
+		`color:#c05621;">小心 <strong>加粗</strong></p>`,
+		`<p style="margin:0 0 6px;font-weight:bold;color:#2b6cb0;">custom</p>`,
+		"见 笔记",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("HTML missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, calloutPlaceholderPrefix) || strings.Count(out, "<blockquote") != strings.Count(out, "</blockquote>") || strings.Count(out, "<blockquote") != 1 {
+		t.Errorf("callout not rendered as box:\n%s", out)
+	}
+
+	// 未开启 Obsidian 模式时保持原样
+	plain := conv.Convert(&ConvertRequest{Markdown: markdown, Mode: ModeLocal, BaseDir: dir})
+	if !strings.Contains(plain.HTML, "[!warning]") || len(plain.Images) != 0 {
+		t.Errorf("obsidian syntax converted without Obsidian mode:\n%s", plain.HTML)
+	}
+}
+
+func TestCalloutColors(t *testing.T) {
+	style := NewLocalStyle(&Theme{Colors: map[string]string{"primary": "#000", "callout_tip": "#ff0000"}})
+	if box, _ := style.Callout("tip"); !strings.Contains(box, "background-color:#ffe6e6;border-left:4px solid #ff0000;") {
+		t.Errorf("tip box = %s", box)
+	}
+	if box, _ := style.Callout("note"); !strings.Contains(box, "background-color:#e6e6e6;border-left:4px solid #000;") {
+		t.Errorf("note box = %s", box)
+	}
+	if got := tintColor("rgb(0,0,0)", "#fff", 0.1); got != "" {
+		t.Errorf("tintColor(rgb) = %q", got)
+	}
+}
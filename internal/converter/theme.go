@@ -1,6 +1,7 @@
 package converter
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -11,14 +12,21 @@ import (
 
 // Theme 主题定义
 type Theme struct {
-	Name        string            `yaml:"name"`
-	Type        string            `yaml:"type"`         // "api" | "ai"
-	Description string            `yaml:"description"`
-	Version     string            `yaml:"version"`
-	StyleInfo   ThemeStyleInfo    `yaml:"style_info,omitempty"`
-	Colors      map[string]string `yaml:"colors,omitempty"`
-	APITheme    string            `yaml:"api_theme,omitempty"`
-	Prompt      string            `yaml:"prompt,omitempty"`
+	Name        string                    `yaml:"name"`
+	Extends     string                    `yaml:"extends,omitempty"` // 继承的父主题（文件名，不含扩展名）
+	Type        string                    `yaml:"type"`              // "api" | "ai" | "css"
+	Description string                    `yaml:"description"`
+	Version     string                    `yaml:"version"`
+	StyleInfo   ThemeStyleInfo            `yaml:"style_info,omitempty"`
+	Colors      map[string]string         `yaml:"colors,omitempty"`
+	APITheme    string                    `yaml:"api_theme,omitempty"`
+	Prompt      string                    `yaml:"prompt,omitempty"`
+	Stylesheet  string                    `yaml:"stylesheet,omitempty"` // CSS 主题的样式表（相对于主题文件）
+	Highlight   ThemeHighlight            `yaml:"highlight,omitempty"`  // 代码高亮配置
+	Containers  map[string]ThemeContainer `yaml:"containers,omitempty"` // ::: 容器的样式（按类型）
+
+	sheet *Stylesheet // 已解析的样式表（CSS 主题）
+	files []string    // 主题的源文件：主题文件、继承的父主题文件和样式表
 }
 
 // ThemeStyleInfo 主题风格信息
@@ -26,21 +34,30 @@ type ThemeStyleInfo struct {
 	Mood    string `yaml:"mood"`
 	Colors  string `yaml:"colors"`
 	BestFor string `yaml:"best_for"`
+
+	// 本地渲染排版参数（可选）
+	FontSize      string `yaml:"font_size,omitempty"`
+	LineHeight    string `yaml:"line_height,omitempty"`
+	FontFamily    string `yaml:"font_family,omitempty"`
+	LetterSpacing string `yaml:"letter_spacing,omitempty"`
 }
 
 // ThemeManager 主题管理器
 type ThemeManager struct {
-	themes map[string]Theme
+	themes  map[string]Theme
+	invalid map[string]error // 加载失败的主题文件（按主题名和文件名），请求这些主题时返回错误
 }
 
 // NewThemeManager 创建主题管理器
 func NewThemeManager() *ThemeManager {
 	return &ThemeManager{
-		themes: make(map[string]Theme),
+		themes:  make(map[string]Theme),
+		invalid: make(map[string]error),
 	}
 }
 
 // LoadThemes 从 YAML 文件加载主题
+// 某个文件无效时记录其错误并继续加载其它主题，返回所有无效文件的错误
 func (tm *ThemeManager) LoadThemes() error {
 	// 获取主题目录
 	themeDir := tm.getThemeDir()
@@ -55,6 +72,7 @@ func (tm *ThemeManager) LoadThemes() error {
 		return fmt.Errorf("read theme directory: %w", err)
 	}
 
+	var errs []error
 	for _, entry := range entries {
 		if entry.IsDir() {
 			continue
@@ -68,32 +86,69 @@ func (tm *ThemeManager) LoadThemes() error {
 		// 加载主题文件
 		themePath := filepath.Join(themeDir, entry.Name())
 		if err := tm.loadThemeFromFile(themePath); err != nil {
-			return fmt.Errorf("load theme from %s: %w", themePath, err)
+			var schemaErr *ThemeSchemaError
+			if !errors.As(err, &schemaErr) {
+				// 结构错误的信息中已包含文件名和行号
+				err = fmt.Errorf("load theme from %s: %w", themePath, err)
+			}
+			for _, name := range themeFileNames(themePath) {
+				tm.invalid[name] = err
+			}
+			errs = append(errs, err)
 		}
 	}
 
-	return nil
+	return errors.Join(errs...)
+}
+
+// themeFileNames 主题文件可能对应的主题名：文件名（不含扩展名）和文件中声明的 name
+func themeFileNames(path string) []string {
+	names := []string{strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))}
+	var header struct {
+		Name string `yaml:"name"`
+	}
+	if data, err := os.ReadFile(path); err == nil && yaml.Unmarshal(data, &header) == nil && header.Name != "" && header.Name != names[0] {
+		names = append(names, header.Name)
+	}
+	return names
 }
 
 // loadThemeFromFile 从文件加载单个主题
 func (tm *ThemeManager) loadThemeFromFile(path string) error {
-	data, err := os.ReadFile(path)
+	node, files, err := tm.readThemeNode(path, nil)
 	if err != nil {
 		return err
 	}
+	flattenPrompt(node)
+	if issues := checkRequired(node); len(issues) > 0 {
+		return &ThemeSchemaError{Path: path, Issues: issues}
+	}
 
 	var theme Theme
-	if err := yaml.Unmarshal(data, &theme); err != nil {
+	if err := node.Decode(&theme); err != nil {
 		return fmt.Errorf("parse yaml: %w", err)
 	}
+	theme.files = files
 
-	// 验证主题
-	if theme.Name == "" {
-		return fmt.Errorf("theme name is required")
-	}
+	// 验证主题（字段结构已在 readThemeNode 中检查）
 	if theme.Type == "" {
 		theme.Type = "ai" // 默认为 AI 模式
 	}
+	switch theme.Type {
+	case "api", "ai":
+	case "css":
+		if err := theme.loadStylesheet(filepath.Dir(path)); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown theme type %q (api, ai or css)", theme.Type)
+	}
+	if err := theme.Highlight.validate(); err != nil {
+		return fmt.Errorf("theme %q: %w", theme.Name, err)
+	}
+	if err := theme.validateContainers(); err != nil {
+		return fmt.Errorf("theme %q: %w", theme.Name, err)
+	}
 
 	// 如果 description 为空，设置默认值
 	if theme.Description == "" {
@@ -101,9 +156,16 @@ func (tm *ThemeManager) loadThemeFromFile(path string) error {
 	}
 
 	tm.themes[theme.Name] = theme
+	delete(tm.invalid, theme.Name)
 	return nil
 }
 
+// SourceFiles 返回主题的源文件（主题文件、继承的父主题文件和 CSS 主题的样式表），
+// 用于判断主题内容是否变化
+func (t *Theme) SourceFiles() []string {
+	return append([]string(nil), t.files...)
+}
+
 // getThemeDir 获取主题目录
 func (tm *ThemeManager) getThemeDir() string {
 	// 优先使用项目根目录的 themes/ 文件夹
@@ -128,16 +190,18 @@ func (tm *ThemeManager) LoadTheme(path string) error {
 }
 
 // GetTheme 获取主题
+// 只有请求的主题本身无效时才返回加载错误，其它主题文件的错误不影响
 func (tm *ThemeManager) GetTheme(name string) (*Theme, error) {
 	// 如果主题未加载，尝试从文件加载
 	if _, ok := tm.themes[name]; !ok {
-		if err := tm.LoadThemes(); err != nil {
-			return nil, fmt.Errorf("theme not found: %s (load error: %w)", name, err)
-		}
+		_ = tm.LoadThemes()
 	}
 
 	theme, ok := tm.themes[name]
 	if !ok {
+		if err := tm.invalid[name]; err != nil {
+			return nil, fmt.Errorf("theme not found: %s (load error: %w)", name, err)
+		}
 		return nil, fmt.Errorf("theme not found: %s", name)
 	}
 	return &theme, nil
@@ -174,6 +238,17 @@ func (tm *ThemeManager) ListAPIThemes() []string {
 	return names
 }
 
+// ListCSSThemes 列出所有 CSS 主题
+func (tm *ThemeManager) ListCSSThemes() []string {
+	var names []string
+	for name, theme := range tm.themes {
+		if theme.Type == "css" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
 // GetAPITheme 获取 API 模式的主题名
 func (tm *ThemeManager) GetAPITheme(name string) (string, error) {
 	theme, err := tm.GetTheme(name)
@@ -239,6 +314,15 @@ func (tm *ThemeManager) IsAPITheme(name string) bool {
 	return theme.Type == "api"
 }
 
+// IsCSSTheme 检查是否是 CSS 主题
+func (tm *ThemeManager) IsCSSTheme(name string) bool {
+	theme, err := tm.GetTheme(name)
+	if err != nil {
+		return false
+	}
+	return theme.Type == "css"
+}
+
 // IsAITheme 检查是否是 AI 主题
 func (tm *ThemeManager) IsAITheme(name string) bool {
 	theme, err := tm.GetTheme(name)
@@ -269,6 +353,7 @@ func (tm *ThemeManager) GetThemeColors(name string) (map[string]string, error) {
 // ReloadThemes 重新加载所有主题
 func (tm *ThemeManager) ReloadThemes() error {
 	tm.themes = make(map[string]Theme)
+	tm.invalid = make(map[string]error)
 	return tm.LoadThemes()
 }
 
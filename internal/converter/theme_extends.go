@@ -0,0 +1,143 @@
+package converter
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// notInherited 子主题不从父主题继承的字段
+var notInherited = map[string]bool{"name": true, "description": true, "version": true, "extends": true}
+
+// readThemeNode 读取主题文件并检查结构，有 extends 时与父主题合并，
+// 同时返回读取过的主题文件（自身在前，随后是各级父主题）。
+// chain 为正在读取的继承链（绝对路径），用于发现循环继承
+func (tm *ThemeManager) readThemeNode(path string, chain []string) (*yaml.Node, []string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, nil, fmt.Errorf("parse yaml: %w", err)
+	}
+	root := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", Line: 1}
+	if len(doc.Content) > 0 {
+		root = doc.Content[0]
+	}
+	if issues := checkThemeSchema(root); len(issues) > 0 {
+		return nil, nil, &ThemeSchemaError{Path: path, Issues: issues}
+	}
+
+	key, _ := mappingEntry(root, "extends")
+	parentName := scalarValue(root, "extends")
+	if parentName == "" {
+		return root, []string{path}, nil
+	}
+
+	abs, _ := filepath.Abs(path)
+	chain = append(chain, abs)
+	parentPath, err := tm.resolveExtends(path, parentName)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s:%d: extends %q: %w", path, key.Line, parentName, err)
+	}
+	parentAbs, _ := filepath.Abs(parentPath)
+	for i, p := range chain {
+		if p == parentAbs {
+			cycle := append(append([]string{}, chain[i:]...), parentAbs)
+			for j := range cycle {
+				cycle[j] = filepath.Base(cycle[j])
+			}
+			return nil, nil, fmt.Errorf("%s:%d: extends %q: circular inheritance (%s)", path, key.Line, parentName, strings.Join(cycle, " -> "))
+		}
+	}
+
+	parent, parentFiles, err := tm.readThemeNode(parentPath, chain)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s:%d: extends %q: %w", path, key.Line, parentName, err)
+	}
+	// 父主题的样式表相对于父主题文件；转为绝对路径，避免主题目录为相对路径时再次拼接子主题目录
+	if _, sheet := mappingEntry(parent, "stylesheet"); sheet != nil && sheet.Value != "" && !filepath.IsAbs(sheet.Value) {
+		sheet.Value = filepath.Join(filepath.Dir(parentAbs), sheet.Value)
+	}
+	return mergeThemeNodes(parent, root), append([]string{path}, parentFiles...), nil
+}
+
+// resolveExtends 查找父主题文件：依次在当前主题所在目录和主题目录中按文件名查找
+func (tm *ThemeManager) resolveExtends(path, name string) (string, error) {
+	names := []string{name}
+	if ext := filepath.Ext(name); ext != ".yaml" && ext != ".yml" {
+		names = []string{name + ".yaml", name + ".yml"}
+	}
+	for _, dir := range []string{filepath.Dir(path), tm.getThemeDir()} {
+		for _, n := range names {
+			p := n
+			if !filepath.IsAbs(p) {
+				p = filepath.Join(dir, n)
+			}
+			if info, err := os.Stat(p); err == nil && !info.IsDir() {
+				return p, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("theme file not found")
+}
+
+// mergeThemeNodes 将子主题合并到父主题上（name、description、version 不继承）
+func mergeThemeNodes(parent, child *yaml.Node) *yaml.Node {
+	base := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	for i := 0; i+1 < len(parent.Content); i += 2 {
+		if !notInherited[parent.Content[i].Value] {
+			base.Content = append(base.Content, parent.Content[i], parent.Content[i+1])
+		}
+	}
+	return mergeMappings(base, child)
+}
+
+// mergeMappings 深度合并两个映射：两边都是映射的键递归合并，其它值由 over 覆盖；
+// 保持 base 中键的顺序，over 新增的键追加在后面（分段提示词依赖这个顺序）
+func mergeMappings(base, over *yaml.Node) *yaml.Node {
+	out := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", Line: over.Line, Column: over.Column}
+	index := make(map[string]int, len(base.Content)/2)
+	for i := 0; i+1 < len(base.Content); i += 2 {
+		index[base.Content[i].Value] = len(out.Content)
+		out.Content = append(out.Content, base.Content[i], resolveAlias(base.Content[i+1]))
+	}
+	for i := 0; i+1 < len(over.Content); i += 2 {
+		key, value := over.Content[i], resolveAlias(over.Content[i+1])
+		j, ok := index[key.Value]
+		if !ok {
+			index[key.Value] = len(out.Content)
+			out.Content = append(out.Content, key, value)
+			continue
+		}
+		if old := out.Content[j+1]; old.Kind == yaml.MappingNode && value.Kind == yaml.MappingNode {
+			value = mergeMappings(old, value)
+		}
+		out.Content[j], out.Content[j+1] = key, value
+	}
+	return out
+}
+
+// flattenPrompt 将分段的提示词按顺序拼接为完整文本，内容为空的段落被省略（子主题可以用空值删除父主题的段落）
+func flattenPrompt(root *yaml.Node) {
+	_, prompt := mappingEntry(root, "prompt")
+	if prompt == nil || prompt.Kind != yaml.MappingNode {
+		return
+	}
+	var parts []string
+	for i := 1; i < len(prompt.Content); i += 2 {
+		if text := strings.TrimRight(resolveAlias(prompt.Content[i]).Value, "\n"); strings.TrimSpace(text) != "" {
+			parts = append(parts, text)
+		}
+	}
+	text := ""
+	if len(parts) > 0 {
+		text = strings.Join(parts, "\n\n") + "\n"
+	}
+	*prompt = yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: text, Line: prompt.Line}
+}
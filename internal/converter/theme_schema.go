@@ -0,0 +1,300 @@
+package converter
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// themeColorPattern 主题颜色值：十六进制或 rgb()/rgba()/hsl()/hsla()
+var themeColorPattern = regexp.MustCompile(`^(#([0-9a-fA-F]{3,4}|[0-9a-fA-F]{6}|[0-9a-fA-F]{8})|(rgb|rgba|hsl|hsla)\([0-9.,%\s/+-]+\))$`)
+
+// namedColors CSS 颜色名
+var namedColors = toSet(strings.Fields(`
+	transparent currentcolor aliceblue antiquewhite aqua aquamarine azure beige bisque black blanchedalmond blue
+	blueviolet brown burlywood cadetblue chartreuse chocolate coral cornflowerblue cornsilk crimson cyan darkblue
+	darkcyan darkgoldenrod darkgray darkgreen darkgrey darkkhaki darkmagenta darkolivegreen darkorange darkorchid
+	darkred darksalmon darkseagreen darkslateblue darkslategray darkslategrey darkturquoise darkviolet deeppink
+	deepskyblue dimgray dimgrey dodgerblue firebrick floralwhite forestgreen fuchsia gainsboro ghostwhite gold
+	goldenrod gray green greenyellow grey honeydew hotpink indianred indigo ivory khaki lavender lavenderblush
+	lawngreen lemonchiffon lightblue lightcoral lightcyan lightgoldenrodyellow lightgray lightgreen lightgrey
+	lightpink lightsalmon lightseagreen lightskyblue lightslategray lightslategrey lightsteelblue lightyellow lime
+	limegreen linen magenta maroon mediumaquamarine mediumblue mediumorchid mediumpurple mediumseagreen
+	mediumslateblue mediumspringgreen mediumturquoise mediumvioletred midnightblue mintcream mistyrose moccasin
+	navajowhite navy oldlace olive olivedrab orange orangered orchid palegoldenrod palegreen paleturquoise
+	palevioletred papayawhip peachpuff peru pink plum powderblue purple rebeccapurple red rosybrown royalblue
+	saddlebrown salmon sandybrown seagreen seashell sienna silver skyblue slateblue slategray slategrey snow
+	springgreen steelblue tan teal thistle tomato turquoise violet wheat white whitesmoke yellow yellowgreen
+`))
+
+// SchemaIssue 主题文件中的一处错误
+type SchemaIssue struct {
+	Line    int
+	Message string
+}
+
+// ThemeSchemaError 主题文件没有通过结构检查，列出所有错误及其行号
+type ThemeSchemaError struct {
+	Path   string
+	Issues []SchemaIssue
+}
+
+func (e *ThemeSchemaError) Error() string {
+	lines := make([]string, len(e.Issues))
+	for i, issue := range e.Issues {
+		lines[i] = fmt.Sprintf("%s:%d: %s", e.Path, issue.Line, issue.Message)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// checkThemeSchema 按 Theme 结构体的 yaml 标签检查主题文件：
+// 未知的键、类型不符的值、无效的颜色和主题类型
+func checkThemeSchema(root *yaml.Node) []SchemaIssue {
+	var s schemaChecker
+	s.check(root, reflect.TypeOf(Theme{}), "")
+	sort.SliceStable(s.issues, func(i, j int) bool { return s.issues[i].Line < s.issues[j].Line })
+	return s.issues
+}
+
+// checkRequired 检查合并父主题并拼接提示词后的主题是否包含必填字段
+func checkRequired(root *yaml.Node) []SchemaIssue {
+	var issues []SchemaIssue
+	missing := func(format string, args ...any) {
+		issues = append(issues, SchemaIssue{Line: root.Line, Message: "missing required field " + fmt.Sprintf(format, args...)})
+	}
+	if scalarValue(root, "name") == "" {
+		missing(`"name"`)
+	}
+	themeType := scalarValue(root, "type")
+	if themeType == "" {
+		themeType = "ai"
+	}
+	switch {
+	case themeType == "ai" && scalarValue(root, "prompt") == "":
+		missing(`"prompt" (type ai)`)
+	case themeType == "css" && scalarValue(root, "stylesheet") == "":
+		missing(`"stylesheet" (type css)`)
+	}
+	return issues
+}
+
+// schemaChecker 遍历 YAML 节点并收集错误
+type schemaChecker struct {
+	issues []SchemaIssue
+}
+
+func (s *schemaChecker) add(n *yaml.Node, format string, args ...any) {
+	s.issues = append(s.issues, SchemaIssue{Line: n.Line, Message: fmt.Sprintf(format, args...)})
+}
+
+// check 检查节点是否符合类型 t，path 为点分隔的键路径（用于错误信息）
+func (s *schemaChecker) check(n *yaml.Node, t reflect.Type, path string) {
+	n = resolveAlias(n)
+	if n.Kind == yaml.ScalarNode && n.Tag == "!!null" {
+		return
+	}
+	if path == "prompt" {
+		s.checkPrompt(n)
+		return
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		if !s.expectMapping(n, path) {
+			return
+		}
+		fields := yamlFields(t)
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			key, value := n.Content[i], n.Content[i+1]
+			field, ok := fields[key.Value]
+			if !ok {
+				s.add(key, "%s", unknownKeyMessage(path, key.Value, fields))
+				continue
+			}
+			s.check(value, field, joinKeyPath(path, key.Value))
+		}
+	case reflect.Map:
+		if !s.expectMapping(n, path) {
+			return
+		}
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			key, value := n.Content[i], n.Content[i+1]
+			if path == "highlight.palette" {
+				if _, ok := defaultHighlightPalette[key.Value]; !ok {
+					s.add(key, "highlight.palette: unknown key %q", key.Value)
+					continue
+				}
+			}
+			s.check(value, t.Elem(), joinKeyPath(path, key.Value))
+		}
+	case reflect.String:
+		if n.Kind != yaml.ScalarNode {
+			s.add(n, "%s: expected a string", path)
+			return
+		}
+		s.checkValue(n, path)
+	case reflect.Bool:
+		if n.Kind != yaml.ScalarNode || n.Tag != "!!bool" {
+			s.add(n, "%s: expected true or false", path)
+		}
+	}
+}
+
+// checkValue 检查有取值限制的字段
+func (s *schemaChecker) checkValue(n *yaml.Node, path string) {
+	switch {
+	case path == "type":
+		switch n.Value {
+		case "api", "ai", "css":
+		default:
+			s.add(n, "unknown theme type %q (api, ai or css)", n.Value)
+		}
+	case strings.HasPrefix(path, "colors.") || strings.HasPrefix(path, "highlight.palette."):
+		if !isThemeColor(n.Value) {
+			s.add(n, "%s: invalid color %q", path, n.Value)
+		}
+	}
+}
+
+// checkPrompt 提示词是一段文本，或者由名称到文本的映射（按段合并父主题的提示词）
+func (s *schemaChecker) checkPrompt(n *yaml.Node) {
+	switch n.Kind {
+	case yaml.ScalarNode:
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			if value := resolveAlias(n.Content[i+1]); value.Kind != yaml.ScalarNode {
+				s.add(value, "prompt.%s: expected a string", n.Content[i].Value)
+			}
+		}
+	default:
+		s.add(n, "prompt: expected a string or a mapping of sections")
+	}
+}
+
+// expectMapping 检查节点是否为映射
+func (s *schemaChecker) expectMapping(n *yaml.Node, path string) bool {
+	if n.Kind == yaml.MappingNode {
+		return true
+	}
+	if path == "" {
+		s.add(n, "expected a mapping of theme fields")
+	} else {
+		s.add(n, "%s: expected a mapping", path)
+	}
+	return false
+}
+
+// isThemeColor 判断是否为有效的颜色值
+func isThemeColor(value string) bool {
+	value = strings.TrimSpace(value)
+	return themeColorPattern.MatchString(value) || namedColors[strings.ToLower(value)]
+}
+
+// yamlFields 返回结构体的 yaml 键及其类型
+func yamlFields(t reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+		name, _, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(f.Name)
+		}
+		fields[name] = f.Type
+	}
+	return fields
+}
+
+// unknownKeyMessage 未知键的错误信息，拼写相近时给出建议
+func unknownKeyMessage(path, key string, fields map[string]reflect.Type) string {
+	msg := fmt.Sprintf("unknown key %q", key)
+	if path != "" {
+		msg = path + ": " + msg
+	}
+	best, bestDist := "", 3
+	for name := range fields {
+		if d := editDistance(key, name); d < bestDist || d == bestDist && name < best {
+			best, bestDist = name, d
+		}
+	}
+	if best != "" {
+		msg += fmt.Sprintf(" (did you mean %q?)", best)
+	}
+	return msg
+}
+
+// editDistance 两个字符串的编辑距离
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
+
+// joinKeyPath 拼接键路径
+func joinKeyPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// mappingEntry 查找映射中的键，返回键和值节点
+func mappingEntry(n *yaml.Node, key string) (*yaml.Node, *yaml.Node) {
+	if n == nil || n.Kind != yaml.MappingNode {
+		return nil, nil
+	}
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		if n.Content[i].Value == key {
+			return n.Content[i], resolveAlias(n.Content[i+1])
+		}
+	}
+	return nil, nil
+}
+
+// scalarValue 返回映射中标量键的值，不存在时为空
+func scalarValue(n *yaml.Node, key string) string {
+	if _, value := mappingEntry(n, key); value != nil && value.Kind == yaml.ScalarNode && value.Tag != "!!null" {
+		return strings.TrimSpace(value.Value)
+	}
+	return ""
+}
+
+// resolveAlias 展开 YAML 别名
+func resolveAlias(n *yaml.Node) *yaml.Node {
+	for n.Kind == yaml.AliasNode && n.Alias != nil {
+		n = n.Alias
+	}
+	return n
+}
+
+// toSet 将字符串列表转为集合
+func toSet(values []string) map[string]bool {
+	set := make(map[string]bool, len(values))
+	for _, v := range values {
+		set[v] = true
+	}
+	return set
+}
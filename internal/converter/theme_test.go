@@ -0,0 +1,224 @@
+package converter
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeThemeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestThemeExtends(t *testing.T) {
+	dir := writeThemeFiles(t, map[string]string{
+		"base.yaml": "name: base\ntype: ai\ndescription: 基础\nversion: \"2.0\"\n" +
+			"style_info:\n  mood: 安静\n  colors: 绿色\n" +
+			"colors:\n  primary: \"#111111\"\n  text: \"#222222\"\n" +
+			"prompt:\n  intro: |\n    开头\n  body: |\n    正文规则\n  extra: 附加\n  outro: |\n    请转换：\n",
+		"child.yaml": "name: child\nextends: base\n" +
+			"style_info:\n  mood: 热烈\n" +
+			"colors:\n  primary: \"#ff0000\"\n" +
+			"prompt:\n  body: |\n    新规则\n  extra: \"\"\n  more: 追加\n",
+		"grandchild.yaml": "name: grandchild\nextends: child.yaml\nprompt: 整段替换\n",
+		"css/paper.yaml":  "name: paper\ntype: css\nstylesheet: paper.css\ncolors:\n  primary: \"#123456\"\n",
+		"css/paper.css":   "h2 { color: var(--primary) }\n",
+		"dark.yaml":       "name: dark\nextends: css/paper\ncolors:\n  primary: \"#abcdef\"\n",
+	})
+
+	tm := NewThemeManager()
+	for _, name := range []string{"child.yaml", "grandchild.yaml", "dark.yaml"} {
+		if err := tm.LoadTheme(filepath.Join(dir, name)); err != nil {
+			t.Fatalf("LoadTheme(%s) error = %v", name, err)
+		}
+	}
+
+	child, _ := tm.GetTheme("child")
+	if child.Type != "ai" || child.Description != "child" || child.Version != "" || child.Extends != "base" {
+		t.Errorf("child = %+v", child)
+	}
+	if child.StyleInfo.Mood != "热烈" || child.StyleInfo.Colors != "绿色" {
+		t.Errorf("StyleInfo = %+v", child.StyleInfo)
+	}
+	if child.Colors["primary"] != "#ff0000" || child.Colors["text"] != "#222222" {
+		t.Errorf("Colors = %v", child.Colors)
+	}
+	// 段落按父主题的顺序合并，空段落被删除，新段落追加在最后
+	if want := "开头\n\n新规则\n\n请转换：\n\n追加\n"; child.Prompt != want {
+		t.Errorf("Prompt = %q, want %q", child.Prompt, want)
+	}
+
+	grandchild, _ := tm.GetTheme("grandchild")
+	if grandchild.Prompt != "整段替换" || grandchild.Colors["primary"] != "#ff0000" {
+		t.Errorf("grandchild = %+v", grandchild)
+	}
+
+	// 继承的样式表相对于父主题文件
+	dark, _ := tm.GetTheme("dark")
+	if dark.sheet == nil {
+		t.Fatal("dark theme has no stylesheet")
+	}
+	html, err := InlineCSS("<h2>标题</h2>", dark.sheet)
+	if err != nil || !strings.Contains(html, "color:#abcdef") {
+		t.Errorf("Inline() = %q, %v", html, err)
+	}
+
+	// 源文件包含各级父主题和样式表
+	want := []string{filepath.Join(dir, "dark.yaml"), filepath.Join(dir, "css", "paper.yaml"), filepath.Join(dir, "css", "paper.css")}
+	if got := dark.SourceFiles(); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("SourceFiles() = %v, want %v", got, want)
+	}
+}
+
+func TestThemeExtendsErrors(t *testing.T) {
+	dir := writeThemeFiles(t, map[string]string{
+		"a.yaml":       "name: a\nextends: b\nprompt: x\n",
+		"b.yaml":       "name: b\n\nextends: a\nprompt: x\n",
+		"orphan.yaml":  "name: orphan\nextends: missing\n",
+		"broken.yaml":  "name: broken\ncolors:\n  primary: red-ish\nprompt: x\n",
+		"invalid.yaml": "name: invalid\nextends: broken\n",
+	})
+	tests := []struct {
+		file string
+		want string
+	}{
+		{"a.yaml", `a.yaml:2: extends "b": ` + filepath.Join(dir, "b.yaml") + `:3: extends "a": circular inheritance (a.yaml -> b.yaml -> a.yaml)`},
+		{"orphan.yaml", `orphan.yaml:2: extends "missing": theme file not found`},
+		{"invalid.yaml", `invalid.yaml:2: extends "broken": ` + filepath.Join(dir, "broken.yaml") + `:3: colors.primary: invalid color "red-ish"`},
+	}
+	for _, tt := range tests {
+		err := NewThemeManager().LoadTheme(filepath.Join(dir, tt.file))
+		if err == nil || !strings.HasSuffix(err.Error(), tt.want) {
+			t.Errorf("LoadTheme(%s) error = %v, want suffix %q", tt.file, err, tt.want)
+		}
+	}
+}
+
+func TestThemeSchema(t *testing.T) {
+	dir := writeThemeFiles(t, map[string]string{
+		"bad.yaml": `name: bad
+tpye: api
+colors:
+  primary: "#12345"
+  text: ffffff
+  border: rgba(0, 0, 0, 0.1)
+  background: White
+style_info:
+  mood: 安静
+  font: serif
+highlight:
+  line_numbers: "yes"
+  palette:
+    keyword: "#zzz"
+prompt:
+  intro:
+    - list
+containers:
+  tip: 提示
+`,
+		"nameless.yaml": "# 没有名字\ntype: css\n",
+		"typo.yaml":     "name: typo\ntype: ia\n",
+	})
+
+	err := NewThemeManager().LoadTheme(filepath.Join(dir, "bad.yaml"))
+	if err == nil {
+		t.Fatal("LoadTheme(bad) succeeded")
+	}
+	path := filepath.Join(dir, "bad.yaml")
+	want := []string{
+		path + `:2: unknown key "tpye" (did you mean "type"?)`,
+		path + `:4: colors.primary: invalid color "#12345"`,
+		path + `:5: colors.text: invalid color "ffffff"`,
+		path + `:10: style_info: unknown key "font"`,
+		path + `:12: highlight.line_numbers: expected true or false`,
+		path + `:14: highlight.palette.keyword: invalid color "#zzz"`,
+		path + `:17: prompt.intro: expected a string`,
+		path + `:19: containers.tip: expected a mapping`,
+	}
+	if got := err.Error(); got != strings.Join(want, "\n") {
+		t.Errorf("LoadTheme(bad) error =\n%s\nwant\n%s", got, strings.Join(want, "\n"))
+	}
+
+	err = NewThemeManager().LoadTheme(filepath.Join(dir, "nameless.yaml"))
+	if err == nil || err.Error() != filepath.Join(dir, "nameless.yaml")+`:2: missing required field "name"`+"\n"+filepath.Join(dir, "nameless.yaml")+`:2: missing required field "stylesheet" (type css)` {
+		t.Errorf("LoadTheme(nameless) error = %v", err)
+	}
+	err = NewThemeManager().LoadTheme(filepath.Join(dir, "typo.yaml"))
+	if err == nil || !strings.HasSuffix(err.Error(), `typo.yaml:2: unknown theme type "ia" (api, ai or css)`) {
+		t.Errorf("LoadTheme(typo) error = %v", err)
+	}
+}
+
+func TestBuiltinThemes(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("..", "..", "themes", "*.yaml"))
+	if err != nil || len(files) == 0 {
+		t.Fatalf("themes not found: %v", err)
+	}
+	tm := NewThemeManager()
+	for _, f := range files {
+		if err := tm.LoadTheme(f); err != nil {
+			t.Errorf("LoadTheme(%s) error = %v", f, err)
+		}
+	}
+	ocean, err := tm.GetTheme("ocean-calm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ocean.Type != "ai" || !strings.Contains(ocean.Prompt, "深海静谧") || !strings.HasSuffix(ocean.Prompt, "请转换以下 Markdown内容：\n") {
+		t.Errorf("ocean-calm = %+v", ocean)
+	}
+}
+
+func TestLoadThemesSkipsInvalid(t *testing.T) {
+	dir := writeThemeFiles(t, map[string]string{
+		"themes/good.yaml":   "name: good\ntype: api\napi_theme: default\n",
+		"themes/bad.yaml":    "name: bad\ntype: api\ncolors:\n  primary: nope\n",
+		"themes/custom.yaml": "name: renamed\ntype: ai\n",
+		"themes/paper.yaml":  "name: paper\ntype: css\nstylesheet: paper.css\n",
+		"themes/paper.css":   "h2 { color: #111111 }\n",
+		"themes/dark.yaml":   "name: dark\nextends: paper\n",
+	})
+	t.Chdir(dir)
+
+	tm := NewThemeManager()
+	err := tm.LoadThemes()
+	if err == nil || !strings.Contains(err.Error(), `bad.yaml:4: colors.primary: invalid color "nope"`) || !strings.Contains(err.Error(), "custom.yaml") {
+		t.Errorf("LoadThemes() error = %v", err)
+	}
+
+	// 其它文件无效不影响有效的主题
+	if theme, err := tm.GetTheme("good"); err != nil || theme.APITheme != "default" {
+		t.Errorf("GetTheme(good) = %+v, %v", theme, err)
+	}
+
+	// 主题目录为相对路径时，继承的样式表仍相对于父主题文件
+	if theme, err := tm.GetTheme("dark"); err != nil || theme.sheet == nil {
+		t.Errorf("GetTheme(dark) = %+v, %v", theme, err)
+	}
+
+	// 请求无效的主题时返回该文件的错误（按文件名或声明的名称）
+	_, err = tm.GetTheme("bad")
+	var schemaErr *ThemeSchemaError
+	if !errors.As(err, &schemaErr) || schemaErr.Path != filepath.Join("themes", "bad.yaml") {
+		t.Errorf("GetTheme(bad) error = %v, want schema error", err)
+	}
+	if _, err := tm.GetTheme("renamed"); err == nil || !strings.Contains(err.Error(), `missing required field "prompt"`) {
+		t.Errorf("GetTheme(renamed) error = %v", err)
+	}
+	if _, err := tm.GetTheme("missing"); err == nil || err.Error() != "theme not found: missing" {
+		t.Errorf("GetTheme(missing) error = %v", err)
+	}
+}
@@ -0,0 +1,123 @@
+package diagram
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// commandTimeout 单个图表的渲染超时（mmdc 需要启动浏览器，首次较慢）
+const commandTimeout = 60 * time.Second
+
+// pngMagic PNG 文件头
+var pngMagic = []byte("\x89PNG\r\n\x1a\n")
+
+// CommandRenderer 调用外部程序渲染图表
+// mermaid 使用 mermaid-cli（mmdc -i in.mmd -o out.png），plantuml 使用 plantuml -tpng -pipe
+// 命令可以带参数，如 "mmdc -p puppeteer.json" 或 "java -jar /opt/plantuml.jar"
+type CommandRenderer struct {
+	kind    Kind
+	command []string
+}
+
+// NewCommandRenderer 创建外部程序渲染器
+func NewCommandRenderer(kind Kind, command string) *CommandRenderer {
+	return &CommandRenderer{kind: kind, command: strings.Fields(command)}
+}
+
+// Name 返回渲染器名称
+func (r *CommandRenderer) Name() string {
+	if len(r.command) == 0 {
+		return string(r.kind)
+	}
+	return filepath.Base(r.command[0])
+}
+
+// Render 渲染图表
+func (r *CommandRenderer) Render(ctx context.Context, kind Kind, source string) ([]byte, error) {
+	if kind != r.kind || len(r.command) == 0 {
+		return nil, ErrUnsupported
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, commandTimeout)
+	defer cancel()
+
+	if kind == KindPlantUML {
+		return r.renderPlantUML(ctx, source)
+	}
+	return r.renderMermaid(ctx, source)
+}
+
+// renderMermaid 通过临时文件调用 mmdc
+func (r *CommandRenderer) renderMermaid(ctx context.Context, source string) ([]byte, error) {
+	dir, err := os.MkdirTemp("", "md2wechat-mermaid-")
+	if err != nil {
+		return nil, fmt.Errorf("create temp dir: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	input := filepath.Join(dir, "diagram.mmd")
+	output := filepath.Join(dir, "diagram.png")
+	if err := os.WriteFile(input, []byte(source), 0644); err != nil {
+		return nil, fmt.Errorf("write diagram source: %w", err)
+	}
+
+	if _, err := r.run(ctx, nil, "-i", input, "-o", output, "-b", "white", "-s", "2"); err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(output)
+	if err != nil {
+		return nil, fmt.Errorf("read rendered diagram: %w", err)
+	}
+	return data, nil
+}
+
+// renderPlantUML 通过标准输入输出调用 plantuml
+func (r *CommandRenderer) renderPlantUML(ctx context.Context, source string) ([]byte, error) {
+	if !strings.Contains(source, "@start") {
+		source = "@startuml\n" + source + "\n@enduml\n"
+	}
+	data, err := r.run(ctx, strings.NewReader(source), "-tpng", "-pipe", "-charset", "UTF-8")
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.HasPrefix(data, pngMagic) {
+		return nil, fmt.Errorf("output is not a PNG image")
+	}
+	return data, nil
+}
+
+// run 执行命令，返回标准输出；失败时错误中带上标准错误输出
+func (r *CommandRenderer) run(ctx context.Context, stdin *strings.Reader, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, r.command[0], append(r.command[1:len(r.command):len(r.command)], args...)...)
+	if stdin != nil {
+		cmd.Stdin = stdin
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return nil, fmt.Errorf("timed out after %s", commandTimeout)
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%w: %s", err, firstLine(msg))
+		}
+		return nil, err
+	}
+	return stdout.Bytes(), nil
+}
+
+// firstLine 返回第一行（外部程序的错误输出可能很长）
+func firstLine(s string) string {
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		return s[:i]
+	}
+	return s
+}
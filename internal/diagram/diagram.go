@@ -0,0 +1,106 @@
+// Package diagram 将 Markdown 中的 mermaid / plantuml 代码块渲染为 PNG 图片
+// 优先调用本地安装的渲染程序（mermaid-cli 的 mmdc、plantuml），
+// 未安装或渲染失败时，简单的 mermaid 流程图由纯 Go 实现的 FlowchartRenderer 兜底
+package diagram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/geekjourneyx/md2wechat-skill/internal/config"
+)
+
+// Kind 图表语言
+type Kind string
+
+const (
+	KindMermaid  Kind = "mermaid"
+	KindPlantUML Kind = "plantuml"
+)
+
+// ErrUnsupported 渲染器不支持该图表（语言或图表类型），由下一个渲染器处理
+var ErrUnsupported = errors.New("diagram type not supported")
+
+// Renderer 图表渲染器接口
+type Renderer interface {
+	// Name 返回渲染器名称（参与缓存键，更换渲染器后重新渲染）
+	Name() string
+
+	// Render 渲染图表，返回 PNG 数据；不支持时返回 ErrUnsupported
+	Render(ctx context.Context, kind Kind, source string) ([]byte, error)
+}
+
+// KindOf 根据代码块的语言标记判断图表语言
+func KindOf(info string) (Kind, bool) {
+	fields := strings.Fields(info)
+	if len(fields) == 0 {
+		return "", false
+	}
+	switch strings.ToLower(fields[0]) {
+	case "mermaid":
+		return KindMermaid, true
+	case "plantuml", "puml", "uml":
+		return KindPlantUML, true
+	}
+	return "", false
+}
+
+// NewRenderer 根据配置创建渲染器
+// 依次尝试：配置的（或 PATH 中找到的）mmdc、plantuml，最后是纯 Go 流程图渲染
+func NewRenderer(cfg *config.Config) Renderer {
+	var chain Chain
+	if command := findCommand(cfg.MermaidCommand, "mmdc"); command != "" {
+		chain = append(chain, NewCommandRenderer(KindMermaid, command))
+	}
+	if command := findCommand(cfg.PlantUMLCommand, "plantuml"); command != "" {
+		chain = append(chain, NewCommandRenderer(KindPlantUML, command))
+	}
+	return append(chain, NewFlowchartRenderer(cfg.DiagramFont))
+}
+
+// findCommand 返回配置的命令；未配置时在 PATH 中查找默认程序，"off" 表示不使用外部程序
+func findCommand(configured, fallback string) string {
+	switch configured {
+	case "off", "none":
+		return ""
+	case "":
+		if path, err := exec.LookPath(fallback); err == nil {
+			return path
+		}
+		return ""
+	}
+	return configured
+}
+
+// Chain 按顺序尝试多个渲染器，返回第一个成功的结果
+type Chain []Renderer
+
+// Name 返回所有渲染器的名称
+func (c Chain) Name() string {
+	names := make([]string, len(c))
+	for i, r := range c {
+		names[i] = r.Name()
+	}
+	return strings.Join(names, "+")
+}
+
+// Render 依次尝试各渲染器；前面的渲染器失败时继续尝试后面的，全部失败时返回第一个错误
+func (c Chain) Render(ctx context.Context, kind Kind, source string) ([]byte, error) {
+	var first error
+	for _, r := range c {
+		data, err := r.Render(ctx, kind, source)
+		if err == nil {
+			return data, nil
+		}
+		if first == nil && !errors.Is(err, ErrUnsupported) {
+			first = fmt.Errorf("%s: %w", r.Name(), err)
+		}
+	}
+	if first != nil {
+		return nil, first
+	}
+	return nil, fmt.Errorf("%s: %w", kind, ErrUnsupported)
+}
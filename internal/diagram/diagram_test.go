@@ -0,0 +1,179 @@
+package diagram
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image/png"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestKindOf(t *testing.T) {
+	tests := map[string]Kind{"mermaid": KindMermaid, "Mermaid {.x}": KindMermaid, "plantuml": KindPlantUML, "puml": KindPlantUML}
+	for info, want := range tests {
+		if got, ok := KindOf(info); !ok || got != want {
+			t.Errorf("KindOf(%q) = %q, %v", info, got, ok)
+		}
+	}
+	for _, info := range []string{"", "go", "mermaidx"} {
+		if _, ok := KindOf(info); ok {
+			t.Errorf("KindOf(%q) matched", info)
+		}
+	}
+}
+
+func TestParseFlowchart(t *testing.T) {
+	fc, err := parseFlowchart(`graph LR
+  %% comment
+  A[Start] --> B{Ok?}
+  B -->|yes| C(("Done<br/>!")) ; B -- no --> D([Retry])
+  D -.-> A & C
+  C ==> E{{End}}:::big
+  classDef big fill:#f9f
+  A <--> E`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fc.direction != "LR" {
+		t.Errorf("direction = %q", fc.direction)
+	}
+
+	var nodes []string
+	for _, n := range fc.nodes {
+		nodes = append(nodes, n.id+"="+n.label)
+	}
+	if got := strings.Join(nodes, ","); got != "A=Start,B=Ok?,C=Done\n!,D=Retry,E=End" {
+		t.Errorf("nodes = %q", got)
+	}
+	if s := fc.index["B"].shape; s != shapeDiamond {
+		t.Errorf("B shape = %v", s)
+	}
+	if s := fc.index["C"].shape; s != shapeCircle {
+		t.Errorf("C shape = %v", s)
+	}
+
+	var edges []string
+	for _, e := range fc.edges {
+		edges = append(edges, e.from.id+e.to.id+":"+e.label)
+	}
+	if got := strings.Join(edges, ","); got != "AB:,BC:yes,BD:no,DA:,DC:,CE:,AE:" {
+		t.Errorf("edges = %q", got)
+	}
+	if e := fc.edges[3]; e.style != edgeDotted || !e.arrow {
+		t.Errorf("D-.->A = %+v", e)
+	}
+	if e := fc.edges[5]; e.style != edgeThick {
+		t.Errorf("C==>E style = %v", e.style)
+	}
+	if e := fc.edges[6]; !e.arrow || !e.arrowBack {
+		t.Errorf("A<-->E = %+v", e)
+	}
+}
+
+func TestParseFlowchartErrors(t *testing.T) {
+	if _, err := parseFlowchart("sequenceDiagram\n A->>B: hi"); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("sequence diagram error = %v, want ErrUnsupported", err)
+	}
+	if _, err := parseFlowchart("graph TD\n A[unclosed --> B"); err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("unclosed node error = %v", err)
+	}
+}
+
+func TestFlowchartRenderer(t *testing.T) {
+	r := NewFlowchartRenderer("")
+	size := func(src string) (int, int) {
+		t.Helper()
+		data, err := r.Render(context.Background(), KindMermaid, src)
+		if err != nil {
+			t.Fatalf("Render() error = %v", err)
+		}
+		img, err := png.Decode(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("decode png: %v", err)
+		}
+		return img.Bounds().Dx(), img.Bounds().Dy()
+	}
+
+	// 含环和跨层连线
+	src := "A --> B --> C --> D\nA --> D\nD --> A\nB --> B"
+	tw, th := size("graph TD\n" + src)
+	lw, lh := size("graph LR\n" + src)
+	if th <= tw || lw <= lh {
+		t.Errorf("TD = %dx%d, LR = %dx%d, want TD taller and LR wider", tw, th, lw, lh)
+	}
+
+	if _, err := r.Render(context.Background(), KindPlantUML, "A -> B"); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("plantuml error = %v, want ErrUnsupported", err)
+	}
+}
+
+// fakeRenderer 测试用渲染器
+type fakeRenderer struct {
+	name string
+	data []byte
+	err  error
+}
+
+func (f *fakeRenderer) Name() string { return f.name }
+func (f *fakeRenderer) Render(ctx context.Context, kind Kind, source string) ([]byte, error) {
+	return f.data, f.err
+}
+
+func TestChain(t *testing.T) {
+	failing := &fakeRenderer{name: "mmdc", err: errors.New("browser crashed")}
+	fallback := &fakeRenderer{name: "flowchart", data: []byte("png")}
+
+	chain := Chain{failing, fallback}
+	if chain.Name() != "mmdc+flowchart" {
+		t.Errorf("Name() = %q", chain.Name())
+	}
+	if data, err := chain.Render(context.Background(), KindMermaid, "graph TD"); err != nil || string(data) != "png" {
+		t.Errorf("Render() = %q, %v, want fallback result", data, err)
+	}
+
+	fallback.err, fallback.data = ErrUnsupported, nil
+	if _, err := chain.Render(context.Background(), KindMermaid, "x"); err == nil || !strings.Contains(err.Error(), "mmdc: browser crashed") {
+		t.Errorf("Render() error = %v, want first real error", err)
+	}
+
+	if _, err := (Chain{fallback}).Render(context.Background(), KindPlantUML, "x"); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("Render() error = %v, want ErrUnsupported", err)
+	}
+}
+
+func TestCommandRenderer(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script")
+	}
+	dir := t.TempDir()
+	script := filepath.Join(dir, "fake-plantuml")
+	// 输出 PNG 文件头和收到的源码，便于检查参数传递
+	body := "#!/bin/sh\nprintf '\\211PNG\\r\\n\\032\\n'\necho \"$@\"\ncat\n"
+	if err := os.WriteFile(script, []byte(body), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	r := NewCommandRenderer(KindPlantUML, script)
+	data, err := r.Render(context.Background(), KindPlantUML, "Alice -> Bob")
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	for _, want := range []string{"-tpng -pipe", "@startuml\nAlice -> Bob\n@enduml"} {
+		if !bytes.Contains(data, []byte(want)) {
+			t.Errorf("output missing %q:\n%s", want, data)
+		}
+	}
+
+	if _, err := r.Render(context.Background(), KindMermaid, "graph TD"); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("mermaid error = %v, want ErrUnsupported", err)
+	}
+
+	failing := NewCommandRenderer(KindPlantUML, "true")
+	if _, err := failing.Render(context.Background(), KindPlantUML, "x"); err == nil {
+		t.Error("non-PNG output should fail")
+	}
+}
@@ -0,0 +1,322 @@
+package diagram
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"math"
+
+	"golang.org/x/image/vector"
+)
+
+// renderScale 绘制倍率，适配高清屏
+const renderScale = 2
+
+// maxCanvasSize 画布最大边长（像素），防止异常输入生成超大图片
+const maxCanvasSize = 8000
+
+// 流程图配色（与 mermaid 默认主题一致）
+var (
+	colorBackground = color.NRGBA{0xff, 0xff, 0xff, 0xff}
+	colorNodeFill   = color.NRGBA{0xec, 0xec, 0xff, 0xff}
+	colorNodeStroke = color.NRGBA{0x93, 0x70, 0xdb, 0xff}
+	colorText       = color.NRGBA{0x33, 0x33, 0x33, 0xff}
+	colorEdge       = color.NRGBA{0x33, 0x33, 0x33, 0xff}
+	colorLabelFill  = color.NRGBA{0xe8, 0xe8, 0xe8, 0xff}
+)
+
+// FlowchartRenderer 纯 Go 实现的 mermaid 流程图渲染器（graph / flowchart）
+// 支持常用的节点形状、实线/虚线/粗线连线、连线文字和多行文字；子图和样式语句会被忽略
+type FlowchartRenderer struct {
+	fontPath string
+}
+
+// NewFlowchartRenderer 创建流程图渲染器，fontPath 为标签字体（为空时使用 Go 字体和系统中文字体）
+func NewFlowchartRenderer(fontPath string) *FlowchartRenderer {
+	return &FlowchartRenderer{fontPath: fontPath}
+}
+
+// Name 返回渲染器名称
+func (r *FlowchartRenderer) Name() string {
+	return "flowchart"
+}
+
+// Render 渲染流程图
+func (r *FlowchartRenderer) Render(ctx context.Context, kind Kind, source string) ([]byte, error) {
+	if kind != KindMermaid {
+		return nil, ErrUnsupported
+	}
+	fc, err := parseFlowchart(source)
+	if err != nil {
+		return nil, err
+	}
+	fonts, err := newFontSet(r.fontPath)
+	if err != nil {
+		return nil, err
+	}
+
+	layout := layoutFlowchart(fc, func(s string) float64 { return fonts.measure(s, fontSize) })
+	if len(fonts.missing) > 0 {
+		return nil, fmt.Errorf("no font contains %q, set diagram.font to a font file that does", string(fonts.missing[0]))
+	}
+	width := int(math.Ceil(layout.width * renderScale))
+	height := int(math.Ceil(layout.height * renderScale))
+	if width > maxCanvasSize || height > maxCanvasSize {
+		return nil, fmt.Errorf("flowchart too large (%dx%d)", width, height)
+	}
+
+	c := newCanvas(width, height, fonts)
+	for _, e := range layout.edges {
+		c.drawEdge(e)
+	}
+	for _, n := range layout.nodes {
+		c.drawNode(n)
+	}
+	for _, e := range layout.edges {
+		c.drawEdgeLabel(e)
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, c.img); err != nil {
+		return nil, fmt.Errorf("encode png: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// canvas 绘图画布，坐标使用布局像素（内部乘以 renderScale）
+type canvas struct {
+	img   *image.NRGBA
+	z     *vector.Rasterizer
+	fonts *fontSet
+}
+
+func newCanvas(width, height int, fonts *fontSet) *canvas {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(img, img.Bounds(), image.NewUniform(colorBackground), image.Point{}, draw.Src)
+	return &canvas{img: img, z: vector.NewRasterizer(width, height), fonts: fonts}
+}
+
+// fill 用指定颜色填充 build 生成的路径
+func (c *canvas) fill(col color.Color, build func()) {
+	b := c.img.Bounds()
+	c.z.Reset(b.Dx(), b.Dy())
+	c.z.DrawOp = draw.Over
+	build()
+	c.z.Draw(c.img, b, image.NewUniform(col), image.Point{})
+}
+
+// polygon 加入多边形，统一为顺时针方向（与其它图形叠加时不会相互抵消）
+func (c *canvas) polygon(pts []point) {
+	var area float64
+	for i := range pts {
+		j := (i + 1) % len(pts)
+		area += pts[i].x*pts[j].y - pts[j].x*pts[i].y
+	}
+	c.z.MoveTo(float32(pts[0].x*renderScale), float32(pts[0].y*renderScale))
+	for i := 1; i < len(pts); i++ {
+		k := i
+		if area < 0 {
+			k = len(pts) - i
+		}
+		c.z.LineTo(float32(pts[k].x*renderScale), float32(pts[k].y*renderScale))
+	}
+	c.z.ClosePath()
+}
+
+// stroke 描边折线，线段两端加圆形连接点
+func (c *canvas) stroke(pts []point, width float64, closed bool) {
+	if closed {
+		pts = append(pts[:len(pts):len(pts)], pts[0])
+	}
+	for i := 1; i < len(pts); i++ {
+		a, b := pts[i-1], pts[i]
+		length := math.Hypot(b.x-a.x, b.y-a.y)
+		if length == 0 {
+			continue
+		}
+		nx, ny := -(b.y-a.y)/length*width/2, (b.x-a.x)/length*width/2
+		c.polygon([]point{{a.x + nx, a.y + ny}, {b.x + nx, b.y + ny}, {b.x - nx, b.y - ny}, {a.x - nx, a.y - ny}})
+	}
+	for _, p := range pts {
+		c.polygon(ellipse(p, width/2, width/2))
+	}
+}
+
+// dashed 将折线拆分为虚线段
+func dashed(pts []point, dash, gap float64) [][]point {
+	var out [][]point
+	var cur []point
+	on, left := true, dash
+	cur = append(cur, pts[0])
+	for i := 1; i < len(pts); i++ {
+		a, b := pts[i-1], pts[i]
+		length := math.Hypot(b.x-a.x, b.y-a.y)
+		pos := 0.0
+		for length-pos > left {
+			pos += left
+			t := pos / length
+			p := point{a.x + (b.x-a.x)*t, a.y + (b.y-a.y)*t}
+			if on {
+				out = append(out, append(cur, p))
+				cur = nil
+				left = gap
+			} else {
+				cur = []point{p}
+				left = dash
+			}
+			on = !on
+		}
+		left -= length - pos
+		if on {
+			cur = append(cur, b)
+		}
+	}
+	if on && len(cur) > 1 {
+		out = append(out, cur)
+	}
+	return out
+}
+
+// drawNode 绘制节点形状和文字
+func (c *canvas) drawNode(n *layoutNode) {
+	outline := nodeOutline(n)
+	c.fill(colorNodeFill, func() { c.polygon(outline) })
+	c.fill(colorNodeStroke, func() { c.stroke(outline, 1, true) })
+	c.drawLines(n.lines, n.x, n.y)
+}
+
+// nodeOutline 节点形状的轮廓多边形
+func nodeOutline(n *layoutNode) []point {
+	x0, y0, x1, y1 := n.x-n.w/2, n.y-n.h/2, n.x+n.w/2, n.y+n.h/2
+	switch n.node.shape {
+	case shapeDiamond:
+		return []point{{n.x, y0}, {x1, n.y}, {n.x, y1}, {x0, n.y}}
+	case shapeCircle:
+		return ellipse(point{n.x, n.y}, n.w/2, n.h/2)
+	case shapeHexagon:
+		d := n.h / 4
+		return []point{{x0 + d, y0}, {x1 - d, y0}, {x1, n.y}, {x1 - d, y1}, {x0 + d, y1}, {x0, n.y}}
+	case shapeStadium:
+		return roundedRect(x0, y0, x1, y1, n.h/2)
+	case shapeRound:
+		return roundedRect(x0, y0, x1, y1, 8)
+	}
+	return roundedRect(x0, y0, x1, y1, 2)
+}
+
+// drawEdge 绘制连线和箭头
+func (c *canvas) drawEdge(e *layoutEdge) {
+	pts := append([]point(nil), e.points...)
+	width := 1.5
+	if e.edge.style == edgeThick {
+		width = 3
+	}
+	const arrowSize = 8.0
+
+	var heads [][]point
+	if e.edge.arrow {
+		heads = append(heads, arrowHead(pts[len(pts)-2], pts[len(pts)-1], arrowSize+width))
+		pts[len(pts)-1] = shorten(pts[len(pts)-2], pts[len(pts)-1], arrowSize)
+	}
+	if e.edge.arrowBack {
+		heads = append(heads, arrowHead(pts[1], pts[0], arrowSize+width))
+		pts[0] = shorten(pts[1], pts[0], arrowSize)
+	}
+
+	c.fill(colorEdge, func() {
+		if e.edge.style == edgeDotted {
+			for _, dash := range dashed(pts, 3, 3) {
+				c.stroke(dash, width, false)
+			}
+		} else {
+			c.stroke(pts, width, false)
+		}
+		for _, head := range heads {
+			c.polygon(head)
+		}
+	})
+}
+
+// drawEdgeLabel 绘制连线文字（带背景，压在连线上方）
+func (c *canvas) drawEdgeLabel(e *layoutEdge) {
+	if e.label == nil {
+		return
+	}
+	p := e.labelP
+	c.fill(colorLabelFill, func() {
+		c.polygon(roundedRect(p.x-e.labelW/2, p.y-e.labelH/2, p.x+e.labelW/2, p.y+e.labelH/2, 2))
+	})
+	c.drawLines(e.label, p.x, p.y)
+}
+
+// drawLines 以 (cx, cy) 为中心绘制多行文字
+func (c *canvas) drawLines(lines []string, cx, cy float64) {
+	top := cy - float64(len(lines))*lineHeight/2
+	c.fill(colorText, func() {
+		for i, line := range lines {
+			w := c.fonts.measure(line, fontSize)
+			// 基线位于行高中间偏下（约 0.35em）
+			baseline := top + float64(i)*lineHeight + lineHeight/2 + fontSize*0.35
+			c.fonts.drawText(c.z, line, (cx-w/2)*renderScale, baseline*renderScale, fontSize*renderScale)
+		}
+	})
+}
+
+// arrowHead 指向 tip 的三角形箭头
+func arrowHead(from, tip point, size float64) []point {
+	length := math.Hypot(tip.x-from.x, tip.y-from.y)
+	if length == 0 {
+		return []point{tip, tip, tip}
+	}
+	ux, uy := (tip.x-from.x)/length, (tip.y-from.y)/length
+	bx, by := tip.x-ux*size, tip.y-uy*size
+	half := size * 0.45
+	return []point{tip, {bx - uy*half, by + ux*half}, {bx + uy*half, by - ux*half}}
+}
+
+// shorten 将线段终点向起点方向缩短 d
+func shorten(from, to point, d float64) point {
+	length := math.Hypot(to.x-from.x, to.y-from.y)
+	if length <= d {
+		return to
+	}
+	t := (length - d) / length
+	return point{from.x + (to.x-from.x)*t, from.y + (to.y-from.y)*t}
+}
+
+// ellipse 椭圆轮廓
+func ellipse(center point, rx, ry float64) []point {
+	const n = 32
+	pts := make([]point, n)
+	for i := range pts {
+		a := 2 * math.Pi * float64(i) / n
+		pts[i] = point{center.x + rx*math.Cos(a), center.y + ry*math.Sin(a)}
+	}
+	return pts
+}
+
+// roundedRect 圆角矩形轮廓
+func roundedRect(x0, y0, x1, y1, r float64) []point {
+	r = math.Min(r, math.Min(x1-x0, y1-y0)/2)
+	corners := []struct {
+		cx, cy, start float64
+	}{
+		{x1 - r, y0 + r, -math.Pi / 2},
+		{x1 - r, y1 - r, 0},
+		{x0 + r, y1 - r, math.Pi / 2},
+		{x0 + r, y0 + r, math.Pi},
+	}
+	const steps = 8
+	var pts []point
+	for _, corner := range corners {
+		for i := 0; i <= steps; i++ {
+			a := corner.start + math.Pi/2*float64(i)/steps
+			pts = append(pts, point{corner.cx + r*math.Cos(a), corner.cy + r*math.Sin(a)})
+		}
+	}
+	return pts
+}
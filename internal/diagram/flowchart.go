@@ -0,0 +1,343 @@
+package diagram
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// nodeShape 流程图节点形状
+type nodeShape int
+
+const (
+	shapeRect    nodeShape = iota // A[文本]（以及 [[ ]]、[( )]、[/ /] 等按矩形绘制的形状）
+	shapeRound                    // A(文本)
+	shapeStadium                  // A([文本])
+	shapeCircle                   // A((文本))
+	shapeDiamond                  // A{文本}
+	shapeHexagon                  // A{{文本}}
+)
+
+// edgeStyle 连线样式
+type edgeStyle int
+
+const (
+	edgeSolid  edgeStyle = iota // -->
+	edgeDotted                  // -.->
+	edgeThick                   // ==>
+)
+
+// flowNode 流程图节点
+type flowNode struct {
+	id    string
+	label string
+	shape nodeShape
+}
+
+// flowEdge 流程图连线
+type flowEdge struct {
+	from, to  *flowNode
+	label     string
+	style     edgeStyle
+	arrow     bool // 终点有箭头
+	arrowBack bool // 起点有箭头（<-->）
+}
+
+// flowchart 解析后的 mermaid 流程图
+type flowchart struct {
+	direction string // TB、BT、LR、RL
+	nodes     []*flowNode
+	edges     []*flowEdge
+	index     map[string]*flowNode
+}
+
+var (
+	// flowchartHeader 流程图声明：graph TD / flowchart LR
+	flowchartHeader = regexp.MustCompile(`^(?:graph|flowchart)(?:\s+(TD|TB|BT|LR|RL))?\s*;?\s*(.*)$`)
+
+	// labeledEdgePattern 文字写在中间的连线：A -- 文本 --> B、A -. 文本 .-> B、A == 文本 ==> B
+	labeledEdgePattern = regexp.MustCompile(`^(<?)(--|==|-\.)\s+(.+?)\s*(-{2,}>|-{3,}|={2,}>|={3,}|\.-+>|\.-+)`)
+
+	// edgePattern 连线，可带 |文本|：A --> B、A --- B、A -.-> B、A ==> B、A <--> B、A -->|文本| B
+	edgePattern = regexp.MustCompile(`^(<?)(-{2,}>|-{3,}|={2,}>|={3,}|-\.+->|-\.+-|--[ox]|==[ox])(?:\s*\|([^|]*)\|)?`)
+
+	// lineBreakPattern 标签中的换行
+	lineBreakPattern = regexp.MustCompile(`(?i)<br\s*/?>`)
+)
+
+// ignoredStatements 不影响布局的语句（样式、交互、子图边界等）
+var ignoredStatements = map[string]bool{
+	"classDef": true, "class": true, "style": true, "linkStyle": true, "click": true,
+	"subgraph": true, "end": true, "direction": true, "accTitle": true, "accDescr": true,
+}
+
+// nodeShapes 节点形状的定界符，按匹配优先级排列
+var nodeShapes = []struct {
+	open, close string
+	shape       nodeShape
+}{
+	{"((", "))", shapeCircle},
+	{"([", "])", shapeStadium},
+	{"[[", "]]", shapeRect},
+	{"[(", ")]", shapeRect},
+	{"[/", "/]", shapeRect},
+	{"[\\", "\\]", shapeRect},
+	{"{{", "}}", shapeHexagon},
+	{"[", "]", shapeRect},
+	{"(", ")", shapeRound},
+	{"{", "}", shapeDiamond},
+	{">", "]", shapeRect},
+}
+
+// parseFlowchart 解析 mermaid 流程图，不是流程图时返回 ErrUnsupported
+func parseFlowchart(source string) (*flowchart, error) {
+	fc := &flowchart{direction: "TB", index: make(map[string]*flowNode)}
+
+	header := true
+	for n, line := range strings.Split(source, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "%%") {
+			continue
+		}
+		if header {
+			m := flowchartHeader.FindStringSubmatch(line)
+			if m == nil {
+				return nil, fmt.Errorf("mermaid %q: %w", strings.Fields(line)[0], ErrUnsupported)
+			}
+			if m[1] != "" {
+				fc.direction = m[1]
+			}
+			if fc.direction == "TD" {
+				fc.direction = "TB"
+			}
+			header = false
+			line = m[2]
+		}
+
+		for _, stmt := range splitStatements(line) {
+			if err := fc.parseStatement(stmt); err != nil {
+				return nil, fmt.Errorf("line %d: %w", n+1, err)
+			}
+		}
+	}
+	if header {
+		return nil, fmt.Errorf("empty mermaid diagram: %w", ErrUnsupported)
+	}
+	if len(fc.nodes) == 0 {
+		return nil, fmt.Errorf("flowchart has no nodes")
+	}
+	return fc, nil
+}
+
+// splitStatements 按分号拆分语句（忽略引号和括号内的分号）
+func splitStatements(line string) []string {
+	var stmts []string
+	depth, quoted, start := 0, false, 0
+	for i, r := range line {
+		switch {
+		case r == '"':
+			quoted = !quoted
+		case quoted:
+		case strings.ContainsRune("[({", r):
+			depth++
+		case strings.ContainsRune("])}", r) && depth > 0:
+			depth--
+		case r == ';' && depth == 0:
+			stmts = append(stmts, line[start:i])
+			start = i + 1
+		}
+	}
+	stmts = append(stmts, line[start:])
+
+	out := stmts[:0]
+	for _, s := range stmts {
+		if s = strings.TrimSpace(s); s != "" {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// parseStatement 解析一条语句：节点定义或连线链 A --> B & C -.-> D
+func (fc *flowchart) parseStatement(stmt string) error {
+	if keyword := strings.Fields(stmt)[0]; ignoredStatements[keyword] {
+		return nil
+	}
+
+	p := &statementParser{src: stmt}
+	from, err := fc.parseNodeGroup(p)
+	if err != nil {
+		return err
+	}
+	for {
+		p.skipSpace()
+		if p.done() {
+			return nil
+		}
+		edge, ok := p.parseEdge()
+		if !ok {
+			return fmt.Errorf("unexpected %q", p.rest())
+		}
+		to, err := fc.parseNodeGroup(p)
+		if err != nil {
+			return err
+		}
+		for _, a := range from {
+			for _, b := range to {
+				e := edge
+				e.from, e.to = a, b
+				fc.edges = append(fc.edges, &e)
+			}
+		}
+		from = to
+	}
+}
+
+// parseNodeGroup 解析以 & 连接的一组节点
+func (fc *flowchart) parseNodeGroup(p *statementParser) ([]*flowNode, error) {
+	var nodes []*flowNode
+	for {
+		p.skipSpace()
+		node, err := fc.parseNode(p)
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, node)
+
+		p.skipSpace()
+		if !p.consume("&") {
+			return nodes, nil
+		}
+	}
+}
+
+// parseNode 解析节点：id 后可跟形状和文本，以及 :::class 后缀
+func (fc *flowchart) parseNode(p *statementParser) (*flowNode, error) {
+	start := p.pos
+	for !p.done() {
+		r := p.peekRune()
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' {
+			break
+		}
+		p.pos += len(string(r))
+	}
+	id := p.src[start:p.pos]
+	if id == "" {
+		return nil, fmt.Errorf("expected node at %q", p.rest())
+	}
+
+	node := fc.index[id]
+	if node == nil {
+		node = &flowNode{id: id, label: id}
+		fc.index[id] = node
+		fc.nodes = append(fc.nodes, node)
+	}
+
+	for _, s := range nodeShapes {
+		if !p.consume(s.open) {
+			continue
+		}
+		label, err := p.parseLabel(s.close)
+		if err != nil {
+			return nil, fmt.Errorf("node %s: %w", id, err)
+		}
+		node.label, node.shape = label, s.shape
+		break
+	}
+
+	// 样式类后缀 A:::warning
+	if p.consume(":::") {
+		for !p.done() && !unicode.IsSpace(p.peekRune()) && p.peekRune() != '&' {
+			p.pos++
+		}
+	}
+	return node, nil
+}
+
+// statementParser 语句扫描器
+type statementParser struct {
+	src string
+	pos int
+}
+
+func (p *statementParser) done() bool     { return p.pos >= len(p.src) }
+func (p *statementParser) rest() string   { return p.src[p.pos:] }
+func (p *statementParser) peekRune() rune { return []rune(p.src[p.pos:])[0] }
+
+func (p *statementParser) skipSpace() {
+	for !p.done() && (p.src[p.pos] == ' ' || p.src[p.pos] == '\t') {
+		p.pos++
+	}
+}
+
+func (p *statementParser) consume(s string) bool {
+	if strings.HasPrefix(p.src[p.pos:], s) {
+		p.pos += len(s)
+		return true
+	}
+	return false
+}
+
+// parseLabel 读取节点文本直到结束定界符，支持 "带引号的文本"
+func (p *statementParser) parseLabel(close string) (string, error) {
+	var label string
+	if p.consume(`"`) {
+		end := strings.IndexByte(p.rest(), '"')
+		if end < 0 {
+			return "", fmt.Errorf("unterminated string")
+		}
+		label = p.src[p.pos : p.pos+end]
+		p.pos += end + 1
+		if !p.consume(close) {
+			return "", fmt.Errorf("missing %q", close)
+		}
+	} else {
+		end := strings.Index(p.rest(), close)
+		if end < 0 {
+			return "", fmt.Errorf("missing %q", close)
+		}
+		label = p.src[p.pos : p.pos+end]
+		p.pos += end + len(close)
+	}
+	return cleanLabel(label), nil
+}
+
+// parseEdge 解析连线
+func (p *statementParser) parseEdge() (flowEdge, bool) {
+	var e flowEdge
+	var op string
+	if m := labeledEdgePattern.FindStringSubmatch(p.rest()); m != nil {
+		e.arrowBack = m[1] != ""
+		e.label = cleanLabel(m[3])
+		op = m[2] + m[4]
+		p.pos += len(m[0])
+	} else if m := edgePattern.FindStringSubmatch(p.rest()); m != nil {
+		e.arrowBack = m[1] != ""
+		e.label = cleanLabel(m[3])
+		op = m[2]
+		p.pos += len(m[0])
+	} else {
+		return e, false
+	}
+
+	switch {
+	case strings.Contains(op, "="):
+		e.style = edgeThick
+	case strings.Contains(op, "."):
+		e.style = edgeDotted
+	}
+	last := op[len(op)-1]
+	e.arrow = last == '>' || last == 'o' || last == 'x'
+	return e, true
+}
+
+// cleanLabel 处理标签中的换行和多余空白
+func cleanLabel(s string) string {
+	s = lineBreakPattern.ReplaceAllString(s, "\n")
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		lines[i] = strings.Join(strings.Fields(line), " ")
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
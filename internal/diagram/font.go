@@ -0,0 +1,158 @@
+package diagram
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"unicode"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/sfnt"
+	"golang.org/x/image/math/fixed"
+	"golang.org/x/image/vector"
+)
+
+// cjkFontPaths 常见系统中文字体，Go 字体缺少的字符（如中文）依次在这些字体中查找
+var cjkFontPaths = []string{
+	"/System/Library/Fonts/PingFang.ttc",
+	"/System/Library/Fonts/STHeiti Light.ttc",
+	"/System/Library/Fonts/Hiragino Sans GB.ttc",
+	`C:\Windows\Fonts\msyh.ttc`,
+	`C:\Windows\Fonts\simhei.ttf`,
+	"/usr/share/fonts/opentype/noto/NotoSansCJK-Regular.ttc",
+	"/usr/share/fonts/noto-cjk/NotoSansCJK-Regular.ttc",
+	"/usr/share/fonts/truetype/wqy/wqy-microhei.ttc",
+	"/usr/share/fonts/wqy-microhei/wqy-microhei.ttc",
+}
+
+var (
+	goFontOnce sync.Once
+	goFont     *sfnt.Font
+
+	systemFontsOnce sync.Once
+	systemFonts     []*sfnt.Font
+)
+
+// fontSet 按顺序查找字符的字体列表：配置的字体、Go 字体、系统中文字体
+type fontSet struct {
+	fonts   []*sfnt.Font
+	buf     sfnt.Buffer
+	loaded  bool // 是否已加入系统字体
+	missing []rune
+}
+
+// newFontSet 创建字体列表，path 为配置的字体文件（可为空）
+func newFontSet(path string) (*fontSet, error) {
+	goFontOnce.Do(func() {
+		goFont, _ = sfnt.Parse(goregular.TTF)
+	})
+
+	fs := &fontSet{}
+	if path != "" {
+		f, err := loadFont(path)
+		if err != nil {
+			return nil, err
+		}
+		fs.fonts = append(fs.fonts, f)
+	}
+	fs.fonts = append(fs.fonts, goFont)
+	return fs, nil
+}
+
+// loadFont 加载 TTF/OTF 字体或 TTC 字体集中的第一个字体
+func loadFont(path string) (*sfnt.Font, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read font: %w", err)
+	}
+	if strings.HasPrefix(string(data), "ttcf") {
+		c, err := sfnt.ParseCollection(data)
+		if err != nil {
+			return nil, fmt.Errorf("parse font %s: %w", path, err)
+		}
+		return c.Font(0)
+	}
+	f, err := sfnt.Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("parse font %s: %w", path, err)
+	}
+	return f, nil
+}
+
+// glyph 查找字符所在的字体和字形，找不到时使用 Go 字体的缺字符号
+func (fs *fontSet) glyph(r rune) (*sfnt.Font, sfnt.GlyphIndex) {
+	for {
+		for _, f := range fs.fonts {
+			if i, err := f.GlyphIndex(&fs.buf, r); err == nil && i != 0 {
+				return f, i
+			}
+		}
+		if fs.loaded {
+			if !unicode.IsSpace(r) {
+				fs.missing = append(fs.missing, r)
+			}
+			return goFont, 0
+		}
+		fs.loaded = true
+		systemFontsOnce.Do(func() {
+			for _, path := range cjkFontPaths {
+				if f, err := loadFont(path); err == nil {
+					systemFonts = append(systemFonts, f)
+				}
+			}
+		})
+		fs.fonts = append(fs.fonts, systemFonts...)
+	}
+}
+
+// measure 测量文字宽度（像素）
+func (fs *fontSet) measure(s string, size float64) float64 {
+	ppem := fixed.Int26_6(size * 64)
+	var w fixed.Int26_6
+	for _, r := range s {
+		f, i := fs.glyph(r)
+		if adv, err := f.GlyphAdvance(&fs.buf, i, ppem, font.HintingNone); err == nil {
+			w += adv
+		}
+	}
+	return float64(w) / 64
+}
+
+// drawText 将文字轮廓加入光栅器，(x, y) 为基线起点（像素）
+func (fs *fontSet) drawText(z *vector.Rasterizer, s string, x, y, size float64) {
+	ppem := fixed.Int26_6(size * 64)
+	for _, r := range s {
+		f, i := fs.glyph(r)
+		segments, err := f.LoadGlyph(&fs.buf, i, ppem, nil)
+		if err != nil {
+			continue
+		}
+		pt := func(p fixed.Point26_6) (float32, float32) {
+			return float32(x + float64(p.X)/64), float32(y + float64(p.Y)/64)
+		}
+		for _, seg := range segments {
+			switch seg.Op {
+			case sfnt.SegmentOpMoveTo:
+				z.ClosePath()
+				z.MoveTo(pt(seg.Args[0]))
+			case sfnt.SegmentOpLineTo:
+				z.LineTo(pt(seg.Args[0]))
+			case sfnt.SegmentOpQuadTo:
+				ax, ay := pt(seg.Args[0])
+				bx, by := pt(seg.Args[1])
+				z.QuadTo(ax, ay, bx, by)
+			case sfnt.SegmentOpCubeTo:
+				ax, ay := pt(seg.Args[0])
+				bx, by := pt(seg.Args[1])
+				cx, cy := pt(seg.Args[2])
+				z.CubeTo(ax, ay, bx, by, cx, cy)
+			}
+		}
+		z.ClosePath()
+		if adv, err := f.GlyphAdvance(&fs.buf, i, ppem, font.HintingNone); err == nil {
+			x += float64(adv) / 64
+		}
+	}
+}
@@ -0,0 +1,406 @@
+package diagram
+
+import (
+	"math"
+	"sort"
+	"strings"
+)
+
+// 布局尺寸（CSS 像素，绘制时乘以 renderScale）
+const (
+	fontSize     = 14.0
+	lineHeight   = fontSize * 1.5
+	nodePaddingX = 16.0
+	nodePaddingY = 10.0
+	nodeGap      = 40.0 // 同一层节点之间的间距
+	rankGap      = 50.0 // 相邻两层之间的间距
+	dummySize    = 10.0 // 跨层连线经过的虚拟节点的宽度
+	labelPadding = 4.0
+	canvasMargin = 16.0
+)
+
+// point 坐标
+type point struct{ x, y float64 }
+
+// layoutNode 布局中的节点；node 为 nil 时是跨层连线经过的虚拟节点
+type layoutNode struct {
+	node  *flowNode
+	lines []string
+	w, h  float64 // 节点尺寸
+	x, y  float64 // 中心坐标
+	rank  int
+	order float64
+	ins   []*layoutNode // 上一层的相邻节点
+	outs  []*layoutNode // 下一层的相邻节点
+}
+
+// layoutEdge 布局后的连线
+type layoutEdge struct {
+	edge   *flowEdge
+	points []point // 折线（已裁剪到节点边框）
+	label  []string
+	labelW float64
+	labelH float64
+	labelP point // 标签中心
+}
+
+// flowLayout 布局结果
+type flowLayout struct {
+	nodes  []*layoutNode // 仅真实节点
+	edges  []*layoutEdge
+	width  float64
+	height float64
+}
+
+// measureFunc 测量一行文字的宽度
+type measureFunc func(s string) float64
+
+// layoutFlowchart 分层布局：去环、最长路径分层、重心法排序、逐层居中
+func layoutFlowchart(fc *flowchart, measure measureFunc) *flowLayout {
+	horizontal := fc.direction == "LR" || fc.direction == "RL"
+
+	// 节点尺寸
+	byNode := make(map[*flowNode]*layoutNode, len(fc.nodes))
+	var nodes []*layoutNode
+	for _, n := range fc.nodes {
+		ln := &layoutNode{node: n, lines: strings.Split(n.label, "\n")}
+		ln.w, ln.h = nodeSize(n.shape, ln.lines, measure)
+		byNode[n] = ln
+		nodes = append(nodes, ln)
+	}
+
+	// 去环：DFS 中指向栈内节点的边反向参与分层
+	reversed := findBackEdges(fc)
+	rankEdges := func(e *flowEdge) (*layoutNode, *layoutNode) {
+		if reversed[e] {
+			return byNode[e.to], byNode[e.from]
+		}
+		return byNode[e.from], byNode[e.to]
+	}
+
+	// 最长路径分层
+	for changed := true; changed; {
+		changed = false
+		for _, e := range fc.edges {
+			if e.from == e.to {
+				continue
+			}
+			from, to := rankEdges(e)
+			if to.rank < from.rank+1 {
+				to.rank = from.rank + 1
+				changed = true
+			}
+		}
+	}
+
+	// 连线经过的节点链（跨层时插入虚拟节点）
+	all := append([]*layoutNode(nil), nodes...)
+	chains := make(map[*flowEdge][]*layoutNode, len(fc.edges))
+	for _, e := range fc.edges {
+		if e.from == e.to {
+			continue
+		}
+		from, to := rankEdges(e)
+		chain := []*layoutNode{from}
+		for r := from.rank + 1; r < to.rank; r++ {
+			dummy := &layoutNode{rank: r, w: dummySize, h: dummySize}
+			all = append(all, dummy)
+			chain = append(chain, dummy)
+		}
+		chain = append(chain, to)
+		for i := 1; i < len(chain); i++ {
+			chain[i-1].outs = append(chain[i-1].outs, chain[i])
+			chain[i].ins = append(chain[i].ins, chain[i-1])
+		}
+		chains[e] = chain
+	}
+
+	layers := buildLayers(all)
+	orderLayers(layers)
+	placeLayers(layers, horizontal, fc.direction == "BT" || fc.direction == "RL")
+
+	layout := &flowLayout{nodes: nodes}
+	for _, e := range fc.edges {
+		le := &layoutEdge{edge: e}
+		if e.label != "" {
+			le.label = strings.Split(e.label, "\n")
+			le.labelW, le.labelH = textSize(le.label, measure)
+			le.labelW += 2 * labelPadding
+			le.labelH += labelPadding
+		}
+		if e.from == e.to {
+			le.points = selfLoop(byNode[e.from], horizontal)
+		} else {
+			le.points = edgePoints(chains[e], reversed[e])
+		}
+		le.labelP = midpoint(le.points)
+		layout.edges = append(layout.edges, le)
+	}
+
+	layout.normalize()
+	return layout
+}
+
+// nodeSize 根据文字和形状计算节点尺寸
+func nodeSize(shape nodeShape, lines []string, measure measureFunc) (float64, float64) {
+	tw, th := textSize(lines, measure)
+	w, h := tw+2*nodePaddingX, th+2*nodePaddingY
+	switch shape {
+	case shapeDiamond:
+		// 文字放在菱形内接矩形中
+		w, h = tw*1.5+2*nodePaddingY, th*1.5+2*nodePaddingY
+		h = math.Max(h, w*0.45)
+	case shapeCircle:
+		d := math.Hypot(tw, th) + nodePaddingY
+		w, h = d, d
+	case shapeHexagon:
+		w += h / 2
+	case shapeStadium, shapeRound:
+		w += h / 4
+	}
+	return w, h
+}
+
+// textSize 多行文字的尺寸
+func textSize(lines []string, measure measureFunc) (float64, float64) {
+	var w float64
+	for _, line := range lines {
+		w = math.Max(w, measure(line))
+	}
+	return w, float64(len(lines)) * lineHeight
+}
+
+// findBackEdges 按节点声明顺序做 DFS，返回构成环的边
+func findBackEdges(fc *flowchart) map[*flowEdge]bool {
+	outs := make(map[*flowNode][]*flowEdge)
+	for _, e := range fc.edges {
+		if e.from != e.to {
+			outs[e.from] = append(outs[e.from], e)
+		}
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make(map[*flowNode]int)
+	back := make(map[*flowEdge]bool)
+	var visit func(n *flowNode)
+	visit = func(n *flowNode) {
+		state[n] = visiting
+		for _, e := range outs[n] {
+			switch state[e.to] {
+			case visiting:
+				back[e] = true
+			case unvisited:
+				visit(e.to)
+			}
+		}
+		state[n] = visited
+	}
+	for _, n := range fc.nodes {
+		if state[n] == unvisited {
+			visit(n)
+		}
+	}
+	return back
+}
+
+// buildLayers 按层分组（保持加入顺序）
+func buildLayers(nodes []*layoutNode) [][]*layoutNode {
+	var layers [][]*layoutNode
+	for _, n := range nodes {
+		for len(layers) <= n.rank {
+			layers = append(layers, nil)
+		}
+		layers[n.rank] = append(layers[n.rank], n)
+	}
+	for _, layer := range layers {
+		for i, n := range layer {
+			n.order = float64(i)
+		}
+	}
+	return layers
+}
+
+// orderLayers 重心法减少交叉：上下交替扫描，按相邻层节点位置的平均值排序
+func orderLayers(layers [][]*layoutNode) {
+	barycenter := func(n *layoutNode, neighbors []*layoutNode) float64 {
+		if len(neighbors) == 0 {
+			return n.order
+		}
+		var sum float64
+		for _, m := range neighbors {
+			sum += m.order
+		}
+		return sum / float64(len(neighbors))
+	}
+	sortLayer := func(layer []*layoutNode, key func(*layoutNode) float64) {
+		keys := make(map[*layoutNode]float64, len(layer))
+		for _, n := range layer {
+			keys[n] = key(n)
+		}
+		sort.SliceStable(layer, func(i, j int) bool { return keys[layer[i]] < keys[layer[j]] })
+		for i, n := range layer {
+			n.order = float64(i)
+		}
+	}
+
+	for iter := 0; iter < 4; iter++ {
+		for r := 1; r < len(layers); r++ {
+			sortLayer(layers[r], func(n *layoutNode) float64 { return barycenter(n, n.ins) })
+		}
+		for r := len(layers) - 2; r >= 0; r-- {
+			sortLayer(layers[r], func(n *layoutNode) float64 { return barycenter(n, n.outs) })
+		}
+	}
+}
+
+// placeLayers 计算坐标：每层沿交叉方向居中排列，层与层沿主方向依次排列
+func placeLayers(layers [][]*layoutNode, horizontal, flip bool) {
+	// 主方向为层的排列方向，交叉方向为同层节点的排列方向
+	main := func(n *layoutNode) float64 { return n.h }
+	cross := func(n *layoutNode) float64 { return n.w }
+	if horizontal {
+		main, cross = cross, main
+	}
+
+	offset := 0.0
+	for r, layer := range layers {
+		var size, total float64
+		for _, n := range layer {
+			size = math.Max(size, main(n))
+			total += cross(n)
+		}
+		total += nodeGap * float64(len(layer)-1)
+
+		c := -total / 2
+		for _, n := range layer {
+			pos, depth := c+cross(n)/2, offset+size/2
+			if flip {
+				depth = -depth
+			}
+			if horizontal {
+				n.x, n.y = depth, pos
+			} else {
+				n.x, n.y = pos, depth
+			}
+			c += cross(n) + nodeGap
+		}
+		offset += size
+		if r < len(layers)-1 {
+			offset += rankGap
+		}
+	}
+}
+
+// edgePoints 连线折线：依次经过节点链的中心，两端裁剪到节点边框
+func edgePoints(chain []*layoutNode, reversed bool) []point {
+	pts := make([]point, len(chain))
+	for i, n := range chain {
+		pts[i] = point{n.x, n.y}
+	}
+	pts[0] = clipToNode(chain[0], pts[1])
+	pts[len(pts)-1] = clipToNode(chain[len(chain)-1], pts[len(pts)-2])
+	if reversed {
+		for i, j := 0, len(pts)-1; i < j; i, j = i+1, j-1 {
+			pts[i], pts[j] = pts[j], pts[i]
+		}
+	}
+	return pts
+}
+
+// clipToNode 从节点中心指向 toward 的射线与节点边框的交点
+func clipToNode(n *layoutNode, toward point) point {
+	dx, dy := toward.x-n.x, toward.y-n.y
+	if dx == 0 && dy == 0 {
+		return point{n.x, n.y}
+	}
+	hw, hh := n.w/2, n.h/2
+	var t float64
+	switch {
+	case n.node == nil:
+		return point{n.x, n.y}
+	case n.node.shape == shapeDiamond:
+		t = 1 / (math.Abs(dx)/hw + math.Abs(dy)/hh)
+	case n.node.shape == shapeCircle:
+		t = hw / math.Hypot(dx, dy)
+	default:
+		t = math.Min(safeDiv(hw, math.Abs(dx)), safeDiv(hh, math.Abs(dy)))
+	}
+	return point{n.x + dx*t, n.y + dy*t}
+}
+
+func safeDiv(a, b float64) float64 {
+	if b == 0 {
+		return math.Inf(1)
+	}
+	return a / b
+}
+
+// selfLoop 指向自身的连线，画在节点右侧（横向布局时在下方）
+func selfLoop(n *layoutNode, horizontal bool) []point {
+	const out = 20.0
+	if horizontal {
+		y := n.y + n.h/2
+		return []point{{n.x - n.w/4, y}, {n.x - n.w/4, y + out}, {n.x + n.w/4, y + out}, {n.x + n.w/4, y}}
+	}
+	x := n.x + n.w/2
+	return []point{{x, n.y - n.h/4}, {x + out, n.y - n.h/4}, {x + out, n.y + n.h/4}, {x, n.y + n.h/4}}
+}
+
+// midpoint 折线按长度的中点
+func midpoint(pts []point) point {
+	var total float64
+	for i := 1; i < len(pts); i++ {
+		total += math.Hypot(pts[i].x-pts[i-1].x, pts[i].y-pts[i-1].y)
+	}
+	half := total / 2
+	for i := 1; i < len(pts); i++ {
+		seg := math.Hypot(pts[i].x-pts[i-1].x, pts[i].y-pts[i-1].y)
+		if seg >= half && seg > 0 {
+			t := half / seg
+			return point{pts[i-1].x + (pts[i].x-pts[i-1].x)*t, pts[i-1].y + (pts[i].y-pts[i-1].y)*t}
+		}
+		half -= seg
+	}
+	return pts[0]
+}
+
+// normalize 平移坐标使内容从画布边距开始，并计算画布尺寸
+func (l *flowLayout) normalize() {
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	extend := func(x0, y0, x1, y1 float64) {
+		minX, minY = math.Min(minX, x0), math.Min(minY, y0)
+		maxX, maxY = math.Max(maxX, x1), math.Max(maxY, y1)
+	}
+	for _, n := range l.nodes {
+		extend(n.x-n.w/2, n.y-n.h/2, n.x+n.w/2, n.y+n.h/2)
+	}
+	for _, e := range l.edges {
+		for _, p := range e.points {
+			extend(p.x, p.y, p.x, p.y)
+		}
+		if e.label != nil {
+			extend(e.labelP.x-e.labelW/2, e.labelP.y-e.labelH/2, e.labelP.x+e.labelW/2, e.labelP.y+e.labelH/2)
+		}
+	}
+
+	dx, dy := canvasMargin-minX, canvasMargin-minY
+	for _, n := range l.nodes {
+		n.x += dx
+		n.y += dy
+	}
+	for _, e := range l.edges {
+		for i := range e.points {
+			e.points[i].x += dx
+			e.points[i].y += dy
+		}
+		e.labelP.x += dx
+		e.labelP.y += dy
+	}
+	l.width = maxX - minX + 2*canvasMargin
+	l.height = maxY - minY + 2*canvasMargin
+}
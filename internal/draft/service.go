@@ -1,14 +1,16 @@
 package draft
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
-	"regexp"
 	"strings"
+	"time"
 
 	"github.com/geekjourneyx/md2wechat-skill/internal/config"
+	"github.com/geekjourneyx/md2wechat-skill/internal/converter"
 	"github.com/geekjourneyx/md2wechat-skill/internal/wechat"
 	"github.com/silenceper/wechat/v2/officialaccount/draft"
 	"go.uber.org/zap"
@@ -30,6 +32,9 @@ func NewService(cfg *config.Config, log *zap.Logger) *Service {
 	}
 }
 
+// MaxArticlesPerDraft 单个草稿（多图文）最多包含的文章数
+const MaxArticlesPerDraft = 8
+
 // ArticleType 文章类型
 type ArticleType string
 
@@ -62,6 +67,9 @@ type Article struct {
 	ContentSourceURL string `json:"content_source_url,omitempty"`
 	ThumbMediaID     string `json:"thumb_media_id,omitempty"`
 	ShowCoverPic     int    `json:"show_cover_pic,omitempty"`
+	PicCrop235_1     string `json:"pic_crop_235_1,omitempty"` // 2.35:1 封面裁剪坐标
+	PicCrop1_1       string `json:"pic_crop_1_1,omitempty"`   // 1:1 封面裁剪坐标
+	URL              string `json:"url,omitempty"`            // 草稿预览链接（只读）
 
 	// 小绿书/图片消息专用字段
 	ArticleType        ArticleType `json:"article_type,omitempty"`
@@ -114,6 +122,9 @@ func (s *Service) CreateDraftFromFile(jsonFile string) (*DraftResult, error) {
 	if len(req.Articles) == 0 {
 		return nil, fmt.Errorf("no articles in request")
 	}
+	if len(req.Articles) > MaxArticlesPerDraft {
+		return nil, fmt.Errorf("too many articles: %d (max %d per draft)", len(req.Articles), MaxArticlesPerDraft)
+	}
 
 	// 转换为 SDK 格式
 	var articles []*draft.Article
@@ -125,23 +136,7 @@ func (s *Service) CreateDraftFromFile(jsonFile string) (*DraftResult, error) {
 			return nil, fmt.Errorf("article %d: content is required", i)
 		}
 
-		article := &draft.Article{
-			Title:   a.Title,
-			Content: a.Content,
-			Digest:  a.Digest,
-			Author:  a.Author,
-		}
-
-		if a.ThumbMediaID != "" {
-			article.ThumbMediaID = a.ThumbMediaID
-			article.ShowCoverPic = uint(a.ShowCoverPic)
-		}
-
-		if a.ContentSourceURL != "" {
-			article.ContentSourceURL = a.ContentSourceURL
-		}
-
-		articles = append(articles, article)
+		articles = append(articles, toSDKArticle(a))
 	}
 
 	// 调用微信 API
@@ -156,28 +151,19 @@ func (s *Service) CreateDraftFromFile(jsonFile string) (*DraftResult, error) {
 	}, nil
 }
 
-// CreateDraft 创建草稿
+// CreateDraft 创建草稿（多篇文章时按顺序组成多图文，第一篇为头条）
 func (s *Service) CreateDraft(articles []Article) (*DraftResult, error) {
+	if len(articles) == 0 {
+		return nil, fmt.Errorf("no articles in request")
+	}
+	if len(articles) > MaxArticlesPerDraft {
+		return nil, fmt.Errorf("too many articles: %d (max %d per draft)", len(articles), MaxArticlesPerDraft)
+	}
+
 	// 转换为 SDK 格式
 	var draftArticles []*draft.Article
 	for _, a := range articles {
-		article := &draft.Article{
-			Title:   a.Title,
-			Content: a.Content,
-			Digest:  a.Digest,
-			Author:  a.Author,
-		}
-
-		if a.ThumbMediaID != "" {
-			article.ThumbMediaID = a.ThumbMediaID
-			article.ShowCoverPic = uint(a.ShowCoverPic)
-		}
-
-		if a.ContentSourceURL != "" {
-			article.ContentSourceURL = a.ContentSourceURL
-		}
-
-		draftArticles = append(draftArticles, article)
+		draftArticles = append(draftArticles, toSDKArticle(a))
 	}
 
 	// 调用微信 API
@@ -192,6 +178,197 @@ func (s *Service) CreateDraft(articles []Article) (*DraftResult, error) {
 	}, nil
 }
 
+// toSDKArticle 转换为 SDK 文章格式
+func toSDKArticle(a Article) *draft.Article {
+	article := &draft.Article{
+		Title:   a.Title,
+		Content: a.Content,
+		Digest:  a.Digest,
+		Author:  a.Author,
+	}
+
+	if a.ThumbMediaID != "" {
+		article.ThumbMediaID = a.ThumbMediaID
+		article.ShowCoverPic = uint(a.ShowCoverPic)
+	}
+
+	if a.ContentSourceURL != "" {
+		article.ContentSourceURL = a.ContentSourceURL
+	}
+
+	article.NeedOpenComment = uint(a.NeedOpenComment)
+	article.OnlyFansCanComment = uint(a.OnlyFansCanComment)
+
+	return article
+}
+
+// fromSDKArticle 从 SDK 文章格式转换
+func fromSDKArticle(a *draft.Article) Article {
+	return Article{
+		Title:              a.Title,
+		Author:             a.Author,
+		Digest:             a.Digest,
+		Content:            a.Content,
+		ContentSourceURL:   a.ContentSourceURL,
+		ThumbMediaID:       a.ThumbMediaID,
+		ShowCoverPic:       int(a.ShowCoverPic),
+		NeedOpenComment:    int(a.NeedOpenComment),
+		OnlyFansCanComment: int(a.OnlyFansCanComment),
+	}
+}
+
+// fromDraftArticle 从草稿接口返回的文章转换，保留全部字段
+func fromDraftArticle(a *wechat.DraftArticle) Article {
+	article := Article{
+		Title:              a.Title,
+		Author:             a.Author,
+		Digest:             a.Digest,
+		Content:            a.Content,
+		ContentSourceURL:   a.ContentSourceURL,
+		ThumbMediaID:       a.ThumbMediaID,
+		ShowCoverPic:       int(a.ShowCoverPic),
+		PicCrop235_1:       a.PicCrop235_1,
+		PicCrop1_1:         a.PicCrop1_1,
+		URL:                a.URL,
+		ArticleType:        ArticleType(a.ArticleType),
+		NeedOpenComment:    int(a.NeedOpenComment),
+		OnlyFansCanComment: int(a.OnlyFansCanComment),
+	}
+	if a.ImageInfo != nil {
+		article.ImageInfo = &ImageInfo{}
+		for _, item := range a.ImageInfo.ImageList {
+			article.ImageInfo.ImageList = append(article.ImageInfo.ImageList, ImageItem{ImageMediaID: item.ImageMediaID})
+		}
+	}
+	return article
+}
+
+// toDraftArticle 转换为草稿接口的文章格式（fromDraftArticle 的逆过程）
+func toDraftArticle(a Article) *wechat.DraftArticle {
+	article := &wechat.DraftArticle{
+		Title:              a.Title,
+		Author:             a.Author,
+		Digest:             a.Digest,
+		Content:            a.Content,
+		ContentSourceURL:   a.ContentSourceURL,
+		ThumbMediaID:       a.ThumbMediaID,
+		ShowCoverPic:       uint(a.ShowCoverPic),
+		PicCrop235_1:       a.PicCrop235_1,
+		PicCrop1_1:         a.PicCrop1_1,
+		ArticleType:        string(a.ArticleType),
+		NeedOpenComment:    uint(a.NeedOpenComment),
+		OnlyFansCanComment: uint(a.OnlyFansCanComment),
+	}
+	if a.ImageInfo != nil {
+		article.ImageInfo = &wechat.NewspicImageInfo{}
+		for _, item := range a.ImageInfo.ImageList {
+			article.ImageInfo.ImageList = append(article.ImageInfo.ImageList, wechat.NewspicImageItem{ImageMediaID: item.ImageMediaID})
+		}
+	}
+	return article
+}
+
+// DraftItem 草稿列表项
+type DraftItem struct {
+	MediaID   string    `json:"media_id"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Articles  []Article `json:"articles"`
+}
+
+// DraftList 草稿列表
+type DraftList struct {
+	TotalCount int         `json:"total_count"`
+	ItemCount  int         `json:"item_count"`
+	Items      []DraftItem `json:"items"`
+}
+
+// ListDrafts 分页获取草稿列表（count 取值 1~20），withContent 为 false 时不返回正文
+func (s *Service) ListDrafts(offset, count int, withContent bool) (*DraftList, error) {
+	if offset < 0 {
+		return nil, fmt.Errorf("offset must be >= 0")
+	}
+	if count < 1 || count > 20 {
+		return nil, fmt.Errorf("count must be between 1 and 20")
+	}
+
+	list, err := s.ws.ListDrafts(int64(offset), int64(count), !withContent)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &DraftList{
+		TotalCount: int(list.TotalCount),
+		ItemCount:  int(list.ItemCount),
+		Items:      make([]DraftItem, 0, len(list.Item)),
+	}
+	for _, item := range list.Item {
+		draftItem := DraftItem{
+			MediaID:   item.MediaID,
+			UpdatedAt: time.Unix(item.UpdateTime, 0),
+			Articles:  make([]Article, 0, len(item.Content.NewsItem)),
+		}
+		for i := range item.Content.NewsItem {
+			draftItem.Articles = append(draftItem.Articles, fromSDKArticle(&item.Content.NewsItem[i]))
+		}
+		result.Items = append(result.Items, draftItem)
+	}
+	return result, nil
+}
+
+// GetDraft 获取草稿中的所有文章
+func (s *Service) GetDraft(mediaID string) ([]Article, error) {
+	if mediaID == "" {
+		return nil, fmt.Errorf("media_id is required")
+	}
+
+	draftArticles, err := s.ws.GetDraft(mediaID)
+	if err != nil {
+		return nil, err
+	}
+
+	articles := make([]Article, 0, len(draftArticles))
+	for _, a := range draftArticles {
+		articles = append(articles, fromDraftArticle(a))
+	}
+	return articles, nil
+}
+
+// UpdateDraft 用 article 整体替换草稿中第 index 篇文章（从 0 开始）
+// article 通常来自 GetDraft，未修改的字段（封面裁剪等）原样提交
+func (s *Service) UpdateDraft(mediaID string, index int, article Article) error {
+	if mediaID == "" {
+		return fmt.Errorf("media_id is required")
+	}
+	if index < 0 {
+		return fmt.Errorf("index must be >= 0")
+	}
+	if article.Title == "" {
+		return fmt.Errorf("title is required")
+	}
+	if article.Content == "" {
+		return fmt.Errorf("content is required")
+	}
+
+	return s.ws.UpdateDraft(mediaID, uint(index), toDraftArticle(article))
+}
+
+// DeleteDraft 删除草稿（不可恢复）
+func (s *Service) DeleteDraft(mediaID string) error {
+	if mediaID == "" {
+		return fmt.Errorf("media_id is required")
+	}
+	return s.ws.DeleteDraft(mediaID)
+}
+
+// CountDrafts 获取草稿总数
+func (s *Service) CountDrafts() (int, error) {
+	total, err := s.ws.CountDraft()
+	if err != nil {
+		return 0, err
+	}
+	return int(total), nil
+}
+
 // GenerateDigestFromContent 从内容生成摘要
 func GenerateDigestFromContent(content string, maxLen int) string {
 	if maxLen == 0 {
@@ -202,11 +379,11 @@ func GenerateDigestFromContent(content string, maxLen int) string {
 	// 实际应该使用 HTML 解析器
 
 	// 移除 HTML 标签的简单方法
-	content = stripHTML(content)
+	content = strings.Join(strings.Fields(stripHTML(content)), " ")
 
-	// 截取
-	if len(content) > maxLen {
-		content = content[:maxLen] + "..."
+	// 按字符截取（避免截断多字节字符）
+	if runes := []rune(content); len(runes) > maxLen {
+		content = string(runes[:maxLen]) + "..."
 	}
 
 	return content
@@ -272,7 +449,7 @@ func (s *Service) CreateImagePost(req *ImagePostRequest) (*ImagePostResult, erro
 			zap.Int("total", len(images)),
 			zap.String("path", imgPath))
 
-		result, err := s.ws.UploadMaterialWithRetry(imgPath, 3)
+		result, err := s.ws.UploadMaterialWithRetry(context.Background(), imgPath, 3)
 		if err != nil {
 			return nil, fmt.Errorf("upload image %d (%s): %w", i+1, imgPath, err)
 		}
@@ -322,26 +499,12 @@ func extractImagesFromMarkdown(mdFile string) []string {
 		return nil
 	}
 
-	// 匹配 Markdown 图片语法: ![alt](path) 或 ![alt](path "title")
-	re := regexp.MustCompile(`!\[[^\]]*\]\(([^)"\s]+)(?:\s+"[^"]*")?\)`)
-	matches := re.FindAllStringSubmatch(string(content), -1)
-
-	// 获取 Markdown 文件所在目录
-	mdDir := filepath.Dir(mdFile)
-
+	// 解析图片引用，相对路径基于 Markdown 文件所在目录
 	var images []string
-	for _, match := range matches {
-		if len(match) > 1 {
-			imgPath := match[1]
-			// 跳过网络图片
-			if strings.HasPrefix(imgPath, "http://") || strings.HasPrefix(imgPath, "https://") {
-				continue
-			}
-			// 转换相对路径为绝对路径
-			if !filepath.IsAbs(imgPath) {
-				imgPath = filepath.Join(mdDir, imgPath)
-			}
-			images = append(images, imgPath)
+	for _, img := range converter.ParseImages(string(content), filepath.Dir(mdFile)) {
+		// 只收集本地图片，跳过网络图片和 AI 图片
+		if img.Type == converter.ImageTypeLocal {
+			images = append(images, img.Original)
 		}
 	}
 
@@ -0,0 +1,168 @@
+package draft
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/geekjourneyx/md2wechat-skill/internal/config"
+	"github.com/geekjourneyx/md2wechat-skill/internal/wechat"
+	"github.com/silenceper/wechat/v2/util"
+	"go.uber.org/zap"
+)
+
+// newTestWechat 启动模拟的微信接口，responses 为接口路径到响应内容的映射；
+// 返回连接到该接口的草稿服务和按路径记录的请求内容
+func newTestWechat(t *testing.T, responses map[string]string) (*Service, map[string]string) {
+	t.Helper()
+	requests := make(map[string]string)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/cgi-bin/token" {
+			_, _ = io.WriteString(w, `{"access_token":"test-token","expires_in":7200}`)
+			return
+		}
+		if r.URL.Query().Get("access_token") != "test-token" {
+			t.Errorf("%s: access_token = %q", r.URL.Path, r.URL.Query().Get("access_token"))
+		}
+		body, _ := io.ReadAll(r.Body)
+		requests[r.URL.Path] = string(body)
+		resp, ok := responses[r.URL.Path]
+		if !ok {
+			t.Errorf("unexpected request %s", r.URL.Path)
+			resp = `{"errcode":-1,"errmsg":"unexpected"}`
+		}
+		_, _ = io.WriteString(w, resp)
+	}))
+	util.SetURIModifier(func(uri string) string {
+		return strings.Replace(uri, "https://api.weixin.qq.com", srv.URL, 1)
+	})
+	t.Cleanup(func() {
+		util.SetURIModifier(nil)
+		srv.Close()
+	})
+	t.Setenv("MD2WECHAT_CACHE_DIR", t.TempDir())
+
+	cfg := &config.Config{WechatAppID: "wx-test", WechatSecret: "secret"}
+	return NewService(cfg, zap.NewNop()), requests
+}
+
+func TestService_GetAndUpdateDraft(t *testing.T) {
+	svc, requests := newTestWechat(t, map[string]string{
+		"/cgi-bin/draft/get": `{"news_item":[{"title":"旧标题","author":"作者","content":"<p>正文</p>",
+			"thumb_media_id":"thumb-1","show_cover_pic":1,"need_open_comment":1,
+			"pic_crop_235_1":"0.1_0_0.9_1","pic_crop_1_1":"0.2_0_0.8_1",
+			"url":"https://mp.weixin.qq.com/s/preview","thumb_url":"https://mmbiz.qpic.cn/cover"}]}`,
+		"/cgi-bin/draft/update": `{"errcode":0,"errmsg":"ok"}`,
+	})
+
+	articles, err := svc.GetDraft("media-1")
+	if err != nil {
+		t.Fatalf("GetDraft() error = %v", err)
+	}
+	if len(articles) != 1 {
+		t.Fatalf("GetDraft() = %+v", articles)
+	}
+	article := articles[0]
+	if article.Title != "旧标题" || article.PicCrop235_1 != "0.1_0_0.9_1" || article.URL != "https://mp.weixin.qq.com/s/preview" {
+		t.Errorf("GetDraft() = %+v", article)
+	}
+	if requests["/cgi-bin/draft/get"] != `{"media_id":"media-1"}`+"\n" {
+		t.Errorf("get request = %s", requests["/cgi-bin/draft/get"])
+	}
+
+	article.Title = "新标题"
+	if err := svc.UpdateDraft("media-1", 0, article); err != nil {
+		t.Fatalf("UpdateDraft() error = %v", err)
+	}
+	var req struct {
+		MediaID  string          `json:"media_id"`
+		Index    uint            `json:"index"`
+		Articles json.RawMessage `json:"articles"`
+	}
+	if err := json.Unmarshal([]byte(requests["/cgi-bin/draft/update"]), &req); err != nil {
+		t.Fatalf("update request = %s", requests["/cgi-bin/draft/update"])
+	}
+	// 未修改的字段原样提交，只读的链接不提交
+	want := `{"title":"新标题","author":"作者","content":"<p>正文</p>","thumb_media_id":"thumb-1","show_cover_pic":1,` +
+		`"need_open_comment":1,"only_fans_can_comment":0,"pic_crop_235_1":"0.1_0_0.9_1","pic_crop_1_1":"0.2_0_0.8_1"}`
+	if req.MediaID != "media-1" || req.Index != 0 || string(req.Articles) != want {
+		t.Errorf("update request = %s\nwant articles %s", requests["/cgi-bin/draft/update"], want)
+	}
+}
+
+func TestService_UpdateDraftValidation(t *testing.T) {
+	svc := NewService(&config.Config{}, zap.NewNop())
+	tests := []struct {
+		name    string
+		mediaID string
+		index   int
+		article Article
+		want    string
+	}{
+		{"no media id", "", 0, Article{Title: "t", Content: "c"}, "media_id is required"},
+		{"negative index", "m", -1, Article{Title: "t", Content: "c"}, "index must be >= 0"},
+		{"no title", "m", 0, Article{Content: "c"}, "title is required"},
+		{"no content", "m", 0, Article{Title: "t"}, "content is required"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := svc.UpdateDraft(tt.mediaID, tt.index, tt.article); err == nil || err.Error() != tt.want {
+				t.Errorf("UpdateDraft() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestService_ListDrafts(t *testing.T) {
+	svc, requests := newTestWechat(t, map[string]string{
+		"/cgi-bin/draft/batchget": `{"total_count":3,"item_count":1,"item":[{"media_id":"media-1","update_time":1700000000,
+			"content":{"news_item":[{"title":"第一篇","thumb_media_id":"thumb-1"},{"title":"第二篇"}]}}]}`,
+	})
+
+	list, err := svc.ListDrafts(2, 1, false)
+	if err != nil {
+		t.Fatalf("ListDrafts() error = %v", err)
+	}
+	if list.TotalCount != 3 || list.ItemCount != 1 || len(list.Items) != 1 {
+		t.Fatalf("ListDrafts() = %+v", list)
+	}
+	item := list.Items[0]
+	if item.MediaID != "media-1" || item.UpdatedAt.Unix() != 1700000000 || len(item.Articles) != 2 || item.Articles[1].Title != "第二篇" {
+		t.Errorf("item = %+v", item)
+	}
+	if got := requests["/cgi-bin/draft/batchget"]; got != `{"count":1,"offset":2,"no_content":true}`+"\n" {
+		t.Errorf("batchget request = %s", got)
+	}
+
+	for _, args := range [][2]int{{-1, 1}, {0, 0}, {0, 21}} {
+		if _, err := svc.ListDrafts(args[0], args[1], false); err == nil {
+			t.Errorf("ListDrafts(%d, %d) succeeded", args[0], args[1])
+		}
+	}
+}
+
+func TestService_DeleteAndCountDrafts(t *testing.T) {
+	svc, requests := newTestWechat(t, map[string]string{
+		"/cgi-bin/draft/delete": `{"errcode":40007,"errmsg":"invalid media_id"}`,
+		"/cgi-bin/draft/count":  `{"total_count":5}`,
+	})
+
+	err := svc.DeleteDraft("gone")
+	if !wechat.IsInvalidMediaID(err) {
+		t.Errorf("DeleteDraft() error = %v, want invalid media_id", err)
+	}
+	if got := requests["/cgi-bin/draft/delete"]; got != `{"media_id":"gone"}`+"\n" {
+		t.Errorf("delete request = %s", got)
+	}
+	if err := svc.DeleteDraft(""); err == nil {
+		t.Error("DeleteDraft(\"\") succeeded")
+	}
+
+	total, err := svc.CountDrafts()
+	if err != nil || total != 5 {
+		t.Errorf("CountDrafts() = %d, %v", total, err)
+	}
+}
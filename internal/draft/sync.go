@@ -0,0 +1,120 @@
+package draft
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/geekjourneyx/md2wechat-skill/internal/wechat"
+	"go.uber.org/zap"
+)
+
+// 同步动作
+const (
+	SyncActionCreated = "created"
+	SyncActionUpdated = "updated"
+	SyncActionSkipped = "skipped"
+)
+
+// DraftStore 同步使用的草稿接口，由 *Service 实现
+type DraftStore interface {
+	CreateDraft(articles []Article) (*DraftResult, error)
+	UpdateDraft(mediaID string, index int, article Article) error
+}
+
+// SyncArticle 待同步的文章
+type SyncArticle struct {
+	Key       string // 文章标识
+	File      string
+	Hash      string // 影响草稿内容的所有输入的哈希
+	CoverHash string
+}
+
+// SyncResult 单篇文章的同步结果
+type SyncResult struct {
+	File    string `json:"file"`
+	Key     string `json:"key"`
+	Action  string `json:"action"`
+	MediaID string `json:"media_id,omitempty"`
+	Title   string `json:"title,omitempty"`
+}
+
+// Syncer 根据同步记录决定每篇文章是新建、更新还是跳过草稿
+type Syncer struct {
+	State  *SyncState
+	Store  DraftStore
+	AppID  string
+	Force  bool // 内容未变化也更新
+	DryRun bool // 只返回将要执行的动作
+	Log    *zap.Logger
+}
+
+// Sync 同步一篇文章：首次新建草稿，内容变化时更新，未变化时跳过；
+// 记录的草稿已不存在（已删除或已发布）时新建。
+// prepare 只在需要新建或更新时调用，previous 为已有的同步记录（可能为 nil），用于复用未变化的封面
+func (s *Syncer) Sync(a SyncArticle, prepare func(previous *SyncEntry) (Article, error)) (*SyncResult, error) {
+	result := &SyncResult{File: a.File, Key: a.Key}
+	entry, exists := s.State.Get(s.AppID, a.Key)
+	if exists {
+		result.MediaID = entry.MediaID
+		result.Title = entry.Title
+	}
+
+	switch {
+	case exists && entry.Hash == a.Hash && !s.Force:
+		result.Action = SyncActionSkipped
+		s.Log.Info("article unchanged, skipped", zap.String("file", a.File), zap.String("key", a.Key))
+		return result, nil
+	case s.DryRun && exists:
+		result.Action = SyncActionUpdated
+		return result, nil
+	case s.DryRun:
+		result.Action = SyncActionCreated
+		return result, nil
+	}
+
+	article, err := prepare(entry)
+	if err != nil {
+		return nil, err
+	}
+
+	result.Action = SyncActionCreated
+	if exists {
+		err := s.Store.UpdateDraft(entry.MediaID, 0, article)
+		switch {
+		case err == nil:
+			result.Action = SyncActionUpdated
+		case wechat.IsInvalidMediaID(err):
+			s.Log.Warn("recorded draft no longer exists, creating a new one",
+				zap.String("file", a.File),
+				zap.String("key", a.Key))
+		default:
+			return nil, err
+		}
+	}
+
+	if result.Action == SyncActionCreated {
+		draftResult, err := s.Store.CreateDraft([]Article{article})
+		if err != nil {
+			return nil, fmt.Errorf("create draft: %w", err)
+		}
+		result.MediaID = draftResult.MediaID
+		entry = nil
+	}
+	result.Title = article.Title
+
+	next := SyncEntry{
+		Key:          a.Key,
+		AppID:        s.AppID,
+		File:         filepath.ToSlash(a.File),
+		MediaID:      result.MediaID,
+		Hash:         a.Hash,
+		Title:        article.Title,
+		ThumbMediaID: article.ThumbMediaID,
+		CoverHash:    a.CoverHash,
+	}
+	if entry != nil {
+		next.CreatedAt = entry.CreatedAt
+	}
+	s.State.Put(next)
+	return result, nil
+}
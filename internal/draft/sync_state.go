@@ -0,0 +1,139 @@
+package draft
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// DefaultSyncStateFile 默认同步状态文件名（位于当前目录）
+const DefaultSyncStateFile = ".md2wechat-sync.json"
+
+// SyncEntry 一篇文章的同步记录
+type SyncEntry struct {
+	Key          string    `json:"key"`   // front matter 的 id，或相对于状态文件的路径
+	AppID        string    `json:"appid"` // 草稿所属公众号
+	File         string    `json:"file"`
+	MediaID      string    `json:"media_id"`
+	Hash         string    `json:"sha256"` // 文章内容（正文、图片、封面、转换参数）的哈希
+	Title        string    `json:"title"`
+	ThumbMediaID string    `json:"thumb_media_id"`
+	CoverHash    string    `json:"cover_sha256,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// SyncState 文章与草稿的对应关系，用于重复同步时更新已有草稿而不是新建
+type SyncState struct {
+	path    string
+	entries map[string]*SyncEntry
+}
+
+// OpenSyncState 打开（或创建）同步状态文件
+func OpenSyncState(path string) (*SyncState, error) {
+	s := &SyncState{
+		path:    path,
+		entries: make(map[string]*SyncEntry),
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read sync state: %w", err)
+	}
+
+	var entries []*SyncEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("parse sync state %s: %w", path, err)
+	}
+	for _, e := range entries {
+		s.entries[syncStateKey(e.AppID, e.Key)] = e
+	}
+	return s, nil
+}
+
+// Path 返回状态文件路径
+func (s *SyncState) Path() string {
+	return s.path
+}
+
+// syncStateKey 同一篇文章在不同公众号下分别记录
+func syncStateKey(appID, key string) string {
+	return appID + ":" + key
+}
+
+// Get 查找文章的同步记录
+func (s *SyncState) Get(appID, key string) (*SyncEntry, bool) {
+	e, ok := s.entries[syncStateKey(appID, key)]
+	if !ok {
+		return nil, false
+	}
+	entry := *e
+	return &entry, true
+}
+
+// Put 写入同步记录
+func (s *SyncState) Put(entry SyncEntry) {
+	now := time.Now()
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = now
+	}
+	entry.UpdatedAt = now
+	s.entries[syncStateKey(entry.AppID, entry.Key)] = &entry
+}
+
+// Delete 删除同步记录
+func (s *SyncState) Delete(appID, key string) {
+	delete(s.entries, syncStateKey(appID, key))
+}
+
+// List 返回所有同步记录（按 key 排序，便于版本管理）
+func (s *SyncState) List() []SyncEntry {
+	entries := make([]SyncEntry, 0, len(s.entries))
+	for _, e := range s.entries {
+		entries = append(entries, *e)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Key == entries[j].Key {
+			return entries[i].AppID < entries[j].AppID
+		}
+		return entries[i].Key < entries[j].Key
+	})
+	return entries
+}
+
+// Save 将状态写回磁盘（先写临时文件再重命名）
+func (s *SyncState) Save() error {
+	data, err := json.MarshalIndent(s.List(), "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal sync state: %w", err)
+	}
+
+	dir := filepath.Dir(s.path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("create sync state dir: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(dir, filepath.Base(s.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("create temp file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return fmt.Errorf("write sync state: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("write sync state: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("save sync state: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package draft
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestSyncState(t *testing.T) {
+	path := filepath.Join(t.TempDir(), DefaultSyncStateFile)
+
+	state, err := OpenSyncState(path)
+	if err != nil {
+		t.Fatalf("OpenSyncState() error = %v", err)
+	}
+	if _, ok := state.Get("wx1", "posts/a.md"); ok {
+		t.Fatal("Get() on empty state = hit, want miss")
+	}
+
+	state.Put(SyncEntry{Key: "posts/a.md", AppID: "wx1", MediaID: "draft-1", Hash: "h1"})
+	state.Put(SyncEntry{Key: "weekly-42", AppID: "wx1", MediaID: "draft-2", Hash: "h2"})
+	if err := state.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	reopened, err := OpenSyncState(path)
+	if err != nil {
+		t.Fatalf("OpenSyncState() error = %v", err)
+	}
+
+	entry, ok := reopened.Get("wx1", "posts/a.md")
+	if !ok || entry.MediaID != "draft-1" || entry.Hash != "h1" || entry.CreatedAt.IsZero() {
+		t.Errorf("Get() after reopen = %+v, %v", entry, ok)
+	}
+
+	// 草稿属于某个公众号，其他账号不能复用
+	if _, ok := reopened.Get("wx2", "posts/a.md"); ok {
+		t.Error("Get() with another AppID = hit, want miss")
+	}
+
+	// 更新时保留创建时间
+	created := entry.CreatedAt
+	entry.Hash = "h3"
+	reopened.Put(*entry)
+	updated, _ := reopened.Get("wx1", "posts/a.md")
+	if updated.Hash != "h3" || !updated.CreatedAt.Equal(created) {
+		t.Errorf("Put() update = %+v", updated)
+	}
+
+	reopened.Delete("wx1", "weekly-42")
+	if got := len(reopened.List()); got != 1 {
+		t.Errorf("len(List()) after Delete = %d, want 1", got)
+	}
+}
@@ -0,0 +1,114 @@
+package draft
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/silenceper/wechat/v2/util"
+	"go.uber.org/zap"
+)
+
+// fakeDraftStore 记录草稿调用的模拟实现
+type fakeDraftStore struct {
+	updateErr error
+	created   int
+	updated   []string // 更新过的 media_id
+}
+
+func (f *fakeDraftStore) CreateDraft(articles []Article) (*DraftResult, error) {
+	f.created++
+	return &DraftResult{MediaID: "new-draft"}, nil
+}
+
+func (f *fakeDraftStore) UpdateDraft(mediaID string, index int, article Article) error {
+	f.updated = append(f.updated, mediaID)
+	return f.updateErr
+}
+
+func TestSyncer_Sync(t *testing.T) {
+	existing := SyncEntry{Key: "a", AppID: "wx1", MediaID: "old-draft", Hash: "h1", Title: "旧标题", ThumbMediaID: "thumb-1", CoverHash: "c1"}
+	tests := []struct {
+		name        string
+		entry       *SyncEntry
+		hash        string
+		force       bool
+		dryRun      bool
+		updateErr   error
+		wantAction  string
+		wantMediaID string
+		wantCreated int
+		wantUpdated int
+		wantPrepare bool
+		wantErr     bool
+	}{
+		{name: "first sync creates", hash: "h1", wantAction: SyncActionCreated, wantMediaID: "new-draft", wantCreated: 1, wantPrepare: true},
+		{name: "unchanged skips", entry: &existing, hash: "h1", wantAction: SyncActionSkipped, wantMediaID: "old-draft"},
+		{name: "force updates", entry: &existing, hash: "h1", force: true, wantAction: SyncActionUpdated, wantMediaID: "old-draft", wantUpdated: 1, wantPrepare: true},
+		{name: "changed updates", entry: &existing, hash: "h2", wantAction: SyncActionUpdated, wantMediaID: "old-draft", wantUpdated: 1, wantPrepare: true},
+		{
+			name: "deleted draft recreated", entry: &existing, hash: "h2",
+			updateErr:  &util.CommonError{ErrCode: 40007, ErrMsg: "invalid media_id"},
+			wantAction: SyncActionCreated, wantMediaID: "new-draft", wantCreated: 1, wantUpdated: 1, wantPrepare: true,
+		},
+		{name: "update error", entry: &existing, hash: "h2", updateErr: errors.New("network down"), wantUpdated: 1, wantPrepare: true, wantErr: true},
+		{name: "dry run new", hash: "h1", dryRun: true, wantAction: SyncActionCreated},
+		{name: "dry run changed", entry: &existing, hash: "h2", dryRun: true, wantAction: SyncActionUpdated, wantMediaID: "old-draft"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state, _ := OpenSyncState(filepath.Join(t.TempDir(), DefaultSyncStateFile))
+			if tt.entry != nil {
+				state.Put(*tt.entry)
+			}
+			store := &fakeDraftStore{updateErr: tt.updateErr}
+			syncer := &Syncer{State: state, Store: store, AppID: "wx1", Force: tt.force, DryRun: tt.dryRun, Log: zap.NewNop()}
+
+			var previous *SyncEntry
+			prepared := false
+			result, err := syncer.Sync(SyncArticle{Key: "a", File: "a.md", Hash: tt.hash, CoverHash: "c1"}, func(p *SyncEntry) (Article, error) {
+				prepared, previous = true, p
+				return Article{Title: "新标题", Content: "<p>正文</p>", ThumbMediaID: "thumb-2"}, nil
+			})
+
+			if prepared != tt.wantPrepare {
+				t.Errorf("prepare called = %v, want %v", prepared, tt.wantPrepare)
+			}
+			if prepared && (previous == nil) != (tt.entry == nil) {
+				t.Errorf("prepare previous = %+v", previous)
+			}
+			if store.created != tt.wantCreated || len(store.updated) != tt.wantUpdated {
+				t.Errorf("created %d, updated %v", store.created, store.updated)
+			}
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("Sync() succeeded, want error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Sync() error = %v", err)
+			}
+			if result.Action != tt.wantAction || result.MediaID != tt.wantMediaID {
+				t.Errorf("Sync() = %+v, want %s %s", result, tt.wantAction, tt.wantMediaID)
+			}
+
+			// 只有真正新建或更新后才改写同步记录
+			entry, ok := state.Get("wx1", "a")
+			switch {
+			case tt.wantPrepare:
+				if !ok || entry.MediaID != tt.wantMediaID || entry.Hash != tt.hash || entry.ThumbMediaID != "thumb-2" || entry.Title != "新标题" {
+					t.Errorf("state entry = %+v", entry)
+				}
+			case tt.entry != nil:
+				if !ok || entry.Hash != tt.entry.Hash || entry.MediaID != tt.entry.MediaID {
+					t.Errorf("state entry = %+v, want unchanged", entry)
+				}
+			default:
+				if ok {
+					t.Errorf("state entry = %+v, want none", entry)
+				}
+			}
+		})
+	}
+}
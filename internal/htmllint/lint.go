@@ -0,0 +1,505 @@
+// Package htmllint 检查生成的 HTML 中会被微信公众号编辑器删除或拒绝的内容
+// 例如 <style>、class、<script>、position 定位、外部字体和非微信链接，并可自动修复
+package htmllint
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+)
+
+// Severity 问题级别
+type Severity string
+
+const (
+	SeverityError   Severity = "error"   // 内容会丢失或草稿会被拒绝
+	SeverityWarning Severity = "warning" // 显示效果会改变
+	SeverityInfo    Severity = "info"    // 建议
+)
+
+// rank 级别排序（越大越严重）
+func (s Severity) rank() int {
+	switch s {
+	case SeverityError:
+		return 3
+	case SeverityWarning:
+		return 2
+	case SeverityInfo:
+		return 1
+	}
+	return 0
+}
+
+// ParseSeverity 解析级别，"none" 返回空级别（从不失败）
+func ParseSeverity(s string) (Severity, error) {
+	switch Severity(s) {
+	case SeverityError, SeverityWarning, SeverityInfo:
+		return Severity(s), nil
+	case "none", "":
+		return "", nil
+	}
+	return "", fmt.Errorf("invalid severity %q (error, warning, info or none)", s)
+}
+
+// Rule 检查规则
+type Rule string
+
+const (
+	RuleUnsupportedTag   Rule = "unsupported-tag"   // 会被删除的标签
+	RuleUnsupportedAttr  Rule = "unsupported-attr"  // 会被删除的属性
+	RuleUnsupportedStyle Rule = "unsupported-style" // 会被删除的样式声明（position 等）
+	RuleExternalResource Rule = "external-resource" // 样式中引用的外部资源（字体、背景图）
+	RuleExternalLink     Rule = "external-link"     // 非微信链接
+	RuleExternalImage    Rule = "external-image"    // 未上传到微信的图片
+	RuleMissingStyle     Rule = "missing-style"     // 缺少内联样式
+	RuleBase64Image      Rule = "base64-image"      // 过大的内嵌 base64 图片
+	RuleBodySize         Rule = "body-size"         // 正文超过大小限制
+	RuleTextLength       Rule = "text-length"       // 正文超过字数限制
+)
+
+// Issue 一个问题
+type Issue struct {
+	Rule     Rule     `json:"rule"`
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
+	Line     int      `json:"line,omitempty"`
+	Tag      string   `json:"tag,omitempty"`
+	Attr     string   `json:"attr,omitempty"`
+	Fixable  bool     `json:"fixable"`
+	Fixed    bool     `json:"fixed,omitempty"`
+}
+
+// Report 检查报告
+type Report struct {
+	Issues    []Issue `json:"issues"`
+	Errors    int     `json:"errors"`
+	Warnings  int     `json:"warnings"`
+	Infos     int     `json:"infos"`
+	Fixed     int     `json:"fixed"`
+	Bytes     int     `json:"bytes"`
+	TextChars int     `json:"text_chars"`
+}
+
+// Failed 是否存在不低于 level 且未修复的问题（level 为空时从不失败）
+func (r *Report) Failed(level Severity) bool {
+	if level == "" {
+		return false
+	}
+	for _, issue := range r.Issues {
+		if !issue.Fixed && issue.Severity.rank() >= level.rank() {
+			return true
+		}
+	}
+	return false
+}
+
+// add 记录问题
+func (r *Report) add(issue Issue) {
+	switch issue.Severity {
+	case SeverityError:
+		r.Errors++
+	case SeverityWarning:
+		r.Warnings++
+	case SeverityInfo:
+		r.Infos++
+	}
+	if issue.Fixed {
+		r.Fixed++
+	}
+	r.Issues = append(r.Issues, issue)
+}
+
+// Options 检查参数
+type Options struct {
+	MaxBodyBytes    int      // 正文 HTML 最大字节数
+	MaxTextChars    int      // 正文最大字数
+	MaxDataURIBytes int      // 内嵌 data: 图片最大字节数
+	LinkHosts       []string // 允许的链接域名
+	ImageHosts      []string // 允许的图片域名
+}
+
+// DefaultOptions 微信草稿接口的限制
+func DefaultOptions() Options {
+	return Options{
+		MaxBodyBytes:    1024 * 1024,
+		MaxTextChars:    20000,
+		MaxDataURIBytes: 64 * 1024,
+		LinkHosts:       []string{"mp.weixin.qq.com"},
+		ImageHosts:      []string{"mmbiz.qpic.cn", "mmbiz.qlogo.cn"},
+	}
+}
+
+// Lint 检查 HTML
+func Lint(src string, opts Options) *Report {
+	l := newLinter(src, opts, false)
+	l.run()
+	return l.report
+}
+
+// Fix 检查并修复 HTML，返回修复后的 HTML 和报告（可修复的问题标记为 fixed）
+func Fix(src string, opts Options) (string, *Report) {
+	l := newLinter(src, opts, true)
+	l.run()
+	return l.out.String(), l.report
+}
+
+// linter 基于 token 流的检查器，修复时同时输出修复后的 HTML
+type linter struct {
+	src    string
+	opts   Options
+	fix    bool
+	report *Report
+	out    bytes.Buffer
+	line   int
+
+	skipTag   string // 正在删除的元素
+	skipDepth int
+	anchors   []bool // 已打开的 <a> 是否被去掉
+}
+
+func newLinter(src string, opts Options, fix bool) *linter {
+	return &linter{
+		src:    src,
+		opts:   opts,
+		fix:    fix,
+		report: &Report{Issues: []Issue{}, Bytes: len(src)},
+		line:   1,
+	}
+}
+
+// run 遍历 token
+func (l *linter) run() {
+	z := html.NewTokenizer(strings.NewReader(l.src))
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			// 分词器只会在读取结束时返回错误
+			break
+		}
+
+		raw := append([]byte(nil), z.Raw()...)
+		tok := z.Token()
+		l.token(tt, tok, raw)
+		l.line += bytes.Count(raw, []byte("\n"))
+	}
+
+	l.checkSize()
+}
+
+// token 处理一个 token
+func (l *linter) token(tt html.TokenType, tok html.Token, raw []byte) {
+	if l.skipTag != "" {
+		l.skip(tt, tok, raw)
+		return
+	}
+
+	switch tt {
+	case html.StartTagToken, html.SelfClosingTagToken:
+		l.startTag(tok, raw, tt == html.SelfClosingTagToken)
+	case html.EndTagToken:
+		l.endTag(tok, raw)
+	case html.TextToken:
+		for _, r := range tok.Data {
+			if !unicode.IsSpace(r) {
+				l.report.TextChars++
+			}
+		}
+		l.out.Write(raw)
+	default:
+		l.out.Write(raw)
+	}
+}
+
+// skip 跳过会被删除的元素内部的 token（不计入字数，修复时不输出）
+func (l *linter) skip(tt html.TokenType, tok html.Token, raw []byte) {
+	if !l.fix {
+		l.out.Write(raw)
+	}
+	if tok.Data != l.skipTag {
+		return
+	}
+	switch tt {
+	case html.StartTagToken:
+		l.skipDepth++
+	case html.EndTagToken:
+		l.skipDepth--
+		if l.skipDepth == 0 {
+			l.skipTag = ""
+		}
+	}
+}
+
+// startTag 检查开始标签
+func (l *linter) startTag(tok html.Token, raw []byte, selfClosing bool) {
+	name := tok.Data
+
+	if removedTags[name] {
+		msg := fmt.Sprintf("<%s> 会被微信删除（包括其内容）", name)
+		if name == "style" || name == "link" {
+			msg = fmt.Sprintf("<%s> 会被微信删除，样式必须写成内联 style", name)
+		}
+		l.issue(Issue{Rule: RuleUnsupportedTag, Severity: SeverityError, Message: msg, Tag: name, Fixable: true})
+		if !selfClosing && !voidTags[name] {
+			l.skipTag = name
+			l.skipDepth = 1
+		}
+		if !l.fix {
+			l.out.Write(raw)
+		}
+		return
+	}
+	if unwrappedTags[name] {
+		l.issue(Issue{Rule: RuleUnsupportedTag, Severity: SeverityWarning, Message: fmt.Sprintf("<%s> 标签会被微信删除，仅保留内容", name), Tag: name, Fixable: true})
+		if !l.fix {
+			l.out.Write(raw)
+		}
+		return
+	}
+
+	changed := l.checkAttrs(&tok)
+
+	if name == "a" {
+		unwrap := l.checkLink(tok)
+		if !selfClosing {
+			l.anchors = append(l.anchors, unwrap)
+		}
+		if unwrap {
+			return
+		}
+	}
+	if name == "img" {
+		l.checkImage(tok)
+	}
+	if styledTags[name] && attrValue(tok, "style") == "" {
+		l.issue(Issue{Rule: RuleMissingStyle, Severity: SeverityInfo, Message: fmt.Sprintf("<%s> 没有内联样式，将使用微信默认样式", name), Tag: name})
+	}
+
+	if changed {
+		l.out.WriteString(tok.String())
+	} else {
+		l.out.Write(raw)
+	}
+}
+
+// endTag 处理结束标签
+func (l *linter) endTag(tok html.Token, raw []byte) {
+	name := tok.Data
+	if removedTags[name] || unwrappedTags[name] {
+		if !l.fix {
+			l.out.Write(raw)
+		}
+		return
+	}
+	if name == "a" && len(l.anchors) > 0 {
+		unwrap := l.anchors[len(l.anchors)-1]
+		l.anchors = l.anchors[:len(l.anchors)-1]
+		if unwrap {
+			return
+		}
+	}
+	l.out.Write(raw)
+}
+
+// checkAttrs 检查属性，修复时删除不支持的属性和样式声明，返回是否修改了 token
+func (l *linter) checkAttrs(tok *html.Token) bool {
+	name := tok.Data
+	hasClass, hasStyle := false, false
+	changed := false
+	kept := tok.Attr[:0:0]
+
+	for _, attr := range tok.Attr {
+		key := strings.ToLower(attr.Key)
+		switch {
+		case droppedAttrs[key] != "":
+			if key == "class" {
+				hasClass = true
+			}
+			l.issue(Issue{Rule: RuleUnsupportedAttr, Severity: SeverityWarning, Message: droppedAttrs[key], Tag: name, Attr: key, Fixable: true})
+			if l.fix {
+				changed = true
+				continue
+			}
+		case strings.HasPrefix(key, "on"):
+			l.issue(Issue{Rule: RuleUnsupportedAttr, Severity: SeverityError, Message: fmt.Sprintf("事件属性 %s 会被微信删除", key), Tag: name, Attr: key, Fixable: true})
+			if l.fix {
+				changed = true
+				continue
+			}
+		case (key == "href" || key == "src") && isScriptURL(attr.Val):
+			l.issue(Issue{Rule: RuleUnsupportedAttr, Severity: SeverityError, Message: "javascript: 链接会被微信删除", Tag: name, Attr: key, Fixable: true})
+			if l.fix {
+				changed = true
+				continue
+			}
+		case key == "style":
+			hasStyle = strings.TrimSpace(attr.Val) != ""
+			if style, ok := l.checkStyle(name, attr.Val); ok && l.fix {
+				changed = true
+				if strings.TrimSpace(style) == "" {
+					continue
+				}
+				attr.Val = style
+			}
+		}
+		kept = append(kept, attr)
+	}
+
+	if hasClass && !hasStyle {
+		l.issue(Issue{Rule: RuleMissingStyle, Severity: SeverityWarning, Message: fmt.Sprintf("<%s> 只有 class 没有内联 style，在微信中没有样式", name), Tag: name})
+	}
+
+	tok.Attr = kept
+	return changed
+}
+
+// cssURLPattern 样式中的 url(...)
+var cssURLPattern = regexp.MustCompile(`(?i)url\(\s*['"]?([^'")]*)['"]?\s*\)`)
+
+// checkStyle 检查内联样式声明，返回删除不支持声明后的样式和是否有修改
+func (l *linter) checkStyle(tag, style string) (string, bool) {
+	decls := splitDeclarations(style)
+	kept := make([]string, 0, len(decls))
+	removed := false
+
+	for _, decl := range decls {
+		prop, value, ok := strings.Cut(decl, ":")
+		if !ok {
+			kept = append(kept, decl)
+			continue
+		}
+		prop = strings.ToLower(strings.TrimSpace(prop))
+		value = strings.TrimSpace(value)
+
+		if prop == "position" && removedPositions[strings.ToLower(strings.TrimSuffix(value, "!important"))] {
+			l.issue(Issue{Rule: RuleUnsupportedStyle, Severity: SeverityWarning, Message: fmt.Sprintf("position: %s 会被微信删除", value), Tag: tag, Attr: "style", Fixable: true})
+			removed = true
+			continue
+		}
+
+		if m := cssURLPattern.FindStringSubmatch(value); m != nil && !strings.HasPrefix(strings.ToLower(m[1]), "data:") && !isWeChatURL(m[1], l.opts.ImageHosts) {
+			l.issue(Issue{Rule: RuleExternalResource, Severity: SeverityWarning, Message: fmt.Sprintf("%s 引用的外部资源会被微信删除: %s", prop, m[1]), Tag: tag, Attr: "style", Fixable: true})
+			removed = true
+			continue
+		}
+
+		kept = append(kept, strings.TrimSpace(decl))
+	}
+
+	if !removed {
+		return style, false
+	}
+	if len(kept) == 0 {
+		return "", true
+	}
+	return strings.Join(kept, "; ") + ";", true
+}
+
+// splitDeclarations 按分号拆分样式声明（忽略括号和引号内的分号）
+func splitDeclarations(style string) []string {
+	var decls []string
+	depth, quote, start := 0, rune(0), 0
+	for i, r := range style {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '(':
+			depth++
+		case r == ')' && depth > 0:
+			depth--
+		case r == ';' && depth == 0:
+			if s := strings.TrimSpace(style[start:i]); s != "" {
+				decls = append(decls, s)
+			}
+			start = i + 1
+		}
+	}
+	if s := strings.TrimSpace(style[start:]); s != "" {
+		decls = append(decls, s)
+	}
+	return decls
+}
+
+// checkLink 检查链接，返回修复时是否去掉 <a> 标签（保留文字）
+func (l *linter) checkLink(tok html.Token) bool {
+	href := strings.TrimSpace(attrValue(tok, "href"))
+	if href == "" || isScriptURL(href) || isWeChatURL(href, l.opts.LinkHosts) {
+		return false
+	}
+
+	l.issue(Issue{Rule: RuleExternalLink, Severity: SeverityWarning, Message: fmt.Sprintf("非公众号文章链接不可点击，会被微信删除: %s", href), Tag: "a", Attr: "href", Fixable: true})
+	return l.fix
+}
+
+// checkImage 检查图片地址
+func (l *linter) checkImage(tok html.Token) {
+	src := strings.TrimSpace(attrValue(tok, "src"))
+	switch {
+	case src == "":
+		return
+	case strings.HasPrefix(strings.ToLower(src), "data:"):
+		if len(src) > l.opts.MaxDataURIBytes {
+			l.issue(Issue{Rule: RuleBase64Image, Severity: SeverityError,
+				Message: fmt.Sprintf("内嵌 base64 图片 %s 超过 %s，请改为上传图片", formatBytes(len(src)), formatBytes(l.opts.MaxDataURIBytes)),
+				Tag:     "img", Attr: "src"})
+		}
+	case !isWeChatURL(src, l.opts.ImageHosts):
+		l.issue(Issue{Rule: RuleExternalImage, Severity: SeverityWarning, Message: fmt.Sprintf("图片未上传到微信，会被过滤: %s", truncate(src, 80)), Tag: "img", Attr: "src"})
+	}
+}
+
+// checkSize 检查正文大小和字数
+func (l *linter) checkSize() {
+	if l.opts.MaxBodyBytes > 0 && l.report.Bytes > l.opts.MaxBodyBytes {
+		l.report.add(Issue{Rule: RuleBodySize, Severity: SeverityError,
+			Message: fmt.Sprintf("正文 %s 超过微信限制 %s", formatBytes(l.report.Bytes), formatBytes(l.opts.MaxBodyBytes))})
+	}
+	if l.opts.MaxTextChars > 0 && l.report.TextChars > l.opts.MaxTextChars {
+		l.report.add(Issue{Rule: RuleTextLength, Severity: SeverityError,
+			Message: fmt.Sprintf("正文 %d 字超过微信限制 %d 字", l.report.TextChars, l.opts.MaxTextChars)})
+	}
+}
+
+// issue 记录当前行的问题
+func (l *linter) issue(issue Issue) {
+	issue.Line = l.line
+	issue.Fixed = l.fix && issue.Fixable
+	l.report.add(issue)
+}
+
+// attrValue 获取属性值
+func attrValue(tok html.Token, key string) string {
+	for _, attr := range tok.Attr {
+		if strings.EqualFold(attr.Key, key) {
+			return attr.Val
+		}
+	}
+	return ""
+}
+
+// formatBytes 格式化字节数
+func formatBytes(n int) string {
+	switch {
+	case n >= 1024*1024:
+		return fmt.Sprintf("%.1fMB", float64(n)/1024/1024)
+	case n >= 1024:
+		return fmt.Sprintf("%.1fKB", float64(n)/1024)
+	}
+	return fmt.Sprintf("%dB", n)
+}
+
+// truncate 截断过长的字符串
+func truncate(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n]) + "..."
+}
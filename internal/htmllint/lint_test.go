@@ -0,0 +1,87 @@
+package htmllint
+
+import (
+	"strings"
+	"testing"
+)
+
+// rules 返回报告中的规则列表
+func rules(r *Report) []string {
+	var out []string
+	for _, issue := range r.Issues {
+		out = append(out, string(issue.Rule))
+	}
+	return out
+}
+
+func TestLint(t *testing.T) {
+	tests := []struct {
+		name string
+		html string
+		want []string
+	}{
+		{"clean", `<section style="color:#333"><p style="margin:0">你好</p><img src="https://mmbiz.qpic.cn/a.png"></section>`, nil},
+		{"style tag", "<style>p{color:red}</style><p style=\"x:y\">a</p>", []string{"unsupported-tag"}},
+		{"script", `<script>alert(1)</script>`, []string{"unsupported-tag"}},
+		{"class without style", `<p class="lead">a</p>`, []string{"unsupported-attr", "missing-style"}},
+		{"event handler", `<p style="a:b" onclick="x()">a</p>`, []string{"unsupported-attr"}},
+		{"position", `<div style="position:absolute;top:0">a</div>`, []string{"unsupported-style"}},
+		{"external font", `<p style="font-family:X;background:url('https://fonts.example.com/x.woff')">a</p>`, []string{"external-resource"}},
+		{"external link", `<a href="https://example.com">x</a><a href="https://mp.weixin.qq.com/s/abc">y</a>`, []string{"external-link"}},
+		{"external image", `<img src="https://example.com/a.png">`, []string{"external-image"}},
+		{"bare heading", `<h2>标题</h2>`, []string{"missing-style"}},
+		{"large base64", `<img src="data:image/png;base64,` + strings.Repeat("A", 70*1024) + `">`, []string{"base64-image"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := rules(Lint(tt.html, DefaultOptions()))
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("Lint() rules = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLint_LineAndSize(t *testing.T) {
+	opts := DefaultOptions()
+	opts.MaxBodyBytes = 50
+	opts.MaxTextChars = 5
+
+	report := Lint("<p style=\"a:b\">一二三</p>\n<p style=\"a:b\">四五六</p>\n<script>var x = 1</script>", opts)
+	if report.TextChars != 6 {
+		t.Errorf("TextChars = %d, want 6 (script excluded)", report.TextChars)
+	}
+	if report.Issues[0].Line != 3 {
+		t.Errorf("script issue line = %d, want 3", report.Issues[0].Line)
+	}
+	if got := strings.Join(rules(report), ","); got != "unsupported-tag,body-size,text-length" {
+		t.Errorf("rules = %s", got)
+	}
+	if !report.Failed(SeverityError) || report.Errors != 3 {
+		t.Errorf("Failed(error) = false or Errors = %d, want 3", report.Errors)
+	}
+}
+
+func TestFix(t *testing.T) {
+	src := `<html><body><style>p{}</style><p class="a" style="color:red;position:fixed" onclick="x()">见<a href="https://example.com/x">链接</a>` +
+		`和<a href="https://mp.weixin.qq.com/s/1">文章</a></p><script>1</script></body></html>`
+
+	fixed, report := Fix(src, DefaultOptions())
+
+	want := `<p style="color:red;">见链接和<a href="https://mp.weixin.qq.com/s/1">文章</a></p>`
+	if fixed != want {
+		t.Errorf("Fix() html =\n%s\nwant\n%s", fixed, want)
+	}
+	if report.Fixed != len(report.Issues) {
+		t.Errorf("Fixed = %d of %d issues", report.Fixed, len(report.Issues))
+	}
+	if report.Failed(SeverityError) {
+		t.Error("Failed(error) after fix = true, want false")
+	}
+
+	// 修复结果应无需再修复
+	if again := Lint(fixed, DefaultOptions()); len(again.Issues) != 0 {
+		t.Errorf("Lint(fixed) issues = %v", rules(again))
+	}
+}
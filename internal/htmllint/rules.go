@@ -0,0 +1,84 @@
+package htmllint
+
+import (
+	"net/url"
+	"strings"
+)
+
+// removedTags 微信会连同内容一起删除的标签
+var removedTags = map[string]bool{
+	"script":   true,
+	"style":    true,
+	"noscript": true,
+	"iframe":   true,
+	"frame":    true,
+	"frameset": true,
+	"object":   true,
+	"embed":    true,
+	"applet":   true,
+	"link":     true,
+	"meta":     true,
+	"base":     true,
+	"head":     true,
+	"title":    true,
+	"canvas":   true,
+	"input":    true,
+	"textarea": true,
+	"select":   true,
+}
+
+// unwrappedTags 微信会删除标签但保留内容的标签
+var unwrappedTags = map[string]bool{
+	"html":   true,
+	"body":   true,
+	"form":   true,
+	"label":  true,
+	"button": true,
+}
+
+// voidTags 没有结束标签的元素
+var voidTags = map[string]bool{
+	"area": true, "base": true, "br": true, "col": true, "embed": true, "hr": true, "img": true,
+	"input": true, "link": true, "meta": true, "source": true, "track": true, "wbr": true,
+}
+
+// styledTags 没有内联样式时在微信中显示为默认样式的元素
+var styledTags = map[string]bool{
+	"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+	"blockquote": true, "pre": true, "table": true,
+}
+
+// droppedAttrs 微信会删除的属性
+var droppedAttrs = map[string]string{
+	"class":           "class 属性会被删除，依赖样式表的样式将丢失",
+	"id":              "id 属性会被删除，页内锚点不可用",
+	"contenteditable": "contenteditable 属性会被删除",
+	"tabindex":        "tabindex 属性会被删除",
+}
+
+// removedPositions 微信会删除的 position 取值
+var removedPositions = map[string]bool{
+	"absolute": true,
+	"fixed":    true,
+	"sticky":   true,
+}
+
+// isWeChatURL 判断链接是否指向允许的域名
+func isWeChatURL(raw string, hosts []string) bool {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || u.Host == "" {
+		return false
+	}
+	host := strings.ToLower(u.Hostname())
+	for _, h := range hosts {
+		if host == h || strings.HasSuffix(host, "."+h) {
+			return true
+		}
+	}
+	return false
+}
+
+// isScriptURL 判断是否为 javascript: 链接
+func isScriptURL(raw string) bool {
+	return strings.HasPrefix(strings.ToLower(strings.TrimSpace(raw)), "javascript:")
+}
@@ -0,0 +1,230 @@
+package image
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/geekjourneyx/md2wechat-skill/internal/wechat"
+)
+
+// uploadCacheFile 上传缓存索引文件名
+const uploadCacheFile = "uploads.json"
+
+// CacheEntry 上传缓存条目
+type CacheEntry struct {
+	Key        string     `json:"key"`
+	AppID      string     `json:"appid"`
+	Kind       UploadKind `json:"kind"`
+	Hash       string     `json:"sha256"`
+	MediaID    string     `json:"media_id"`
+	WechatURL  string     `json:"wechat_url"`
+	Source     string     `json:"source,omitempty"` // 最近一次上传的来源（路径或 URL）
+	Size       int64      `json:"size"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt time.Time  `json:"last_used_at"`
+}
+
+// UploadCache 按内容寻址的上传缓存（SHA-256(处理后字节) + AppID + 上传方式）
+// 避免重复上传相同图片占用永久素材配额。
+// 多个进程可以同时使用同一缓存文件：Save 在文件锁内合并其它进程已写入的条目
+type UploadCache struct {
+	path    string
+	mu      sync.Mutex
+	entries map[string]*CacheEntry
+	changed map[string]bool // 本进程写入或删除的条目，Save 时覆盖磁盘上的版本
+	used    map[string]bool // 本进程命中的条目，Save 时更新磁盘上的最近使用时间
+}
+
+// OpenUploadCache 打开（或创建）dir 下的上传缓存
+func OpenUploadCache(dir string) (*UploadCache, error) {
+	c := &UploadCache{
+		path:    filepath.Join(dir, uploadCacheFile),
+		changed: make(map[string]bool),
+		used:    make(map[string]bool),
+	}
+	entries, err := readCacheEntries(c.path)
+	if err != nil {
+		return nil, err
+	}
+	c.entries = entries
+	return c, nil
+}
+
+// readCacheEntries 读取缓存文件，文件不存在时返回空缓存
+func readCacheEntries(path string) (map[string]*CacheEntry, error) {
+	entries := make(map[string]*CacheEntry)
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return entries, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read upload cache: %w", err)
+	}
+
+	var list []*CacheEntry
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("parse upload cache %s: %w", path, err)
+	}
+	for _, e := range list {
+		// 旧版本缓存没有 kind 字段，均为永久素材
+		if e.Kind == "" {
+			e.Kind = UploadMaterial
+			e.Key = cacheKey(e.AppID, e.Kind, e.Hash)
+		}
+		entries[e.Key] = e
+	}
+	return entries, nil
+}
+
+// Path 返回缓存文件路径
+func (c *UploadCache) Path() string {
+	return c.path
+}
+
+// HashContent 计算内容的 SHA-256
+func HashContent(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// cacheKey 生成缓存键（永久素材和内容图片分别缓存）
+func cacheKey(appID string, kind UploadKind, hash string) string {
+	if kind == "" {
+		kind = UploadMaterial
+	}
+	return appID + ":" + string(kind) + ":" + hash
+}
+
+// Get 查找缓存，命中时更新最近使用时间
+func (c *UploadCache) Get(appID string, kind UploadKind, hash string) (*CacheEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.entries[cacheKey(appID, kind, hash)]
+	if !ok {
+		return nil, false
+	}
+	e.LastUsedAt = time.Now()
+	c.used[e.Key] = true
+	entry := *e
+	return &entry, true
+}
+
+// Put 写入缓存条目
+func (c *UploadCache) Put(entry CacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if entry.Kind == "" {
+		entry.Kind = UploadMaterial
+	}
+	entry.Key = cacheKey(entry.AppID, entry.Kind, entry.Hash)
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = now
+	}
+	entry.LastUsedAt = now
+	c.entries[entry.Key] = &entry
+	c.changed[entry.Key] = true
+}
+
+// Delete 删除缓存条目
+func (c *UploadCache) Delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, key)
+	c.changed[key] = true
+}
+
+// List 返回所有缓存条目（按创建时间排序）
+func (c *UploadCache) List() []CacheEntry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return sortEntries(c.entries)
+}
+
+// sortEntries 按创建时间排序缓存条目
+func sortEntries(m map[string]*CacheEntry) []CacheEntry {
+	entries := make([]CacheEntry, 0, len(m))
+	for _, e := range m {
+		entries = append(entries, *e)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].CreatedAt.Equal(entries[j].CreatedAt) {
+			return entries[i].Key < entries[j].Key
+		}
+		return entries[i].CreatedAt.Before(entries[j].CreatedAt)
+	})
+	return entries
+}
+
+// Save 将缓存写回磁盘（先写临时文件再重命名，避免写入中断损坏缓存）。
+// 在文件锁内重新读取磁盘上的缓存，只覆盖本进程写入、删除或命中的条目，
+// 避免并行运行的进程互相丢弃对方新上传的条目
+func (c *UploadCache) Save() error {
+	if err := os.MkdirAll(filepath.Dir(c.path), 0700); err != nil {
+		return fmt.Errorf("create cache dir: %w", err)
+	}
+	unlock, err := wechat.LockFile(c.path + ".lock")
+	if err != nil {
+		return fmt.Errorf("lock upload cache: %w", err)
+	}
+	defer unlock()
+
+	merged, err := readCacheEntries(c.path)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key := range c.changed {
+		if e, ok := c.entries[key]; ok {
+			merged[key] = e
+		} else {
+			delete(merged, key)
+		}
+	}
+	for key := range c.used {
+		e, d := c.entries[key], merged[key]
+		if e != nil && d != nil && !c.changed[key] && e.LastUsedAt.After(d.LastUsedAt) {
+			d.LastUsedAt = e.LastUsedAt
+		}
+	}
+
+	data, err := json.MarshalIndent(sortEntries(merged), "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal upload cache: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(c.path), uploadCacheFile+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("create temp file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("write upload cache: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("write upload cache: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), c.path); err != nil {
+		return fmt.Errorf("save upload cache: %w", err)
+	}
+
+	// 内存中的缓存同步为合并后的内容
+	c.entries = merged
+	c.changed = make(map[string]bool)
+	c.used = make(map[string]bool)
+	return nil
+}
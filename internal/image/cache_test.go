@@ -0,0 +1,148 @@
+package image
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/geekjourneyx/md2wechat-skill/internal/config"
+	"go.uber.org/zap"
+)
+
+func TestUploadCache(t *testing.T) {
+	dir := t.TempDir()
+
+	cache, err := OpenUploadCache(dir)
+	if err != nil {
+		t.Fatalf("OpenUploadCache() error = %v", err)
+	}
+
+	hash := HashContent([]byte("image bytes"))
+	cache.Put(CacheEntry{AppID: "wx1", Hash: hash, MediaID: "media-1", WechatURL: "https://mmbiz.qpic.cn/1"})
+	if err := cache.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	reopened, err := OpenUploadCache(dir)
+	if err != nil {
+		t.Fatalf("OpenUploadCache() error = %v", err)
+	}
+
+	entry, ok := reopened.Get("wx1", UploadMaterial, hash)
+	if !ok {
+		t.Fatal("Get() after reopen = miss, want hit")
+	}
+	if entry.MediaID != "media-1" || entry.WechatURL != "https://mmbiz.qpic.cn/1" {
+		t.Errorf("Get() = %+v", entry)
+	}
+
+	// 同一内容在其他公众号下不能复用
+	if _, ok := reopened.Get("wx2", UploadMaterial, hash); ok {
+		t.Error("Get() with another AppID = hit, want miss")
+	}
+
+	// 永久素材和内容图片分别缓存
+	if _, ok := reopened.Get("wx1", UploadContent, hash); ok {
+		t.Error("Get() with content kind = hit, want miss")
+	}
+
+	reopened.Delete(entry.Key)
+	if got := len(reopened.List()); got != 0 {
+		t.Errorf("len(List()) after Delete = %d, want 0", got)
+	}
+}
+
+func TestUploadCache_SaveMerges(t *testing.T) {
+	dir := t.TempDir()
+	seed, _ := OpenUploadCache(dir)
+	seed.Put(CacheEntry{AppID: "wx1", Hash: "old", MediaID: "media-old"})
+	if err := seed.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	// 两次并行运行各自打开缓存
+	a, _ := OpenUploadCache(dir)
+	b, _ := OpenUploadCache(dir)
+	a.Put(CacheEntry{AppID: "wx1", Hash: "a", MediaID: "media-a"})
+	a.Delete(cacheKey("wx1", UploadMaterial, "old"))
+	if err := a.Save(); err != nil {
+		t.Fatal(err)
+	}
+	b.Put(CacheEntry{AppID: "wx1", Hash: "b", MediaID: "media-b"})
+	if _, ok := b.Get("wx1", UploadMaterial, "old"); !ok {
+		t.Fatal("Get(old) = miss")
+	}
+	if err := b.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	// 后保存的一方保留另一方新增的条目，也不会恢复另一方删除的条目
+	reopened, _ := OpenUploadCache(dir)
+	var got []string
+	for _, e := range reopened.List() {
+		got = append(got, e.MediaID)
+	}
+	if strings.Join(got, ",") != "media-a,media-b" {
+		t.Errorf("entries after both saves = %v, want [media-a media-b]", got)
+	}
+	if len(b.List()) != 2 {
+		t.Errorf("b.List() after Save = %+v, want merged entries", b.List())
+	}
+}
+
+func TestUploadCache_SaveConcurrent(t *testing.T) {
+	dir := t.TempDir()
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c, err := OpenUploadCache(dir)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			c.Put(CacheEntry{AppID: "wx1", Hash: fmt.Sprint(i), MediaID: fmt.Sprint("media-", i)})
+			if err := c.Save(); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	reopened, err := OpenUploadCache(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := len(reopened.List()); got != 8 {
+		t.Errorf("len(List()) = %d, want 8", got)
+	}
+}
+
+func TestProcessor_UploadCoverCached(t *testing.T) {
+	dir := t.TempDir()
+	cover := filepath.Join(dir, "cover.png")
+	data := []byte("cover bytes")
+	if err := os.WriteFile(cover, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cache, err := OpenUploadCache(dir)
+	if err != nil {
+		t.Fatalf("OpenUploadCache() error = %v", err)
+	}
+	cache.Put(CacheEntry{AppID: "wx1", Kind: UploadCover, Hash: HashContent(data), MediaID: "cover-media"})
+
+	// 命中缓存时不调用微信接口（ws 为 nil）
+	p := &Processor{cfg: &config.Config{WechatAppID: "wx1"}, log: zap.NewNop(), cache: cache}
+	result, err := p.UploadCover(cover)
+	if err != nil {
+		t.Fatalf("UploadCover() error = %v", err)
+	}
+	if !result.Cached || result.MediaID != "cover-media" {
+		t.Errorf("UploadCover() = %+v, want cached cover-media", result)
+	}
+}
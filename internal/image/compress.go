@@ -105,10 +105,17 @@ func (c *Compressor) CompressImage(filePath string) (string, bool, error) {
 		ext = ".jpg"
 	}
 
-	tempPath := filepath.Join(tempDir, "compressed_"+baseName+ext)
+	// 使用唯一文件名，避免并发处理同名图片时互相覆盖
+	tempFile, err := os.CreateTemp(tempDir, "compressed_"+baseName+"_*"+ext)
+	if err != nil {
+		return "", false, fmt.Errorf("create temp file: %w", err)
+	}
+	tempFile.Close()
+	tempPath := tempFile.Name()
 
 	// 保存压缩后的图片
 	if err := c.saveImage(processedImg, tempPath, outputFormat); err != nil {
+		os.Remove(tempPath)
 		return "", false, fmt.Errorf("save compressed image: %w", err)
 	}
 
@@ -0,0 +1,223 @@
+package image
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// JobType 图片任务类型
+type JobType string
+
+const (
+	JobLocal    JobType = "local"    // 上传本地图片
+	JobOnline   JobType = "online"   // 下载在线图片后上传
+	JobGenerate JobType = "generate" // AI 生成图片后上传
+)
+
+// JobStatus 图片任务状态
+type JobStatus string
+
+const (
+	StatusUploaded  JobStatus = "uploaded"  // 上传成功
+	StatusFailed    JobStatus = "failed"    // 处理失败
+	StatusCancelled JobStatus = "cancelled" // 已取消（未开始，或下载、上传被中断）
+)
+
+// Job 图片处理任务
+type Job struct {
+	Index  int        // 图片在文章中的位置索引
+	Type   JobType    // 任务类型
+	Source string     // 本地路径、图片 URL 或 AI 提示词
+	Kind   UploadKind // 上传方式，为空时按永久素材上传
+}
+
+// JobReport 单张图片的处理报告
+type JobReport struct {
+	Index       int       `json:"index"`
+	Type        JobType   `json:"type"`
+	Source      string    `json:"source"`
+	Status      JobStatus `json:"status"`
+	MediaID     string    `json:"media_id,omitempty"`
+	WechatURL   string    `json:"wechat_url,omitempty"`
+	BytesBefore int64     `json:"bytes_before,omitempty"` // 压缩前字节数
+	BytesAfter  int64     `json:"bytes_after,omitempty"`  // 实际上传字节数
+	Cached      bool      `json:"cached,omitempty"`       // 命中上传缓存，未重复上传
+	DurationMs  int64     `json:"duration_ms"`
+	Error       string    `json:"error,omitempty"`
+}
+
+// PipelineReport 图片处理汇总报告
+type PipelineReport struct {
+	Total      int         `json:"total"`
+	Uploaded   int         `json:"uploaded"`
+	Failed     int         `json:"failed"`
+	Cancelled  int         `json:"cancelled"`
+	DurationMs int64       `json:"duration_ms"`
+	Images     []JobReport `json:"images"`
+}
+
+// OK 是否全部上传成功
+func (r *PipelineReport) OK() bool {
+	return r.Uploaded == r.Total
+}
+
+// Pipeline 并发图片处理流水线
+type Pipeline struct {
+	processor *Processor
+	log       *zap.Logger
+	workers   int
+	handle    func(ctx context.Context, job Job) (*UploadResult, error)
+}
+
+// NewPipeline 创建图片处理流水线，workers 为并发数（小于 1 时按 1 处理）
+func NewPipeline(processor *Processor, log *zap.Logger, workers int) *Pipeline {
+	if workers < 1 {
+		workers = 1
+	}
+	p := &Pipeline{
+		processor: processor,
+		log:       log,
+		workers:   workers,
+	}
+	p.handle = p.process
+	return p
+}
+
+// Run 并发执行所有任务，报告按任务顺序返回
+// ctx 取消后不再开始新任务并中断进行中的下载和上传，这些任务标记为 cancelled
+func (p *Pipeline) Run(ctx context.Context, jobs []Job) *PipelineReport {
+	start := time.Now()
+	report := &PipelineReport{
+		Total:  len(jobs),
+		Images: make([]JobReport, len(jobs)),
+	}
+
+	queue := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < min(p.workers, len(jobs)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range queue {
+				report.Images[i] = p.runJob(ctx, jobs[i])
+			}
+		}()
+	}
+
+	// 分发任务，取消后剩余任务直接标记
+	for i, job := range jobs {
+		if ctx.Err() != nil {
+			report.Images[i] = cancelledReport(job, ctx.Err())
+			continue
+		}
+		select {
+		case queue <- i:
+		case <-ctx.Done():
+			report.Images[i] = cancelledReport(job, ctx.Err())
+		}
+	}
+	close(queue)
+	wg.Wait()
+
+	for _, r := range report.Images {
+		switch r.Status {
+		case StatusUploaded:
+			report.Uploaded++
+		case StatusFailed:
+			report.Failed++
+		case StatusCancelled:
+			report.Cancelled++
+		}
+	}
+	report.DurationMs = time.Since(start).Milliseconds()
+
+	return report
+}
+
+// runJob 执行单个任务
+func (p *Pipeline) runJob(ctx context.Context, job Job) JobReport {
+	report := JobReport{
+		Index:  job.Index,
+		Type:   job.Type,
+		Source: job.Source,
+	}
+	if err := ctx.Err(); err != nil {
+		return cancelledReport(job, err)
+	}
+
+	start := time.Now()
+	result, err := p.handle(ctx, job)
+	report.DurationMs = time.Since(start).Milliseconds()
+
+	if err != nil && ctx.Err() != nil {
+		// 进行中的下载或上传因取消而中断
+		return cancelledReport(job, ctx.Err())
+	}
+	if err != nil {
+		report.Status = StatusFailed
+		report.Error = err.Error()
+		p.log.Warn("image processing failed",
+			zap.Int("index", job.Index),
+			zap.String("type", string(job.Type)),
+			zap.Error(err))
+		return report
+	}
+
+	report.Status = StatusUploaded
+	report.MediaID = result.MediaID
+	report.WechatURL = result.WechatURL
+	report.BytesBefore = result.OriginalSize
+	report.BytesAfter = result.UploadedSize
+	report.Cached = result.Cached
+
+	p.log.Info("image uploaded",
+		zap.Int("index", job.Index),
+		zap.String("type", string(job.Type)),
+		zap.Int64("duration_ms", report.DurationMs))
+
+	return report
+}
+
+// process 根据任务类型调用对应的处理方法
+func (p *Pipeline) process(ctx context.Context, job Job) (*UploadResult, error) {
+	kind := job.Kind
+	if kind == "" {
+		kind = UploadMaterial
+	}
+
+	switch job.Type {
+	case JobLocal:
+		return p.processor.uploadLocal(ctx, job.Source, kind)
+	case JobOnline:
+		return p.processor.downloadAndUpload(ctx, job.Source, kind)
+	case JobGenerate:
+		result, err := p.processor.generateAndUpload(ctx, job.Source, kind)
+		if err != nil {
+			return nil, err
+		}
+		return &UploadResult{
+			MediaID:      result.MediaID,
+			WechatURL:    result.WechatURL,
+			OriginalSize: result.OriginalSize,
+			UploadedSize: result.UploadedSize,
+			Cached:       result.Cached,
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown image job type: %s", job.Type)
+	}
+}
+
+// cancelledReport 生成已取消任务的报告
+func cancelledReport(job Job, err error) JobReport {
+	return JobReport{
+		Index:  job.Index,
+		Type:   job.Type,
+		Source: job.Source,
+		Status: StatusCancelled,
+		Error:  err.Error(),
+	}
+}
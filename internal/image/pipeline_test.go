@@ -0,0 +1,111 @@
+package image
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/geekjourneyx/md2wechat-skill/internal/config"
+	"go.uber.org/zap"
+)
+
+func TestPipeline_Run(t *testing.T) {
+	var running, maxRunning int32
+	pipeline := NewPipeline(nil, zap.NewNop(), 2)
+	pipeline.handle = func(ctx context.Context, job Job) (*UploadResult, error) {
+		n := atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+		for {
+			m := atomic.LoadInt32(&maxRunning)
+			if n <= m || atomic.CompareAndSwapInt32(&maxRunning, m, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+
+		if job.Source == "bad.png" {
+			return nil, errors.New("upload failed")
+		}
+		return &UploadResult{MediaID: "id-" + job.Source, WechatURL: "https://mmbiz.qpic.cn/" + job.Source, OriginalSize: 200, UploadedSize: 100}, nil
+	}
+
+	jobs := []Job{
+		{Index: 0, Type: JobLocal, Source: "a.png"},
+		{Index: 1, Type: JobLocal, Source: "bad.png"},
+		{Index: 2, Type: JobOnline, Source: "c.png"},
+		{Index: 3, Type: JobGenerate, Source: "d.png"},
+	}
+	report := pipeline.Run(context.Background(), jobs)
+
+	if report.Total != 4 || report.Uploaded != 3 || report.Failed != 1 {
+		t.Errorf("report = %+v, want 3 uploaded and 1 failed", report)
+	}
+	if report.OK() {
+		t.Error("OK() = true, want false")
+	}
+	if maxRunning > 2 {
+		t.Errorf("max concurrent jobs = %d, want <= 2", maxRunning)
+	}
+	for i, r := range report.Images {
+		if r.Index != i {
+			t.Errorf("Images[%d].Index = %d, want report in job order", i, r.Index)
+		}
+	}
+	if got := report.Images[0]; got.Status != StatusUploaded || got.MediaID != "id-a.png" || got.BytesBefore != 200 || got.BytesAfter != 100 {
+		t.Errorf("Images[0] = %+v", got)
+	}
+	if got := report.Images[1]; got.Status != StatusFailed || got.Error != "upload failed" {
+		t.Errorf("Images[1] = %+v", got)
+	}
+}
+
+func TestPipeline_RunCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	pipeline := NewPipeline(nil, zap.NewNop(), 4)
+	pipeline.handle = func(ctx context.Context, job Job) (*UploadResult, error) {
+		t.Errorf("job %d should not run after cancellation", job.Index)
+		return &UploadResult{}, nil
+	}
+
+	report := pipeline.Run(ctx, []Job{{Index: 0, Type: JobLocal}, {Index: 1, Type: JobLocal}})
+	if report.Cancelled != 2 || report.OK() {
+		t.Errorf("report = %+v, want all cancelled", report)
+	}
+}
+
+func TestPipeline_RunCancelledInFlight(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	started := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-r.Context().Done() // 下载一直不返回，直到请求被取消
+	}))
+	defer srv.Close()
+	go func() {
+		<-started
+		cancel()
+	}()
+
+	processor := &Processor{cfg: &config.Config{}, log: zap.NewNop()}
+	done := make(chan *PipelineReport)
+	go func() {
+		done <- NewPipeline(processor, zap.NewNop(), 1).Run(ctx, []Job{{Index: 0, Type: JobOnline, Source: srv.URL + "/a.png"}})
+	}()
+
+	select {
+	case report := <-done:
+		if report.Cancelled != 1 || report.Images[0].Status != StatusCancelled {
+			t.Errorf("report = %+v, want the download cancelled", report)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run() did not stop the in-flight download after cancellation")
+	}
+}
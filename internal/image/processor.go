@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/geekjourneyx/md2wechat-skill/internal/config"
 	"github.com/geekjourneyx/md2wechat-skill/internal/wechat"
@@ -17,6 +19,7 @@ type Processor struct {
 	ws         *wechat.Service
 	compressor *Compressor
 	provider   Provider
+	cache      *UploadCache // 为 nil 时不使用上传缓存
 }
 
 // NewProcessor 创建图片处理器
@@ -30,26 +33,57 @@ func NewProcessor(cfg *config.Config, log *zap.Logger) *Processor {
 		}
 	}
 
+	// 打开上传缓存，失败时不影响上传
+	cache, err := OpenUploadCache(cfg.CacheDir())
+	if err != nil {
+		log.Warn("failed to open upload cache, images will always be uploaded", zap.Error(err))
+		cache = nil
+	}
+
 	return &Processor{
 		cfg:        cfg,
 		log:        log,
 		ws:         wechat.NewService(cfg, log),
 		compressor: NewCompressor(log, cfg.MaxImageWidth, cfg.MaxImageSize),
 		provider:   provider,
+		cache:      cache,
 	}
 }
 
+// DisableCache 禁用上传缓存（总是重新上传）
+func (p *Processor) DisableCache() {
+	p.cache = nil
+}
+
 // UploadResult 上传结果
 type UploadResult struct {
 	MediaID   string `json:"media_id"`
 	WechatURL string `json:"wechat_url"`
 	Width     int    `json:"width"`
 	Height    int    `json:"height"`
+
+	OriginalSize int64 `json:"original_size,omitempty"` // 压缩前字节数
+	UploadedSize int64 `json:"uploaded_size,omitempty"` // 实际上传字节数
+	Cached       bool  `json:"cached,omitempty"`        // 是否命中上传缓存
 }
 
-// UploadLocalImage 上传本地图片
+// UploadKind 图片上传方式
+type UploadKind string
+
+const (
+	UploadMaterial UploadKind = "material" // 永久素材：返回 media_id，用于封面和小绿书
+	UploadContent  UploadKind = "content"  // 图文内容图片（uploadimg）：只返回 URL，不占素材配额
+	UploadCover    UploadKind = "cover"    // 封面：按永久素材上传，与正文素材分别缓存
+)
+
+// UploadLocalImage 上传本地图片（永久素材）
 func (p *Processor) UploadLocalImage(filePath string) (*UploadResult, error) {
-	p.log.Info("uploading local image", zap.String("path", filePath))
+	return p.uploadLocal(context.Background(), filePath, UploadMaterial)
+}
+
+// uploadLocal 按指定方式上传本地图片，ctx 取消时中断上传
+func (p *Processor) uploadLocal(ctx context.Context, filePath string, kind UploadKind) (*UploadResult, error) {
+	p.log.Info("uploading local image", zap.String("path", filePath), zap.String("kind", string(kind)))
 
 	// 检查文件是否存在
 	if _, err := os.Stat(filePath); os.IsNotExist(err) {
@@ -61,37 +95,29 @@ func (p *Processor) UploadLocalImage(filePath string) (*UploadResult, error) {
 		return nil, fmt.Errorf("unsupported image format: %s", filePath)
 	}
 
-	// 如果需要压缩，先处理
-	processedPath := filePath
-	if p.cfg.CompressImages {
-		compressedPath, compressed, err := p.compressor.CompressImage(filePath)
-		if err != nil {
-			p.log.Warn("compress failed, using original", zap.Error(err))
-		} else if compressed {
-			processedPath = compressedPath
-			defer os.Remove(compressedPath)
-			p.log.Info("using compressed image", zap.String("path", processedPath))
-		}
-	}
+	// 按需压缩后上传
+	return p.compressAndUpload(ctx, filePath, filePath, kind)
+}
 
-	// 上传到微信
-	result, err := p.ws.UploadMaterialWithRetry(processedPath, 3)
-	if err != nil {
-		return nil, err
+// UploadCover 上传封面图片（本地路径或在线图片 URL），相同内容复用已上传的 media_id
+func (p *Processor) UploadCover(imagePath string) (*UploadResult, error) {
+	if strings.HasPrefix(imagePath, "http://") || strings.HasPrefix(imagePath, "https://") {
+		return p.downloadAndUpload(context.Background(), imagePath, UploadCover)
 	}
-
-	return &UploadResult{
-		MediaID:   result.MediaID,
-		WechatURL: result.WechatURL,
-	}, nil
+	return p.uploadLocal(context.Background(), imagePath, UploadCover)
 }
 
-// DownloadAndUpload 下载在线图片并上传
+// DownloadAndUpload 下载在线图片并上传（永久素材）
 func (p *Processor) DownloadAndUpload(url string) (*UploadResult, error) {
-	p.log.Info("downloading and uploading image", zap.String("url", url))
+	return p.downloadAndUpload(context.Background(), url, UploadMaterial)
+}
+
+// downloadAndUpload 下载在线图片并按指定方式上传，ctx 取消时中断下载和上传
+func (p *Processor) downloadAndUpload(ctx context.Context, url string, kind UploadKind) (*UploadResult, error) {
+	p.log.Info("downloading and uploading image", zap.String("url", url), zap.String("kind", string(kind)))
 
 	// 下载图片
-	tmpPath, err := wechat.DownloadFile(url)
+	tmpPath, err := wechat.DownloadFileContext(ctx, url)
 	if err != nil {
 		return nil, fmt.Errorf("download failed: %w", err)
 	}
@@ -102,10 +128,15 @@ func (p *Processor) DownloadAndUpload(url string) (*UploadResult, error) {
 		return nil, fmt.Errorf("downloaded file is not a valid image")
 	}
 
-	// 压缩（如果需要）
-	processedPath := tmpPath
+	return p.compressAndUpload(ctx, tmpPath, url, kind)
+}
+
+// compressAndUpload 按配置压缩图片后上传到微信，并记录压缩前后的大小
+// 处理后的内容已上传过（同一 AppID、同一上传方式）时直接复用缓存结果
+func (p *Processor) compressAndUpload(ctx context.Context, filePath, source string, kind UploadKind) (*UploadResult, error) {
+	processedPath := filePath
 	if p.cfg.CompressImages {
-		compressedPath, compressed, err := p.compressor.CompressImage(tmpPath)
+		compressedPath, compressed, err := p.compressor.CompressImage(filePath)
 		if err != nil {
 			p.log.Warn("compress failed, using original", zap.Error(err))
 		} else if compressed {
@@ -115,16 +146,89 @@ func (p *Processor) DownloadAndUpload(url string) (*UploadResult, error) {
 		}
 	}
 
+	// uploadimg 只支持 1MB 以内的 jpg/png，其它图片改用永久素材
+	if kind == UploadContent && !isArticleImage(processedPath) {
+		p.log.Info("image not accepted by uploadimg, using permanent material",
+			zap.String("source", source))
+		kind = UploadMaterial
+	}
+
+	// 查找上传缓存
+	var hash string
+	if p.cache != nil {
+		data, err := os.ReadFile(processedPath)
+		if err != nil {
+			return nil, fmt.Errorf("read image: %w", err)
+		}
+		hash = HashContent(data)
+		if entry, ok := p.cache.Get(p.cfg.WechatAppID, kind, hash); ok {
+			p.log.Info("image cache hit, skip upload",
+				zap.String("source", source),
+				zap.String("sha256", hash[:12]))
+			return &UploadResult{
+				MediaID:      entry.MediaID,
+				WechatURL:    entry.WechatURL,
+				OriginalSize: fileSize(filePath),
+				UploadedSize: fileSize(processedPath),
+				Cached:       true,
+			}, nil
+		}
+	}
+
 	// 上传到微信
-	result, err := p.ws.UploadMaterialWithRetry(processedPath, 3)
+	var result *wechat.UploadMaterialResult
+	var err error
+	if kind == UploadContent {
+		result, err = p.ws.UploadArticleImageWithRetry(ctx, processedPath, 3)
+	} else {
+		result, err = p.ws.UploadMaterialWithRetry(ctx, processedPath, 3)
+	}
 	if err != nil {
 		return nil, err
 	}
 
-	return &UploadResult{
-		MediaID:   result.MediaID,
-		WechatURL: result.WechatURL,
-	}, nil
+	uploadResult := &UploadResult{
+		MediaID:      result.MediaID,
+		WechatURL:    result.WechatURL,
+		OriginalSize: fileSize(filePath),
+		UploadedSize: fileSize(processedPath),
+	}
+
+	// 写入上传缓存
+	if p.cache != nil {
+		p.cache.Put(CacheEntry{
+			AppID:     p.cfg.WechatAppID,
+			Kind:      kind,
+			Hash:      hash,
+			MediaID:   result.MediaID,
+			WechatURL: result.WechatURL,
+			Source:    source,
+			Size:      uploadResult.UploadedSize,
+		})
+		if err := p.cache.Save(); err != nil {
+			p.log.Warn("failed to save upload cache", zap.Error(err))
+		}
+	}
+
+	return uploadResult, nil
+}
+
+// isArticleImage 检查图片是否可以通过 uploadimg 接口上传
+func isArticleImage(filePath string) bool {
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".jpg", ".jpeg", ".png":
+		return fileSize(filePath) <= wechat.ArticleImageMaxSize
+	}
+	return false
+}
+
+// fileSize 获取文件大小，失败时返回 0
+func fileSize(path string) int64 {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0
+	}
+	return info.Size()
 }
 
 // GenerateAndUploadResult AI 生成图片结果
@@ -135,10 +239,19 @@ type GenerateAndUploadResult struct {
 	WechatURL   string `json:"wechat_url"`
 	Width       int    `json:"width"`
 	Height      int    `json:"height"`
+
+	OriginalSize int64 `json:"original_size,omitempty"` // 压缩前字节数
+	UploadedSize int64 `json:"uploaded_size,omitempty"` // 实际上传字节数
+	Cached       bool  `json:"cached,omitempty"`        // 是否命中上传缓存
 }
 
-// GenerateAndUpload AI 生成图片并上传
+// GenerateAndUpload AI 生成图片并上传（永久素材）
 func (p *Processor) GenerateAndUpload(prompt string) (*GenerateAndUploadResult, error) {
+	return p.generateAndUpload(context.Background(), prompt, UploadMaterial)
+}
+
+// generateAndUpload AI 生成图片并按指定方式上传（支持取消）
+func (p *Processor) generateAndUpload(ctx context.Context, prompt string, kind UploadKind) (*GenerateAndUploadResult, error) {
 	p.log.Info("generating image via AI", zap.String("prompt", prompt))
 
 	// 验证配置
@@ -152,7 +265,6 @@ func (p *Processor) GenerateAndUpload(prompt string) (*GenerateAndUploadResult,
 	}
 
 	// 调用图片生成 API
-	ctx := context.Background()
 	result, err := p.provider.Generate(ctx, prompt)
 	if err != nil {
 		return nil, fmt.Errorf("generate image: %w", err)
@@ -163,36 +275,26 @@ func (p *Processor) GenerateAndUpload(prompt string) (*GenerateAndUploadResult,
 		zap.String("size", result.Size))
 
 	// 下载生成的图片
-	tmpPath, err := wechat.DownloadFile(result.URL)
+	tmpPath, err := wechat.DownloadFileContext(ctx, result.URL)
 	if err != nil {
 		return nil, fmt.Errorf("download generated image: %w", err)
 	}
 	defer os.Remove(tmpPath)
 
-	// 压缩（如果需要）
-	processedPath := tmpPath
-	if p.cfg.CompressImages {
-		compressedPath, compressed, err := p.compressor.CompressImage(tmpPath)
-		if err != nil {
-			p.log.Warn("compress failed, using original", zap.Error(err))
-		} else if compressed {
-			processedPath = compressedPath
-			defer os.Remove(compressedPath)
-			p.log.Info("using compressed image", zap.String("path", processedPath))
-		}
-	}
-
-	// 上传到微信
-	uploadResult, err := p.ws.UploadMaterialWithRetry(processedPath, 3)
+	// 压缩（如果需要）并上传到微信
+	uploadResult, err := p.compressAndUpload(ctx, tmpPath, result.URL, kind)
 	if err != nil {
 		return nil, err
 	}
 
 	return &GenerateAndUploadResult{
-		Prompt:      prompt,
-		OriginalURL: result.URL,
-		MediaID:     uploadResult.MediaID,
-		WechatURL:   uploadResult.WechatURL,
+		Prompt:       prompt,
+		OriginalURL:  result.URL,
+		MediaID:      uploadResult.MediaID,
+		WechatURL:    uploadResult.WechatURL,
+		OriginalSize: uploadResult.OriginalSize,
+		UploadedSize: uploadResult.UploadedSize,
+		Cached:       uploadResult.Cached,
 	}, nil
 }
 
@@ -242,7 +344,7 @@ func (p *Processor) GenerateAndUploadWithSize(prompt string, size string) (*Gene
 	defer os.Remove(tmpPath)
 
 	// 上传到微信
-	uploadResult, err := p.ws.UploadMaterialWithRetry(tmpPath, 3)
+	uploadResult, err := p.ws.UploadMaterialWithRetry(ctx, tmpPath, 3)
 	if err != nil {
 		return nil, err
 	}
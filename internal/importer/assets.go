@@ -0,0 +1,204 @@
+package importer
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// maxImageBytes 单张图片最大字节数
+const maxImageBytes = 50 * 1024 * 1024
+
+// imageExtensions 按内容识别的图片类型对应的扩展名
+var imageExtensions = map[string]string{
+	"image/png":  ".png",
+	"image/jpeg": ".jpg",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+	"image/bmp":  ".bmp",
+}
+
+// ImageResult 一张图片的保存结果
+type ImageResult struct {
+	Source string `json:"source"`
+	Path   string `json:"path,omitempty"`   // Markdown 中使用的相对路径
+	Cached bool   `json:"cached,omitempty"` // 文件已存在，未重新下载
+	Error  string `json:"error,omitempty"`
+}
+
+// SaveImages 将图片保存到 dir：下载在线图片、解码 data: 图片、复制本地图片（相对路径基于 baseDir）
+// baseDir 为空时（导入网页）只接受 http(s) 和 data: 图片，页面中的 file: 等地址不会读取本地文件
+// 文件名取图片地址的哈希，重复导入同一篇文章不会重复下载；rel 为 dir 相对于 Markdown 文件的路径
+// 返回图片地址到 Markdown 路径的映射，保存失败的图片不在映射中（保留原地址）
+func SaveImages(ctx context.Context, client *http.Client, sources []string, baseDir, dir, rel string) (map[string]string, []ImageResult) {
+	paths := make(map[string]string)
+	results := make([]ImageResult, 0, len(sources))
+	for _, src := range sources {
+		result := ImageResult{Source: src}
+		name, cached, err := saveImage(ctx, client, src, baseDir, dir)
+		if err != nil {
+			result.Error = err.Error()
+		} else {
+			result.Path = path.Join(filepath.ToSlash(rel), name)
+			result.Cached = cached
+			paths[src] = result.Path
+		}
+		if strings.HasPrefix(src, "data:") {
+			// data: 地址可能很长，报告中只保留类型
+			result.Source, _, _ = strings.Cut(src, ",")
+		}
+		results = append(results, result)
+	}
+	return paths, results
+}
+
+// saveImage 保存一张图片，返回文件名
+func saveImage(ctx context.Context, client *http.Client, src, baseDir, dir string) (string, bool, error) {
+	sum := sha256.Sum256([]byte(src))
+	base := hex.EncodeToString(sum[:8])
+	if matches, _ := filepath.Glob(filepath.Join(dir, base+".*")); len(matches) > 0 {
+		return filepath.Base(matches[0]), true, nil
+	}
+
+	data, err := loadImage(ctx, client, src, baseDir)
+	if err != nil {
+		return "", false, err
+	}
+	ext, err := imageExt(data)
+	if err != nil {
+		return "", false, err
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", false, fmt.Errorf("create assets dir: %w", err)
+	}
+	name := base + ext
+	if err := os.WriteFile(filepath.Join(dir, name), data, 0644); err != nil {
+		return "", false, fmt.Errorf("write image: %w", err)
+	}
+	return name, false, nil
+}
+
+// loadImage 读取图片内容
+func loadImage(ctx context.Context, client *http.Client, src, baseDir string) ([]byte, error) {
+	if strings.HasPrefix(src, "data:") {
+		return decodeDataURI(src)
+	}
+
+	u, err := url.Parse(src)
+	if err == nil && (u.Scheme == "http" || u.Scheme == "https") {
+		return downloadImage(ctx, client, src)
+	}
+
+	if baseDir == "" {
+		return nil, fmt.Errorf("unsupported image source (only http, https and data: for web pages)")
+	}
+
+	// 本地图片（如浏览器“另存为”生成的 xxx_files 目录）
+	p := src
+	if err == nil && (u.Scheme == "file" || u.Scheme == "") {
+		p = u.Path
+	}
+	if !filepath.IsAbs(p) {
+		p = filepath.Join(baseDir, filepath.FromSlash(p))
+	}
+	data, err := os.ReadFile(p)
+	if err != nil {
+		return nil, fmt.Errorf("read image: %w", err)
+	}
+	return data, nil
+}
+
+// downloadImage 下载在线图片
+func downloadImage(ctx context.Context, client *http.Client, src string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, src, nil)
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("User-Agent", userAgent)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("download image: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("download image: status %d", resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxImageBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("download image: %w", err)
+	}
+	if len(data) > maxImageBytes {
+		return nil, fmt.Errorf("image larger than %d bytes", maxImageBytes)
+	}
+	return data, nil
+}
+
+// decodeDataURI 解码 data: 图片
+func decodeDataURI(src string) ([]byte, error) {
+	header, payload, ok := strings.Cut(strings.TrimPrefix(src, "data:"), ",")
+	if !ok {
+		return nil, fmt.Errorf("invalid data uri")
+	}
+	if strings.HasSuffix(header, ";base64") {
+		data, err := base64.StdEncoding.DecodeString(payload)
+		if err != nil {
+			return nil, fmt.Errorf("decode data uri: %w", err)
+		}
+		return data, nil
+	}
+	text, err := url.PathUnescape(payload)
+	if err != nil {
+		return nil, fmt.Errorf("decode data uri: %w", err)
+	}
+	return []byte(text), nil
+}
+
+// imageExt 根据内容确定扩展名（不参考地址中的扩展名或 wx_fmt 参数，内容不是图片时报错）
+func imageExt(data []byte) (string, error) {
+	contentType := http.DetectContentType(data)
+	if ext, ok := imageExtensions[contentType]; ok {
+		return ext, nil
+	}
+	if isSVG(data) {
+		return ".svg", nil
+	}
+	return "", fmt.Errorf("not an image (%s)", contentType)
+}
+
+// isSVG 判断内容是否为 SVG：跳过 XML 声明、注释和 DOCTYPE 后以 <svg 开头
+func isSVG(data []byte) bool {
+	if len(data) > 4096 {
+		data = data[:4096]
+	}
+	s := strings.TrimSpace(strings.TrimPrefix(string(data), "\ufeff"))
+	for {
+		var end string
+		switch {
+		case strings.HasPrefix(s, "<?"):
+			end = "?>"
+		case strings.HasPrefix(s, "<!--"):
+			end = "-->"
+		case strings.HasPrefix(s, "<!"):
+			end = ">"
+		default:
+			return strings.HasPrefix(s, "<svg") && len(s) > 4 && strings.ContainsRune(" \t\r\n>/", rune(s[4]))
+		}
+		_, rest, ok := strings.Cut(s, end)
+		if !ok {
+			return false
+		}
+		s = strings.TrimSpace(rest)
+	}
+}
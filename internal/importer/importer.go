@@ -0,0 +1,212 @@
+// Package importer 将微信公众号文章页面或草稿 HTML 还原为 Markdown
+// 去除内联样式，还原标题、列表、引用、代码块和表格，图片可下载到本地目录
+package importer
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/geekjourneyx/md2wechat-skill/internal/converter"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+	"gopkg.in/yaml.v3"
+)
+
+// userAgent 抓取文章页面时使用的浏览器标识（微信对非浏览器请求会返回验证页）
+const userAgent = "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0 Safari/537.36"
+
+// maxPageBytes 文章页面最大字节数
+const maxPageBytes = 20 * 1024 * 1024
+
+// Article 解析后的文章
+type Article struct {
+	Meta converter.ArticleMeta // 标题、作者、摘要
+
+	content *html.Node // 正文根节点
+	base    *url.URL   // 页面地址，用于解析相对图片地址（本地文件为 nil）
+}
+
+// Parse 解析文章 HTML
+// 微信文章页面取 #js_content 作为正文，并从页面中读取标题、作者和摘要；其它 HTML（如草稿内容）整体作为正文
+// pageURL 为页面地址（本地文件传空字符串）
+func Parse(src, pageURL string) (*Article, error) {
+	doc, err := html.Parse(strings.NewReader(src))
+	if err != nil {
+		return nil, fmt.Errorf("parse html: %w", err)
+	}
+
+	a := &Article{}
+	if pageURL != "" {
+		if a.base, err = url.Parse(pageURL); err != nil {
+			return nil, fmt.Errorf("invalid url %q: %w", pageURL, err)
+		}
+	}
+
+	a.content = findByID(doc, "js_content")
+	if a.content == nil {
+		a.content = findElement(doc, atom.Body)
+	}
+	if a.content == nil {
+		a.content = doc
+	}
+
+	a.Meta.Title = firstNonEmpty(
+		nodeText(findByID(doc, "activity-name")),
+		metaContent(doc, "og:title"),
+		metaContent(doc, "twitter:title"),
+		nodeText(findElement(doc, atom.Title)),
+	)
+	a.Meta.Author = firstNonEmpty(
+		nodeText(findByID(doc, "js_author_name")),
+		metaContent(doc, "author"),
+		metaContent(doc, "og:article:author"),
+	)
+	a.Meta.Digest = firstNonEmpty(
+		metaContent(doc, "description"),
+		metaContent(doc, "og:description"),
+	)
+	return a, nil
+}
+
+// ImageSources 正文中的图片地址（按出现顺序，已去重并解析为绝对地址）
+func (a *Article) ImageSources() []string {
+	var sources []string
+	seen := make(map[string]bool)
+	w := &writer{image: func(src string) string {
+		if !seen[src] {
+			seen[src] = true
+			sources = append(sources, src)
+		}
+		return src
+	}, base: a.base}
+	w.render(a.content)
+	return sources
+}
+
+// Markdown 生成 Markdown
+// images 将图片地址映射为 Markdown 中使用的路径，未包含的图片保留原地址；frontMatter 为 true 时输出 front matter
+func (a *Article) Markdown(images map[string]string, frontMatter bool) (string, error) {
+	w := &writer{image: func(src string) string {
+		if path, ok := images[src]; ok {
+			return path
+		}
+		return src
+	}, base: a.base}
+	body := w.render(a.content)
+
+	if !frontMatter || (a.Meta.Title == "" && a.Meta.Author == "" && a.Meta.Digest == "") {
+		return body, nil
+	}
+	data, err := yaml.Marshal(&a.Meta)
+	if err != nil {
+		return "", fmt.Errorf("marshal front matter: %w", err)
+	}
+	return "---\n" + string(data) + "---\n\n" + body, nil
+}
+
+// Fetch 下载文章页面
+func Fetch(ctx context.Context, client *http.Client, pageURL string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, pageURL, nil)
+	if err != nil {
+		return "", fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("User-Agent", userAgent)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("fetch article: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("fetch article: status %d", resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxPageBytes))
+	if err != nil {
+		return "", fmt.Errorf("read article: %w", err)
+	}
+	return string(data), nil
+}
+
+// NewHTTPClient 创建下载文章和图片使用的 HTTP 客户端
+func NewHTTPClient() *http.Client {
+	return &http.Client{Timeout: 60 * time.Second}
+}
+
+// findByID 查找指定 id 的元素
+func findByID(n *html.Node, id string) *html.Node {
+	return find(n, func(n *html.Node) bool { return attr(n, "id") == id })
+}
+
+// findElement 查找第一个指定标签的元素
+func findElement(n *html.Node, tag atom.Atom) *html.Node {
+	return find(n, func(n *html.Node) bool { return n.DataAtom == tag })
+}
+
+// find 深度优先查找第一个满足条件的元素
+func find(n *html.Node, match func(*html.Node) bool) *html.Node {
+	if n.Type == html.ElementNode && match(n) {
+		return n
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if found := find(c, match); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// metaContent 读取 <meta name|property="key" content="..."> 的内容
+func metaContent(doc *html.Node, key string) string {
+	n := find(doc, func(n *html.Node) bool {
+		return n.DataAtom == atom.Meta && (attr(n, "name") == key || attr(n, "property") == key)
+	})
+	if n == nil {
+		return ""
+	}
+	return strings.TrimSpace(attr(n, "content"))
+}
+
+// attr 读取元素属性
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+// nodeText 元素的文字内容（合并空白）
+func nodeText(n *html.Node) string {
+	if n == nil {
+		return ""
+	}
+	var b strings.Builder
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			b.WriteString(n.Data)
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(n)
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// firstNonEmpty 返回第一个非空字符串
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
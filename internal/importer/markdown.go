@@ -0,0 +1,573 @@
+package importer
+
+import (
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// hardBreak <br> 在行内内容中的标记，整理段落空白后再转换为 Markdown 换行
+const hardBreak = "\x00"
+
+var (
+	// spaceRun 连续空白（含 &nbsp;），段落中合并为一个空格
+	spaceRun = regexp.MustCompile(`[ \t\r\n\f\x{00a0}]+`)
+
+	// lineStartPattern 行首会被识别为块级语法的文字：标题、引用、列表、分割线
+	lineStartPattern = regexp.MustCompile(`^(?:#{1,6}(?:\s|$)|>|[-+](?:\s|$)|[-=]+\s*$|\d{1,9}[.)](?:\s|$))`)
+
+	// listItemPattern 渲染后的列表项
+	listItemPattern = regexp.MustCompile(`^(?:- |\d+\. )`)
+
+	// codeLanguagePattern 从 class 中识别代码语言（language-go、lang-go）
+	codeLanguagePattern = regexp.MustCompile(`(?i)\blang(?:uage)?-([\w+#-]+)`)
+
+	// boldStylePattern 和 italicStylePattern 识别用样式表示的粗体和斜体
+	boldStylePattern   = regexp.MustCompile(`(?i)font-weight\s*:\s*(?:bold|[6-9]00)`)
+	italicStylePattern = regexp.MustCompile(`(?i)font-style\s*:\s*italic`)
+	strikeStylePattern = regexp.MustCompile(`(?i)text-decoration[\w-]*\s*:[^;]*line-through`)
+	hiddenStylePattern = regexp.MustCompile(`(?i)display\s*:\s*none`)
+)
+
+// skippedTags 不输出的元素：脚本样式、媒体和公众号专用组件（名片、音频、视频号等）
+var skippedTags = map[string]bool{
+	"head": true, "script": true, "style": true, "title": true, "meta": true, "link": true,
+	"noscript": true, "template": true, "iframe": true, "svg": true, "video": true, "audio": true,
+	"object": true, "embed": true, "button": true, "input": true, "select": true, "textarea": true,
+	"mpvoice": true, "mpvideo": true, "qqmusic": true, "mpprofile": true, "mp-common-profile": true,
+	"mp-style-type": true, "mp-common-videosnap": true, "mp-common-mpaudio": true,
+}
+
+// blockTags 块级元素，其余元素按行内内容处理
+var blockTags = map[string]bool{
+	"html": true, "body": true, "address": true, "article": true, "aside": true, "blockquote": true,
+	"center": true, "dd": true, "details": true, "div": true, "dl": true, "dt": true, "fieldset": true,
+	"figcaption": true, "figure": true, "footer": true, "form": true, "header": true, "hr": true,
+	"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true, "li": true, "main": true,
+	"nav": true, "ol": true, "p": true, "pre": true, "section": true, "summary": true, "table": true, "ul": true,
+}
+
+// writer 将 HTML 节点树转换为 Markdown
+type writer struct {
+	image func(src string) string // 图片地址到 Markdown 路径的映射
+	base  *url.URL
+
+	// 当前所在的强调元素层数，嵌套的同类元素只输出一次标记
+	bold, italic, strike int
+}
+
+// render 渲染节点的全部内容
+func (w *writer) render(n *html.Node) string {
+	blocks := w.blocks(n)
+	if len(blocks) == 0 {
+		return ""
+	}
+	return strings.Join(blocks, "\n\n") + "\n"
+}
+
+// blocks 渲染子节点为块列表：连续的行内内容合并为一个段落
+func (w *writer) blocks(n *html.Node) []string {
+	var out []string
+	var para strings.Builder
+	flush := func() {
+		if p := paragraph(para.String()); p != "" {
+			out = append(out, p)
+		}
+		para.Reset()
+	}
+
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if skipped(c) {
+			continue
+		}
+		if c.Type == html.ElementNode && blockTags[c.Data] {
+			flush()
+			out = append(out, w.block(c)...)
+			continue
+		}
+		para.WriteString(w.inline(c))
+	}
+	flush()
+	return out
+}
+
+// block 渲染块级元素
+func (w *writer) block(n *html.Node) []string {
+	switch n.DataAtom {
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		// 标题本身已加粗，不再输出粗体标记
+		w.bold++
+		text := strings.Join(strings.Fields(strings.ReplaceAll(w.inlineChildren(n), hardBreak, " ")), " ")
+		w.bold--
+		if text == "" {
+			return nil
+		}
+		return []string{strings.Repeat("#", int(n.Data[1]-'0')) + " " + text}
+	case atom.Blockquote:
+		inner := strings.Join(w.blocks(n), "\n\n")
+		if inner == "" {
+			return nil
+		}
+		lines := strings.Split(inner, "\n")
+		for i, line := range lines {
+			if line == "" {
+				lines[i] = ">"
+			} else {
+				lines[i] = "> " + line
+			}
+		}
+		return []string{strings.Join(lines, "\n")}
+	case atom.Ul, atom.Ol:
+		if list := w.list(n); list != "" {
+			return []string{list}
+		}
+		return nil
+	case atom.Pre:
+		return []string{codeBlock(n)}
+	case atom.Table:
+		if table := w.table(n); table != "" {
+			return []string{table}
+		}
+		return nil
+	case atom.Hr:
+		return []string{"---"}
+	}
+	return w.blocks(n)
+}
+
+// inline 渲染行内节点
+func (w *writer) inline(n *html.Node) string {
+	switch n.Type {
+	case html.TextNode:
+		return escapeText(n.Data)
+	case html.ElementNode:
+	default:
+		return ""
+	}
+	if skipped(n) {
+		return ""
+	}
+
+	switch n.DataAtom {
+	case atom.Br:
+		return hardBreak
+	case atom.Strong, atom.B:
+		return w.emphasis(n, &w.bold, "**")
+	case atom.Em, atom.I:
+		return w.emphasis(n, &w.italic, "*")
+	case atom.Del, atom.S, atom.Strike:
+		return w.emphasis(n, &w.strike, "~~")
+	case atom.Code, atom.Kbd, atom.Tt:
+		return codeSpan(rawText(n))
+	case atom.A:
+		return w.link(n)
+	case atom.Img:
+		return w.img(n)
+	}
+
+	// 嵌在行内元素中的块级元素（如 <span><p>）按换行分隔
+	if blockTags[n.Data] {
+		return hardBreak + w.inlineChildren(n) + hardBreak
+	}
+
+	// 公众号编辑器常用 <span style="font-weight:bold"> 表示粗体
+	style := attr(n, "style")
+	switch {
+	case boldStylePattern.MatchString(style):
+		return w.emphasis(n, &w.bold, "**")
+	case italicStylePattern.MatchString(style):
+		return w.emphasis(n, &w.italic, "*")
+	case strikeStylePattern.MatchString(style):
+		return w.emphasis(n, &w.strike, "~~")
+	}
+	return w.inlineChildren(n)
+}
+
+// inlineChildren 渲染全部子节点的行内内容
+func (w *writer) inlineChildren(n *html.Node) string {
+	var b strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		b.WriteString(w.inline(c))
+	}
+	return b.String()
+}
+
+// emphasis 渲染粗体、斜体或删除线，首尾空白移到标记外（**text** 内侧不能有空格）
+func (w *writer) emphasis(n *html.Node, depth *int, marker string) string {
+	*depth++
+	inner := w.inlineChildren(n)
+	*depth--
+	if *depth > 0 {
+		return inner
+	}
+
+	trimmed := strings.TrimFunc(inner, isSpaceOrBreak)
+	if trimmed == "" {
+		return inner
+	}
+	lead := inner[:len(inner)-len(strings.TrimLeftFunc(inner, isSpaceOrBreak))]
+	trail := inner[len(lead)+len(trimmed):]
+	return lead + marker + trimmed + marker + trail
+}
+
+// link 渲染链接；页内锚点和 javascript: 链接只保留文字
+func (w *writer) link(n *html.Node) string {
+	inner := w.inlineChildren(n)
+	href := strings.TrimSpace(attr(n, "href"))
+	if href == "" || strings.HasPrefix(href, "#") || strings.HasPrefix(strings.ToLower(href), "javascript:") {
+		return inner
+	}
+	href = w.resolve(href)
+
+	text := strings.TrimFunc(inner, isSpaceOrBreak)
+	switch text {
+	case "":
+		return inner
+	case escapeText(href):
+		return "<" + href + ">"
+	}
+	return "[" + text + "](" + destination(href) + ")"
+}
+
+// img 渲染图片；公众号文章的图片地址在 data-src 中（src 为懒加载占位图）
+func (w *writer) img(n *html.Node) string {
+	src := strings.TrimSpace(firstNonEmpty(attr(n, "data-src"), attr(n, "src")))
+	if src == "" {
+		return ""
+	}
+	src = w.resolve(src)
+	return "![" + escapeText(attr(n, "alt")) + "](" + destination(w.image(src)) + ")"
+}
+
+// resolve 将相对地址解析为绝对地址（本地文件中的相对地址保持不变）
+func (w *writer) resolve(ref string) string {
+	if strings.HasPrefix(ref, "data:") {
+		return ref
+	}
+	if strings.HasPrefix(ref, "//") {
+		ref = "https:" + ref
+	}
+	if w.base == nil {
+		return ref
+	}
+	u, err := url.Parse(ref)
+	if err != nil {
+		return ref
+	}
+	return w.base.ResolveReference(u).String()
+}
+
+// list 渲染列表，列表项的后续行按标记宽度缩进
+func (w *writer) list(n *html.Node) string {
+	ordered := n.DataAtom == atom.Ol
+	number := 1
+	if start, err := strconv.Atoi(attr(n, "start")); err == nil && ordered {
+		number = start
+	}
+
+	var items []string
+	indent := 2
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type != html.ElementNode || skipped(c) {
+			continue
+		}
+		// 直接嵌在列表中的子列表归入上一项
+		if (c.DataAtom == atom.Ul || c.DataAtom == atom.Ol) && len(items) > 0 {
+			if sub := w.list(c); sub != "" {
+				items[len(items)-1] += "\n" + indentLines(sub, indent, true)
+			}
+			continue
+		}
+
+		marker := "- "
+		if ordered {
+			marker = strconv.Itoa(number) + ". "
+			number++
+		}
+		indent = len(marker)
+
+		var body strings.Builder
+		for i, b := range w.blocks(c) {
+			if i > 0 {
+				if isList(b) {
+					body.WriteString("\n")
+				} else {
+					body.WriteString("\n\n")
+				}
+			}
+			body.WriteString(b)
+		}
+		items = append(items, strings.TrimRight(marker+indentLines(body.String(), indent, false), " "))
+	}
+	return strings.Join(items, "\n")
+}
+
+// table 渲染 GFM 表格，第一行作为表头
+func (w *writer) table(n *html.Node) string {
+	var rows [][]string
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			switch c.DataAtom {
+			case atom.Thead, atom.Tbody, atom.Tfoot:
+				walk(c)
+			case atom.Tr:
+				var row []string
+				for cell := c.FirstChild; cell != nil; cell = cell.NextSibling {
+					if cell.DataAtom != atom.Th && cell.DataAtom != atom.Td {
+						continue
+					}
+					text := strings.ReplaceAll(normalize(w.inlineChildren(cell)), "  \n", "<br>")
+					text = strings.ReplaceAll(text, "\n\n", "<br>")
+					row = append(row, strings.ReplaceAll(text, "|", `\|`))
+				}
+				if len(row) > 0 {
+					rows = append(rows, row)
+				}
+			}
+		}
+	}
+	walk(n)
+	if len(rows) == 0 {
+		return ""
+	}
+
+	cols := 0
+	for _, row := range rows {
+		cols = max(cols, len(row))
+	}
+	var b strings.Builder
+	writeRow := func(row []string) {
+		for len(row) < cols {
+			row = append(row, "")
+		}
+		b.WriteString("| " + strings.Join(row, " | ") + " |\n")
+	}
+	writeRow(rows[0])
+	sep := make([]string, cols)
+	for i := range sep {
+		sep[i] = "---"
+	}
+	writeRow(sep)
+	for _, row := range rows[1:] {
+		writeRow(row)
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// codeBlock 渲染代码块
+// 支持 <pre><code> 中用 <br> 换行的高亮代码，以及公众号编辑器每行一个 <code> 的代码块；行号不输出
+func codeBlock(pre *html.Node) string {
+	var b strings.Builder
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			switch {
+			case c.Type == html.TextNode:
+				b.WriteString(c.Data)
+			case c.Type != html.ElementNode || skipped(c) || isLineNumber(c):
+			case c.DataAtom == atom.Br:
+				b.WriteString("\n")
+			case c.DataAtom == atom.Code && prevElement(c) != nil && prevElement(c).DataAtom == atom.Code:
+				b.WriteString("\n")
+				walk(c)
+			default:
+				walk(c)
+			}
+		}
+	}
+	walk(pre)
+	code := strings.Trim(strings.ReplaceAll(b.String(), "\u00a0", " "), "\n")
+
+	fence := "```"
+	for strings.Contains(code, fence) {
+		fence += "`"
+	}
+	return fence + codeLanguage(pre) + "\n" + code + "\n" + fence
+}
+
+// codeLanguage 代码块的语言：data-lang 属性或 pre/code 上的 language-xxx 类
+func codeLanguage(pre *html.Node) string {
+	if lang := attr(pre, "data-lang"); lang != "" {
+		return strings.Fields(lang)[0]
+	}
+	for _, n := range []*html.Node{pre, findElement(pre, atom.Code)} {
+		if n == nil {
+			continue
+		}
+		if m := codeLanguagePattern.FindStringSubmatch(attr(n, "class")); m != nil {
+			return m[1]
+		}
+	}
+	return ""
+}
+
+// isLineNumber 判断是否为行号元素：公众号代码块的行号列表，或本工具高亮输出的行号 <span>
+func isLineNumber(n *html.Node) bool {
+	if strings.Contains(attr(n, "class"), "line-index") {
+		return true
+	}
+	if n.DataAtom != atom.Span || !strings.Contains(attr(n, "style"), "inline-block") {
+		return false
+	}
+	text := rawText(n)
+	return text != "" && strings.TrimFunc(text, unicode.IsDigit) == ""
+}
+
+// prevElement 上一个兄弟元素
+func prevElement(n *html.Node) *html.Node {
+	for p := n.PrevSibling; p != nil; p = p.PrevSibling {
+		if p.Type == html.ElementNode {
+			return p
+		}
+	}
+	return nil
+}
+
+// skipped 判断节点是否不输出
+func skipped(n *html.Node) bool {
+	switch n.Type {
+	case html.TextNode:
+		return false
+	case html.ElementNode:
+		return skippedTags[n.Data] || hiddenStylePattern.MatchString(attr(n, "style")) ||
+			strings.Contains(attr(n, "class"), "code-snippet__line-index")
+	}
+	return true
+}
+
+// rawText 元素的原始文字（不转义、不合并空白）
+func rawText(n *html.Node) string {
+	var b strings.Builder
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type == html.TextNode {
+				b.WriteString(c.Data)
+			} else if c.DataAtom == atom.Br {
+				b.WriteString("\n")
+			} else {
+				walk(c)
+			}
+		}
+	}
+	walk(n)
+	return b.String()
+}
+
+// codeSpan 渲染行内代码，内容含反引号时使用更长的定界符
+func codeSpan(text string) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if text == "" {
+		return ""
+	}
+	fence := "`"
+	for strings.Contains(text, fence) {
+		fence += "`"
+	}
+	if len(fence) > 1 || strings.HasPrefix(text, "`") || strings.HasSuffix(text, "`") {
+		return fence + " " + text + " " + fence
+	}
+	return fence + text + fence
+}
+
+// paragraph 整理段落：合并空白、转换换行，并转义行首会被识别为块级语法的文字
+// 连续的多个 <br> 视为段落分隔
+func paragraph(s string) string {
+	text := normalize(s)
+	if text == "" {
+		return ""
+	}
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if loc := lineStartPattern.FindStringIndex(line); loc != nil {
+			if line[0] >= '0' && line[0] <= '9' {
+				// 有序列表：转义数字后的 . 或 )
+				j := strings.IndexAny(line, ".)")
+				lines[i] = line[:j] + `\` + line[j:]
+			} else {
+				lines[i] = `\` + line
+			}
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// normalize 合并空白并将换行标记转换为 Markdown 硬换行（两个空格加换行），连续换行转换为分段
+func normalize(s string) string {
+	s = spaceRun.ReplaceAllString(s, " ")
+	var b strings.Builder
+	blank := false
+	for _, line := range strings.Split(s, hardBreak) {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			blank = b.Len() > 0
+			continue
+		}
+		if b.Len() > 0 {
+			if blank {
+				b.WriteString("\n\n")
+			} else {
+				b.WriteString("  \n")
+			}
+		}
+		blank = false
+		b.WriteString(line)
+	}
+	return b.String()
+}
+
+// escapeText 转义文字中的 Markdown 特殊字符；单词中间的下划线不会被识别为强调，不转义
+func escapeText(s string) string {
+	var b strings.Builder
+	for i, r := range s {
+		switch r {
+		case '\\', '*', '`', '[', ']', '<':
+			b.WriteByte('\\')
+		case '_':
+			prev, _ := utf8.DecodeLastRuneInString(s[:i])
+			next, _ := utf8.DecodeRuneInString(s[i+1:])
+			if !isWordRune(prev) || !isWordRune(next) {
+				b.WriteByte('\\')
+			}
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// destination 链接和图片地址中的空格和括号需要编码
+func destination(u string) string {
+	return strings.NewReplacer(" ", "%20", "(", "%28", ")", "%29").Replace(u)
+}
+
+// indentLines 缩进多行文字；first 为 false 时第一行不缩进（接在列表标记后）
+func indentLines(s string, n int, first bool) string {
+	pad := strings.Repeat(" ", n)
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		if line != "" && (i > 0 || first) {
+			lines[i] = pad + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// isList 判断渲染后的块是否为列表
+func isList(block string) bool {
+	return listItemPattern.MatchString(block)
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func isSpaceOrBreak(r rune) bool {
+	return unicode.IsSpace(r) || r == '\x00'
+}
@@ -0,0 +1,694 @@
+package input
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"html"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// maxDocxPartBytes docx 中单个文件的最大解压字节数
+const maxDocxPartBytes = 100 * 1024 * 1024
+
+// monospaceFonts 视为行内代码的等宽字体
+var monospaceFonts = []string{"consolas", "courier", "menlo", "monaco", "source code", "jetbrains mono", "fira code", "lucida console"}
+
+// fieldHyperlinkPattern 域代码中的超链接：HYPERLINK "https://..."
+var fieldHyperlinkPattern = regexp.MustCompile(`HYPERLINK\s+"([^"]+)"`)
+
+// xmlNode 简化的 XML 节点（只保留本地名称）
+type xmlNode struct {
+	name     string
+	attrs    map[string]string
+	children []*xmlNode
+	text     string
+}
+
+// child 第一个指定名称的子节点
+func (n *xmlNode) child(name string) *xmlNode {
+	if n == nil {
+		return nil
+	}
+	for _, c := range n.children {
+		if c.name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+// attr 读取属性（n 为 nil 时返回空字符串）
+func (n *xmlNode) attr(name string) string {
+	if n == nil {
+		return ""
+	}
+	return n.attrs[name]
+}
+
+// find 第一个指定名称的后代节点
+func (n *xmlNode) find(name string) *xmlNode {
+	for _, c := range n.children {
+		if c.name == name {
+			return c
+		}
+		if found := c.find(name); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// parseXML 将 XML 解析为节点树
+func parseXML(r io.Reader) (*xmlNode, error) {
+	dec := xml.NewDecoder(r)
+	root := &xmlNode{}
+	stack := []*xmlNode{root}
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			n := &xmlNode{name: t.Name.Local, attrs: make(map[string]string, len(t.Attr))}
+			for _, a := range t.Attr {
+				n.attrs[a.Name.Local] = a.Value
+			}
+			parent := stack[len(stack)-1]
+			parent.children = append(parent.children, n)
+			stack = append(stack, n)
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+		case xml.CharData:
+			stack[len(stack)-1].text += string(t)
+		}
+	}
+	return root, nil
+}
+
+// styleKind 段落和字符样式的用途
+type styleKind struct {
+	heading int  // 标题级别（0 表示不是标题）
+	quote   bool // 引用
+	code    bool // 代码
+	list    int  // 列表样式：1 无序，2 有序
+}
+
+// docxReader 将 Word 文档转换为简单 HTML（再由 importer 转换为 Markdown）
+type docxReader struct {
+	files     map[string]*zip.File
+	rels      map[string]string       // 关系 ID -> 目标（外部链接或 word/ 下的文件）
+	styles    map[string]styleKind    // 样式 ID -> 用途
+	numbering map[string]map[int]bool // 编号 ID -> 级别 -> 是否为有序列表
+	mediaDir  string
+	media     map[string]string // 压缩包中的图片 -> 保存后的路径
+
+	out   strings.Builder
+	lists []string // 当前打开的列表标签（ul/ol），按级别
+	block string   // 当前合并中的块：quote / code
+}
+
+// loadDocx 将 Word 文档转换为 Markdown，图片解压到工作目录
+func loadDocx(path, workDir string) (*Document, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read docx file: %w", err)
+	}
+	zr, err := zip.NewReader(strings.NewReader(string(data)), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("open docx: %w", err)
+	}
+
+	d := &docxReader{
+		files:    make(map[string]*zip.File, len(zr.File)),
+		mediaDir: contentDir(workDir, "docx", data),
+		media:    make(map[string]string),
+	}
+	for _, f := range zr.File {
+		d.files[f.Name] = f
+	}
+
+	doc, err := d.parsePart("word/document.xml")
+	if err != nil {
+		return nil, err
+	}
+	if doc == nil {
+		return nil, fmt.Errorf("open docx: word/document.xml not found")
+	}
+	if err := d.loadRelations(); err != nil {
+		return nil, err
+	}
+	if err := d.loadStyles(); err != nil {
+		return nil, err
+	}
+	if err := d.loadNumbering(); err != nil {
+		return nil, err
+	}
+
+	d.out.WriteString("<html><head>")
+	if title := d.title(); title != "" {
+		d.out.WriteString("<title>" + html.EscapeString(title) + "</title>")
+	}
+	d.out.WriteString("</head><body>")
+	if body := doc.find("body"); body != nil {
+		if err := d.blocks(body); err != nil {
+			return nil, err
+		}
+	}
+	d.closeBlocks()
+	d.out.WriteString("</body></html>")
+
+	markdown, err := htmlToMarkdown(d.out.String(), d.mediaDir)
+	if err != nil {
+		return nil, err
+	}
+	return &Document{Format: FormatDocx, Markdown: markdown, Path: path}, nil
+}
+
+// parsePart 解析压缩包中的 XML 文件，不存在时返回 nil
+func (d *docxReader) parsePart(name string) (*xmlNode, error) {
+	f, ok := d.files[name]
+	if !ok {
+		return nil, nil
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return nil, fmt.Errorf("open %s: %w", name, err)
+	}
+	defer rc.Close()
+	n, err := parseXML(io.LimitReader(rc, maxDocxPartBytes))
+	if err != nil {
+		return nil, fmt.Errorf("parse %s: %w", name, err)
+	}
+	return n, nil
+}
+
+// loadRelations 读取正文引用的链接和图片
+func (d *docxReader) loadRelations() error {
+	d.rels = make(map[string]string)
+	root, err := d.parsePart("word/_rels/document.xml.rels")
+	if err != nil || root == nil {
+		return err
+	}
+	for _, rel := range root.find("Relationships").children {
+		target := rel.attr("Target")
+		if rel.attr("TargetMode") != "External" {
+			target = path.Join("word", target)
+			if strings.HasPrefix(rel.attr("Target"), "/") {
+				target = strings.TrimPrefix(rel.attr("Target"), "/")
+			}
+		}
+		d.rels[rel.attr("Id")] = target
+	}
+	return nil
+}
+
+// loadStyles 按样式名称识别标题、引用、代码和列表样式
+func (d *docxReader) loadStyles() error {
+	d.styles = make(map[string]styleKind)
+	root, err := d.parsePart("word/styles.xml")
+	if err != nil || root == nil {
+		return err
+	}
+	for _, s := range root.find("styles").children {
+		if s.name != "style" {
+			continue
+		}
+		name := strings.ToLower(s.child("name").attr("val"))
+		var kind styleKind
+		switch {
+		case strings.HasPrefix(name, "heading "):
+			kind.heading, _ = strconv.Atoi(strings.TrimPrefix(name, "heading "))
+		case name == "title":
+			kind.heading = 1
+		case strings.Contains(name, "quote") || name == "block text":
+			kind.quote = true
+		case strings.Contains(name, "code") || strings.Contains(name, "preformatted") || strings.Contains(name, "verbatim"):
+			kind.code = true
+		case strings.HasPrefix(name, "list bullet"):
+			kind.list = 1
+		case strings.HasPrefix(name, "list number"):
+			kind.list = 2
+		}
+		if kind.heading > 6 {
+			kind.heading = 6
+		}
+		d.styles[s.attr("styleId")] = kind
+	}
+	return nil
+}
+
+// loadNumbering 读取编号定义，区分有序和无序列表
+func (d *docxReader) loadNumbering() error {
+	d.numbering = make(map[string]map[int]bool)
+	root, err := d.parsePart("word/numbering.xml")
+	if err != nil || root == nil {
+		return err
+	}
+	numbering := root.find("numbering")
+	abstract := make(map[string]map[int]bool)
+	for _, n := range numbering.children {
+		if n.name != "abstractNum" {
+			continue
+		}
+		levels := make(map[int]bool)
+		for _, lvl := range n.children {
+			if lvl.name != "lvl" {
+				continue
+			}
+			ilvl, _ := strconv.Atoi(lvl.attr("ilvl"))
+			format := lvl.child("numFmt").attr("val")
+			levels[ilvl] = format != "bullet" && format != "none" && format != ""
+		}
+		abstract[n.attr("abstractNumId")] = levels
+	}
+	for _, n := range numbering.children {
+		if n.name == "num" {
+			d.numbering[n.attr("numId")] = abstract[n.child("abstractNumId").attr("val")]
+		}
+	}
+	return nil
+}
+
+// title 文档属性中的标题
+func (d *docxReader) title() string {
+	root, err := d.parsePart("docProps/core.xml")
+	if err != nil || root == nil {
+		return ""
+	}
+	return strings.TrimSpace(root.find("title").textContent())
+}
+
+// textContent 节点及其后代的文字
+func (n *xmlNode) textContent() string {
+	if n == nil {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString(n.text)
+	for _, c := range n.children {
+		b.WriteString(c.textContent())
+	}
+	return b.String()
+}
+
+// blocks 转换正文中的段落和表格
+func (d *docxReader) blocks(parent *xmlNode) error {
+	for _, n := range parent.children {
+		switch n.name {
+		case "p":
+			if err := d.paragraph(n); err != nil {
+				return err
+			}
+		case "tbl":
+			d.closeBlocks()
+			if err := d.table(n); err != nil {
+				return err
+			}
+		case "sdt":
+			if content := n.child("sdtContent"); content != nil {
+				if err := d.blocks(content); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// paragraph 转换段落：标题、列表项、引用、代码行或普通段落
+func (d *docxReader) paragraph(p *xmlNode) error {
+	props := p.child("pPr")
+	kind := d.styles[props.child("pStyle").attr("val")]
+	if lvl := props.child("outlineLvl"); lvl != nil && kind.heading == 0 {
+		if n, err := strconv.Atoi(lvl.attr("val")); err == nil && n < 6 {
+			kind.heading = n + 1
+		}
+	}
+
+	if kind.code {
+		if d.block != "code" {
+			d.closeBlocks()
+			d.out.WriteString("<pre><code>")
+			d.block = "code"
+		} else {
+			d.out.WriteString("\n")
+		}
+		d.out.WriteString(html.EscapeString(p.textContent()))
+		return nil
+	}
+
+	content, err := d.inline(p)
+	if err != nil {
+		return err
+	}
+
+	level, ordered, isList := d.listLevel(props, kind)
+	switch {
+	case kind.heading > 0:
+		d.closeBlocks()
+		tag := "h" + strconv.Itoa(kind.heading)
+		d.out.WriteString("<" + tag + ">" + content + "</" + tag + ">")
+	case isList:
+		d.closeBlock()
+		d.listItem(level, ordered, content)
+	case kind.quote:
+		if d.block != "quote" {
+			d.closeBlocks()
+			d.out.WriteString("<blockquote>")
+			d.block = "quote"
+		}
+		d.out.WriteString("<p>" + content + "</p>")
+	default:
+		d.closeBlocks()
+		d.out.WriteString("<p>" + content + "</p>")
+	}
+	return nil
+}
+
+// listLevel 段落的列表级别；编号来自段落属性或列表样式
+func (d *docxReader) listLevel(props *xmlNode, kind styleKind) (int, bool, bool) {
+	numPr := props.child("numPr")
+	numID := numPr.child("numId").attr("val")
+	if numPr != nil && numID != "" && numID != "0" {
+		level, _ := strconv.Atoi(numPr.child("ilvl").attr("val"))
+		return level, d.numbering[numID][level], true
+	}
+	if kind.list > 0 {
+		return 0, kind.list == 2, true
+	}
+	return 0, false, false
+}
+
+// listItem 输出列表项，按级别打开或关闭嵌套列表
+func (d *docxReader) listItem(level int, ordered bool, content string) {
+	tag := "ul"
+	if ordered {
+		tag = "ol"
+	}
+	for len(d.lists) > level+1 {
+		d.out.WriteString("</li></" + d.lists[len(d.lists)-1] + ">")
+		d.lists = d.lists[:len(d.lists)-1]
+	}
+	if len(d.lists) == level+1 {
+		if d.lists[level] == tag {
+			d.out.WriteString("</li>")
+		} else {
+			d.out.WriteString("</li></" + d.lists[level] + ">")
+			d.lists = d.lists[:level]
+		}
+	}
+	for len(d.lists) < level+1 {
+		d.out.WriteString("<" + tag + ">")
+		d.lists = append(d.lists, tag)
+	}
+	d.out.WriteString("<li>" + content)
+}
+
+// closeBlock 结束正在合并的引用或代码块
+func (d *docxReader) closeBlock() {
+	switch d.block {
+	case "quote":
+		d.out.WriteString("</blockquote>")
+	case "code":
+		d.out.WriteString("</code></pre>")
+	}
+	d.block = ""
+}
+
+// closeBlocks 结束引用、代码块和所有打开的列表
+func (d *docxReader) closeBlocks() {
+	d.closeBlock()
+	for len(d.lists) > 0 {
+		d.out.WriteString("</li></" + d.lists[len(d.lists)-1] + ">")
+		d.lists = d.lists[:len(d.lists)-1]
+	}
+}
+
+// table 转换表格，单元格中的多个段落以换行分隔
+func (d *docxReader) table(tbl *xmlNode) error {
+	d.out.WriteString("<table>")
+	for _, tr := range tbl.children {
+		if tr.name != "tr" {
+			continue
+		}
+		d.out.WriteString("<tr>")
+		for _, tc := range tr.children {
+			if tc.name != "tc" {
+				continue
+			}
+			var parts []string
+			for _, p := range tc.children {
+				if p.name != "p" {
+					continue
+				}
+				content, err := d.inline(p)
+				if err != nil {
+					return err
+				}
+				parts = append(parts, content)
+			}
+			d.out.WriteString("<td>" + strings.Join(parts, "<br>") + "</td>")
+		}
+		d.out.WriteString("</tr>")
+	}
+	d.out.WriteString("</table>")
+	return nil
+}
+
+// runFormat 文字格式
+type runFormat struct {
+	bold, italic, strike, code, sup, sub bool
+}
+
+// segment 段落中的一段内容，相邻的同格式内容合并后再加标记
+type segment struct {
+	format runFormat
+	html   string
+}
+
+// inline 转换段落中的文字、链接和图片
+func (d *docxReader) inline(p *xmlNode) (string, error) {
+	var segs []segment
+	var fieldURL string // 域代码超链接的地址
+	var fieldSegs []segment
+	inField := false
+
+	var walk func(*xmlNode) error
+	walk = func(parent *xmlNode) error {
+		for _, n := range parent.children {
+			switch n.name {
+			case "r":
+				if c := n.child("fldChar"); c != nil {
+					switch c.attr("fldCharType") {
+					case "begin":
+						fieldURL, fieldSegs, inField = "", nil, true
+					case "end":
+						if inField && fieldURL != "" {
+							segs = append(segs, segment{html: `<a href="` + html.EscapeString(fieldURL) + `">` + renderSegments(fieldSegs) + `</a>`})
+						} else {
+							segs = append(segs, fieldSegs...)
+						}
+						inField = false
+					}
+					continue
+				}
+				if instr := n.child("instrText"); instr != nil {
+					if m := fieldHyperlinkPattern.FindStringSubmatch(instr.text); m != nil {
+						fieldURL = m[1]
+					}
+					continue
+				}
+				run, err := d.run(n)
+				if err != nil {
+					return err
+				}
+				if inField {
+					fieldSegs = append(fieldSegs, run...)
+				} else {
+					segs = append(segs, run...)
+				}
+			case "hyperlink":
+				var inner []segment
+				saved := segs
+				segs = nil
+				if err := walk(n); err != nil {
+					return err
+				}
+				inner, segs = segs, saved
+				target := d.rels[n.attr("id")]
+				if target == "" || !strings.Contains(target, ":") {
+					segs = append(segs, inner...)
+				} else {
+					segs = append(segs, segment{html: `<a href="` + html.EscapeString(target) + `">` + renderSegments(inner) + `</a>`})
+				}
+			case "ins", "smartTag", "customXml", "fldSimple", "sdt", "sdtContent":
+				if err := walk(n); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	if err := walk(p); err != nil {
+		return "", err
+	}
+	return renderSegments(segs), nil
+}
+
+// run 转换一段文字（w:r）
+func (d *docxReader) run(r *xmlNode) ([]segment, error) {
+	props := r.child("rPr")
+	format := runFormat{
+		bold:   toggle(props.child("b")),
+		italic: toggle(props.child("i")),
+		strike: toggle(props.child("strike")) || toggle(props.child("dstrike")),
+		code:   d.styles[props.child("rStyle").attr("val")].code || isMonospace(props.child("rFonts").attr("ascii")),
+		sup:    props.child("vertAlign").attr("val") == "superscript",
+		sub:    props.child("vertAlign").attr("val") == "subscript",
+	}
+
+	var segs []segment
+	for _, n := range r.children {
+		switch n.name {
+		case "t":
+			segs = append(segs, segment{format: format, html: html.EscapeString(n.text)})
+		case "tab":
+			segs = append(segs, segment{format: format, html: " "})
+		case "br":
+			if n.attr("type") == "" || n.attr("type") == "textWrapping" {
+				segs = append(segs, segment{html: "<br>"})
+			}
+		case "noBreakHyphen":
+			segs = append(segs, segment{format: format, html: "-"})
+		case "drawing", "pict", "object":
+			img, err := d.image(n)
+			if err != nil {
+				return nil, err
+			}
+			if img != "" {
+				segs = append(segs, segment{html: img})
+			}
+		}
+	}
+	return segs, nil
+}
+
+// image 解压图片并返回 <img> 标签
+func (d *docxReader) image(n *xmlNode) (string, error) {
+	id := n.find("blip").attr("embed")
+	if id == "" {
+		id = n.find("imagedata").attr("id")
+	}
+	target, ok := d.rels[id]
+	if !ok {
+		return "", nil
+	}
+	if strings.Contains(target, "://") {
+		return `<img src="` + html.EscapeString(target) + `">`, nil
+	}
+
+	saved, ok := d.media[target]
+	if !ok {
+		f, exists := d.files[target]
+		if !exists {
+			return "", nil
+		}
+		saved = filepath.Join(d.mediaDir, path.Base(target))
+		if err := extractZipFile(f, saved); err != nil {
+			return "", err
+		}
+		d.media[target] = saved
+	}
+
+	alt := n.find("docPr").attr("descr")
+	if alt == "" {
+		alt = n.find("docPr").attr("title")
+	}
+	return `<img src="` + html.EscapeString(filepath.ToSlash(saved)) + `" alt="` + html.EscapeString(alt) + `">`, nil
+}
+
+// renderSegments 合并相邻同格式的内容并加上格式标签
+func renderSegments(segs []segment) string {
+	var b strings.Builder
+	for i := 0; i < len(segs); {
+		j := i
+		var text strings.Builder
+		for j < len(segs) && segs[j].format == segs[i].format {
+			text.WriteString(segs[j].html)
+			j++
+		}
+		b.WriteString(wrapFormat(text.String(), segs[i].format))
+		i = j
+	}
+	return b.String()
+}
+
+// wrapFormat 按格式加标签
+func wrapFormat(s string, f runFormat) string {
+	tags := []struct {
+		on  bool
+		tag string
+	}{{f.code, "code"}, {f.sup, "sup"}, {f.sub, "sub"}, {f.strike, "del"}, {f.italic, "em"}, {f.bold, "strong"}}
+	for _, t := range tags {
+		if t.on {
+			s = "<" + t.tag + ">" + s + "</" + t.tag + ">"
+		}
+	}
+	return s
+}
+
+// toggle 读取开关属性（<w:b/>、<w:b w:val="true"/> 为开，val 为 false/0 为关）
+func toggle(n *xmlNode) bool {
+	if n == nil {
+		return false
+	}
+	switch n.attr("val") {
+	case "false", "0", "off", "none":
+		return false
+	}
+	return true
+}
+
+// isMonospace 判断是否为等宽字体
+func isMonospace(font string) bool {
+	font = strings.ToLower(font)
+	for _, f := range monospaceFonts {
+		if strings.Contains(font, f) {
+			return true
+		}
+	}
+	return false
+}
+
+// extractZipFile 解压压缩包中的单个文件
+func extractZipFile(f *zip.File, dest string) error {
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return fmt.Errorf("create dir: %w", err)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return fmt.Errorf("open %s: %w", f.Name, err)
+	}
+	defer rc.Close()
+
+	out, err := os.Create(dest)
+	if err != nil {
+		return fmt.Errorf("create %s: %w", dest, err)
+	}
+	if _, err := io.Copy(out, io.LimitReader(rc, maxDocxPartBytes)); err != nil {
+		out.Close()
+		return fmt.Errorf("extract %s: %w", f.Name, err)
+	}
+	return out.Close()
+}
@@ -0,0 +1,50 @@
+package input
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/geekjourneyx/md2wechat-skill/internal/importer"
+)
+
+// loadHTML 将 HTML 文件转换为 Markdown
+// 相对路径的图片基于 HTML 文件所在目录，在线图片保留原地址（转换时下载上传），data: 图片保存到工作目录
+func loadHTML(path, workDir string) (*Document, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read html file: %w", err)
+	}
+	markdown, err := htmlToMarkdown(string(data), contentDir(workDir, "html", data))
+	if err != nil {
+		return nil, err
+	}
+	return &Document{Format: FormatHTML, Markdown: markdown, Path: path}, nil
+}
+
+// htmlToMarkdown 通过 importer 将 HTML 转换为 Markdown（含标题等 front matter），内嵌的 data: 图片保存到 mediaDir
+func htmlToMarkdown(src, mediaDir string) (string, error) {
+	article, err := importer.Parse(src, "")
+	if err != nil {
+		return "", err
+	}
+
+	var embedded []string
+	for _, s := range article.ImageSources() {
+		if strings.HasPrefix(s, "data:") {
+			embedded = append(embedded, s)
+		}
+	}
+	var paths map[string]string
+	if len(embedded) > 0 {
+		var results []importer.ImageResult
+		paths, results = importer.SaveImages(context.Background(), nil, embedded, "", mediaDir, mediaDir)
+		for _, r := range results {
+			if r.Error != "" {
+				return "", fmt.Errorf("save embedded image: %s", r.Error)
+			}
+		}
+	}
+	return article.Markdown(paths, true)
+}
@@ -0,0 +1,134 @@
+// Package input 将其它格式的文章规范化为 Markdown：Word 文档（.docx）、HTML、Notion 导出和 Obsidian 笔记库
+// 嵌入的图片保存为本地文件，转换时与 Markdown 中的本地图片一样上传；Obsidian 语法由转换器处理
+package input
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Format 输入格式
+type Format string
+
+const (
+	FormatMarkdown Format = "markdown" // Markdown 文件（原样读取）
+	FormatDocx     Format = "docx"     // Word 文档
+	FormatHTML     Format = "html"     // HTML 文件（含公众号文章页面）
+	FormatNotion   Format = "notion"   // Notion 导出（目录或 .zip）
+	FormatObsidian Format = "obsidian" // Obsidian 笔记库（目录或其中的笔记）
+)
+
+// Formats 支持的输入格式
+var Formats = []Format{FormatMarkdown, FormatDocx, FormatHTML, FormatNotion, FormatObsidian}
+
+// Document 规范化后的文章
+type Document struct {
+	Format   Format // 输入格式
+	Markdown string // Markdown 内容
+	Path     string // Markdown 对应的文件，相对图片路径和封面路径基于其所在目录
+	Vault    string // Obsidian 笔记库根目录（其它格式为空）
+}
+
+// BaseDir 解析相对图片路径的目录
+func (d *Document) BaseDir() string {
+	return filepath.Dir(d.Path)
+}
+
+// Load 读取文章并转换为 Markdown
+// from 为空时按扩展名识别格式（目录按导出内容识别）；workDir 用于保存解压的文件和嵌入图片
+func Load(path string, from Format, workDir string) (*Document, error) {
+	if abs, err := filepath.Abs(workDir); err == nil {
+		workDir = abs // 解压的图片以绝对路径写入 Markdown
+	}
+	if from == "" {
+		var err error
+		if from, err = Detect(path); err != nil {
+			return nil, err
+		}
+	}
+
+	switch from {
+	case FormatMarkdown:
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("read markdown file: %w", err)
+		}
+		return &Document{Format: FormatMarkdown, Markdown: string(data), Path: path}, nil
+	case FormatDocx:
+		return loadDocx(path, workDir)
+	case FormatHTML:
+		return loadHTML(path, workDir)
+	case FormatNotion:
+		return loadNotion(path, workDir)
+	case FormatObsidian:
+		return loadObsidian(path)
+	}
+	return nil, fmt.Errorf("unsupported input format %q (%s)", from, formatList())
+}
+
+// Detect 识别输入格式：.docx、.html/.htm、.zip（Notion 导出）按扩展名，目录按其中的内容
+// Obsidian 笔记库（含 .obsidian 目录）中的 Markdown 文件视为 Obsidian 笔记，其它文件视为 Markdown
+func Detect(path string) (Format, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	if info.IsDir() {
+		if _, err := os.Stat(filepath.Join(path, ".obsidian")); err == nil {
+			return FormatObsidian, nil
+		}
+		if notes, _ := filepath.Glob(filepath.Join(path, "*.md")); len(notes) > 0 && isNotionName(notes[0]) {
+			return FormatNotion, nil
+		}
+		return "", fmt.Errorf("cannot detect the export format of folder %s, use --from notion or --from obsidian", path)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".docx":
+		return FormatDocx, nil
+	case ".html", ".htm":
+		return FormatHTML, nil
+	case ".zip":
+		return FormatNotion, nil
+	case ".md", ".markdown":
+		if findVault(path) != "" {
+			return FormatObsidian, nil
+		}
+	}
+	return FormatMarkdown, nil
+}
+
+// ParseFormat 解析 --from 参数
+func ParseFormat(s string) (Format, error) {
+	if s == "" || s == "auto" {
+		return "", nil
+	}
+	for _, f := range Formats {
+		if string(f) == strings.ToLower(s) {
+			return f, nil
+		}
+	}
+	if strings.EqualFold(s, "md") {
+		return FormatMarkdown, nil
+	}
+	return "", fmt.Errorf("unsupported input format %q (auto, %s)", s, formatList())
+}
+
+// formatList 支持的格式列表（用于错误提示）
+func formatList() string {
+	names := make([]string, len(Formats))
+	for i, f := range Formats {
+		names[i] = string(f)
+	}
+	return strings.Join(names, ", ")
+}
+
+// contentDir 按文件内容哈希生成的工作子目录，同一文件重复转换时图片路径不变（上传缓存和同步哈希保持有效）
+func contentDir(workDir, kind string, data []byte) string {
+	sum := sha256.Sum256(data)
+	return filepath.Join(workDir, kind, hex.EncodeToString(sum[:8]))
+}
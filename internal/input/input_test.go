@@ -0,0 +1,247 @@
+package input
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// onePixelPNG 1x1 PNG 图片
+var onePixelPNG, _ = base64.StdEncoding.DecodeString("iVBORw0KGgoAAAANSUhEUgAAAAEAAAABCAYAAAAfFcSJAAAADUlEQVR42mP8z8BQDwAEhQGAhKmMIQAAAABJRU5ErkJggg==")
+
+// writeFiles 在目录中写入测试文件
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// zipFiles 生成 zip 文件内容
+func zipFiles(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+const docxNS = `xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships" xmlns:a="http://schemas.openxmlformats.org/drawingml/2006/main" xmlns:wp="http://schemas.openxmlformats.org/drawingml/2006/wordprocessingDrawing"`
+
+func TestLoadDocx(t *testing.T) {
+	document := `<w:document ` + docxNS + `><w:body>
+<w:p><w:pPr><w:pStyle w:val="Heading1"/></w:pPr><w:r><w:t>标题</w:t></w:r></w:p>
+<w:p><w:r><w:t xml:space="preserve">普通 </w:t></w:r><w:r><w:rPr><w:b/></w:rPr><w:t>加</w:t></w:r><w:r><w:rPr><w:b/></w:rPr><w:t>粗</w:t></w:r><w:r><w:t xml:space="preserve"> </w:t></w:r><w:r><w:rPr><w:rFonts w:ascii="Consolas"/></w:rPr><w:t>code</w:t></w:r><w:r><w:t xml:space="preserve"> </w:t></w:r><w:hyperlink r:id="rIdLink"><w:r><w:t>链接</w:t></w:r></w:hyperlink></w:p>
+<w:p><w:pPr><w:numPr><w:ilvl w:val="0"/><w:numId w:val="1"/></w:numPr></w:pPr><w:r><w:t>一</w:t></w:r></w:p>
+<w:p><w:pPr><w:numPr><w:ilvl w:val="1"/><w:numId w:val="1"/></w:numPr></w:pPr><w:r><w:t>嵌套</w:t></w:r></w:p>
+<w:p><w:pPr><w:numPr><w:ilvl w:val="0"/><w:numId w:val="2"/></w:numPr></w:pPr><w:r><w:t>步骤</w:t></w:r></w:p>
+<w:p><w:pPr><w:pStyle w:val="Quote"/></w:pPr><w:r><w:t>引用</w:t></w:r></w:p>
+<w:p><w:pPr><w:pStyle w:val="Code"/></w:pPr><w:r><w:t>a := 1</w:t></w:r></w:p>
+<w:p><w:pPr><w:pStyle w:val="Code"/></w:pPr><w:r><w:t>b := 2</w:t></w:r></w:p>
+<w:p><w:r><w:drawing><wp:inline><wp:docPr id="1" name="图片 1" descr="示意图"/><a:graphic><a:graphicData><a:blip r:embed="rIdImg"/></a:graphicData></a:graphic></wp:inline></w:drawing></w:r></w:p>
+<w:tbl><w:tr><w:tc><w:p><w:r><w:t>a</w:t></w:r></w:p></w:tc><w:tc><w:p><w:r><w:t>b</w:t></w:r></w:p></w:tc></w:tr><w:tr><w:tc><w:p><w:r><w:t>1</w:t></w:r></w:p></w:tc><w:tc><w:p><w:r><w:t>2</w:t></w:r></w:p></w:tc></w:tr></w:tbl>
+</w:body></w:document>`
+	styles := `<w:styles ` + docxNS + `>
+<w:style w:styleId="Heading1"><w:name w:val="heading 1"/></w:style>
+<w:style w:styleId="Quote"><w:name w:val="Quote"/></w:style>
+<w:style w:styleId="Code"><w:name w:val="Code Block"/></w:style>
+</w:styles>`
+	numbering := `<w:numbering ` + docxNS + `>
+<w:abstractNum w:abstractNumId="0"><w:lvl w:ilvl="0"><w:numFmt w:val="bullet"/></w:lvl><w:lvl w:ilvl="1"><w:numFmt w:val="bullet"/></w:lvl></w:abstractNum>
+<w:abstractNum w:abstractNumId="1"><w:lvl w:ilvl="0"><w:numFmt w:val="decimal"/></w:lvl></w:abstractNum>
+<w:num w:numId="1"><w:abstractNumId w:val="0"/></w:num>
+<w:num w:numId="2"><w:abstractNumId w:val="1"/></w:num>
+</w:numbering>`
+	rels := `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rIdLink" Type="hyperlink" Target="https://example.com/a" TargetMode="External"/>
+<Relationship Id="rIdImg" Type="image" Target="media/image1.png"/>
+</Relationships>`
+	core := `<cp:coreProperties xmlns:cp="http://schemas.openxmlformats.org/package/2006/metadata/core-properties" xmlns:dc="http://purl.org/dc/elements/1.1/"><dc:title>文档标题</dc:title></cp:coreProperties>`
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "article.docx")
+	data := zipFiles(t, map[string]string{
+		"word/document.xml":            document,
+		"word/styles.xml":              styles,
+		"word/numbering.xml":           numbering,
+		"word/_rels/document.xml.rels": rels,
+		"word/media/image1.png":        string(onePixelPNG),
+		"docProps/core.xml":            core,
+	})
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	workDir := filepath.Join(dir, "work")
+	doc, err := Load(path, "", workDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc.Format != FormatDocx || doc.BaseDir() != dir {
+		t.Errorf("Format = %s, BaseDir() = %s", doc.Format, doc.BaseDir())
+	}
+
+	image := filepath.ToSlash(filepath.Join(contentDir(workDir, "docx", data), "image1.png"))
+	want := "---\ntitle: 文档标题\n---\n\n# 标题\n\n普通 **加粗** `code` [链接](https://example.com/a)\n\n" +
+		"- 一\n  - 嵌套\n\n1. 步骤\n\n> 引用\n\n```\na := 1\nb := 2\n```\n\n![示意图](" + image + ")\n\n" +
+		"| a | b |\n| --- | --- |\n| 1 | 2 |\n"
+	if doc.Markdown != want {
+		t.Errorf("Markdown =\n%s\nwant\n%s", doc.Markdown, want)
+	}
+	if _, err := os.Stat(filepath.FromSlash(image)); err != nil {
+		t.Errorf("image not extracted: %v", err)
+	}
+}
+
+func TestLoadHTML(t *testing.T) {
+	dir := t.TempDir()
+	png := base64.StdEncoding.EncodeToString(onePixelPNG)
+	writeFiles(t, dir, map[string]string{
+		"page.html": `<html><head><title>页面</title></head><body><h2>小节</h2><p>正文 <img src="a.png"></p><p><img src="data:image/png;base64,` + png + `"></p></body></html>`,
+	})
+
+	doc, err := Load(filepath.Join(dir, "page.html"), "", filepath.Join(dir, "work"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(doc.Markdown, "---\ntitle: 页面\n---\n\n## 小节\n\n正文 ![](a.png)\n\n![](") {
+		t.Fatalf("Markdown =\n%s", doc.Markdown)
+	}
+	if strings.Contains(doc.Markdown, "data:") || !strings.Contains(doc.Markdown, filepath.ToSlash(filepath.Join(dir, "work", "html"))) {
+		t.Errorf("embedded image not saved:\n%s", doc.Markdown)
+	}
+}
+
+func TestLoadNotion(t *testing.T) {
+	page := "# 我的页面\n\nCreated: 2024年1月1日\nTags: Go, 写作\n\n正文段落\n\n![图](%E6%88%91%E7%9A%84%E9%A1%B5%E9%9D%A2%200123456789abcdef0123456789abcdef/a.png)\n"
+	files := map[string]string{
+		"我的页面 0123456789abcdef0123456789abcdef.md":                                      page,
+		"我的页面 0123456789abcdef0123456789abcdef/a.png":                                   string(onePixelPNG),
+		"我的页面 0123456789abcdef0123456789abcdef/子页面 fedcba9876543210fedcba9876543210.md": "# 子页面\n",
+	}
+	want := "---\ntitle: 我的页面\n---\n\n正文段落\n\n![图](%E6%88%91%E7%9A%84%E9%A1%B5%E9%9D%A2%200123456789abcdef0123456789abcdef/a.png)\n"
+
+	t.Run("folder", func(t *testing.T) {
+		dir := t.TempDir()
+		writeFiles(t, dir, files)
+		if f, err := Detect(dir); err != nil || f != FormatNotion {
+			t.Fatalf("Detect() = %s, %v", f, err)
+		}
+		doc, err := Load(dir, "", filepath.Join(dir, "work"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if doc.Markdown != want {
+			t.Errorf("Markdown =\n%s\nwant\n%s", doc.Markdown, want)
+		}
+		if doc.BaseDir() != dir {
+			t.Errorf("BaseDir() = %s", doc.BaseDir())
+		}
+	})
+
+	t.Run("zip", func(t *testing.T) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "export.zip")
+		if err := os.WriteFile(path, zipFiles(t, files), 0644); err != nil {
+			t.Fatal(err)
+		}
+		doc, err := Load(path, "", filepath.Join(dir, "work"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if doc.Markdown != want {
+			t.Errorf("Markdown =\n%s\nwant\n%s", doc.Markdown, want)
+		}
+		if _, err := os.Stat(filepath.Join(doc.BaseDir(), "我的页面 0123456789abcdef0123456789abcdef", "a.png")); err != nil {
+			t.Errorf("image not extracted: %v", err)
+		}
+	})
+
+	t.Run("zip slip", func(t *testing.T) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "evil.zip")
+		if err := os.WriteFile(path, zipFiles(t, map[string]string{"../evil.md": "x"}), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Load(path, FormatNotion, filepath.Join(dir, "work")); err == nil || !strings.Contains(err.Error(), "invalid file path") {
+			t.Errorf("err = %v", err)
+		}
+	})
+}
+
+func TestNormalizeNotion(t *testing.T) {
+	tests := []struct {
+		name, in, want string
+	}{
+		{"no title", "正文\n", "正文\n"},
+		{"single line kept", "# T\n\nNote: 这是正文\n", "---\ntitle: T\n---\n\nNote: 这是正文\n"},
+		{"properties", "# T\nStatus: Done\nOwner: 张三\n\n正文\n", "---\ntitle: T\n---\n\n正文\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := normalizeNotion(tt.in); got != tt.want {
+				t.Errorf("normalizeNotion() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadObsidian(t *testing.T) {
+	vault := t.TempDir()
+	writeFiles(t, vault, map[string]string{
+		".obsidian/app.json": "{}",
+		"notes/post.md":      "见 [[other]]\n",
+	})
+
+	doc, err := Load(filepath.Join(vault, "notes", "post.md"), "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc.Format != FormatObsidian || doc.Vault != vault {
+		t.Errorf("Format = %s, Vault = %s (vault detected from .obsidian)", doc.Format, doc.Vault)
+	}
+	if doc.Markdown != "见 [[other]]\n" {
+		t.Errorf("Markdown = %q, want unchanged", doc.Markdown)
+	}
+
+	// 笔记库目录中有多篇同级笔记时需要指定文件
+	if _, err := Load(filepath.Join(vault, "notes"), FormatObsidian, ""); err != nil {
+		t.Errorf("single note folder: %v", err)
+	}
+	writeFiles(t, vault, map[string]string{"notes/other.md": "y"})
+	if _, err := Load(filepath.Join(vault, "notes"), FormatObsidian, ""); err == nil || !strings.Contains(err.Error(), "2 markdown pages") {
+		t.Errorf("err = %v", err)
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	for in, want := range map[string]Format{"": "", "auto": "", "md": FormatMarkdown, "DOCX": FormatDocx, "notion": FormatNotion} {
+		if got, err := ParseFormat(in); err != nil || got != want {
+			t.Errorf("ParseFormat(%q) = %q, %v", in, got, err)
+		}
+	}
+	if _, err := ParseFormat("pdf"); err == nil {
+		t.Error("ParseFormat(pdf) should fail")
+	}
+}
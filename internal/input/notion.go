@@ -0,0 +1,156 @@
+package input
+
+import (
+	"archive/zip"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	// notionIDPattern Notion 导出文件名末尾的页面 ID：页面标题 0123456789abcdef0123456789abcdef.md
+	notionIDPattern = regexp.MustCompile(`(?i)\s[0-9a-f]{32}$`)
+	// notionPropertyPattern Notion 页面属性行：Key: value
+	notionPropertyPattern = regexp.MustCompile(`^[^\s:][^:]{0,40}: \S`)
+)
+
+// isNotionName 判断文件名是否带 Notion 页面 ID
+func isNotionName(path string) bool {
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	return notionIDPattern.MatchString(name)
+}
+
+// loadNotion 读取 Notion 导出（Markdown & CSV）：目录或 .zip
+// 页面标题写入 front matter，页面属性块被移除，子页面的图片按相对路径解析
+func loadNotion(path, workDir string) (*Document, error) {
+	dir := path
+	if info, err := os.Stat(path); err != nil {
+		return nil, err
+	} else if !info.IsDir() {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("read notion export: %w", err)
+		}
+		dir = contentDir(workDir, "notion", data)
+		if err := unzip(path, dir); err != nil {
+			return nil, err
+		}
+	}
+
+	note, err := rootNote(dir)
+	if err != nil {
+		return nil, fmt.Errorf("notion export: %w", err)
+	}
+	data, err := os.ReadFile(note)
+	if err != nil {
+		return nil, fmt.Errorf("read notion page: %w", err)
+	}
+	return &Document{Format: FormatNotion, Markdown: normalizeNotion(string(data)), Path: note}, nil
+}
+
+// normalizeNotion 将页面开头的 # 标题移到 front matter，并移除紧随其后的属性块
+func normalizeNotion(markdown string) string {
+	lines := strings.Split(strings.ReplaceAll(markdown, "\r\n", "\n"), "\n")
+	i := 0
+	for i < len(lines) && strings.TrimSpace(lines[i]) == "" {
+		i++
+	}
+	if i == len(lines) || !strings.HasPrefix(lines[i], "# ") {
+		return markdown
+	}
+	title := strings.TrimSpace(strings.TrimPrefix(lines[i], "# "))
+	rest := lines[i+1:]
+
+	// 属性块：标题后的连续 Key: value 行（至少两行，避免误删正文）
+	j := 0
+	for j < len(rest) && strings.TrimSpace(rest[j]) == "" {
+		j++
+	}
+	k := j
+	for k < len(rest) && notionPropertyPattern.MatchString(rest[k]) {
+		k++
+	}
+	if k-j >= 2 && (k == len(rest) || strings.TrimSpace(rest[k]) == "") {
+		rest = rest[k:]
+	}
+
+	front, err := yaml.Marshal(map[string]string{"title": title})
+	if err != nil {
+		return markdown
+	}
+	body := strings.TrimLeft(strings.Join(rest, "\n"), "\n")
+	return "---\n" + string(front) + "---\n\n" + body
+}
+
+// rootNote 找到目录中层级最浅的唯一 Markdown 文件（其它为子页面），跳过隐藏目录
+func rootNote(dir string) (string, error) {
+	var found []string
+	depth := -1
+	err := filepath.WalkDir(dir, func(p string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(dir, p)
+		level := strings.Count(rel, string(filepath.Separator))
+		if d.IsDir() {
+			if p != dir && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.EqualFold(filepath.Ext(p), ".md") {
+			return nil
+		}
+		switch {
+		case depth == -1 || level < depth:
+			found, depth = []string{p}, level
+		case level == depth:
+			found = append(found, p)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	switch len(found) {
+	case 0:
+		return "", fmt.Errorf("no markdown page found in %s", dir)
+	case 1:
+		return found[0], nil
+	}
+	return "", fmt.Errorf("%d markdown pages found in %s, pass the page file instead", len(found), dir)
+}
+
+// unzip 解压到目标目录（已解压过时直接复用）
+func unzip(path, dest string) error {
+	if _, err := os.Stat(dest); err == nil {
+		return nil
+	}
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		return fmt.Errorf("open zip: %w", err)
+	}
+	defer zr.Close()
+
+	tmp := dest + ".tmp"
+	if err := os.RemoveAll(tmp); err != nil {
+		return fmt.Errorf("clean dir: %w", err)
+	}
+	for _, f := range zr.File {
+		target := filepath.Join(tmp, filepath.FromSlash(f.Name))
+		if !strings.HasPrefix(target, tmp+string(filepath.Separator)) {
+			return fmt.Errorf("invalid file path in zip: %s", f.Name)
+		}
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		if err := extractZipFile(f, target); err != nil {
+			return err
+		}
+	}
+	return os.Rename(tmp, dest)
+}
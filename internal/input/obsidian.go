@@ -0,0 +1,48 @@
+package input
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// loadObsidian 读取 Obsidian 笔记：笔记文件，或只有一篇根笔记的笔记库目录
+// Markdown 保持原样，嵌入、内部链接和 callout 由转换器的 Obsidian 兼容模式处理
+func loadObsidian(path string) (*Document, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	note, vault := path, path
+	if info.IsDir() {
+		if note, err = rootNote(path); err != nil {
+			return nil, fmt.Errorf("obsidian vault: %w", err)
+		}
+	} else if vault = findVault(path); vault == "" {
+		vault = filepath.Dir(path)
+	}
+
+	data, err := os.ReadFile(note)
+	if err != nil {
+		return nil, fmt.Errorf("read obsidian note: %w", err)
+	}
+	return &Document{Format: FormatObsidian, Markdown: string(data), Path: note, Vault: vault}, nil
+}
+
+// findVault 笔记所在的笔记库：最近的包含 .obsidian 的上级目录，不在笔记库中时返回空字符串
+func findVault(note string) string {
+	dir, err := filepath.Abs(filepath.Dir(note))
+	if err != nil {
+		return ""
+	}
+	for {
+		if info, err := os.Stat(filepath.Join(dir, ".obsidian")); err == nil && info.IsDir() {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
@@ -0,0 +1,353 @@
+package latex
+
+import (
+	"fmt"
+	"math"
+	"sync"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/gobolditalic"
+	"golang.org/x/image/font/gofont/goitalic"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/sfnt"
+	"golang.org/x/image/math/fixed"
+)
+
+// fontVariant 字体变体
+type fontVariant int
+
+const (
+	variantRoman fontVariant = iota
+	variantItalic
+	variantBold
+	variantBoldItalic
+)
+
+// fontData 内置的 Go 字体（与变体一一对应）
+var fontData = [...][]byte{goregular.TTF, goitalic.TTF, gobold.TTF, gobolditalic.TTF}
+
+// glyphPPEM 读取字形轮廓时的 ppem，坐标除以它得到 em 单位
+const glyphPPEM = 1024
+
+// glyph 字形轮廓（em 单位，原点在基线左端，y 轴向下）
+type glyph struct {
+	outline path
+	advance float64
+	top     float64 // 基线以上的高度
+	bottom  float64 // 基线以下的深度
+}
+
+type glyphKey struct {
+	variant fontVariant
+	r       rune
+}
+
+var (
+	fontsOnce sync.Once
+	fonts     [len(fontData)]*sfnt.Font
+	fontsErr  error
+
+	glyphMu    sync.Mutex
+	glyphCache = map[glyphKey]*glyph{}
+	glyphBuf   sfnt.Buffer
+)
+
+// loadFonts 解析内置字体（只执行一次）
+func loadFonts() error {
+	fontsOnce.Do(func() {
+		for i, data := range fontData {
+			f, err := sfnt.Parse(data)
+			if err != nil {
+				fontsErr = fmt.Errorf("parse font: %w", err)
+				return
+			}
+			fonts[i] = f
+		}
+	})
+	return fontsErr
+}
+
+// glyphSubstitute 字体中缺少的符号由其它字形替代（可翻转）
+type glyphSubstitute struct {
+	r       rune
+	variant fontVariant
+	flipX   bool
+	flipY   bool
+}
+
+// substitutes Go 字体缺少的常用数学符号
+var substitutes = map[rune]glyphSubstitute{
+	'∀': {r: 'A', flipY: true},
+	'∃': {r: 'E', flipX: true},
+	'∇': {r: '∆', flipY: true},
+	'∪': {r: '∩', flipY: true},
+	'∅': {r: 'Ø'},
+	'∮': {r: '∫'},
+	'∼': {r: '~'},
+	'≪': {r: '«'},
+	'≫': {r: '»'},
+	'ϵ': {r: 'ε', variant: variantItalic},
+	'ϕ': {r: 'φ', variant: variantItalic},
+	'ϑ': {r: 'θ', variant: variantItalic},
+	'∗': {r: '*'},
+	'∓': {r: '±', flipY: true},
+	'∐': {r: '∏', flipY: true},
+	'ℝ': {r: 'R', variant: variantBold},
+	'ℕ': {r: 'N', variant: variantBold},
+	'ℤ': {r: 'Z', variant: variantBold},
+	'ℚ': {r: 'Q', variant: variantBold},
+	'ℂ': {r: 'C', variant: variantBold},
+}
+
+// lookupGlyph 返回字形，字体中缺少时依次尝试替代字形和绘制的字形
+func lookupGlyph(variant fontVariant, r rune) (*glyph, error) {
+	if err := loadFonts(); err != nil {
+		return nil, err
+	}
+
+	glyphMu.Lock()
+	defer glyphMu.Unlock()
+	return lookupGlyphLocked(variant, r)
+}
+
+func lookupGlyphLocked(variant fontVariant, r rune) (*glyph, error) {
+	key := glyphKey{variant, r}
+	if g, ok := glyphCache[key]; ok {
+		return g, nil
+	}
+
+	g, err := loadFontGlyph(fonts[variant], r)
+	if err != nil {
+		return nil, err
+	}
+	if g == nil {
+		if sub, ok := substitutes[r]; ok {
+			base, err := lookupGlyphLocked(sub.variant, sub.r)
+			if err != nil {
+				return nil, err
+			}
+			g = base.flip(sub.flipX, sub.flipY)
+		} else if draw, ok := drawnGlyphs[r]; ok {
+			g = draw()
+		} else {
+			return nil, fmt.Errorf("unsupported character %q", r)
+		}
+	}
+
+	glyphCache[key] = g
+	return g, nil
+}
+
+// loadFontGlyph 从字体读取字形，字体中没有该字符时返回 nil
+func loadFontGlyph(f *sfnt.Font, r rune) (*glyph, error) {
+	idx, err := f.GlyphIndex(&glyphBuf, r)
+	if err != nil {
+		return nil, fmt.Errorf("glyph %q: %w", r, err)
+	}
+	if idx == 0 {
+		return nil, nil
+	}
+
+	ppem := fixed.I(glyphPPEM)
+	advance, err := f.GlyphAdvance(&glyphBuf, idx, ppem, font.HintingNone)
+	if err != nil {
+		return nil, fmt.Errorf("glyph %q: %w", r, err)
+	}
+	segments, err := f.LoadGlyph(&glyphBuf, idx, ppem, nil)
+	if err != nil {
+		return nil, fmt.Errorf("glyph %q: %w", r, err)
+	}
+
+	toEm := func(p fixed.Point26_6) point {
+		return point{float64(p.X) / 64 / glyphPPEM, float64(p.Y) / 64 / glyphPPEM}
+	}
+	g := &glyph{advance: float64(advance) / 64 / glyphPPEM}
+	for i, seg := range segments {
+		switch seg.Op {
+		case sfnt.SegmentOpMoveTo:
+			if i > 0 {
+				g.outline = append(g.outline, pathOp{op: 'Z'})
+			}
+			g.outline = append(g.outline, pathOp{op: 'M', pts: [3]point{toEm(seg.Args[0])}})
+		case sfnt.SegmentOpLineTo:
+			g.outline = append(g.outline, pathOp{op: 'L', pts: [3]point{toEm(seg.Args[0])}})
+		case sfnt.SegmentOpQuadTo:
+			g.outline = append(g.outline, pathOp{op: 'Q', pts: [3]point{toEm(seg.Args[0]), toEm(seg.Args[1])}})
+		case sfnt.SegmentOpCubeTo:
+			g.outline = append(g.outline, pathOp{op: 'C', pts: [3]point{toEm(seg.Args[0]), toEm(seg.Args[1]), toEm(seg.Args[2])}})
+		}
+	}
+	if len(g.outline) > 0 {
+		g.outline = append(g.outline, pathOp{op: 'Z'})
+	}
+	g.measure()
+	return g, nil
+}
+
+// measure 根据轮廓计算高度和深度（完全在基线之上的字形深度为负）
+func (g *glyph) measure() {
+	minY, maxY := math.Inf(1), math.Inf(-1)
+	g.outline.each(func(p point) {
+		minY = math.Min(minY, p.y)
+		maxY = math.Max(maxY, p.y)
+	})
+	if len(g.outline) == 0 {
+		minY, maxY = 0, 0
+	}
+	g.top, g.bottom = -minY, maxY
+}
+
+// flip 翻转字形（在自身包围盒内）
+func (g *glyph) flip(flipX, flipY bool) *glyph {
+	minX, maxX := math.Inf(1), math.Inf(-1)
+	g.outline.each(func(p point) {
+		minX = math.Min(minX, p.x)
+		maxX = math.Max(maxX, p.x)
+	})
+	out := &glyph{advance: g.advance, top: g.top, bottom: g.bottom}
+	out.outline = g.outline.mapPoints(func(p point) point {
+		if flipX {
+			p.x = minX + maxX - p.x
+		}
+		if flipY {
+			p.y = g.bottom - g.top - p.y
+		}
+		return p
+	})
+	return out
+}
+
+// drawnGlyphs 字体中没有、也无法替代的符号，用线条绘制
+// 坐标为 em 单位，基线 y=0，数学轴 y=-0.25
+var drawnGlyphs = map[rune]func() *glyph{
+	'∈': func() *glyph { return elementGlyph(false) },
+	'∉': func() *glyph { return elementGlyph(true) },
+	'⊂': func() *glyph { return subsetGlyph(false, false) },
+	'⊃': func() *glyph { return subsetGlyph(true, false) },
+	'⊆': func() *glyph { return subsetGlyph(false, true) },
+	'⊇': func() *glyph { return subsetGlyph(true, true) },
+	'∧': func() *glyph {
+		return drawnGlyph(0.7, stroke(0.06, point{0.1, 0}, point{0.35, -0.58}, point{0.6, 0}))
+	},
+	'∨': func() *glyph {
+		return drawnGlyph(0.7, stroke(0.06, point{0.1, -0.58}, point{0.35, 0}, point{0.6, -0.58}))
+	},
+	'⇒': func() *glyph { return doubleArrowGlyph(false, true) },
+	'⇐': func() *glyph { return doubleArrowGlyph(true, false) },
+	'⇔': func() *glyph { return doubleArrowGlyph(true, true) },
+	'↦': func() *glyph {
+		return drawnGlyph(1.0,
+			stroke(0.05, point{0.1, -0.38}, point{0.1, -0.12}),
+			stroke(0.05, point{0.1, -0.25}, point{0.88, -0.25}),
+			stroke(0.05, point{0.72, -0.38}, point{0.9, -0.25}, point{0.72, -0.12}))
+	},
+	'∘': func() *glyph {
+		return drawnGlyph(0.5, stroke(0.045, arc(0.25, -0.25, 0.11, 0.11, 0, 2*math.Pi, 24)...))
+	},
+	'‖': func() *glyph {
+		return drawnGlyph(0.45,
+			stroke(0.05, point{0.15, -0.75}, point{0.15, 0.25}),
+			stroke(0.05, point{0.3, -0.75}, point{0.3, 0.25}))
+	},
+	'⟨': func() *glyph {
+		return drawnGlyph(0.4, stroke(0.05, point{0.3, -0.75}, point{0.1, -0.25}, point{0.3, 0.25}))
+	},
+	'⟩': func() *glyph {
+		return drawnGlyph(0.4, stroke(0.05, point{0.1, -0.75}, point{0.3, -0.25}, point{0.1, 0.25}))
+	},
+	'∝': func() *glyph {
+		return drawnGlyph(0.85,
+			stroke(0.05, arc(0.3, -0.25, 0.16, 0.16, math.Pi/4, 7*math.Pi/4, 16)...),
+			stroke(0.05, point{0.41, -0.36}, point{0.72, -0.1}),
+			stroke(0.05, point{0.41, -0.14}, point{0.72, -0.4}))
+	},
+	'∠': func() *glyph {
+		return drawnGlyph(0.85, stroke(0.05, point{0.62, -0.6}, point{0.1, 0}, point{0.75, 0}))
+	},
+	'⊕': func() *glyph { return circledGlyph('+') },
+	'⊗': func() *glyph { return circledGlyph('x') },
+}
+
+// drawnGlyph 由多个路径组成字形
+func drawnGlyph(advance float64, parts ...path) *glyph {
+	g := &glyph{advance: advance}
+	for _, p := range parts {
+		g.outline = append(g.outline, p...)
+	}
+	g.measure()
+	return g
+}
+
+// elementGlyph ∈ 和 ∉
+func elementGlyph(negated bool) *glyph {
+	parts := []path{
+		stroke(0.055, append(append([]point{{0.62, -0.52}},
+			arc(0.37, -0.25, 0.27, 0.27, -math.Pi/2, -3*math.Pi/2, 16)...), point{0.62, 0.02})...),
+		stroke(0.055, point{0.12, -0.25}, point{0.58, -0.25}),
+	}
+	if negated {
+		parts = append(parts, stroke(0.05, point{0.55, -0.7}, point{0.2, 0.2}))
+	}
+	return drawnGlyph(0.75, parts...)
+}
+
+// subsetGlyph ⊂、⊃、⊆、⊇
+func subsetGlyph(mirror, orEqual bool) *glyph {
+	top, bottom := -0.52, 0.02
+	if orEqual {
+		top, bottom = -0.62, -0.08
+	}
+	r := (bottom - top) / 2
+	pts := append(append([]point{{0.65, top}},
+		arc(0.12+r, top+r, r, r, -math.Pi/2, -3*math.Pi/2, 16)...), point{0.65, bottom})
+	parts := []path{stroke(0.055, pts...)}
+	if orEqual {
+		parts = append(parts, stroke(0.055, point{0.12, 0.1}, point{0.65, 0.1}))
+	}
+	g := drawnGlyph(0.77, parts...)
+	if mirror {
+		g.outline = g.outline.mapPoints(func(p point) point { return point{0.77 - p.x, p.y} })
+	}
+	return g
+}
+
+// doubleArrowGlyph ⇒、⇐、⇔
+func doubleArrowGlyph(left, right bool) *glyph {
+	width := 1.0
+	if left && right {
+		width = 1.2
+	}
+	x0, x1 := 0.1, width-0.1
+	lx0, lx1 := x0, x1
+	var parts []path
+	if right {
+		parts = append(parts, stroke(0.05, point{x1 - 0.2, -0.45}, point{x1, -0.25}, point{x1 - 0.2, -0.05}))
+		lx1 -= 0.08
+	}
+	if left {
+		parts = append(parts, stroke(0.05, point{x0 + 0.2, -0.45}, point{x0, -0.25}, point{x0 + 0.2, -0.05}))
+		lx0 += 0.08
+	}
+	parts = append(parts,
+		stroke(0.045, point{lx0, -0.34}, point{lx1, -0.34}),
+		stroke(0.045, point{lx0, -0.16}, point{lx1, -0.16}))
+	return drawnGlyph(width, parts...)
+}
+
+// circledGlyph ⊕ 和 ⊗
+func circledGlyph(mark rune) *glyph {
+	c, r := point{0.4, -0.25}, 0.3
+	parts := []path{stroke(0.05, arc(c.x, c.y, r, r, 0, 2*math.Pi, 32)...)}
+	if mark == '+' {
+		parts = append(parts,
+			stroke(0.05, point{c.x - r, c.y}, point{c.x + r, c.y}),
+			stroke(0.05, point{c.x, c.y - r}, point{c.x, c.y + r}))
+	} else {
+		d := r * math.Sqrt2 / 2
+		parts = append(parts,
+			stroke(0.05, point{c.x - d, c.y - d}, point{c.x + d, c.y + d}),
+			stroke(0.05, point{c.x - d, c.y + d}, point{c.x + d, c.y - d}))
+	}
+	return drawnGlyph(0.8, parts...)
+}
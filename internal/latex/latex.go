@@ -0,0 +1,110 @@
+// Package latex 将 LaTeX 数学公式排版为矢量路径，输出 SVG 或 PNG
+// 纯 Go 实现，不依赖 MathJax、KaTeX 或外部程序；使用内置的 Go 字体，
+// 支持常用的 TeX 数学子集：上下标、分式、根式、大型运算符、定界符、重音、矩阵和多行公式
+package latex
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"strconv"
+
+	"golang.org/x/image/vector"
+)
+
+// Formula 排版后的公式，尺寸以 em 为单位
+type Formula struct {
+	Source  string
+	Display bool
+	Width   float64
+	Height  float64 // 基线以上
+	Depth   float64 // 基线以下
+
+	ops path
+}
+
+// Render 排版公式，display 为 true 时使用行间公式样式（大型运算符更大，上下标放在上下方）
+func Render(src string, display bool) (*Formula, error) {
+	list, err := parse(src)
+	if err != nil {
+		return nil, fmt.Errorf("latex: %w", err)
+	}
+
+	level := levelText
+	if display {
+		level = levelDisplay
+	}
+	b, err := layoutList(list, level)
+	if err != nil {
+		return nil, fmt.Errorf("latex: %w", err)
+	}
+
+	return &Formula{
+		Source:  src,
+		Display: display,
+		Width:   b.width,
+		Height:  b.height,
+		Depth:   b.depth,
+		ops:     b.ops,
+	}, nil
+}
+
+// svgUnits SVG 坐标中每 em 的单位数
+const svgUnits = 1000
+
+// SVG 生成内联 SVG，尺寸使用 em，随周围文字缩放；vertical-align 使公式基线与文字基线对齐
+func (f *Formula) SVG(fill string) string {
+	height := f.Height + f.Depth
+	return fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" width="%sem" height="%sem" viewBox="0 %s %s %s" `+
+		`style="vertical-align:-%sem;overflow:visible;" role="img" aria-label="%s"><path fill="%s" d="%s"/></svg>`,
+		emString(f.Width), emString(height),
+		unitString(-f.Height), unitString(f.Width), unitString(height),
+		emString(f.Depth), html.EscapeString(f.Source), html.EscapeString(fill), f.ops.svgData(svgUnits))
+}
+
+// PNG 生成 PNG 图片，pxPerEm 为每 em 的像素数（按显示字号乘以倍率，以适配高清屏）
+// 返回的图片四周各留 1 像素空白
+func (f *Formula) PNG(pxPerEm float64, fill color.Color) ([]byte, error) {
+	width := int(math.Ceil(f.Width*pxPerEm)) + 2
+	height := int(math.Ceil((f.Height+f.Depth)*pxPerEm)) + 2
+
+	z := vector.NewRasterizer(width, height)
+	f.ops.rasterize(z, pxPerEm, 1, f.Height*pxPerEm+1)
+
+	dst := image.NewNRGBA(image.Rect(0, 0, width, height))
+	z.Draw(dst, dst.Bounds(), image.NewUniform(fill), image.Point{})
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, dst); err != nil {
+		return nil, fmt.Errorf("encode png: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// ParseColor 解析 #rgb 或 #rrggbb 颜色
+func ParseColor(s string) (color.Color, error) {
+	if len(s) == 4 && s[0] == '#' {
+		s = "#" + string([]byte{s[1], s[1], s[2], s[2], s[3], s[3]})
+	}
+	if len(s) != 7 || s[0] != '#' {
+		return nil, fmt.Errorf("invalid color %q", s)
+	}
+	v, err := strconv.ParseUint(s[1:], 16, 32)
+	if err != nil {
+		return nil, fmt.Errorf("invalid color %q", s)
+	}
+	return color.NRGBA{R: uint8(v >> 16), G: uint8(v >> 8), B: uint8(v), A: 0xff}, nil
+}
+
+// emString 保留三位小数
+func emString(v float64) string {
+	return strconv.FormatFloat(math.Round(v*1000)/1000, 'f', -1, 64)
+}
+
+func unitString(v float64) string {
+	return strconv.FormatFloat(math.Round(v*svgUnits), 'f', -1, 64)
+}
@@ -0,0 +1,139 @@
+package latex
+
+import (
+	"bytes"
+	"image/color"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	tests := []string{
+		`E = mc^2`,
+		`x_i^2 + y_{ij}' \le \alpha \cdot \beta`,
+		`\frac{a+b}{c} = \sqrt[3]{x^2+1} \in \mathbb{R}`,
+		`\sum_{i=1}^{n} i = \frac{n(n+1)}{2}`,
+		`\int_0^\infty e^{-x^2}\,dx = \frac{\sqrt{\pi}}{2}`,
+		`\left( \frac{1}{1+e^{-z}} \right) \Rightarrow \hat{y}, \vec{v}, \overline{xy}`,
+		`\begin{pmatrix} 1 & 2 \\ 3 & 4 \end{pmatrix}`,
+		`f(x) = \begin{cases} x & x \ge 0 \\ -x & \text{otherwise} \end{cases}`,
+		`\lim_{n \to \infty} \left(1 + \frac{1}{n}\right)^n = e`,
+		`a &= b + c \\ &= d`,
+		`\binom{n}{k} \quad \operatorname{softmax}(z)_i \mathbf{W} \boldsymbol{\theta}`,
+	}
+	for _, src := range tests {
+		f, err := Render(src, true)
+		if err != nil {
+			t.Errorf("Render(%q) error = %v", src, err)
+			continue
+		}
+		if f.Width <= 0 || f.Height <= 0 || len(f.ops) == 0 {
+			t.Errorf("Render(%q) = %+v, want non-empty formula", src, f)
+		}
+	}
+}
+
+func TestRenderErrors(t *testing.T) {
+	tests := []struct {
+		src  string
+		want string
+	}{
+		{`\foo{x}`, `unknown command \foo`},
+		{`\frac{a}{b`, `missing '}'`},
+		{`x^2^3`, `double superscript`},
+		{`\left( x`, `missing \right`},
+		{`x \right)`, `\right without \left`},
+		{`\begin{foo} x \end{foo}`, `unknown environment "foo"`},
+		{`\begin{matrix} x \end{pmatrix}`, `ended by \end{pmatrix}`},
+		{`x }`, `unexpected '}'`},
+	}
+	for _, tt := range tests {
+		_, err := Render(tt.src, false)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Render(%q) error = %v, want %q", tt.src, err, tt.want)
+		}
+	}
+}
+
+func TestRenderMetrics(t *testing.T) {
+	render := func(src string, display bool) *Formula {
+		t.Helper()
+		f, err := Render(src, display)
+		if err != nil {
+			t.Fatalf("Render(%q) error = %v", src, err)
+		}
+		return f
+	}
+
+	x := render(`x`, false)
+	if x.Depth > 0.05 {
+		t.Errorf("x depth = %v, want ~0", x.Depth)
+	}
+	if sub := render(`x_i`, false); sub.Depth <= x.Depth {
+		t.Errorf("x_i depth = %v, want below baseline", sub.Depth)
+	}
+	if frac := render(`\frac{a}{b}`, false); frac.Height <= x.Height || frac.Depth <= 0 {
+		t.Errorf("fraction = %+v, want taller than x and below baseline", frac)
+	}
+	inline, display := render(`\sum_{i=1}^n`, false), render(`\sum_{i=1}^n`, true)
+	if display.Height <= inline.Height || display.Width >= inline.Width {
+		t.Errorf("display sum = %+v, inline = %+v, want limits above and below", display, inline)
+	}
+	if spaced, tight := render(`a+b`, false), render(`a+b`, false); spaced.Width != tight.Width {
+		t.Errorf("layout is not deterministic")
+	}
+	if bin, unary := render(`a-b`, false), render(`-b`, false); bin.Width-unary.Width < 0.3 {
+		t.Errorf("binary minus should get medium spaces: %v vs %v", bin.Width, unary.Width)
+	}
+}
+
+func TestGlyphCoverage(t *testing.T) {
+	check := func(name string, r rune) {
+		if _, err := lookupGlyph(variantRoman, r); err != nil {
+			t.Errorf("%s (%q): %v", name, r, err)
+		}
+	}
+	for name, s := range symbols {
+		check(name, s.r)
+	}
+	for name, op := range bigOperators {
+		check(name, op.r)
+	}
+	for name, r := range accents {
+		check(name, r)
+	}
+}
+
+func TestFormulaOutput(t *testing.T) {
+	f, err := Render(`\frac{1}{2} < x_i`, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	svg := f.SVG("#333")
+	for _, want := range []string{`<svg xmlns="http://www.w3.org/2000/svg"`, `style="vertical-align:-0.`, `fill="#333"`, `aria-label="\frac{1}{2} &lt; x_i"`} {
+		if !strings.Contains(svg, want) {
+			t.Errorf("SVG() missing %q\n%s", want, svg)
+		}
+	}
+
+	data, err := f.PNG(32, color.Black)
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("decode png: %v", err)
+	}
+	if w := img.Bounds().Dx(); w < int(f.Width*32) || w > int(f.Width*32)+3 {
+		t.Errorf("PNG width = %d, want %v", w, f.Width*32)
+	}
+
+	if _, err := ParseColor("#3f3f3f"); err != nil {
+		t.Errorf("ParseColor() error = %v", err)
+	}
+	if c, err := ParseColor("#fff"); err != nil || c != (color.NRGBA{255, 255, 255, 255}) {
+		t.Errorf("ParseColor(#fff) = %v, %v", c, err)
+	}
+}
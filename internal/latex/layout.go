@@ -0,0 +1,651 @@
+package latex
+
+import (
+	"math"
+)
+
+// mathLevel TeX 数学样式
+type mathLevel int
+
+const (
+	levelDisplay mathLevel = iota + 1
+	levelText
+	levelScript
+	levelScriptScript
+)
+
+// size 样式的字号倍数
+func (l mathLevel) size() float64 {
+	switch l {
+	case levelScript:
+		return 0.7
+	case levelScriptScript:
+		return 0.5
+	}
+	return 1
+}
+
+// script 上下标使用的样式
+func (l mathLevel) script() mathLevel {
+	if l <= levelText {
+		return levelScript
+	}
+	return levelScriptScript
+}
+
+// fraction 分子分母使用的样式
+func (l mathLevel) fraction() mathLevel {
+	if l == levelDisplay {
+		return levelText
+	}
+	return l.script()
+}
+
+// 排版参数（em，乘以字号倍数使用）
+const (
+	axisHeight     = 0.25  // 数学轴（分数线、运算符中心）高度
+	ruleThickness  = 0.045 // 分数线和根号线粗细
+	scriptSpace    = 0.05  // 上下标后的间距
+	nullDelimiter  = 0.12  // 空定界符和分式两侧的宽度
+	delimiterRatio = 0.901 // \left \right 定界符至少覆盖内容的比例
+)
+
+// box 排版盒子：原点在基线左端，路径坐标 y 轴向下
+type box struct {
+	width  float64
+	height float64 // 基线以上
+	depth  float64 // 基线以下
+	ops    path
+	italic bool // 斜体字符，上标需要右移
+}
+
+// add 将子盒子放在 (dx, dy) 处，dy 向下为正
+func (b *box) add(c *box, dx, dy float64) {
+	b.ops = append(b.ops, c.ops.transform(1, 1, dx, dy)...)
+	b.height = math.Max(b.height, c.height-dy)
+	b.depth = math.Max(b.depth, c.depth+dy)
+}
+
+// append 水平追加子盒子
+func (b *box) append(c *box, dy float64) {
+	b.add(c, b.width, dy)
+	b.width += c.width
+	b.italic = c.italic
+}
+
+// addRule 添加实心矩形（y0 < y1，y 轴向下）
+func (b *box) addRule(x0, y0, x1, y1 float64) {
+	b.ops = append(b.ops, rect(x0, y0, x1, y1)...)
+	b.height = math.Max(b.height, -y0)
+	b.depth = math.Max(b.depth, y1)
+}
+
+// layoutList 排版原子序列
+func layoutList(list []*atom, level mathLevel) (*box, error) {
+	classes := resolveClasses(list)
+
+	b := &box{}
+	prev := atomClass(-1)
+	for i, a := range list {
+		if s, ok := a.nucleus.(*styleNode); ok {
+			level = s.level
+			continue
+		}
+
+		c, err := layoutAtom(a, level)
+		if err != nil {
+			return nil, err
+		}
+		class := classes[i]
+		if class >= 0 && prev >= 0 {
+			mu := spacing[prev][class]
+			if mu < 0 {
+				mu = -mu
+				if level >= levelScript {
+					mu = 0
+				}
+			}
+			b.width += float64(mu) / 18 * level.size()
+		}
+		b.append(c, 0)
+		if class >= 0 {
+			prev = class
+		}
+	}
+	return b, nil
+}
+
+// resolveClasses 按 TeX 规则调整二元运算符：不在两个运算对象之间时视为普通符号
+func resolveClasses(list []*atom) []atomClass {
+	classes := make([]atomClass, len(list))
+	prev := atomClass(-1)
+	for i, a := range list {
+		classes[i] = a.class
+		if a.class < 0 {
+			continue
+		}
+		if a.class == classBin {
+			switch prev {
+			case -1, classBin, classOp, classRel, classOpen, classPunct:
+				classes[i] = classOrd
+			}
+		}
+		if prev == classBin {
+			switch a.class {
+			case classRel, classClose, classPunct:
+				classes[lastClassed(classes, i)] = classOrd
+			}
+		}
+		prev = classes[i]
+	}
+	if last := lastClassed(classes, len(classes)); last >= 0 && classes[last] == classBin {
+		classes[last] = classOrd
+	}
+	return classes
+}
+
+// lastClassed 位置 i 之前最后一个有类别的原子
+func lastClassed(classes []atomClass, i int) int {
+	for j := i - 1; j >= 0; j-- {
+		if classes[j] >= 0 {
+			return j
+		}
+	}
+	return -1
+}
+
+// layoutAtom 排版原子（核心加上下标）
+func layoutAtom(a *atom, level mathLevel) (*box, error) {
+	nucleus, err := layoutNode(a.nucleus, level)
+	if err != nil {
+		return nil, err
+	}
+	if !a.hasSup && !a.hasSub && len(a.sup) == 0 {
+		return nucleus, nil
+	}
+
+	if op, ok := a.nucleus.(*opNode); ok {
+		if a.limits == 1 || (a.limits == 0 && op.limits && level == levelDisplay) {
+			return layoutLimits(nucleus, a, level)
+		}
+	}
+	return layoutScripts(nucleus, a, level)
+}
+
+// layoutScripts 上下标放在核心右侧
+func layoutScripts(base *box, a *atom, level mathLevel) (*box, error) {
+	s := level.size()
+	var sup, sub *box
+	var err error
+	if len(a.sup) > 0 || a.hasSup {
+		if sup, err = layoutList(a.sup, level.script()); err != nil {
+			return nil, err
+		}
+	}
+	if a.hasSub {
+		if sub, err = layoutList(a.sub, level.script()); err != nil {
+			return nil, err
+		}
+	}
+
+	// 上标基线抬高 u，下标基线降低 v
+	var u, v float64
+	if sup != nil {
+		minShift := 0.36 * s
+		if level == levelDisplay {
+			minShift = 0.41 * s
+		}
+		u = math.Max(math.Max(base.height-0.25*s, minShift), sup.depth+0.11*s)
+	}
+	if sub != nil {
+		v = math.Max(math.Max(base.depth+0.05*s, 0.15*s), sub.height-0.35*s)
+		if sup != nil {
+			v = math.Max(v, 0.25*s)
+			if gap := (u - sup.depth) - (sub.height - v); gap < 4*ruleThickness*s {
+				v += 4*ruleThickness*s - gap
+			}
+		}
+	}
+
+	out := &box{}
+	out.append(base, 0)
+	width := 0.0
+	if sup != nil {
+		dx := 0.0
+		if base.italic {
+			dx = 0.06 * s
+		}
+		out.add(sup, out.width+dx, -u)
+		width = sup.width + dx
+	}
+	if sub != nil {
+		out.add(sub, out.width, v)
+		width = math.Max(width, sub.width)
+	}
+	out.width += width + scriptSpace*s
+	out.italic = false
+	return out, nil
+}
+
+// layoutLimits 上下标放在核心正上方和正下方（行间公式的 \sum、\lim 等）
+func layoutLimits(base *box, a *atom, level mathLevel) (*box, error) {
+	s := level.size()
+	var sup, sub *box
+	var err error
+	if a.hasSup || len(a.sup) > 0 {
+		if sup, err = layoutList(a.sup, level.script()); err != nil {
+			return nil, err
+		}
+	}
+	if a.hasSub {
+		if sub, err = layoutList(a.sub, level.script()); err != nil {
+			return nil, err
+		}
+	}
+
+	width := base.width
+	if sup != nil {
+		width = math.Max(width, sup.width)
+	}
+	if sub != nil {
+		width = math.Max(width, sub.width)
+	}
+
+	gap := 0.12 * s
+	out := &box{width: width}
+	out.add(base, (width-base.width)/2, 0)
+	if sup != nil {
+		out.add(sup, (width-sup.width)/2, -(base.height + gap + sup.depth))
+	}
+	if sub != nil {
+		out.add(sub, (width-sub.width)/2, base.depth+gap+sub.height)
+	}
+	return out, nil
+}
+
+// layoutNode 排版原子核心
+func layoutNode(n node, level mathLevel) (*box, error) {
+	s := level.size()
+	switch n := n.(type) {
+	case *charNode:
+		return glyphBox(n.r, n.variant, s)
+
+	case *listNode:
+		return layoutList(n.list, level)
+
+	case *opNode:
+		if n.name != "" {
+			return textBox(n.name, variantRoman, s)
+		}
+		return bigOperatorBox(n.r, level)
+
+	case *fracNode:
+		return layoutFraction(n, level)
+
+	case *sqrtNode:
+		return layoutSqrt(n, level)
+
+	case *delimNode:
+		body, err := layoutList(n.body, level)
+		if err != nil {
+			return nil, err
+		}
+		return wrapDelimiters(body, n.left, n.right, s)
+
+	case *accentNode:
+		return layoutAccent(n, level)
+
+	case *textNode:
+		return textBox(n.text, n.variant, s)
+
+	case *spaceNode:
+		return &box{width: n.em * s}, nil
+
+	case *arrayNode:
+		return layoutArray(n, level)
+	}
+	return &box{}, nil
+}
+
+// glyphBox 单个字形
+func glyphBox(r rune, variant fontVariant, s float64) (*box, error) {
+	g, err := lookupGlyph(variant, r)
+	if err != nil {
+		return nil, err
+	}
+	return &box{
+		width:  g.advance * s,
+		height: math.Max(g.top*s, 0),
+		depth:  math.Max(g.bottom*s, 0),
+		ops:    g.outline.transform(s, s, 0, 0),
+		italic: variant == variantItalic || variant == variantBoldItalic,
+	}, nil
+}
+
+// textBox 一段正文文字
+func textBox(text string, variant fontVariant, s float64) (*box, error) {
+	b := &box{}
+	for _, r := range text {
+		g, err := glyphBox(r, variant, s)
+		if err != nil {
+			return nil, err
+		}
+		b.append(g, 0)
+	}
+	return b, nil
+}
+
+// bigOperatorBox 放大的运算符，以数学轴为中心
+func bigOperatorBox(r rune, level mathLevel) (*box, error) {
+	g, err := lookupGlyph(variantRoman, r)
+	if err != nil {
+		return nil, err
+	}
+
+	scale := 1.2
+	if level == levelDisplay {
+		scale = 1.6
+	}
+	if r == '∫' || r == '∮' {
+		scale *= 1.3
+	}
+	scale *= level.size()
+
+	// 字形中心移到数学轴
+	center := (g.bottom - g.top) / 2 * scale
+	dy := -axisHeight*level.size() - center
+	b := &box{width: g.advance * scale}
+	b.ops = g.outline.transform(scale, scale, 0, dy)
+	b.height = g.top*scale - dy
+	b.depth = g.bottom*scale + dy
+	return b, nil
+}
+
+// layoutFraction 分式
+func layoutFraction(n *fracNode, level mathLevel) (*box, error) {
+	if n.level != 0 {
+		level = n.level
+	}
+	s := level.size()
+	num, err := layoutList(n.num, level.fraction())
+	if err != nil {
+		return nil, err
+	}
+	den, err := layoutList(n.den, level.fraction())
+	if err != nil {
+		return nil, err
+	}
+
+	t := ruleThickness * s
+	if !n.bar {
+		t = 0
+	}
+	axis := axisHeight * s
+	gap, numMin, denMin := t, 0.39*s, 0.35*s
+	if level == levelDisplay {
+		gap, numMin, denMin = 3*t, 0.68*s, 0.69*s
+	}
+	if !n.bar {
+		gap = 3 * ruleThickness * s
+	}
+
+	numShift := math.Max(numMin, axis+t/2+gap+num.depth)
+	denShift := math.Max(denMin, den.height+gap+t/2-axis)
+
+	pad := nullDelimiter * s
+	width := math.Max(num.width, den.width) + 2*pad
+	b := &box{width: width}
+	b.add(num, (width-num.width)/2, -numShift)
+	b.add(den, (width-den.width)/2, denShift)
+	if n.bar {
+		b.addRule(pad/2, -axis-t/2, width-pad/2, -axis+t/2)
+	}
+	return b, nil
+}
+
+// layoutSqrt 根式：绘制根号，上方画线
+func layoutSqrt(n *sqrtNode, level mathLevel) (*box, error) {
+	s := level.size()
+	body, err := layoutList(n.body, level)
+	if err != nil {
+		return nil, err
+	}
+
+	t := ruleThickness * s
+	clearance := t + 0.1*s
+	if level == levelDisplay {
+		clearance = t + 0.15*s
+	}
+	body.height = math.Max(body.height, 0.6*s)
+	top := body.height + clearance + t // 根号线顶部（基线以上）
+	bottom := math.Max(body.depth, 0.1*s) + 0.05*s
+	total := top + bottom
+
+	// 根号形状：左侧小勾、粗的下行笔画、细的上行笔画（y 轴向下）
+	rw := math.Min(0.5*s+0.05*total, 0.9*s)
+	tick := bottom - math.Min(0.45*total, 0.5*s)
+	sign := &box{width: rw}
+	sign.ops = append(sign.ops, stroke(0.04*s, point{0, tick + 0.06*s}, point{0.18 * rw, tick})...)
+	sign.ops = append(sign.ops, stroke(0.09*s, point{0.18 * rw, tick}, point{0.45 * rw, bottom})...)
+	sign.ops = append(sign.ops, stroke(0.04*s, point{0.45 * rw, bottom}, point{rw, -top + t/2})...)
+
+	b := &box{}
+	if n.index != nil {
+		index, err := layoutList(n.index, levelScriptScript)
+		if err != nil {
+			return nil, err
+		}
+		shift := math.Max(0, index.width-0.4*rw)
+		b.add(index, 0, -(-bottom+0.6*total)-index.depth)
+		b.width = shift
+	}
+
+	b.add(sign, b.width, 0)
+	b.width += rw
+	b.addRule(b.width-0.02*s, -top, b.width+body.width+0.05*s, -top+t)
+	b.add(body, b.width, 0)
+	b.width += body.width + 0.05*s
+	b.height = math.Max(b.height, top)
+	b.depth = math.Max(b.depth, bottom)
+	return b, nil
+}
+
+// layoutAccent 重音、上划线和下划线
+func layoutAccent(n *accentNode, level mathLevel) (*box, error) {
+	s := level.size()
+	body, err := layoutList(n.body, level)
+	if err != nil {
+		return nil, err
+	}
+	t := ruleThickness * s
+
+	b := &box{width: body.width}
+	b.add(body, 0, 0)
+	switch {
+	case n.over:
+		b.addRule(0, -(body.height + 3*t + t), body.width, -(body.height + 3*t))
+		return b, nil
+	case n.under:
+		b.addRule(0, body.depth+3*t, body.width, body.depth+4*t)
+		return b, nil
+	}
+
+	g, err := lookupGlyph(variantRoman, n.accent)
+	if err != nil {
+		return nil, err
+	}
+	sx, sy := s, s
+	if n.accent == '→' {
+		sx, sy = 0.6*s, 0.6*s
+	}
+	width := g.advance * sx
+	if n.wide && body.width > width {
+		sx *= body.width / width
+		width = body.width
+	}
+
+	// 重音底部放在内容顶部之上，斜体单字右移
+	skew := 0.0
+	if body.italic && len(n.body) == 1 {
+		skew = 0.08 * s
+	}
+	dx := (body.width-width)/2 + skew
+	dy := -(math.Max(body.height, 0.45*s) + 0.05*s) - g.bottom*sy
+	accent := &box{ops: g.outline.transform(sx, sy, 0, 0), height: g.top * sy, depth: g.bottom * sy}
+	b.add(accent, dx, dy)
+	return b, nil
+}
+
+// wrapDelimiters 在内容两侧加上按内容高度拉伸的定界符
+func wrapDelimiters(body *box, left, right rune, s float64) (*box, error) {
+	b := &box{}
+	l, err := delimiterBox(left, body, s)
+	if err != nil {
+		return nil, err
+	}
+	r, err := delimiterBox(right, body, s)
+	if err != nil {
+		return nil, err
+	}
+	b.append(l, 0)
+	b.append(body, 0)
+	b.append(r, 0)
+	return b, nil
+}
+
+// delimiterBox 覆盖内容高度的定界符，以数学轴为中心
+func delimiterBox(r rune, body *box, s float64) (*box, error) {
+	if r == 0 {
+		return &box{width: nullDelimiter * s}, nil
+	}
+	g, err := lookupGlyph(variantRoman, r)
+	if err != nil {
+		return nil, err
+	}
+
+	axis := axisHeight * s
+	half := math.Max(body.height-axis, body.depth+axis)
+	need := math.Max(2*half*delimiterRatio, 2*half-0.5*s)
+
+	natural := (g.top + g.bottom) * s
+	sx, sy := s, s
+	if need > natural {
+		stretch := need / natural
+		sy *= stretch
+		if r != '|' && r != '‖' {
+			sx *= math.Min(1+(stretch-1)*0.15, 1.6)
+		}
+	}
+
+	center := (g.bottom - g.top) / 2 * sy
+	dy := -axis - center
+	b := &box{width: g.advance * sx}
+	b.ops = g.outline.transform(sx, sy, 0, dy)
+	b.height = g.top*sy - dy
+	b.depth = g.bottom*sy + dy
+	return b, nil
+}
+
+// layoutArray 矩阵和多行公式
+func layoutArray(n *arrayNode, level mathLevel) (*box, error) {
+	s := level.size()
+	cellLevel := level
+	if n.level != 0 {
+		cellLevel = n.level
+	} else if level == levelDisplay {
+		cellLevel = levelText
+	}
+
+	// 排版所有单元格
+	var cells [][]*box
+	var colWidths []float64
+	for _, row := range n.rows {
+		var boxes []*box
+		for j, cell := range row {
+			// aligned 右侧单元格以关系符开头时保留间距（相当于 TeX 的 {}=）
+			if n.pairs && j%2 == 1 {
+				cell = append([]*atom{{class: classOrd, nucleus: &listNode{}}}, cell...)
+			}
+			c, err := layoutList(cell, cellLevel)
+			if err != nil {
+				return nil, err
+			}
+			boxes = append(boxes, c)
+			if j >= len(colWidths) {
+				colWidths = append(colWidths, 0)
+			}
+			colWidths[j] = math.Max(colWidths[j], c.width)
+		}
+		cells = append(cells, boxes)
+	}
+
+	// 列位置
+	colX := make([]float64, len(colWidths))
+	x := 0.0
+	for j, w := range colWidths {
+		if j > 0 {
+			switch {
+			case n.pairs && j%2 == 1:
+			case n.pairs:
+				x += 2 * s
+			default:
+				x += 1 * s
+			}
+		}
+		colX[j] = x
+		x += w
+	}
+
+	// 行高：每行至少一个支柱的高度
+	rowGap := 0.15 * s
+	if cellLevel == levelDisplay {
+		rowGap = 0.3 * s
+	}
+	b := &box{width: x}
+	y := 0.0
+	var rows []float64
+	for _, row := range cells {
+		h, d := 0.7*s, 0.3*s
+		for _, c := range row {
+			h = math.Max(h, c.height)
+			d = math.Max(d, c.depth)
+		}
+		if len(rows) > 0 {
+			y += rowGap
+		}
+		y += h
+		rows = append(rows, y)
+		y += d
+	}
+
+	// 整体以数学轴为中心
+	offset := -axisHeight*s - y/2
+	for i, row := range cells {
+		for j, c := range row {
+			align := byte('c')
+			if n.align != "" {
+				align = n.align[j%len(n.align)]
+			}
+			dx := colX[j]
+			switch align {
+			case 'c':
+				dx += (colWidths[j] - c.width) / 2
+			case 'r':
+				dx += colWidths[j] - c.width
+			}
+			b.add(c, dx, offset+rows[i])
+		}
+	}
+	b.height = math.Max(b.height, -offset)
+	b.depth = math.Max(b.depth, offset+y)
+
+	if n.left == 0 && n.right == 0 {
+		return b, nil
+	}
+	pad := &box{width: 0.1 * s}
+	inner := &box{}
+	inner.append(pad, 0)
+	inner.append(b, 0)
+	inner.append(pad, 0)
+	return wrapDelimiters(inner, n.left, n.right, s)
+}
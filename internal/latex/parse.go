@@ -0,0 +1,732 @@
+package latex
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// atom 公式的基本单位：核心加可选的上下标
+type atom struct {
+	class   atomClass
+	nucleus node
+	sup     []*atom
+	sub     []*atom
+	hasSup  bool
+	hasSub  bool
+	limits  int // 0 自动，1 \limits，-1 \nolimits
+}
+
+// node 原子核心
+type node interface{}
+
+type (
+	// charNode 单个字符
+	charNode struct {
+		r       rune
+		variant fontVariant
+	}
+
+	// listNode 花括号分组
+	listNode struct {
+		list []*atom
+	}
+
+	// opNode 大型运算符（r）或运算符名称（name）
+	opNode struct {
+		r      rune
+		name   string
+		limits bool
+	}
+
+	// fracNode 分式，bar 为 false 时没有分数线（\binom）
+	fracNode struct {
+		num, den []*atom
+		bar      bool
+		level    mathLevel // 为 0 时按当前样式
+	}
+
+	// sqrtNode 根式
+	sqrtNode struct {
+		body, index []*atom
+	}
+
+	// delimNode \left ... \right，定界符为 0 表示空定界符（\left.）
+	delimNode struct {
+		left, right rune
+		body        []*atom
+	}
+
+	// accentNode 重音、上划线和下划线
+	accentNode struct {
+		body   []*atom
+		accent rune
+		wide   bool
+		over   bool // \overline
+		under  bool // \underline
+	}
+
+	// textNode \text 等正文文字
+	textNode struct {
+		text    string
+		variant fontVariant
+	}
+
+	// spaceNode 间距（em）
+	spaceNode struct {
+		em float64
+	}
+
+	// styleNode \displaystyle 等样式切换，作用于所在分组的后续内容
+	styleNode struct {
+		level mathLevel
+	}
+
+	// arrayNode 矩阵和多行公式
+	arrayNode struct {
+		rows        [][][]*atom
+		align       string // 每列的对齐方式（l/c/r），列数多于字符数时循环使用
+		pairs       bool   // aligned：列两两成对，对之间加大间距
+		left, right rune
+		level       mathLevel
+	}
+)
+
+// parser LaTeX 公式解析器
+type parser struct {
+	src     string
+	pos     int
+	variant *fontVariant // \mathbf 等设置的字体
+}
+
+// token 词法单元：命令（不含反斜杠）或单个字符
+type token struct {
+	command string
+	r       rune
+	eof     bool
+}
+
+func (t token) is(r rune) bool {
+	return t.command == "" && !t.eof && t.r == r
+}
+
+func (t token) isCommand(name string) bool {
+	return t.command == name
+}
+
+// parse 解析公式
+func parse(src string) ([]*atom, error) {
+	p := &parser{src: src}
+	rows, err := p.parseRows(nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 1 && len(rows[0]) == 1 {
+		return rows[0][0], nil
+	}
+
+	// 顶层的 \\ 和 & 视为多行公式
+	arr := &arrayNode{rows: rows, align: "c"}
+	for _, row := range rows {
+		if len(row) > 1 {
+			arr.align, arr.pairs = "rl", true
+		}
+	}
+	return []*atom{{class: classOrd, nucleus: arr}}, nil
+}
+
+// skipSpace 跳过空白
+func (p *parser) skipSpace() {
+	for p.pos < len(p.src) {
+		r, size := utf8.DecodeRuneInString(p.src[p.pos:])
+		if !unicode.IsSpace(r) {
+			return
+		}
+		p.pos += size
+	}
+}
+
+// next 读取下一个词法单元（数学模式下忽略空白）
+func (p *parser) next() token {
+	p.skipSpace()
+	if p.pos >= len(p.src) {
+		return token{eof: true}
+	}
+
+	r, size := utf8.DecodeRuneInString(p.src[p.pos:])
+	p.pos += size
+	if r != '\\' {
+		return token{r: r}
+	}
+
+	start := p.pos
+	for p.pos < len(p.src) && isASCIILetter(p.src[p.pos]) {
+		p.pos++
+	}
+	if p.pos > start {
+		return token{command: p.src[start:p.pos]}
+	}
+	if p.pos >= len(p.src) {
+		return token{r: '\\'}
+	}
+	r, size = utf8.DecodeRuneInString(p.src[p.pos:])
+	p.pos += size
+	return token{command: string(r)}
+}
+
+// peek 查看下一个词法单元
+func (p *parser) peek() token {
+	pos := p.pos
+	t := p.next()
+	p.pos = pos
+	return t
+}
+
+// errorf 生成带位置的错误
+func (p *parser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("position %d: %s", p.pos, fmt.Sprintf(format, args...))
+}
+
+// parseRows 解析以 & 分隔单元格、\\ 分隔行的内容，直到 } 、\end 或结尾
+// end 不为 nil 时由调用方处理结束标记
+func (p *parser) parseRows(end func(token) bool) ([][][]*atom, error) {
+	var rows [][][]*atom
+	var row [][]*atom
+	for {
+		cell, err := p.parseList()
+		if err != nil {
+			return nil, err
+		}
+		row = append(row, cell)
+
+		t := p.peek()
+		switch {
+		case t.is('&'):
+			p.next()
+		case t.isCommand("\\") || t.isCommand("cr"):
+			p.next()
+			// 可选的行距参数 \\[2pt]
+			if p.peek().is('[') {
+				if _, err := p.readUntil(']'); err != nil {
+					return nil, err
+				}
+			}
+			rows = append(rows, row)
+			row = nil
+		default:
+			if (end == nil && !t.eof) || (end != nil && !end(t)) {
+				switch {
+				case t.isCommand("right"):
+					return nil, p.errorf("\\right without \\left")
+				case t.isCommand("end"):
+					return nil, p.errorf("\\end without \\begin")
+				}
+				return nil, p.errorf("unexpected %s", describe(t))
+			}
+			if len(row) > 1 || len(row[0]) > 0 || len(rows) == 0 {
+				rows = append(rows, row)
+			}
+			return rows, nil
+		}
+	}
+}
+
+// parseList 解析原子序列，遇到 }、&、\\、\right、\end 或结尾时停止（不消耗）
+func (p *parser) parseList() ([]*atom, error) {
+	var list []*atom
+	for {
+		t := p.peek()
+		if t.eof || t.is('}') || t.is('&') || t.isCommand("\\") || t.isCommand("cr") ||
+			t.isCommand("right") || t.isCommand("end") {
+			return list, nil
+		}
+
+		a, err := p.parseAtom()
+		if err != nil {
+			return nil, err
+		}
+		if a != nil {
+			list = append(list, a)
+		}
+	}
+}
+
+// parseAtom 解析一个原子及其上下标
+func (p *parser) parseAtom() (*atom, error) {
+	var a *atom
+	if t := p.peek(); t.is('^') || t.is('_') || t.is('\'') {
+		// 没有核心的上下标
+		a = &atom{class: classOrd, nucleus: &listNode{}}
+	} else {
+		var err error
+		a, err = p.parseNucleus()
+		if err != nil || a == nil {
+			return a, err
+		}
+	}
+
+	for {
+		t := p.peek()
+		switch {
+		case t.is('^'), t.is('_'):
+			p.next()
+			arg, err := p.parseScriptArg()
+			if err != nil {
+				return nil, err
+			}
+			if t.is('^') {
+				if a.hasSup {
+					return nil, p.errorf("double superscript")
+				}
+				a.sup, a.hasSup = append(a.sup, arg...), true
+			} else {
+				if a.hasSub {
+					return nil, p.errorf("double subscript")
+				}
+				a.sub, a.hasSub = arg, true
+			}
+		case t.is('\''):
+			p.next()
+			a.sup = append(a.sup, &atom{class: classOrd, nucleus: &charNode{r: '′'}})
+		case t.isCommand("limits"), t.isCommand("nolimits"):
+			p.next()
+			if _, ok := a.nucleus.(*opNode); !ok {
+				return nil, p.errorf("\\%s must follow an operator", t.command)
+			}
+			a.limits = 1
+			if t.command == "nolimits" {
+				a.limits = -1
+			}
+		default:
+			return a, nil
+		}
+	}
+}
+
+// parseScriptArg 解析上下标：花括号分组或单个原子
+func (p *parser) parseScriptArg() ([]*atom, error) {
+	t := p.peek()
+	if t.eof {
+		return nil, p.errorf("missing script argument")
+	}
+	if t.is('{') {
+		return p.parseGroupArg()
+	}
+	a, err := p.parseNucleus()
+	if err != nil {
+		return nil, err
+	}
+	if a == nil {
+		return nil, p.errorf("invalid script argument")
+	}
+	return []*atom{a}, nil
+}
+
+// parseGroupArg 解析必需参数：{...} 或单个词法单元
+func (p *parser) parseGroupArg() ([]*atom, error) {
+	t := p.peek()
+	if t.eof {
+		return nil, p.errorf("missing argument")
+	}
+	if !t.is('{') {
+		a, err := p.parseNucleus()
+		if err != nil {
+			return nil, err
+		}
+		if a == nil {
+			return nil, nil
+		}
+		return []*atom{a}, nil
+	}
+
+	p.next()
+	list, err := p.parseList()
+	if err != nil {
+		return nil, err
+	}
+	if !p.next().is('}') {
+		return nil, p.errorf("missing '}'")
+	}
+	return list, nil
+}
+
+// parseNucleus 解析原子核心（不含上下标），样式切换等不产生原子时返回 nil
+func (p *parser) parseNucleus() (*atom, error) {
+	t := p.next()
+	switch {
+	case t.eof:
+		return nil, p.errorf("unexpected end of formula")
+	case t.is('{'):
+		p.pos -= 1
+		list, err := p.parseGroupArg()
+		if err != nil {
+			return nil, err
+		}
+		return &atom{class: classOrd, nucleus: &listNode{list: list}}, nil
+	case t.is('}'):
+		return nil, p.errorf("unexpected '}'")
+	case t.command != "":
+		return p.parseCommand(t.command)
+	}
+	return p.charAtom(t.r), nil
+}
+
+// charAtom 普通字符
+func (p *parser) charAtom(r rune) *atom {
+	switch r {
+	case '-':
+		r = '−'
+	case '~':
+		return &atom{class: classOrd, nucleus: &spaceNode{em: 0.25}}
+	}
+
+	variant := variantRoman
+	if isMathLetter(r) {
+		variant = variantItalic
+	}
+	if p.variant != nil {
+		variant = *p.variant
+		if variant == variantItalic && !isMathLetter(r) && !unicode.IsLetter(r) {
+			variant = variantRoman
+		}
+	}
+	return &atom{class: runeClass(r), nucleus: &charNode{r: r, variant: variant}}
+}
+
+// parseCommand 解析命令
+func (p *parser) parseCommand(name string) (*atom, error) {
+	if s, ok := symbols[name]; ok {
+		variant := variantRoman
+		if isMathLetter(s.r) {
+			variant = variantItalic
+		}
+		if p.variant != nil && unicode.IsLetter(s.r) {
+			variant = *p.variant
+		}
+		return &atom{class: s.class, nucleus: &charNode{r: s.r, variant: variant}}, nil
+	}
+	if op, ok := bigOperators[name]; ok {
+		return &atom{class: classOp, nucleus: &opNode{r: op.r, limits: op.limits}}, nil
+	}
+	if limits, ok := operatorNames[name]; ok {
+		return &atom{class: classOp, nucleus: &opNode{name: name, limits: limits}}, nil
+	}
+	if em, ok := spaces[name]; ok {
+		return &atom{class: -1, nucleus: &spaceNode{em: em}}, nil
+	}
+	if r, ok := accents[name]; ok {
+		body, err := p.parseGroupArg()
+		if err != nil {
+			return nil, err
+		}
+		return &atom{class: classOrd, nucleus: &accentNode{body: body, accent: r, wide: strings.HasPrefix(name, "wide")}}, nil
+	}
+
+	switch name {
+	case "frac", "dfrac", "tfrac", "cfrac", "binom", "dbinom", "tbinom":
+		num, err := p.parseGroupArg()
+		if err != nil {
+			return nil, err
+		}
+		den, err := p.parseGroupArg()
+		if err != nil {
+			return nil, err
+		}
+		frac := &fracNode{num: num, den: den, bar: !strings.HasSuffix(name, "binom")}
+		switch name[0] {
+		case 'd', 'c':
+			frac.level = levelDisplay
+		case 't':
+			frac.level = levelText
+		}
+		if !frac.bar {
+			inner := &atom{class: classOrd, nucleus: frac}
+			return &atom{class: classInner, nucleus: &delimNode{left: '(', right: ')', body: []*atom{inner}}}, nil
+		}
+		return &atom{class: classOrd, nucleus: frac}, nil
+
+	case "sqrt":
+		var index []*atom
+		if p.peek().is('[') {
+			p.next()
+			var err error
+			if index, err = p.parseUntil(']'); err != nil {
+				return nil, err
+			}
+		}
+		body, err := p.parseGroupArg()
+		if err != nil {
+			return nil, err
+		}
+		return &atom{class: classOrd, nucleus: &sqrtNode{body: body, index: index}}, nil
+
+	case "overline", "underline":
+		body, err := p.parseGroupArg()
+		if err != nil {
+			return nil, err
+		}
+		return &atom{class: classOrd, nucleus: &accentNode{body: body, over: name == "overline", under: name == "underline"}}, nil
+
+	case "text", "textrm", "textnormal", "mbox", "textbf", "textit", "operatorname":
+		text, err := p.readGroupText()
+		if err != nil {
+			return nil, err
+		}
+		variant := variantRoman
+		switch name {
+		case "textbf":
+			variant = variantBold
+		case "textit":
+			variant = variantItalic
+		}
+		if name == "operatorname" {
+			return &atom{class: classOp, nucleus: &opNode{name: text}}, nil
+		}
+		return &atom{class: classOrd, nucleus: &textNode{text: text, variant: variant}}, nil
+
+	case "mathrm", "mathbf", "mathit", "mathsf", "mathtt", "mathcal", "mathscr", "mathbb", "boldsymbol", "bm":
+		variant := map[string]fontVariant{
+			"mathrm": variantRoman, "mathsf": variantRoman, "mathtt": variantRoman,
+			"mathbf": variantBold, "mathbb": variantBold, "mathit": variantItalic,
+			"mathcal": variantItalic, "mathscr": variantItalic,
+			"boldsymbol": variantBoldItalic, "bm": variantBoldItalic,
+		}[name]
+		saved := p.variant
+		p.variant = &variant
+		body, err := p.parseGroupArg()
+		p.variant = saved
+		if err != nil {
+			return nil, err
+		}
+		return &atom{class: classOrd, nucleus: &listNode{list: body}}, nil
+
+	case "left":
+		return p.parseLeftRight()
+
+	case "begin":
+		return p.parseEnvironment()
+
+	case "displaystyle", "textstyle", "scriptstyle", "scriptscriptstyle":
+		level := map[string]mathLevel{
+			"displaystyle": levelDisplay, "textstyle": levelText,
+			"scriptstyle": levelScript, "scriptscriptstyle": levelScriptScript,
+		}[name]
+		return &atom{class: -1, nucleus: &styleNode{level: level}}, nil
+
+	case "cdots", "dotsb", "dotsc":
+		dots := make([]*atom, 3)
+		for i := range dots {
+			dots[i] = &atom{class: classOrd, nucleus: &charNode{r: '·'}}
+		}
+		return &atom{class: classInner, nucleus: &listNode{list: dots}}, nil
+
+	case "not":
+		// 只支持常用的否定关系
+		t := p.next()
+		switch {
+		case t.is('='):
+			return &atom{class: classRel, nucleus: &charNode{r: '≠'}}, nil
+		case t.isCommand("in"):
+			return &atom{class: classRel, nucleus: &charNode{r: '∉'}}, nil
+		}
+		return nil, p.errorf("\\not is only supported before = and \\in")
+
+	case "right":
+		return nil, p.errorf("\\right without \\left")
+	case "end":
+		return nil, p.errorf("\\end without \\begin")
+	}
+
+	return nil, p.errorf("unknown command \\%s", name)
+}
+
+// parseUntil 解析到指定字符（用于 \sqrt[n]）
+func (p *parser) parseUntil(close rune) ([]*atom, error) {
+	var list []*atom
+	for {
+		t := p.peek()
+		if t.eof {
+			return nil, p.errorf("missing '%c'", close)
+		}
+		if t.is(close) {
+			p.next()
+			return list, nil
+		}
+		a, err := p.parseAtom()
+		if err != nil {
+			return nil, err
+		}
+		if a != nil {
+			list = append(list, a)
+		}
+	}
+}
+
+// readUntil 读取原始文本直到指定字符
+func (p *parser) readUntil(close rune) (string, error) {
+	p.next()
+	end := strings.IndexRune(p.src[p.pos:], close)
+	if end < 0 {
+		return "", p.errorf("missing '%c'", close)
+	}
+	text := p.src[p.pos : p.pos+end]
+	p.pos += end + utf8.RuneLen(close)
+	return text, nil
+}
+
+// readGroupText 读取 {...} 中的原始文本（\text 的参数，保留空格）
+func (p *parser) readGroupText() (string, error) {
+	if !p.next().is('{') {
+		return "", p.errorf("missing '{'")
+	}
+
+	var b strings.Builder
+	depth := 0
+	for p.pos < len(p.src) {
+		r, size := utf8.DecodeRuneInString(p.src[p.pos:])
+		p.pos += size
+		switch {
+		case r == '\\' && p.pos < len(p.src):
+			// \{ \} \_ 等转义字符和 \ 空格
+			e, esize := utf8.DecodeRuneInString(p.src[p.pos:])
+			p.pos += esize
+			b.WriteRune(e)
+		case r == '{':
+			depth++
+		case r == '}':
+			if depth == 0 {
+				return b.String(), nil
+			}
+			depth--
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return "", p.errorf("missing '}'")
+}
+
+// parseLeftRight 解析 \left ... \right
+func (p *parser) parseLeftRight() (*atom, error) {
+	left, err := p.parseDelimiter()
+	if err != nil {
+		return nil, err
+	}
+	body, err := p.parseList()
+	if err != nil {
+		return nil, err
+	}
+	if !p.next().isCommand("right") {
+		return nil, p.errorf("missing \\right")
+	}
+	right, err := p.parseDelimiter()
+	if err != nil {
+		return nil, err
+	}
+	return &atom{class: classInner, nucleus: &delimNode{left: left, right: right, body: body}}, nil
+}
+
+// parseDelimiter 解析 \left 和 \right 后的定界符
+func (p *parser) parseDelimiter() (rune, error) {
+	t := p.next()
+	switch {
+	case t.eof:
+		return 0, p.errorf("missing delimiter")
+	case t.is('.'):
+		return 0, nil
+	case t.command == "":
+		if strings.ContainsRune("()[]|/", t.r) {
+			return t.r, nil
+		}
+	default:
+		if s, ok := symbols[t.command]; ok && (s.class == classOpen || s.class == classClose || s.r == '|' || s.r == '‖') {
+			return s.r, nil
+		}
+	}
+	return 0, p.errorf("invalid delimiter %s", describe(t))
+}
+
+// environments 支持的环境：对齐方式、列成对、左右定界符
+var environments = map[string]arrayNode{
+	"matrix":      {align: "c"},
+	"smallmatrix": {align: "c", level: levelScript},
+	"pmatrix":     {align: "c", left: '(', right: ')'},
+	"bmatrix":     {align: "c", left: '[', right: ']'},
+	"Bmatrix":     {align: "c", left: '{', right: '}'},
+	"vmatrix":     {align: "c", left: '|', right: '|'},
+	"Vmatrix":     {align: "c", left: '‖', right: '‖'},
+	"cases":       {align: "l", left: '{'},
+	"aligned":     {align: "rl", pairs: true, level: levelDisplay},
+	"align":       {align: "rl", pairs: true, level: levelDisplay},
+	"align*":      {align: "rl", pairs: true, level: levelDisplay},
+	"gathered":    {align: "c", level: levelDisplay},
+	"gather":      {align: "c", level: levelDisplay},
+	"gather*":     {align: "c", level: levelDisplay},
+	"split":       {align: "rl", pairs: true, level: levelDisplay},
+	"array":       {},
+}
+
+// parseEnvironment 解析 \begin{name} ... \end{name}
+func (p *parser) parseEnvironment() (*atom, error) {
+	name, err := p.readGroupText()
+	if err != nil {
+		return nil, err
+	}
+	env, ok := environments[name]
+	if !ok {
+		return nil, p.errorf("unknown environment %q", name)
+	}
+
+	arr := env
+	if name == "array" {
+		spec, err := p.readGroupText()
+		if err != nil {
+			return nil, err
+		}
+		arr.align = strings.Map(func(r rune) rune {
+			if r == 'l' || r == 'c' || r == 'r' {
+				return r
+			}
+			return -1
+		}, spec)
+		if arr.align == "" {
+			return nil, p.errorf("invalid array column spec %q", spec)
+		}
+	}
+
+	rows, err := p.parseRows(func(t token) bool { return t.isCommand("end") })
+	if err != nil {
+		return nil, err
+	}
+	p.next()
+	endName, err := p.readGroupText()
+	if err != nil {
+		return nil, err
+	}
+	if endName != name {
+		return nil, p.errorf("\\begin{%s} ended by \\end{%s}", name, endName)
+	}
+
+	arr.rows = rows
+	return &atom{class: classOrd, nucleus: &arr}, nil
+}
+
+// describe 描述词法单元（用于错误信息）
+func describe(t token) string {
+	switch {
+	case t.eof:
+		return "end of formula"
+	case t.command != "":
+		return "\\" + t.command
+	}
+	return fmt.Sprintf("%q", t.r)
+}
+
+// isMathLetter 数学模式下默认使用斜体的字母：拉丁字母和小写希腊字母
+func isMathLetter(r rune) bool {
+	return (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= 'α' && r <= 'ω') || r == 'ϵ' || r == 'ϕ' || r == 'ϑ'
+}
+
+func isASCIILetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
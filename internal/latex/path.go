@@ -0,0 +1,170 @@
+package latex
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"golang.org/x/image/vector"
+)
+
+// point 坐标（em 单位，y 轴向下）
+type point struct {
+	x, y float64
+}
+
+// pathOp 路径指令：'M'、'L'、'Q'、'C' 或 'Z'
+type pathOp struct {
+	op  byte
+	pts [3]point
+}
+
+// path 由若干闭合轮廓组成的填充路径
+type path []pathOp
+
+// pointCount 指令使用的坐标数
+func (o pathOp) pointCount() int {
+	switch o.op {
+	case 'M', 'L':
+		return 1
+	case 'Q':
+		return 2
+	case 'C':
+		return 3
+	}
+	return 0
+}
+
+// each 遍历所有坐标（含控制点）
+func (p path) each(fn func(point)) {
+	for _, o := range p {
+		for i := 0; i < o.pointCount(); i++ {
+			fn(o.pts[i])
+		}
+	}
+}
+
+// mapPoints 返回变换后的新路径
+func (p path) mapPoints(fn func(point) point) path {
+	out := make(path, len(p))
+	for i, o := range p {
+		out[i] = o
+		for j := 0; j < o.pointCount(); j++ {
+			out[i].pts[j] = fn(o.pts[j])
+		}
+	}
+	return out
+}
+
+// transform 缩放后平移
+func (p path) transform(sx, sy, dx, dy float64) path {
+	return p.mapPoints(func(pt point) point {
+		return point{pt.x*sx + dx, pt.y*sy + dy}
+	})
+}
+
+// rect 矩形路径（逆时针，与 stroke 一致）
+func rect(x0, y0, x1, y1 float64) path {
+	return polygon(point{x0, y0}, point{x1, y0}, point{x1, y1}, point{x0, y1})
+}
+
+// polygon 闭合多边形，统一为同一方向，避免重叠部分按非零规则相互抵消
+func polygon(pts ...point) path {
+	area := 0.0
+	for i := range pts {
+		a, b := pts[i], pts[(i+1)%len(pts)]
+		area += a.x*b.y - b.x*a.y
+	}
+	if area > 0 {
+		for i, j := 0, len(pts)-1; i < j; i, j = i+1, j-1 {
+			pts[i], pts[j] = pts[j], pts[i]
+		}
+	}
+
+	out := path{{op: 'M', pts: [3]point{pts[0]}}}
+	for _, pt := range pts[1:] {
+		out = append(out, pathOp{op: 'L', pts: [3]point{pt}})
+	}
+	return append(out, pathOp{op: 'Z'})
+}
+
+// stroke 将折线转为填充路径：每段一个四边形，两端各延长半个线宽以连接拐角
+func stroke(width float64, pts ...point) path {
+	var out path
+	half := width / 2
+	for i := 0; i+1 < len(pts); i++ {
+		a, b := pts[i], pts[i+1]
+		dx, dy := b.x-a.x, b.y-a.y
+		length := math.Hypot(dx, dy)
+		if length == 0 {
+			continue
+		}
+		ux, uy := dx/length*half, dy/length*half // 方向
+		nx, ny := -uy, ux                        // 法线
+		a = point{a.x - ux, a.y - uy}
+		b = point{b.x + ux, b.y + uy}
+		out = append(out, polygon(
+			point{a.x + nx, a.y + ny}, point{b.x + nx, b.y + ny},
+			point{b.x - nx, b.y - ny}, point{a.x - nx, a.y - ny})...)
+	}
+	return out
+}
+
+// arc 椭圆弧上的点，角度从 a0 到 a1（y 轴向下，角度增加为顺时针）
+func arc(cx, cy, rx, ry, a0, a1 float64, n int) []point {
+	pts := make([]point, 0, n+1)
+	for i := 0; i <= n; i++ {
+		a := a0 + (a1-a0)*float64(i)/float64(n)
+		pts = append(pts, point{cx + rx*math.Cos(a), cy + ry*math.Sin(a)})
+	}
+	return pts
+}
+
+// svgData 生成 SVG path 的 d 属性，坐标乘以 scale 并保留整数
+func (p path) svgData(scale float64) string {
+	var b strings.Builder
+	num := func(v float64) {
+		b.WriteString(strconv.FormatFloat(math.Round(v*scale), 'f', -1, 64))
+	}
+	for _, o := range p {
+		if b.Len() > 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteByte(o.op)
+		for i := 0; i < o.pointCount(); i++ {
+			if i > 0 {
+				b.WriteByte(' ')
+			}
+			num(o.pts[i].x)
+			b.WriteByte(' ')
+			num(o.pts[i].y)
+		}
+	}
+	return b.String()
+}
+
+// rasterize 将路径填充到光栅器，坐标乘以 scale 后平移
+func (p path) rasterize(z *vector.Rasterizer, scale, dx, dy float64) {
+	f := func(pt point) (float32, float32) {
+		return float32(pt.x*scale + dx), float32(pt.y*scale + dy)
+	}
+	for _, o := range p {
+		switch o.op {
+		case 'M':
+			z.MoveTo(f(o.pts[0]))
+		case 'L':
+			z.LineTo(f(o.pts[0]))
+		case 'Q':
+			bx, by := f(o.pts[0])
+			cx, cy := f(o.pts[1])
+			z.QuadTo(bx, by, cx, cy)
+		case 'C':
+			bx, by := f(o.pts[0])
+			cx, cy := f(o.pts[1])
+			ex, ey := f(o.pts[2])
+			z.CubeTo(bx, by, cx, cy, ex, ey)
+		case 'Z':
+			z.ClosePath()
+		}
+	}
+}
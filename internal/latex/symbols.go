@@ -0,0 +1,151 @@
+package latex
+
+// atomClass TeX 原子类别，决定相邻原子之间的间距
+type atomClass int
+
+const (
+	classOrd atomClass = iota
+	classOp
+	classBin
+	classRel
+	classOpen
+	classClose
+	classPunct
+	classInner
+)
+
+// symbol 命令对应的字符
+type symbol struct {
+	r     rune
+	class atomClass
+}
+
+// symbols 符号命令
+var symbols = map[string]symbol{
+	// 小写希腊字母
+	"alpha": {'α', classOrd}, "beta": {'β', classOrd}, "gamma": {'γ', classOrd}, "delta": {'δ', classOrd},
+	"epsilon": {'ϵ', classOrd}, "varepsilon": {'ε', classOrd}, "zeta": {'ζ', classOrd}, "eta": {'η', classOrd},
+	"theta": {'θ', classOrd}, "vartheta": {'ϑ', classOrd}, "iota": {'ι', classOrd}, "kappa": {'κ', classOrd},
+	"lambda": {'λ', classOrd}, "mu": {'μ', classOrd}, "nu": {'ν', classOrd}, "xi": {'ξ', classOrd},
+	"pi": {'π', classOrd}, "rho": {'ρ', classOrd}, "sigma": {'σ', classOrd}, "varsigma": {'ς', classOrd},
+	"tau": {'τ', classOrd}, "upsilon": {'υ', classOrd}, "phi": {'ϕ', classOrd}, "varphi": {'φ', classOrd},
+	"chi": {'χ', classOrd}, "psi": {'ψ', classOrd}, "omega": {'ω', classOrd},
+
+	// 大写希腊字母
+	"Gamma": {'Γ', classOrd}, "Delta": {'Δ', classOrd}, "Theta": {'Θ', classOrd}, "Lambda": {'Λ', classOrd},
+	"Xi": {'Ξ', classOrd}, "Pi": {'Π', classOrd}, "Sigma": {'Σ', classOrd}, "Upsilon": {'Υ', classOrd},
+	"Phi": {'Φ', classOrd}, "Psi": {'Ψ', classOrd}, "Omega": {'Ω', classOrd},
+
+	// 其它普通符号
+	"infty": {'∞', classOrd}, "partial": {'∂', classOrd}, "nabla": {'∇', classOrd}, "forall": {'∀', classOrd},
+	"exists": {'∃', classOrd}, "emptyset": {'∅', classOrd}, "varnothing": {'∅', classOrd}, "neg": {'¬', classOrd},
+	"lnot": {'¬', classOrd}, "prime": {'′', classOrd}, "ell": {'ℓ', classOrd}, "hbar": {'ħ', classOrd},
+	"degree": {'°', classOrd}, "ldots": {'…', classInner}, "dots": {'…', classInner},
+	"angle": {'∠', classOrd}, "triangle": {'∆', classOrd},
+
+	// 二元运算符
+	"pm": {'±', classBin}, "mp": {'∓', classBin}, "times": {'×', classBin}, "div": {'÷', classBin},
+	"cdot": {'·', classBin}, "ast": {'∗', classBin}, "circ": {'∘', classBin}, "bullet": {'•', classBin},
+	"cap": {'∩', classBin}, "cup": {'∪', classBin}, "wedge": {'∧', classBin}, "land": {'∧', classBin},
+	"vee": {'∨', classBin}, "lor": {'∨', classBin}, "oplus": {'⊕', classBin}, "otimes": {'⊗', classBin},
+	"setminus": {'\\', classBin},
+
+	// 关系符
+	"le": {'≤', classRel}, "leq": {'≤', classRel}, "ge": {'≥', classRel}, "geq": {'≥', classRel},
+	"ne": {'≠', classRel}, "neq": {'≠', classRel}, "approx": {'≈', classRel}, "equiv": {'≡', classRel},
+	"sim": {'∼', classRel}, "propto": {'∝', classRel}, "ll": {'≪', classRel}, "gg": {'≫', classRel},
+	"in": {'∈', classRel}, "notin": {'∉', classRel}, "subset": {'⊂', classRel}, "supset": {'⊃', classRel},
+	"subseteq": {'⊆', classRel}, "supseteq": {'⊇', classRel}, "mid": {'|', classRel},
+	"to": {'→', classRel}, "rightarrow": {'→', classRel}, "leftarrow": {'←', classRel}, "gets": {'←', classRel},
+	"leftrightarrow": {'↔', classRel}, "uparrow": {'↑', classRel}, "downarrow": {'↓', classRel},
+	"Rightarrow": {'⇒', classRel}, "implies": {'⇒', classRel}, "Leftarrow": {'⇐', classRel},
+	"Leftrightarrow": {'⇔', classRel}, "iff": {'⇔', classRel}, "mapsto": {'↦', classRel},
+
+	// 定界符
+	"langle": {'⟨', classOpen}, "rangle": {'⟩', classClose}, "lbrace": {'{', classOpen}, "rbrace": {'}', classClose},
+	"lvert": {'|', classOpen}, "rvert": {'|', classClose}, "lVert": {'‖', classOpen}, "rVert": {'‖', classClose},
+	"vert": {'|', classOrd}, "Vert": {'‖', classOrd},
+
+	// 转义字符
+	"{": {'{', classOpen}, "}": {'}', classClose}, "_": {'_', classOrd}, "%": {'%', classOrd},
+	"$": {'$', classOrd}, "#": {'#', classOrd}, "&": {'&', classOrd}, "|": {'‖', classOrd},
+}
+
+// bigOperator 大型运算符
+type bigOperator struct {
+	r      rune
+	limits bool // 行间公式中上下标放在上下方
+}
+
+// bigOperators 大型运算符命令
+var bigOperators = map[string]bigOperator{
+	"sum": {'∑', true}, "prod": {'∏', true}, "coprod": {'∐', true},
+	"bigcup": {'∪', true}, "bigcap": {'∩', true}, "bigoplus": {'⊕', true}, "bigotimes": {'⊗', true},
+	"int": {'∫', false}, "oint": {'∮', false},
+}
+
+// operatorNames 正体的运算符名称，值表示是否在行间公式中把上下标放在上下方
+var operatorNames = map[string]bool{
+	"sin": false, "cos": false, "tan": false, "cot": false, "sec": false, "csc": false,
+	"arcsin": false, "arccos": false, "arctan": false, "sinh": false, "cosh": false, "tanh": false,
+	"log": false, "ln": false, "lg": false, "exp": false, "deg": false, "dim": false,
+	"ker": false, "arg": false, "hom": false,
+	"lim": true, "max": true, "min": true, "sup": true, "inf": true, "det": true, "gcd": true, "Pr": true,
+	"argmax": true, "argmin": true,
+}
+
+// spaces 间距命令（em）
+var spaces = map[string]float64{
+	",": 3.0 / 18, "thinspace": 3.0 / 18, ":": 4.0 / 18, ">": 4.0 / 18, "medspace": 4.0 / 18,
+	";": 5.0 / 18, "thickspace": 5.0 / 18, "!": -3.0 / 18, " ": 0.25, "enspace": 0.5,
+	"quad": 1, "qquad": 2,
+}
+
+// accents 重音命令
+var accents = map[string]rune{
+	"hat": 'ˆ', "widehat": 'ˆ', "check": 'ˇ', "tilde": '˜', "widetilde": '˜', "bar": '¯',
+	"vec": '→', "dot": '˙', "ddot": '¨', "acute": '´', "grave": '`', "breve": '˘',
+}
+
+// charClasses 直接输入的字符的类别
+var charClasses = map[rune]atomClass{
+	'+': classBin, '−': classBin, '*': classBin, '/': classOrd,
+	'=': classRel, '<': classRel, '>': classRel, ':': classRel,
+	'(': classOpen, '[': classOpen, ')': classClose, ']': classClose,
+	',': classPunct, ';': classPunct, '!': classClose, '?': classClose,
+}
+
+// runeClass 字符的类别（直接输入的 Unicode 符号按符号表归类）
+func runeClass(r rune) atomClass {
+	if c, ok := charClasses[r]; ok {
+		return c
+	}
+	if c, ok := unicodeClasses[r]; ok {
+		return c
+	}
+	return classOrd
+}
+
+// unicodeClasses 由符号表生成的 Unicode 字符类别
+var unicodeClasses = func() map[rune]atomClass {
+	m := make(map[rune]atomClass)
+	for _, s := range symbols {
+		if s.class != classOrd && s.r > 127 {
+			m[s.r] = s.class
+		}
+	}
+	return m
+}()
+
+// spacing TeX 原子间距表（单位 mu，1/18 em），负值表示上下标中不加间距
+var spacing = [8][8]int{
+	//         Ord  Op  Bin Rel Open Close Punct Inner
+	/* Ord   */ {0, 3, -4, -5, 0, 0, 0, -3},
+	/* Op    */ {3, 3, 0, -5, 0, 0, 0, -3},
+	/* Bin   */ {-4, -4, 0, 0, -4, 0, 0, -4},
+	/* Rel   */ {-5, -5, 0, 0, -5, 0, 0, -5},
+	/* Open  */ {0, 0, 0, 0, 0, 0, 0, 0},
+	/* Close */ {0, 3, -4, -5, 0, 0, 0, -3},
+	/* Punct */ {-3, -3, 0, -3, -3, -3, -3, -3},
+	/* Inner */ {-3, 3, -4, -5, -3, 0, -3, -3},
+}
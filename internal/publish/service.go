@@ -0,0 +1,233 @@
+package publish
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/geekjourneyx/md2wechat-skill/internal/config"
+	"github.com/geekjourneyx/md2wechat-skill/internal/wechat"
+	"github.com/silenceper/wechat/v2/officialaccount/freepublish"
+	"go.uber.org/zap"
+)
+
+// State 发布状态
+type State string
+
+const (
+	StateSuccess       State = "success"        // 发布成功
+	StatePublishing    State = "publishing"     // 发布中
+	StateOriginalFail  State = "original_fail"  // 原创声明失败
+	StateFail          State = "fail"           // 常规失败
+	StateAuditRefused  State = "audit_refused"  // 平台审核不通过
+	StateUserDeleted   State = "user_deleted"   // 成功后用户删除了所有文章
+	StateSystemBanned  State = "system_banned"  // 成功后系统封禁了所有文章
+	StateUnknownStatus State = "unknown_status" // 未知状态码
+)
+
+// stateNames 微信发布状态码到状态的映射
+var stateNames = map[freepublish.PublishStatus]State{
+	freepublish.PublishStatusSuccess:      StateSuccess,
+	freepublish.PublishStatusPublishing:   StatePublishing,
+	freepublish.PublishStatusOriginalFail: StateOriginalFail,
+	freepublish.PublishStatusFail:         StateFail,
+	freepublish.PublishStatusAuditRefused: StateAuditRefused,
+	freepublish.PublishStatusUserDeleted:  StateUserDeleted,
+	freepublish.PublishStatusSystemBanned: StateSystemBanned,
+}
+
+// PublishedArticle 发布成功的文章
+type PublishedArticle struct {
+	Index int    `json:"index"` // 在图文消息中的位置，第一篇为 1
+	URL   string `json:"url"`   // 文章永久链接
+}
+
+// Status 发布任务状态
+type Status struct {
+	PublishID int64              `json:"publish_id"`
+	State     State              `json:"state"`
+	Code      int                `json:"code"` // 微信返回的 publish_status
+	ArticleID string             `json:"article_id,omitempty"`
+	Articles  []PublishedArticle `json:"articles,omitempty"`
+	FailIndex []int              `json:"fail_index,omitempty"` // 原创失败或审核不通过的文章编号，第一篇为 1
+}
+
+// Done 发布任务是否已结束
+func (s *Status) Done() bool {
+	return s.State != StatePublishing
+}
+
+// URL 返回第一篇文章的链接
+func (s *Status) URL() string {
+	if len(s.Articles) == 0 {
+		return ""
+	}
+	return s.Articles[0].URL
+}
+
+// PublishError 发布错误
+type PublishError struct {
+	Code     string  // 错误码
+	Message  string  // 用户友好的错误信息
+	Hint     string  // 解决提示
+	Status   *Status // 失败时的发布状态
+	Original error   // 原始错误
+}
+
+func (e *PublishError) Error() string {
+	msg := fmt.Sprintf("发布失败: %s", e.Message)
+	if e.Hint != "" {
+		msg += fmt.Sprintf("\n提示: %s", e.Hint)
+	}
+	return msg
+}
+
+func (e *PublishError) Unwrap() error {
+	return e.Original
+}
+
+// Service 发布服务
+type Service struct {
+	cfg          *config.Config
+	log          *zap.Logger
+	ws           *wechat.Service
+	pollInterval time.Duration // 轮询间隔，默认 3s
+	maxPollTime  time.Duration // 最大轮询时间，默认 5 分钟
+	getStatus    func(publishID int64) (*freepublish.PublishStatusList, error)
+}
+
+// NewService 创建发布服务
+func NewService(cfg *config.Config, log *zap.Logger) *Service {
+	ws := wechat.NewService(cfg, log)
+	return &Service{
+		cfg:          cfg,
+		log:          log,
+		ws:           ws,
+		pollInterval: 3 * time.Second,
+		maxPollTime:  5 * time.Minute,
+		getStatus:    ws.GetPublishStatus,
+	}
+}
+
+// SetPolling 设置轮询间隔和最大轮询时间（小于等于 0 的值保持默认）
+func (s *Service) SetPolling(interval, maxWait time.Duration) {
+	if interval > 0 {
+		s.pollInterval = interval
+	}
+	if maxWait > 0 {
+		s.maxPollTime = maxWait
+	}
+}
+
+// Submit 提交草稿发布，返回发布任务 ID
+func (s *Service) Submit(mediaID string) (int64, error) {
+	if mediaID == "" {
+		return 0, fmt.Errorf("media_id is required")
+	}
+	return s.ws.Publish(mediaID)
+}
+
+// Status 查询发布任务状态
+func (s *Service) Status(publishID int64) (*Status, error) {
+	list, err := s.getStatus(publishID)
+	if err != nil {
+		return nil, err
+	}
+	return newStatus(publishID, list), nil
+}
+
+// Wait 轮询发布状态直到结束或超时，发布失败时返回 *PublishError
+func (s *Service) Wait(ctx context.Context, publishID int64) (*Status, error) {
+	ticker := time.NewTicker(s.pollInterval)
+	defer ticker.Stop()
+	timeout := time.After(s.maxPollTime)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, &PublishError{
+				Code:     "canceled",
+				Message:  "操作已取消",
+				Hint:     fmt.Sprintf("发布任务仍在进行，可稍后执行 md2wechat publish status %d 查看结果", publishID),
+				Original: ctx.Err(),
+			}
+		case <-timeout:
+			return nil, &PublishError{
+				Code:    "timeout",
+				Message: fmt.Sprintf("等待发布结果超时（超过 %v）", s.maxPollTime),
+				Hint:    fmt.Sprintf("发布任务仍在进行，可稍后执行 md2wechat publish status %d 查看结果", publishID),
+			}
+		case <-ticker.C:
+			status, err := s.Status(publishID)
+			if err != nil {
+				return nil, err
+			}
+			if !status.Done() {
+				s.log.Debug("publish in progress", zap.Int64("publish_id", publishID))
+				continue
+			}
+			if status.State != StateSuccess {
+				return status, &PublishError{
+					Code:    string(status.State),
+					Message: fmt.Sprintf("发布任务状态为 %s", status.State),
+					Hint:    failureHint(status),
+					Status:  status,
+				}
+			}
+			s.log.Info("article published",
+				zap.Int64("publish_id", publishID),
+				zap.String("url", status.URL()))
+			return status, nil
+		}
+	}
+}
+
+// Delete 删除已发布的文章，index 为 0 时删除全部文章
+func (s *Service) Delete(articleID string, index int) error {
+	if articleID == "" {
+		return fmt.Errorf("article_id is required")
+	}
+	if index < 0 {
+		return fmt.Errorf("index must be >= 0")
+	}
+	return s.ws.DeletePublished(articleID, uint(index))
+}
+
+// newStatus 转换微信返回的发布状态
+func newStatus(publishID int64, list *freepublish.PublishStatusList) *Status {
+	state, ok := stateNames[list.PublishStatus]
+	if !ok {
+		state = StateUnknownStatus
+	}
+
+	status := &Status{
+		PublishID: publishID,
+		State:     state,
+		Code:      int(list.PublishStatus),
+		ArticleID: list.ArticleID,
+	}
+	for _, item := range list.ArticleDetail.Items {
+		status.Articles = append(status.Articles, PublishedArticle{
+			Index: int(item.Index),
+			URL:   item.ArticleURL,
+		})
+	}
+	for _, idx := range list.FailIndex {
+		status.FailIndex = append(status.FailIndex, int(idx))
+	}
+	return status
+}
+
+// failureHint 根据失败状态给出提示
+func failureHint(status *Status) string {
+	switch status.State {
+	case StateOriginalFail:
+		return fmt.Sprintf("原创声明未通过（文章编号 %v），请检查是否与已发布内容重复", status.FailIndex)
+	case StateAuditRefused:
+		return fmt.Sprintf("平台审核不通过（文章编号 %v），请修改内容后重新发布", status.FailIndex)
+	case StateFail:
+		return "请在公众号后台查看失败原因后重试"
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,80 @@
+package publish
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/silenceper/wechat/v2/officialaccount/freepublish"
+	"go.uber.org/zap"
+)
+
+// newTestService 创建使用模拟状态查询的发布服务
+func newTestService(statuses ...freepublish.PublishStatusList) (*Service, *int) {
+	calls := 0
+	s := &Service{
+		log:          zap.NewNop(),
+		pollInterval: time.Millisecond,
+		maxPollTime:  time.Second,
+	}
+	s.getStatus = func(publishID int64) (*freepublish.PublishStatusList, error) {
+		status := statuses[min(calls, len(statuses)-1)]
+		calls++
+		return &status, nil
+	}
+	return s, &calls
+}
+
+func TestService_Wait(t *testing.T) {
+	s, calls := newTestService(
+		freepublish.PublishStatusList{PublishStatus: freepublish.PublishStatusPublishing},
+		freepublish.PublishStatusList{PublishStatus: freepublish.PublishStatusPublishing},
+		freepublish.PublishStatusList{
+			PublishStatus: freepublish.PublishStatusSuccess,
+			ArticleID:     "article-1",
+			ArticleDetail: freepublish.PublishArticleDetail{
+				Count: 1,
+				Items: []freepublish.PublishArticleItem{{Index: 1, ArticleURL: "https://mp.weixin.qq.com/s/abc"}},
+			},
+		},
+	)
+
+	status, err := s.Wait(context.Background(), 42)
+	if err != nil {
+		t.Fatalf("Wait() error = %v", err)
+	}
+	if *calls != 3 {
+		t.Errorf("status polled %d times, want 3", *calls)
+	}
+	if status.State != StateSuccess || status.ArticleID != "article-1" || status.URL() != "https://mp.weixin.qq.com/s/abc" {
+		t.Errorf("Wait() = %+v", status)
+	}
+}
+
+func TestService_WaitFailed(t *testing.T) {
+	s, _ := newTestService(freepublish.PublishStatusList{
+		PublishStatus: freepublish.PublishStatusAuditRefused,
+		FailIndex:     []uint{1},
+	})
+
+	status, err := s.Wait(context.Background(), 42)
+	var pubErr *PublishError
+	if !errors.As(err, &pubErr) {
+		t.Fatalf("Wait() error = %v, want *PublishError", err)
+	}
+	if pubErr.Code != string(StateAuditRefused) || status == nil || status.FailIndex[0] != 1 {
+		t.Errorf("Wait() = %+v, %+v", status, pubErr)
+	}
+}
+
+func TestService_WaitTimeout(t *testing.T) {
+	s, _ := newTestService(freepublish.PublishStatusList{PublishStatus: freepublish.PublishStatusPublishing})
+	s.maxPollTime = 20 * time.Millisecond
+
+	_, err := s.Wait(context.Background(), 42)
+	var pubErr *PublishError
+	if !errors.As(err, &pubErr) || pubErr.Code != "timeout" {
+		t.Errorf("Wait() error = %v, want timeout", err)
+	}
+}
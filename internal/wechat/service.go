@@ -2,7 +2,9 @@ package wechat
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"mime/multipart"
@@ -10,15 +12,17 @@ import (
 	neturl "net/url"
 	"os"
 	"path/filepath"
+	"sync"
 	"time"
 
 	"github.com/geekjourneyx/md2wechat-skill/internal/config"
 	"github.com/silenceper/wechat/v2"
-	wechatcache "github.com/silenceper/wechat/v2/cache"
 	"github.com/silenceper/wechat/v2/officialaccount"
 	wechatconfig "github.com/silenceper/wechat/v2/officialaccount/config"
 	"github.com/silenceper/wechat/v2/officialaccount/draft"
+	"github.com/silenceper/wechat/v2/officialaccount/freepublish"
 	"github.com/silenceper/wechat/v2/officialaccount/material"
+	"github.com/silenceper/wechat/v2/util"
 	"go.uber.org/zap"
 )
 
@@ -38,15 +42,67 @@ func NewService(cfg *config.Config, log *zap.Logger) *Service {
 	}
 }
 
+// officialAccounts 进程内共享的公众号实例（按 AppID），避免每次调用都重新获取 access_token
+var (
+	officialAccountsMu sync.Mutex
+	officialAccounts   = make(map[string]*officialAccount)
+)
+
+// officialAccount 公众号实例及其 access_token 获取方式
+type officialAccount struct {
+	oa    *officialaccount.OfficialAccount
+	token *sharedAccessToken
+}
+
 // getOfficialAccount 获取公众号实例
+// access_token 缓存在配置目录下的文件中，多次命令调用和并行进程共享同一个 token
 func (s *Service) getOfficialAccount() *officialaccount.OfficialAccount {
-	memory := wechatcache.NewMemory()
-	wechatCfg := &wechatconfig.Config{
+	return s.account().oa
+}
+
+// account 获取（或创建）当前 AppID 的共享公众号实例
+func (s *Service) account() *officialAccount {
+	officialAccountsMu.Lock()
+	defer officialAccountsMu.Unlock()
+
+	key := s.cfg.WechatAppID + ":" + s.cfg.CacheDir()
+	if acc, ok := officialAccounts[key]; ok {
+		return acc
+	}
+
+	tokenCache := NewFileCache(s.cfg.CacheDir())
+	oa := s.wc.GetOfficialAccount(&wechatconfig.Config{
 		AppID:     s.cfg.WechatAppID,
 		AppSecret: s.cfg.WechatSecret,
-		Cache:     memory,
+		Cache:     tokenCache,
+	})
+	token := newSharedAccessToken(s.cfg.WechatAppID, s.cfg.WechatSecret, tokenCache, s.log)
+	oa.SetAccessTokenHandle(token)
+
+	acc := &officialAccount{oa: oa, token: token}
+	officialAccounts[key] = acc
+	return acc
+}
+
+// invalidateTokenOnAuthError access_token 失效（如被其他工具刷新）时删除缓存，下次调用重新获取
+func (s *Service) invalidateTokenOnAuthError(err error) {
+	var commonErr *util.CommonError
+	if !errors.As(err, &commonErr) {
+		return
+	}
+	switch commonErr.ErrCode {
+	case 40001, 40014, 42001: // access_token 无效、不合法、已过期
+		s.log.Warn("access_token rejected, clearing cached token", zap.Int64("errcode", commonErr.ErrCode))
+		if err := s.account().token.Invalidate(); err != nil {
+			s.log.Warn("failed to clear cached access_token", zap.Error(err))
+		}
 	}
-	return s.wc.GetOfficialAccount(wechatCfg)
+}
+
+// IsInvalidMediaID 判断错误是否为 media_id 无效（例如草稿已被删除或已发布）
+func IsInvalidMediaID(err error) bool {
+	var commonErr *util.CommonError
+	return errors.As(err, &commonErr) && commonErr.ErrCode == 40007
 }
 
 // UploadMaterialResult 上传素材结果
@@ -59,12 +115,19 @@ type UploadMaterialResult struct {
 
 // UploadMaterial 上传素材到微信
 func (s *Service) UploadMaterial(filePath string) (*UploadMaterialResult, error) {
+	return s.UploadMaterialContext(context.Background(), filePath)
+}
+
+// UploadMaterialContext 上传素材到微信，ctx 取消时中断上传
+func (s *Service) UploadMaterialContext(ctx context.Context, filePath string) (*UploadMaterialResult, error) {
 	startTime := time.Now()
-	oa := s.getOfficialAccount()
-	mat := oa.GetMaterial()
 
-	// 调用微信 API 上传（SDK 接受文件路径字符串）
-	mediaID, url, err := mat.AddMaterial(material.MediaTypeImage, filePath)
+	var res struct {
+		util.CommonError
+		MediaID string `json:"media_id"`
+		URL     string `json:"url"`
+	}
+	err := s.postImage(ctx, "AddMaterial", "https://api.weixin.qq.com/cgi-bin/material/add_material?access_token=%s&type=image", filePath, &res)
 	if err != nil {
 		s.log.Error("upload material failed",
 			zap.String("path", filePath),
@@ -75,15 +138,116 @@ func (s *Service) UploadMaterial(filePath string) (*UploadMaterialResult, error)
 	duration := time.Since(startTime)
 	s.log.Info("material uploaded",
 		zap.String("path", filePath),
-		zap.String("media_id", maskMediaID(mediaID)),
+		zap.String("media_id", maskMediaID(res.MediaID)),
 		zap.Duration("duration", duration))
 
 	return &UploadMaterialResult{
-		MediaID:   mediaID,
-		WechatURL: url,
+		MediaID:   res.MediaID,
+		WechatURL: res.URL,
 	}, nil
 }
 
+// postImage 以表单字段 media 上传图片，响应解码到 res（需要嵌入 util.CommonError）
+// SDK 的上传方法不支持 context，这里直接调用接口，以便取消进行中的上传
+func (s *Service) postImage(ctx context.Context, apiName, apiURL, filePath string, res any) error {
+	accessToken, err := s.getOfficialAccount().GetAccessToken()
+	if err != nil {
+		return fmt.Errorf("get access token: %w", err)
+	}
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile("media", filepath.Base(filePath))
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(part, file); err != nil {
+		return fmt.Errorf("read image: %w", err)
+	}
+	if err := form.Close(); err != nil {
+		return err
+	}
+
+	// 经 SDK 发送请求，保持 util.SetURIModifier 设置的接口地址替换
+	data, err := util.HTTPPostContext(ctx, fmt.Sprintf(apiURL, accessToken), body.Bytes(),
+		map[string]string{"Content-Type": form.FormDataContentType()})
+	if err != nil {
+		return err
+	}
+	return util.DecodeWithError(data, res, apiName)
+}
+
+// ArticleImageMaxSize 图文内容图片（uploadimg）大小上限：1MB
+const ArticleImageMaxSize = 1024 * 1024
+
+// UploadArticleImage 上传图文消息内的图片（uploadimg 接口）
+// 只返回图片 URL，不占用永久素材配额；仅支持 jpg/png 且不超过 1MB，适用于正文图片
+func (s *Service) UploadArticleImage(filePath string) (*UploadMaterialResult, error) {
+	return s.UploadArticleImageContext(context.Background(), filePath)
+}
+
+// UploadArticleImageContext 上传图文消息内的图片，ctx 取消时中断上传
+func (s *Service) UploadArticleImageContext(ctx context.Context, filePath string) (*UploadMaterialResult, error) {
+	startTime := time.Now()
+
+	var res struct {
+		util.CommonError
+		URL string `json:"url"`
+	}
+	err := s.postImage(ctx, "ImageUpload", "https://api.weixin.qq.com/cgi-bin/media/uploadimg?access_token=%s", filePath, &res)
+	if err != nil {
+		s.log.Error("upload article image failed",
+			zap.String("path", filePath),
+			zap.Error(err))
+		return nil, fmt.Errorf("upload article image: %w", err)
+	}
+
+	s.log.Info("article image uploaded",
+		zap.String("path", filePath),
+		zap.Duration("duration", time.Since(startTime)))
+
+	return &UploadMaterialResult{
+		WechatURL: res.URL,
+	}, nil
+}
+
+// UploadArticleImageWithRetry 带重试的图文内容图片上传，ctx 取消时中断上传并不再重试
+func (s *Service) UploadArticleImageWithRetry(ctx context.Context, filePath string, maxRetries int) (*UploadMaterialResult, error) {
+	return s.retryUpload(ctx, maxRetries, func() (*UploadMaterialResult, error) {
+		return s.UploadArticleImageContext(ctx, filePath)
+	})
+}
+
+// retryUpload 重试上传，access_token 失效时先清除缓存；ctx 取消后立即返回
+func (s *Service) retryUpload(ctx context.Context, maxRetries int, upload func() (*UploadMaterialResult, error)) (*UploadMaterialResult, error) {
+	var lastErr error
+	for i := 0; i < maxRetries; i++ {
+		result, err := upload()
+		if err == nil {
+			return result, nil
+		}
+		lastErr = err
+		if ctx.Err() != nil {
+			return nil, err
+		}
+		s.invalidateTokenOnAuthError(err)
+		if i < maxRetries-1 {
+			select {
+			case <-ctx.Done():
+				return nil, lastErr
+			case <-time.After(time.Second):
+			}
+		}
+	}
+	return nil, lastErr
+}
+
 // CreateDraftResult 创建草稿结果
 type CreateDraftResult struct {
 	MediaID  string `json:"media_id"`
@@ -117,6 +281,158 @@ func (s *Service) CreateDraft(articles []*draft.Article) (*CreateDraftResult, er
 	}, nil
 }
 
+// DraftArticle 草稿中的文章，包含草稿接口返回的全部字段
+// SDK 的 draft.Article 没有封面裁剪、小绿书等字段，获取后再更新会丢失这些设置
+type DraftArticle struct {
+	Title              string            `json:"title"`
+	Author             string            `json:"author,omitempty"`
+	Digest             string            `json:"digest,omitempty"`
+	Content            string            `json:"content"`
+	ContentSourceURL   string            `json:"content_source_url,omitempty"`
+	ThumbMediaID       string            `json:"thumb_media_id,omitempty"`
+	ShowCoverPic       uint              `json:"show_cover_pic"`
+	NeedOpenComment    uint              `json:"need_open_comment"`
+	OnlyFansCanComment uint              `json:"only_fans_can_comment"`
+	PicCrop235_1       string            `json:"pic_crop_235_1,omitempty"` // 2.35:1 封面裁剪坐标
+	PicCrop1_1         string            `json:"pic_crop_1_1,omitempty"`   // 1:1 封面裁剪坐标
+	ArticleType        string            `json:"article_type,omitempty"`
+	ImageInfo          *NewspicImageInfo `json:"image_info,omitempty"`
+	URL                string            `json:"url,omitempty"`       // 草稿预览链接（只读）
+	ThumbURL           string            `json:"thumb_url,omitempty"` // 封面图片链接（只读）
+}
+
+// draftAPI 调用草稿接口，响应解码到 res（需要嵌入 util.CommonError）
+func (s *Service) draftAPI(apiName, url string, req, res any) error {
+	accessToken, err := s.getOfficialAccount().GetAccessToken()
+	if err != nil {
+		return fmt.Errorf("get access token: %w", err)
+	}
+	response, err := util.PostJSON(url+"?access_token="+accessToken, req)
+	if err != nil {
+		return err
+	}
+	return util.DecodeWithError(response, res, apiName)
+}
+
+// GetDraft 获取草稿中的文章
+func (s *Service) GetDraft(mediaID string) ([]*DraftArticle, error) {
+	req := map[string]string{"media_id": mediaID}
+	var res struct {
+		util.CommonError
+		NewsItem []*DraftArticle `json:"news_item"`
+	}
+	if err := s.draftAPI("GetDraft", "https://api.weixin.qq.com/cgi-bin/draft/get", req, &res); err != nil {
+		s.log.Error("get draft failed", zap.String("media_id", maskMediaID(mediaID)), zap.Error(err))
+		return nil, fmt.Errorf("get draft: %w", err)
+	}
+	return res.NewsItem, nil
+}
+
+// UpdateDraft 更新草稿中第 index 篇文章（从 0 开始），需要提交完整的文章内容
+func (s *Service) UpdateDraft(mediaID string, index uint, article *DraftArticle) error {
+	startTime := time.Now()
+
+	// 预览链接和封面链接由微信生成，不提交
+	body := *article
+	body.URL, body.ThumbURL = "", ""
+	req := struct {
+		MediaID string        `json:"media_id"`
+		Index   uint          `json:"index"`
+		Article *DraftArticle `json:"articles"`
+	}{mediaID, index, &body}
+	var res struct {
+		util.CommonError
+	}
+	if err := s.draftAPI("UpdateDraft", "https://api.weixin.qq.com/cgi-bin/draft/update", req, &res); err != nil {
+		s.log.Error("update draft failed", zap.String("media_id", maskMediaID(mediaID)), zap.Error(err))
+		return fmt.Errorf("update draft: %w", err)
+	}
+
+	s.log.Info("draft updated",
+		zap.String("media_id", maskMediaID(mediaID)),
+		zap.Uint("index", index),
+		zap.Duration("duration", time.Since(startTime)))
+	return nil
+}
+
+// DeleteDraft 删除草稿（不可恢复）
+func (s *Service) DeleteDraft(mediaID string) error {
+	dm := s.getOfficialAccount().GetDraft()
+
+	if err := dm.DeleteDraft(mediaID); err != nil {
+		s.log.Error("delete draft failed", zap.String("media_id", maskMediaID(mediaID)), zap.Error(err))
+		return fmt.Errorf("delete draft: %w", err)
+	}
+
+	s.log.Info("draft deleted", zap.String("media_id", maskMediaID(mediaID)))
+	return nil
+}
+
+// CountDraft 获取草稿总数
+func (s *Service) CountDraft() (uint, error) {
+	dm := s.getOfficialAccount().GetDraft()
+
+	total, err := dm.CountDraft()
+	if err != nil {
+		return 0, fmt.Errorf("count drafts: %w", err)
+	}
+	return total, nil
+}
+
+// ListDrafts 分页获取草稿列表，count 取值 1~20；noContent 为 true 时不返回正文
+func (s *Service) ListDrafts(offset, count int64, noContent bool) (*draft.ArticleList, error) {
+	dm := s.getOfficialAccount().GetDraft()
+
+	list, err := dm.PaginateDraft(offset, count, noContent)
+	if err != nil {
+		return nil, fmt.Errorf("list drafts: %w", err)
+	}
+	return &list, nil
+}
+
+// Publish 通过发布接口（freepublish）提交草稿发布，返回发布任务 ID
+func (s *Service) Publish(mediaID string) (int64, error) {
+	fp := s.getOfficialAccount().GetFreePublish()
+
+	publishID, err := fp.Publish(mediaID)
+	if err != nil {
+		s.log.Error("submit publish failed", zap.String("media_id", maskMediaID(mediaID)), zap.Error(err))
+		return 0, fmt.Errorf("submit publish: %w", err)
+	}
+
+	s.log.Info("publish submitted",
+		zap.String("media_id", maskMediaID(mediaID)),
+		zap.Int64("publish_id", publishID))
+	return publishID, nil
+}
+
+// GetPublishStatus 查询发布任务状态
+func (s *Service) GetPublishStatus(publishID int64) (*freepublish.PublishStatusList, error) {
+	fp := s.getOfficialAccount().GetFreePublish()
+
+	status, err := fp.SelectStatus(publishID)
+	if err != nil {
+		return nil, fmt.Errorf("get publish status: %w", err)
+	}
+	return &status, nil
+}
+
+// DeletePublished 删除已发布的文章（不可恢复）
+// index 为文章在图文消息中的位置（第一篇为 1），为 0 时删除全部文章
+func (s *Service) DeletePublished(articleID string, index uint) error {
+	fp := s.getOfficialAccount().GetFreePublish()
+
+	if err := fp.Delete(articleID, index); err != nil {
+		s.log.Error("delete published article failed", zap.String("article_id", maskMediaID(articleID)), zap.Error(err))
+		return fmt.Errorf("delete published article: %w", err)
+	}
+
+	s.log.Info("published article deleted",
+		zap.String("article_id", maskMediaID(articleID)),
+		zap.Uint("index", index))
+	return nil
+}
+
 // UploadMaterialFromBytes 从字节数据上传素材
 func (s *Service) UploadMaterialFromBytes(data []byte, filename string) (*UploadMaterialResult, error) {
 	// 创建临时文件
@@ -130,6 +446,43 @@ func (s *Service) UploadMaterialFromBytes(data []byte, filename string) (*Upload
 	return s.UploadMaterial(tmpPath)
 }
 
+// DeleteMaterial 删除永久素材
+func (s *Service) DeleteMaterial(mediaID string) error {
+	mat := s.getOfficialAccount().GetMaterial()
+
+	if err := mat.DeleteMaterial(mediaID); err != nil {
+		s.log.Error("delete material failed", zap.String("media_id", maskMediaID(mediaID)), zap.Error(err))
+		return fmt.Errorf("delete material: %w", err)
+	}
+
+	s.log.Info("material deleted", zap.String("media_id", maskMediaID(mediaID)))
+	return nil
+}
+
+// ListImageMaterialIDs 列出素材库中所有永久图片素材的 media_id
+func (s *Service) ListImageMaterialIDs() (map[string]bool, error) {
+	oa := s.getOfficialAccount()
+	mat := oa.GetMaterial()
+
+	const pageSize = 20 // 微信单次最多返回 20 条
+	ids := make(map[string]bool)
+	for offset := int64(0); ; offset += pageSize {
+		list, err := mat.BatchGetMaterial(material.PermanentMaterialTypeImage, offset, pageSize)
+		if err != nil {
+			return nil, fmt.Errorf("list materials: %w", err)
+		}
+		for _, item := range list.Item {
+			ids[item.MediaID] = true
+		}
+		if list.ItemCount == 0 || offset+list.ItemCount >= list.TotalCount {
+			break
+		}
+	}
+
+	s.log.Info("image materials listed", zap.Int("count", len(ids)))
+	return ids, nil
+}
+
 // AccessTokenResult 获取 access_token 结果（用于调试）
 type AccessTokenResult struct {
 	AccessToken string `json:"access_token"`
@@ -158,31 +511,31 @@ func maskMediaID(id string) string {
 	return id[:4] + "***" + id[len(id)-4:]
 }
 
-// UploadMaterialWithRetry 带重试的上传
-func (s *Service) UploadMaterialWithRetry(filePath string, maxRetries int) (*UploadMaterialResult, error) {
-	var lastErr error
-	for i := 0; i < maxRetries; i++ {
-		result, err := s.UploadMaterial(filePath)
-		if err == nil {
-			return result, nil
-		}
-		lastErr = err
-		if i < maxRetries-1 {
-			time.Sleep(time.Second)
-		}
-	}
-	return nil, lastErr
+// UploadMaterialWithRetry 带重试的上传，ctx 取消时中断上传并不再重试
+func (s *Service) UploadMaterialWithRetry(ctx context.Context, filePath string, maxRetries int) (*UploadMaterialResult, error) {
+	return s.retryUpload(ctx, maxRetries, func() (*UploadMaterialResult, error) {
+		return s.UploadMaterialContext(ctx, filePath)
+	})
 }
 
 // DownloadFile 下载文件到临时目录
 func DownloadFile(url string) (string, error) {
+	return DownloadFileContext(context.Background(), url)
+}
+
+// DownloadFileContext 下载文件到临时目录，ctx 取消时中断下载
+func DownloadFileContext(ctx context.Context, url string) (string, error) {
 	// 创建 HTTP 客户端
 	client := &http.Client{
 		Timeout: 60 * time.Second,
 	}
 
 	// 发起请求
-	resp, err := client.Get(url)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", fmt.Errorf("download file: %w", err)
+	}
+	resp, err := client.Do(req)
 	if err != nil {
 		return "", fmt.Errorf("download file: %w", err)
 	}
@@ -201,12 +554,13 @@ func DownloadFile(url string) (string, error) {
 			ext = pathExt
 		}
 	}
-	tmpPath := filepath.Join(tmpDir, "md2wechat_download_"+ext)
-	tmpFile, err := os.Create(tmpPath)
+	// 使用唯一文件名，避免并发下载时互相覆盖
+	tmpFile, err := os.CreateTemp(tmpDir, "md2wechat_download_*"+ext)
 	if err != nil {
 		return "", fmt.Errorf("create temp file: %w", err)
 	}
 	defer tmpFile.Close()
+	tmpPath := tmpFile.Name()
 
 	// 写入文件
 	if _, err := io.Copy(tmpFile, resp.Body); err != nil {
@@ -0,0 +1,65 @@
+package wechat
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/geekjourneyx/md2wechat-skill/internal/config"
+	"github.com/silenceper/wechat/v2/util"
+	"go.uber.org/zap"
+)
+
+func TestService_UploadMaterialContext(t *testing.T) {
+	var uploaded string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/cgi-bin/token":
+			_, _ = io.WriteString(w, `{"access_token":"test-token","expires_in":7200}`)
+		case "/cgi-bin/material/add_material":
+			if r.URL.Query().Get("type") != "image" {
+				t.Errorf("type = %q", r.URL.Query().Get("type"))
+			}
+			file, header, err := r.FormFile("media")
+			if err != nil {
+				t.Fatalf("FormFile(media) error = %v", err)
+			}
+			data, _ := io.ReadAll(file)
+			uploaded = header.Filename + ":" + string(data)
+			_, _ = io.WriteString(w, `{"media_id":"media-1","url":"https://mmbiz.qpic.cn/1"}`)
+		default:
+			t.Errorf("unexpected request %s", r.URL.Path)
+		}
+	}))
+	// 直接上传的请求同样经过 SDK 的接口地址替换
+	util.SetURIModifier(func(uri string) string {
+		return strings.Replace(uri, "https://api.weixin.qq.com", srv.URL, 1)
+	})
+	t.Cleanup(func() {
+		util.SetURIModifier(nil)
+		srv.Close()
+	})
+	t.Setenv("MD2WECHAT_CACHE_DIR", t.TempDir())
+
+	path := filepath.Join(t.TempDir(), "cover.png")
+	if err := os.WriteFile(path, []byte("image bytes"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	svc := NewService(&config.Config{WechatAppID: "wx-upload-test", WechatSecret: "secret"}, zap.NewNop())
+	result, err := svc.UploadMaterialContext(context.Background(), path)
+	if err != nil {
+		t.Fatalf("UploadMaterialContext() error = %v", err)
+	}
+	if result.MediaID != "media-1" || result.WechatURL != "https://mmbiz.qpic.cn/1" {
+		t.Errorf("UploadMaterialContext() = %+v", result)
+	}
+	if uploaded != "cover.png:image bytes" {
+		t.Errorf("uploaded = %q", uploaded)
+	}
+}
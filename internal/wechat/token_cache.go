@@ -0,0 +1,278 @@
+package wechat
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	wechatcache "github.com/silenceper/wechat/v2/cache"
+	"github.com/silenceper/wechat/v2/credential"
+	"go.uber.org/zap"
+)
+
+const (
+	// tokenCacheFile access_token 缓存文件名
+	tokenCacheFile = "tokens.json"
+	// lockRetryInterval 等待文件锁的重试间隔
+	lockRetryInterval = 50 * time.Millisecond
+	// lockTimeout 等待文件锁的最长时间
+	lockTimeout = 15 * time.Second
+	// staleLockAge 超过该时间的锁文件视为进程异常退出遗留
+	staleLockAge = 30 * time.Second
+)
+
+// errCacheUnavailable 缓存目录不可写或无法获取文件锁
+var errCacheUnavailable = errors.New("token cache unavailable")
+
+// 确保 FileCache 实现了 SDK 的缓存接口
+var _ wechatcache.Cache = (*FileCache)(nil)
+
+// FileCache 基于文件的缓存，实现 silenceper/wechat 的 cache.Cache 接口
+// 用于在多次命令调用和并行进程之间共享 access_token
+type FileCache struct {
+	path string
+	mu   sync.Mutex
+}
+
+// fileCacheItem 缓存条目
+type fileCacheItem struct {
+	Value     any       `json:"value"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// NewFileCache 创建文件缓存，数据保存在 dir/tokens.json
+func NewFileCache(dir string) *FileCache {
+	return &FileCache{path: filepath.Join(dir, tokenCacheFile)}
+}
+
+// Path 返回缓存文件路径
+func (c *FileCache) Path() string {
+	return c.path
+}
+
+// Get 获取未过期的缓存值，不存在时返回 nil
+func (c *FileCache) Get(key string) any {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// 写入使用临时文件 + 重命名，读取时无需加文件锁
+	return c.get(key)
+}
+
+// Set 写入缓存值，timeout 后过期
+func (c *FileCache) Set(key string, val any, timeout time.Duration) error {
+	return c.withLock(func(locked wechatcache.Cache) error {
+		return locked.Set(key, val, timeout)
+	})
+}
+
+// IsExist 检查缓存值是否存在且未过期
+func (c *FileCache) IsExist(key string) bool {
+	return c.Get(key) != nil
+}
+
+// Delete 删除缓存值
+func (c *FileCache) Delete(key string) error {
+	return c.withLock(func(locked wechatcache.Cache) error {
+		return locked.Delete(key)
+	})
+}
+
+// withLock 在进程内锁和跨进程文件锁内执行 fn
+// fn 中只能通过传入的 locked 视图读写缓存，否则会死锁
+func (c *FileCache) withLock(fn func(locked wechatcache.Cache) error) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(c.path), 0700); err != nil {
+		return fmt.Errorf("%w: %v", errCacheUnavailable, err)
+	}
+	unlock, err := LockFile(c.path + ".lock")
+	if err != nil {
+		return fmt.Errorf("%w: %v", errCacheUnavailable, err)
+	}
+	defer unlock()
+
+	return fn(lockedFileCache{c})
+}
+
+// get 读取未过期的缓存值（调用方需持有 mu）
+func (c *FileCache) get(key string) any {
+	item, ok := c.read()[key]
+	if !ok || !time.Now().Before(item.ExpiresAt) {
+		return nil
+	}
+	return item.Value
+}
+
+// modify 读取、修改并写回缓存，同时清理已过期的条目（调用方需持有锁）
+func (c *FileCache) modify(fn func(items map[string]fileCacheItem)) error {
+	items := c.read()
+	now := time.Now()
+	for k, item := range items {
+		if !now.Before(item.ExpiresAt) {
+			delete(items, k)
+		}
+	}
+	fn(items)
+	return c.write(items)
+}
+
+// lockedFileCache 持有锁期间使用的缓存视图，读写时不再重复加锁
+type lockedFileCache struct {
+	c *FileCache
+}
+
+func (l lockedFileCache) Get(key string) any {
+	return l.c.get(key)
+}
+
+func (l lockedFileCache) Set(key string, val any, timeout time.Duration) error {
+	return l.c.modify(func(items map[string]fileCacheItem) {
+		items[key] = fileCacheItem{Value: val, ExpiresAt: time.Now().Add(timeout)}
+	})
+}
+
+func (l lockedFileCache) IsExist(key string) bool {
+	return l.c.get(key) != nil
+}
+
+func (l lockedFileCache) Delete(key string) error {
+	return l.c.modify(func(items map[string]fileCacheItem) {
+		delete(items, key)
+	})
+}
+
+// read 读取缓存文件，文件不存在或损坏时返回空缓存
+func (c *FileCache) read() map[string]fileCacheItem {
+	items := make(map[string]fileCacheItem)
+	data, err := os.ReadFile(c.path)
+	if err != nil {
+		return items
+	}
+	if err := json.Unmarshal(data, &items); err != nil {
+		return make(map[string]fileCacheItem)
+	}
+	return items
+}
+
+// write 先写临时文件再重命名，避免其他进程读到写了一半的文件
+func (c *FileCache) write(items map[string]fileCacheItem) error {
+	data, err := json.MarshalIndent(items, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal token cache: %w", err)
+	}
+
+	// CreateTemp 创建的文件权限为 0600，access_token 仅当前用户可读
+	tmp, err := os.CreateTemp(filepath.Dir(c.path), tokenCacheFile+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("create temp file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("write token cache: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("write token cache: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), c.path); err != nil {
+		return fmt.Errorf("save token cache: %w", err)
+	}
+	return nil
+}
+
+// LockFile 通过独占创建锁文件实现跨进程互斥（兼容所有平台），返回释放锁的函数
+// 锁文件超过 staleLockAge 未释放时视为遗留锁并强制删除
+func LockFile(path string) (func(), error) {
+	deadline := time.Now().Add(lockTimeout)
+	for {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+		if err == nil {
+			fmt.Fprintf(f, "%d", os.Getpid())
+			f.Close()
+			return func() { os.Remove(path) }, nil
+		}
+		if !os.IsExist(err) {
+			return nil, fmt.Errorf("create lock file: %w", err)
+		}
+
+		if info, statErr := os.Stat(path); statErr == nil && time.Since(info.ModTime()) > staleLockAge {
+			os.Remove(path)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("timeout waiting for lock %s", path)
+		}
+		time.Sleep(lockRetryInterval)
+	}
+}
+
+// accessTokenCacheKey 返回 SDK 缓存公众号 access_token 使用的键
+func accessTokenCacheKey(appID string) string {
+	return fmt.Sprintf("%s_access_token_%s", credential.CacheKeyOfficialAccountPrefix, appID)
+}
+
+// sharedAccessToken 跨进程共享的 access_token 获取方式
+// 在文件锁内检查缓存并按需刷新，避免并行进程各自获取新 token 导致旧 token 失效
+type sharedAccessToken struct {
+	appID     string
+	appSecret string
+	cache     *FileCache
+	fallback  credential.AccessTokenContextHandle // 文件缓存不可用时使用的进程内缓存
+	log       *zap.Logger
+}
+
+// newSharedAccessToken 创建共享 access_token 获取方式
+func newSharedAccessToken(appID, appSecret string, cache *FileCache, log *zap.Logger) *sharedAccessToken {
+	return &sharedAccessToken{
+		appID:     appID,
+		appSecret: appSecret,
+		cache:     cache,
+		fallback:  credential.NewDefaultAccessToken(appID, appSecret, credential.CacheKeyOfficialAccountPrefix, wechatcache.NewMemory()),
+		log:       log,
+	}
+}
+
+// GetAccessToken 获取 access_token
+func (t *sharedAccessToken) GetAccessToken() (string, error) {
+	return t.GetAccessTokenContext(context.Background())
+}
+
+// GetAccessTokenContext 获取 access_token，缓存有效时直接返回，否则加锁后刷新
+func (t *sharedAccessToken) GetAccessTokenContext(ctx context.Context) (string, error) {
+	if token, ok := t.cache.Get(accessTokenCacheKey(t.appID)).(string); ok && token != "" {
+		return token, nil
+	}
+
+	var accessToken string
+	err := t.cache.withLock(func(locked wechatcache.Cache) error {
+		// 加锁后由 SDK 再次检查缓存，其他进程可能刚刚刷新过
+		inner := credential.NewDefaultAccessToken(t.appID, t.appSecret, credential.CacheKeyOfficialAccountPrefix, locked)
+		token, err := inner.GetAccessTokenContext(ctx)
+		accessToken = token
+		return err
+	})
+	if err != nil && accessToken != "" {
+		// 已获取到 token，只是写缓存失败，不影响本次使用
+		t.log.Warn("failed to save access_token to cache", zap.Error(err))
+		return accessToken, nil
+	}
+	if errors.Is(err, errCacheUnavailable) {
+		// 缓存目录不可用时退化为进程内缓存，token 不再跨进程共享
+		t.log.Warn("access_token will not be shared between processes", zap.Error(err))
+		return t.fallback.GetAccessTokenContext(ctx)
+	}
+	return accessToken, err
+}
+
+// Invalidate 删除缓存的 access_token（token 被其他工具刷新而失效时调用）
+func (t *sharedAccessToken) Invalidate() error {
+	return t.cache.Delete(accessTokenCacheKey(t.appID))
+}
@@ -0,0 +1,89 @@
+package wechat
+
+import (
+	"os"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestFileCache(t *testing.T) {
+	dir := t.TempDir()
+
+	cache := NewFileCache(dir)
+	if err := cache.Set("token", "abc", time.Hour); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if err := cache.Set("expired", "old", -time.Second); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+
+	// 其他进程（新实例）可以读到同一个 token
+	other := NewFileCache(dir)
+	if got := other.Get("token"); got != "abc" {
+		t.Errorf("Get(token) = %v, want abc", got)
+	}
+	if other.IsExist("expired") {
+		t.Error("IsExist(expired) = true, want false")
+	}
+
+	if err := other.Delete("token"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if got := cache.Get("token"); got != nil {
+		t.Errorf("Get(token) after Delete = %v, want nil", got)
+	}
+
+	info, err := os.Stat(cache.Path())
+	if err != nil {
+		t.Fatalf("Stat() error = %v", err)
+	}
+	if perm := info.Mode().Perm(); perm&0077 != 0 && os.PathSeparator == '/' {
+		t.Errorf("token cache mode = %v, want owner-only", perm)
+	}
+}
+
+func TestFileCache_ConcurrentSet(t *testing.T) {
+	dir := t.TempDir()
+
+	var wg sync.WaitGroup
+	keys := []string{"a", "b", "c", "d", "e", "f", "g", "h"}
+	for _, key := range keys {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// 每个 goroutine 使用独立实例，模拟多个进程同时写入
+			if err := NewFileCache(dir).Set(key, key, time.Hour); err != nil {
+				t.Errorf("Set(%s) error = %v", key, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	cache := NewFileCache(dir)
+	for _, key := range keys {
+		if got := cache.Get(key); got != key {
+			t.Errorf("Get(%s) = %v, want %s (lost update)", key, got, key)
+		}
+	}
+}
+
+func TestLockFile_Stale(t *testing.T) {
+	path := t.TempDir() + "/tokens.json.lock"
+	if err := os.WriteFile(path, []byte("1"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-2 * staleLockAge)
+	if err := os.Chtimes(path, old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	unlock, err := LockFile(path)
+	if err != nil {
+		t.Fatalf("LockFile() on stale lock error = %v", err)
+	}
+	unlock()
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("lock file still exists after unlock")
+	}
+}